@@ -31,6 +31,7 @@
 	"github.com/rancher/rancher/pkg/agent/rancher"
 	"github.com/rancher/rancher/pkg/controllers/managementuser/cavalidator"
 	"github.com/rancher/rancher/pkg/features"
+	"github.com/rancher/rancher/pkg/logging"
 	"github.com/rancher/rancher/pkg/logserver"
 	"github.com/rancher/rancher/pkg/rkenodeconfigclient"
 	"github.com/rancher/remotedialer"
@@ -521,9 +522,9 @@ func configureLogrus() {
 	logrus.SetOutput(colorable.NewColorableStdout())
 
 	if os.Getenv("CATTLE_TRACE") == "true" || os.Getenv("RANCHER_TRACE") == "true" {
-		logrus.SetLevel(logrus.TraceLevel)
+		logging.SetLevel(logrus.TraceLevel)
 	} else if os.Getenv("CATTLE_DEBUG") == "true" || os.Getenv("RANCHER_DEBUG") == "true" {
-		logrus.SetLevel(logrus.DebugLevel)
+		logging.SetLevel(logrus.DebugLevel)
 	}
 }
 
@@ -8,10 +8,11 @@
 	"path/filepath"
 
 	"github.com/docker/docker/pkg/reexec"
-	"github.com/ehazlett/simplelog"
 	_ "github.com/rancher/norman/controller"
 	"github.com/rancher/norman/pkg/kwrapper/k8s"
+	"github.com/rancher/rancher/pkg/catalogv2/helm"
 	"github.com/rancher/rancher/pkg/data/management"
+	"github.com/rancher/rancher/pkg/logging"
 	"github.com/rancher/rancher/pkg/logserver"
 	"github.com/rancher/rancher/pkg/rancher"
 	"github.com/rancher/rancher/pkg/version"
@@ -28,6 +29,7 @@
 func main() {
 	management.RegisterPasswordResetCommand()
 	management.RegisterEnsureDefaultAdminCommand()
+	helm.RegisterRenderCommand()
 	if reexec.Init() {
 		return
 	}
@@ -174,21 +176,14 @@ func main() {
 }
 
 func initLogs(c *cli.Context, cfg rancher.Options) {
-	switch c.String("log-format") {
-	case "simple":
-		logrus.SetFormatter(&simplelog.StandardFormatter{})
-	case "text":
-		logrus.SetFormatter(&logrus.TextFormatter{})
-	case "json":
-		logrus.SetFormatter(&logrus.JSONFormatter{})
-	}
+	logging.SetFormat(c.String("log-format"))
 	logrus.SetOutput(os.Stdout)
 	if cfg.Debug {
-		logrus.SetLevel(logrus.DebugLevel)
+		logging.SetLevel(logrus.DebugLevel)
 		logrus.Debugf("Loglevel set to [%v]", logrus.DebugLevel)
 	}
 	if cfg.Trace {
-		logrus.SetLevel(logrus.TraceLevel)
+		logging.SetLevel(logrus.TraceLevel)
 		logrus.Tracef("Loglevel set to [%v]", logrus.TraceLevel)
 	}
 
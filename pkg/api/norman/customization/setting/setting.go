@@ -11,6 +11,7 @@
 	"github.com/rancher/rancher/pkg/auth/providerrefresh"
 	v3client "github.com/rancher/rancher/pkg/client/generated/management/v3"
 	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/settings"
 )
 
 var ReadOnlySettings = []string{
@@ -61,6 +62,10 @@ func Validator(request *types.APIContext, schema *types.Schema, data map[string]
 		return fmt.Errorf("value not string")
 	}
 
+	if err := settings.Validate(id, newValueString); err != nil {
+		return httperror.NewAPIError(httperror.InvalidBodyContent, err.Error())
+	}
+
 	var err error
 	switch id {
 	case "auth-user-info-max-age-seconds":
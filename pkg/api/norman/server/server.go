@@ -12,6 +12,7 @@
 	"github.com/rancher/rancher/pkg/api/norman/server/userstored"
 	"github.com/rancher/rancher/pkg/clustermanager"
 	"github.com/rancher/rancher/pkg/controllers/managementapi"
+	"github.com/rancher/rancher/pkg/logging"
 	clusterSchema "github.com/rancher/rancher/pkg/schemas/cluster.cattle.io/v3"
 	managementSchema "github.com/rancher/rancher/pkg/schemas/management.cattle.io/v3"
 	projectSchema "github.com/rancher/rancher/pkg/schemas/project.cattle.io/v3"
@@ -44,5 +45,5 @@ func New(ctx context.Context, scaledContext *config.ScaledContext, clusterManage
 	}
 
 	chainGzip := responsewriter.Chain{responsewriter.Gzip, responsewriter.ContentType}
-	return chainGzip.Handler(server), nil
+	return chainGzip.Handler(logging.Requests("norman", server)), nil
 }
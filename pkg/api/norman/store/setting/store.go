@@ -11,7 +11,9 @@
 	"github.com/rancher/norman/types/convert"
 	"github.com/rancher/norman/types/slice"
 	"github.com/rancher/rancher/pkg/api/norman/customization/setting"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/settings"
+	"k8s.io/apiserver/pkg/endpoints/request"
 )
 
 type Store struct {
@@ -82,9 +84,38 @@ func (s *Store) Update(apiContext *types.APIContext, schema *types.Schema, data
 		}
 		data["labels"] = labels
 	}
+	if val, ok := data["value"]; ok {
+		if err := s.recordChange(apiContext, schema, data, id, convert.ToString(val)); err != nil {
+			return nil, err
+		}
+	}
 	return s.Store.Update(apiContext, schema, data, id)
 }
 
+// recordChange adds the change of the value of the setting by the user of the request to the history in data.
+func (s *Store) recordChange(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}, id, value string) error {
+	existing, err := s.Store.ByID(apiContext, schema, id)
+	if err != nil {
+		return err
+	}
+	setting := &v3.Setting{}
+	if err := convert.ToObj(existing, setting); err != nil {
+		return err
+	}
+	var userName string
+	if user, ok := request.UserFrom(apiContext.Request.Context()); ok {
+		userName = user.GetName()
+	}
+	settings.RecordChange(setting, value, userName)
+
+	var history []interface{}
+	if err := convert.ToObj(setting.History, &history); err != nil {
+		return err
+	}
+	data["history"] = history
+	return nil
+}
+
 func validate(id, value string) error {
 	var k8sVersion string
 	var k8sCurrVersions []string
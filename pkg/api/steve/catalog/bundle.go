@@ -0,0 +1,114 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	types2 "github.com/rancher/rancher/pkg/api/steve/catalog/types"
+	"github.com/rancher/rancher/pkg/catalogv2/bundle"
+	"github.com/rancher/rancher/pkg/catalogv2/content"
+	catalogcontrollers "github.com/rancher/rancher/pkg/generated/controllers/catalog.cattle.io/v1"
+	namespaces "github.com/rancher/rancher/pkg/namespace"
+	corev1controllers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// catalogBundle implements the http handler interface for the exportBundle and importBundle actions of ClusterRepos.
+type catalogBundle struct {
+	contentManager *content.Manager
+	configMaps     corev1controllers.ConfigMapClient
+	clusterRepos   catalogcontrollers.ClusterRepoClient
+}
+
+// ServeHTTP exports the selected ClusterRepos into a catalog bundle, or imports the catalog bundle of the request
+// body into ClusterRepos served by Rancher.
+func (b *catalogBundle) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	apiRequest := types.GetAPIContext(req.Context())
+
+	var err error
+	switch apiRequest.Action {
+	case "exportBundle":
+		err = b.exportBundle(apiRequest, rw, req.Body)
+	case "importBundle":
+		err = b.importBundle(apiRequest, req.Body)
+	}
+	if err != nil {
+		apiRequest.WriteError(err)
+	}
+}
+
+// exportBundle writes a catalog bundle with the charts selected by the request to the response.
+func (b *catalogBundle) exportBundle(apiRequest *types.APIRequest, rw http.ResponseWriter, body io.Reader) error {
+	input := types2.ExportBundleAction{}
+	if err := json.NewDecoder(body).Decode(&input); err != nil {
+		return apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+	}
+	if len(input.Repos) == 0 {
+		return apierror.NewAPIError(validation.InvalidBodyContent, "no repository to export")
+	}
+	for _, r := range input.Repos {
+		// the charts are read with the credentials of Rancher, so the user must be able to get each repository
+		if err := apiRequest.AccessControl.CanDo(apiRequest, "catalog.cattle.io/clusterrepos", "get", "", r.Name); err != nil {
+			return err
+		}
+		// fail before the response is written if a repository is not available
+		if _, err := b.contentManager.Index("", r.Name, "", true); err != nil {
+			return err
+		}
+	}
+
+	rw.Header().Set("Content-Type", "application/gzip")
+	rw.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=\"catalog-bundle-%s.tar.gz\"", time.Now().UTC().Format("20060102150405")))
+	rw.WriteHeader(http.StatusOK)
+	// the response has been written, an error can only abort the archive
+	return bundle.Export(rw, &contentSource{contentManager: b.contentManager}, input.Repos)
+}
+
+// importBundle imports the catalog bundle of the request body.
+func (b *catalogBundle) importBundle(apiRequest *types.APIRequest, body io.Reader) error {
+	if err := apiRequest.AccessControl.CanCreate(apiRequest, apiRequest.Schema); err != nil {
+		return err
+	}
+	if err := apiRequest.AccessControl.CanUpdate(apiRequest, types.APIObject{}, apiRequest.Schema); err != nil {
+		return err
+	}
+
+	store := bundle.NewClusterStore(namespaces.System, b.configMaps, b.clusterRepos)
+	manifest, images, err := bundle.Import(body, store)
+	if err != nil {
+		return apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+	}
+
+	apiRequest.WriteResponse(http.StatusOK, types.APIObject{
+		Type: "importBundleOutput",
+		Object: &types2.ImportBundleOutput{
+			Repos:  manifest.Repos,
+			Images: images,
+		},
+	})
+	return nil
+}
+
+// contentSource exports the charts of ClusterRepos through the content manager.
+type contentSource struct {
+	contentManager *content.Manager
+}
+
+func (c *contentSource) Index(repoName string) (*repo.IndexFile, error) {
+	return c.contentManager.Index("", repoName, "", true)
+}
+
+func (c *contentSource) Chart(repoName string, chart *repo.ChartVersion) (io.ReadCloser, error) {
+	return c.contentManager.Chart("", repoName, chart.Name, chart.Version, true)
+}
+
+func (c *contentSource) Icon(repoName string, chart *repo.ChartVersion) (io.ReadCloser, string, error) {
+	return c.contentManager.Icon("", repoName, chart.Name, chart.Version)
+}
@@ -20,16 +20,20 @@
 	"github.com/rancher/rancher/pkg/apis/catalog.cattle.io"
 	"github.com/rancher/rancher/pkg/catalogv2/content"
 	"github.com/rancher/rancher/pkg/catalogv2/helmop"
+	catalogcontrollers "github.com/rancher/rancher/pkg/generated/controllers/catalog.cattle.io/v1"
 	schema2 "github.com/rancher/steve/pkg/schema"
 	steve "github.com/rancher/steve/pkg/server"
+	corev1controllers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
 	schemas3 "github.com/rancher/wrangler/v3/pkg/schemas"
 	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
 )
 
-// Register is used to register the two handlers with the apiserver
+// Register is used to register the handlers with the apiserver
 func Register(ctx context.Context, server *steve.Server,
 	helmop *helmop.Operations,
-	contentManager *content.Manager) error {
+	contentManager *content.Manager,
+	configMaps corev1controllers.ConfigMapClient,
+	clusterRepos catalogcontrollers.ClusterRepoClient) error {
 
 	ops := newOperation(helmop, server.ClusterRegistry)
 
@@ -42,12 +46,21 @@ func Register(ctx context.Context, server *steve.Server,
 		contentManager: contentManager,
 	}
 
-	addSchemas(server, ops, index)
+	// Catalog bundles of ClusterRepos for air-gapped environments
+	bundles := &catalogBundle{
+		contentManager: contentManager,
+		configMaps:     configMaps,
+		clusterRepos:   clusterRepos,
+	}
+
+	addSchemas(server, ops, index, bundles)
 	return nil
 }
 
 // addSchemas adds and customizes API schemas for operations, app, repo, and clusterrepo.
-// It adds action handlers and resource actions for install, upgrade, and uninstall operations of Charts.
+// It adds action handlers and resource actions for install, upgrade, rollback and uninstall operations of Charts,
+// and the release history link of apps.
+// ClusterRepos also get the exportBundle and importBundle collection actions for air-gapped catalogs.
 // It also sets up handlers for byID and link requests.
 //
 // The function uses predefined structure templates for API schemas, allowing for customization
@@ -55,14 +68,19 @@ func Register(ctx context.Context, server *steve.Server,
 // defines how to handle different action requests made on different resources.
 //
 // The handlers for retrieving resources by their IDs are also customized.
-func addSchemas(server *steve.Server, ops *operation, index http.Handler) {
+func addSchemas(server *steve.Server, ops *operation, index, bundles http.Handler) {
 	// Imports and generates API schemas to be handled by as requests by the Rancher API server.
 	server.BaseSchemas.MustImportAndCustomize(types2.ChartUninstallAction{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(types2.ChartRollbackAction{}, nil)
 	server.BaseSchemas.MustImportAndCustomize(types2.ChartUpgradeAction{}, nil)
 	server.BaseSchemas.MustImportAndCustomize(types2.ChartUpgrade{}, nil)
 	server.BaseSchemas.MustImportAndCustomize(types2.ChartInstallAction{}, nil)
 	server.BaseSchemas.MustImportAndCustomize(types2.ChartInstall{}, nil)
 	server.BaseSchemas.MustImportAndCustomize(types2.ChartActionOutput{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(types2.ChartDryRunOutput{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(types2.ChartValuesValidationOutput{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(types2.ExportBundleAction{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(types2.ImportBundleOutput{}, nil)
 
 	operationTemplate := schema2.Template{
 		Group: catalog.GroupName,
@@ -84,12 +102,20 @@ func addSchemas(server *steve.Server, ops *operation, index http.Handler) {
 		Customize: func(apiSchema *types.APISchema) {
 			apiSchema.ActionHandlers = map[string]http.Handler{
 				"uninstall": ops,
+				"rollback":  ops,
 			}
 			apiSchema.ResourceActions = map[string]schemas3.Action{
 				"uninstall": {
 					Input:  "chartUninstallAction",
 					Output: "chartActionOutput",
 				},
+				"rollback": {
+					Input:  "chartRollbackAction",
+					Output: "chartActionOutput",
+				},
+			}
+			apiSchema.LinkHandlers = map[string]http.Handler{
+				"history": ops,
 			}
 		},
 	}
@@ -136,6 +162,19 @@ func addSchemas(server *steve.Server, ops *operation, index http.Handler) {
 	}
 	chartRepoTemplate := repoTemplate
 	chartRepoTemplate.Kind = "ClusterRepo"
+	chartRepoTemplate.Customize = func(apiSchema *types.APISchema) {
+		repoTemplate.Customize(apiSchema)
+		apiSchema.ActionHandlers["exportBundle"] = bundles
+		apiSchema.ActionHandlers["importBundle"] = bundles
+		apiSchema.CollectionActions = map[string]schemas3.Action{
+			"exportBundle": {
+				Input: "exportBundleAction",
+			},
+			"importBundle": {
+				Output: "importBundleOutput",
+			},
+		}
+	}
 
 	server.SchemaFactory.AddTemplate(
 		operationTemplate,
@@ -1,11 +1,16 @@
 package catalog
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/rancher/apiserver/pkg/types"
 	catalogtypes "github.com/rancher/rancher/pkg/api/steve/catalog/types"
 	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/catalogv2/helm"
 	"github.com/rancher/rancher/pkg/catalogv2/helmop"
 	"github.com/rancher/rancher/pkg/settings"
 	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
@@ -47,7 +52,9 @@ func newOperation(
 // For example, if the api request is for installing a chart, then it will call the
 // install function of the Operation struct.
 //
-// All chart actions (install, upgrade, and uninstall) are served through this method.
+// All chart actions (install, upgrade, rollback and uninstall) and the release history of apps
+// are served through this method. Install and upgrade requests with the dryRun query parameter
+// return the changes the action would make instead of creating an operation.
 func (o *operation) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	// Get the APIContext from the current request's context. This APIContext
 	// encapsulates the details of the API request, which will be used to
@@ -66,6 +73,12 @@ func (o *operation) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	)
 
 	ns, name := nsAndName(apiRequest)
+	if dryRun, _ := strconv.ParseBool(apiRequest.Query.Get("dryRun")); dryRun &&
+		(apiRequest.Action == "install" || apiRequest.Action == "upgrade") {
+		o.writeDryRun(apiRequest, ns, name, req.Body)
+		return
+	}
+
 	switch apiRequest.Action {
 	case "install":
 		op, err = o.ops.Install(apiRequest.Context(), user, ns, name, req.Body, o.imageOverride)
@@ -73,16 +86,20 @@ func (o *operation) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		op, err = o.ops.Upgrade(apiRequest.Context(), user, ns, name, req.Body, o.imageOverride)
 	case "uninstall":
 		op, err = o.ops.Uninstall(apiRequest.Context(), user, ns, name, req.Body, o.imageOverride)
+	case "rollback":
+		op, err = o.ops.Rollback(apiRequest.Context(), user, ns, name, req.Body, o.imageOverride)
 	}
 
 	switch apiRequest.Link {
 	case "logs":
 		err = o.ops.Log(apiRequest.Response, apiRequest.Request,
 			apiRequest.Namespace, apiRequest.Name)
+	case "history":
+		err = o.writeHistory(apiRequest)
 	}
 
 	if err != nil {
-		apiRequest.WriteError(err)
+		writeError(apiRequest, err)
 		return
 	}
 
@@ -99,6 +116,45 @@ func (o *operation) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	})
 }
 
+// writeDryRun writes how the install or upgrade of the request would change the resources of its releases.
+func (o *operation) writeDryRun(apiRequest *types.APIRequest, namespace, name string, body io.Reader) {
+	diff, err := o.ops.DryRun(apiRequest, namespace, name, body, apiRequest.Action == "upgrade")
+	if err != nil {
+		writeError(apiRequest, err)
+		return
+	}
+	apiRequest.WriteResponse(http.StatusOK, types.APIObject{
+		Type:   "chartDryRunOutput",
+		Object: diff,
+	})
+}
+
+// writeError writes the given error, with the field-level errors of invalid chart values if there are any.
+func writeError(apiRequest *types.APIRequest, err error) {
+	var valuesErr *helm.ValuesError
+	if errors.As(err, &valuesErr) {
+		apiRequest.WriteResponse(http.StatusUnprocessableEntity, types.APIObject{
+			Type: "chartValuesValidationOutput",
+			Object: &catalogtypes.ChartValuesValidationOutput{
+				Errors: valuesErr.Errors,
+			},
+		})
+		return
+	}
+	apiRequest.WriteError(err)
+}
+
+// writeHistory writes the release history of the app of the request as JSON.
+func (o *operation) writeHistory(apiRequest *types.APIRequest) error {
+	history, err := o.ops.History(apiRequest, apiRequest.Namespace, apiRequest.Name)
+	if err != nil {
+		return err
+	}
+	apiRequest.Response.Header().Set("Content-Type", "application/json")
+	apiRequest.Response.WriteHeader(http.StatusOK)
+	return json.NewEncoder(apiRequest.Response).Encode(history)
+}
+
 // OnAdd is registered as a callback of a Kubernetes Informer.
 // It is invoked when a new object is added to the Kubernetes cluster.
 // It purges old roles related to the object being added.
@@ -52,6 +52,14 @@ func pluginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// plugins pinned to expected digests are only served once their verified content is cached
+	if plugin.Pinned(entry.UIPluginEntry) && (entry.CacheState != plugin.Cached || !entry.Ready) {
+		msg := fmt.Sprintf("plugin [name: %s version: %s] content has not been verified", vars["name"], vars["version"])
+		http.Error(w, msg, http.StatusServiceUnavailable)
+		logrus.Debug(msg)
+		return
+	}
+
 	if entry.NoCache || entry.CacheState == plugin.Pending {
 		if entry.Endpoint != "" {
 			logrus.Debugf("[noCache: %v] proxying request to [endpoint: %v]\n", entry.NoCache, entry.Endpoint)
@@ -2,7 +2,7 @@
 Package types define several types representing Helm chart operations.
 
 These types are used by the Steve Catalog API to handle requests and responses
-associated with Helm chart actions such as install, upgrade, and uninstall.
+associated with Helm chart actions such as install, upgrade, uninstall and rollback.
 
 Types in this package include:
 
@@ -12,7 +12,13 @@
   - ChartUninstallAction: Describes the configuration for an uninstallation action.
   - ChartUpgradeAction: Describes the configuration for an upgrade action.
   - ChartUpgrade: Represents a Helm chart upgrade request.
+  - ChartRollbackAction: Describes the configuration for a rollback action.
   - ChartActionOutput: Represents the output after performing a Helm chart action.
+  - ChartDryRunOutput: Represents the changes a dry run of an install or upgrade would make.
+  - ChartValuesValidationOutput: Represents the invalid values of a rejected install or upgrade.
+  - ReleaseHistory: Contains the revisions of a Helm release.
+  - ExportBundleAction: Selects the repositories and charts exported into a catalog bundle.
+  - ImportBundleOutput: Represents the repositories imported from a catalog bundle.
 
 Each type includes fields that map directly to properties of Helm chart operations,
 allowing for a structured approach to managing Helm charts through the API.
@@ -21,6 +27,7 @@
 
 import (
 	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/catalogv2/verify"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -53,6 +60,8 @@ type ChartInfo struct {
 	Values    v3.MapStringInterface `json:"values,omitempty"`
 	Questions v3.MapStringInterface `json:"questions,omitempty"`
 	Chart     v3.MapStringInterface `json:"chart,omitempty"`
+	// Verification is the result of the verification of the chart against the verification policy of its repository.
+	Verification *verify.Result `json:"verification,omitempty"`
 }
 
 // ChartUninstallAction represents the input received when uninstalling a chart
@@ -94,7 +103,110 @@ type ChartUpgrade struct {
 	Annotations map[string]string     `json:"annotations,omitempty"`
 }
 
+// ChartRollbackAction represents the input received when rolling back a release
+type ChartRollbackAction struct {
+	// Revision is the revision to roll back to. Defaults to the previous revision.
+	Revision               int                 `json:"revision,omitempty"`
+	Timeout                *metav1.Duration    `json:"timeout,omitempty"`
+	Wait                   bool                `json:"wait,omitempty"`
+	DisableHooks           bool                `json:"noHooks,omitempty"`
+	DryRun                 bool                `json:"dryRun,omitempty"`
+	Force                  bool                `json:"force,omitempty"`
+	CleanupOnFail          bool                `json:"cleanupOnFail,omitempty"`
+	MaxHistory             int                 `json:"historyMax,omitempty"`
+	OperationTolerations   []corev1.Toleration `json:"operationTolerations,omitempty"`
+	AutomaticCPTolerations bool                `json:"automaticCPTolerations,omitempty"`
+}
+
+// ReleaseHistory represents the revisions of a release, newest first
+type ReleaseHistory struct {
+	Release   string            `json:"release,omitempty"`
+	Namespace string            `json:"namespace,omitempty"`
+	Revisions []ReleaseRevision `json:"revisions"`
+}
+
+// ReleaseRevision represents a single revision of a release
+type ReleaseRevision struct {
+	Revision     int          `json:"revision"`
+	Status       string       `json:"status,omitempty"`
+	ChartName    string       `json:"chartName,omitempty"`
+	ChartVersion string       `json:"chartVersion,omitempty"`
+	AppVersion   string       `json:"appVersion,omitempty"`
+	Description  string       `json:"description,omitempty"`
+	Updated      *metav1.Time `json:"updated,omitempty"`
+}
+
 type ChartActionOutput struct {
 	OperationName      string `json:"operationName,omitempty"`
 	OperationNamespace string `json:"operationNamespace,omitempty"`
 }
+
+// ChartDryRunOutput represents the changes that an install or upgrade would make to the releases it targets
+type ChartDryRunOutput struct {
+	Releases []ReleaseDiff `json:"releases"`
+}
+
+// ReleaseDiff represents the changes that an install or upgrade would make to the resources of a release
+type ReleaseDiff struct {
+	Release   string `json:"release,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	ChartName string `json:"chartName,omitempty"`
+	// Version is the version of the chart that would be installed.
+	Version string `json:"version,omitempty"`
+	// CurrentVersion is the version of the chart of the deployed release, empty if the release is not installed.
+	CurrentVersion string         `json:"currentVersion,omitempty"`
+	Resources      []ResourceDiff `json:"resources"`
+}
+
+// ResourceDiff represents the change that would be made to a resource of a release
+type ResourceDiff struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	// Action is create, update, delete or unchanged.
+	Action  string        `json:"action"`
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// FieldChange represents the change of a single field of a resource. The values of secrets are redacted.
+type FieldChange struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// ExportBundleAction selects the ClusterRepos and charts exported into a catalog bundle
+type ExportBundleAction struct {
+	Repos []ExportBundleRepo `json:"repos"`
+}
+
+// ExportBundleRepo selects the charts of a ClusterRepo that are exported into a catalog bundle
+type ExportBundleRepo struct {
+	Name string `json:"name"`
+	// Charts are the names of the exported charts. All the charts of the repository are exported if empty.
+	Charts []string `json:"charts,omitempty"`
+	// LatestOnly exports only the latest version of each chart.
+	LatestOnly bool `json:"latestOnly,omitempty"`
+}
+
+// ImportBundleOutput represents the ClusterRepos imported from a catalog bundle and the images their charts reference
+type ImportBundleOutput struct {
+	Repos  []string `json:"repos"`
+	Images []string `json:"images"`
+}
+
+// ChartValuesValidationOutput represents the errors of the values of a rejected install or upgrade
+type ChartValuesValidationOutput struct {
+	Errors []ValuesFieldError `json:"errors"`
+}
+
+// ValuesFieldError represents a value of a chart that is invalid or breaks a values policy
+type ValuesFieldError struct {
+	Chart string `json:"chart,omitempty"`
+	// Path is the dotted path of the value, empty if the error applies to all the values.
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+	// Policy is the name of the values policy of the repository that the value breaks, if any.
+	Policy string `json:"policy,omitempty"`
+}
@@ -0,0 +1,619 @@
+// Package diagnostics provides a HTTPHandler to serve a diagnostics bundle of Rancher, a gzipped tar file with the data
+// support needs to investigate an issue. This handler should be registered at Endpoint and the handler of the data of
+// each replica at ReplicaEndpoint.
+package diagnostics
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/util"
+	"github.com/rancher/rancher/pkg/features"
+	managementv3 "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/namespace"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/rancher/pkg/types/config"
+	"github.com/rancher/rancher/pkg/utils"
+	"github.com/rancher/remotedialer"
+	crdv1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/apiextensions.k8s.io/v1"
+	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	authzv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	authv1 "k8s.io/client-go/kubernetes/typed/authorization/v1"
+)
+
+const (
+	// Endpoint The endpoint that the diagnostics bundle is accessible at
+	Endpoint = "/v1/rancherDiagnostics"
+	// ReplicaEndpoint The endpoint that the data of a single replica is accessible at, for the other replicas
+	ReplicaEndpoint = "/v1/rancherDiagnostics/replica"
+
+	// Group, Resource and Verb are the attributes of the SubjectAccessReview a user must pass to get the bundle
+	Group    = "management.cattle.io"
+	Resource = "diagnostics"
+	Verb     = "get"
+
+	tokenHeader     = "X-API-Tunnel-Token"
+	redacted        = "[redacted]"
+	provisioningLog = "provisioning-log"
+	tgzContentType  = "application/gzip"
+	replicaTimeout  = 30 * time.Second
+	// replicaPort is the port of the internal listener of the replicas, whose certificate is signed by internalCAName
+	replicaPort      = "444"
+	internalCAName   = "tls-rancher-internal-ca"
+	logPrefix        = "diagnostics"
+	cattleGroupRegex = `(^|\.)cattle\.io$`
+)
+
+var (
+	// maxReplicaSize is the size of the largest data of a replica that is copied into the bundle
+	maxReplicaSize int64 = 256 << 20
+
+	sensitiveSetting = regexp.MustCompile(`(?i)(password|secret|token|private|credential|key)`)
+	cattleGroup      = regexp.MustCompile(cattleGroupRegex)
+	metricPrefixes   = []string{"rancher_controller_", "cluster_manager_"}
+)
+
+// Handler implements http.Handler - and serves the diagnostics bundle of Rancher, which includes the data of each of
+// its replicas
+type Handler struct {
+	SubjectAccessReviews authv1.SubjectAccessReviewInterface
+	Settings             managementv3.SettingClient
+	Features             managementv3.FeatureClient
+	Clusters             managementv3.ClusterClient
+	ConfigMaps           corecontrollers.ConfigMapClient
+	Endpoints            corecontrollers.EndpointsClient
+	Secrets              corecontrollers.SecretClient
+	CRDs                 crdv1.CustomResourceDefinitionClient
+	TunnelServer         *remotedialer.Server
+	Gatherer             prometheus.Gatherer
+}
+
+// NewHandler creates a handler using the clients defined in scaledContext
+func NewHandler(scaledContext *config.ScaledContext, tunnelServer *remotedialer.Server) *Handler {
+	return &Handler{
+		SubjectAccessReviews: scaledContext.K8sClient.AuthorizationV1().SubjectAccessReviews(),
+		Settings:             scaledContext.Wrangler.Mgmt.Setting(),
+		Features:             scaledContext.Wrangler.Mgmt.Feature(),
+		Clusters:             scaledContext.Wrangler.Mgmt.Cluster(),
+		ConfigMaps:           scaledContext.Wrangler.Core.ConfigMap(),
+		Endpoints:            scaledContext.Wrangler.Core.Endpoints(),
+		Secrets:              scaledContext.Wrangler.Core.Secret(),
+		CRDs:                 scaledContext.Wrangler.CRD.CustomResourceDefinition(),
+		TunnelServer:         tunnelServer,
+		Gatherer:             prometheus.DefaultGatherer,
+	}
+}
+
+// ServeHTTP implements http.Handler - returns the diagnostics bundle if the user passes the SubjectAccessReview of
+// the diagnostics resource
+func (h *Handler) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
+	authorized, err := h.authorize(req)
+	if err != nil {
+		logrus.Errorf("[%s] Failed to authorize user with error: %v", logPrefix, err)
+	}
+	if !authorized {
+		util.ReturnHTTPError(writer, req, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	logrus.Infof("[%s] Generating diagnostics bundle", logPrefix)
+	writer.Header().Set("Content-Type", tgzContentType)
+	writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"rancher_diagnostics_%s.tar.gz\"", time.Now().UTC().Format("20060102T150405Z")))
+	if err := h.writeBundle(req.Context(), writer); err != nil {
+		// the response has started, the error can only be logged
+		logrus.Errorf("[%s] Error when generating diagnostics bundle: %v", logPrefix, err)
+		return
+	}
+	logrus.Infof("[%s] Done generating diagnostics bundle", logPrefix)
+}
+
+// ServeReplica serves the data of this replica, as a tar file, to the other replicas. Requests must have the peer
+// token of the tunnel server shared by the replicas.
+func (h *Handler) ServeReplica(writer http.ResponseWriter, req *http.Request) {
+	token := req.Header.Get(tokenHeader)
+	if h.TunnelServer.PeerToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.TunnelServer.PeerToken)) != 1 {
+		util.ReturnHTTPError(writer, req, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+	files, err := h.replicaFiles()
+	if err != nil {
+		logrus.Errorf("[%s] Error when collecting replica data: %v", logPrefix, err)
+		util.ReturnHTTPError(writer, req, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	tw := tar.NewWriter(writer)
+	for _, name := range sortedNames(files) {
+		if err := writeFile(tw, name, files[name]); err != nil {
+			logrus.Errorf("[%s] Error when writing replica data: %v", logPrefix, err)
+			return
+		}
+	}
+	tw.Close()
+}
+
+// authorize checks to see if the user can get the diagnostics resource. Returns a bool (if the user is authorized) and
+// optionally an error
+func (h *Handler) authorize(r *http.Request) (bool, error) {
+	userInfo, ok := request.UserFrom(r.Context())
+	if !ok {
+		return false, fmt.Errorf("unable to extract user info from context")
+	}
+	extra := map[string]authzv1.ExtraValue{}
+	for k, v := range userInfo.GetExtra() {
+		extra[k] = v
+	}
+	response, err := h.SubjectAccessReviews.Create(r.Context(), &authzv1.SubjectAccessReview{
+		Spec: authzv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &authzv1.ResourceAttributes{
+				Group:    Group,
+				Resource: Resource,
+				Verb:     Verb,
+			},
+			User:   userInfo.GetName(),
+			Groups: userInfo.GetGroups(),
+			Extra:  extra,
+			UID:    userInfo.GetUID(),
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to create a SubjectAccessReview: %w", err)
+	}
+	return response.Status.Allowed, nil
+}
+
+// writeBundle writes the gzipped tar of the diagnostics bundle. A part of the bundle that can't be collected is
+// replaced by a file with the error, so that the rest of the data is still available.
+func (h *Handler) writeBundle(ctx context.Context, w io.Writer) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	collectors := []struct {
+		name    string
+		collect func(ctx context.Context) (interface{}, error)
+	}{
+		{"settings.json", h.settings},
+		{"features.json", h.features},
+		{"clusters.json", h.clusters},
+		{"crds.json", h.crds},
+	}
+	for _, c := range collectors {
+		data, err := c.collect(ctx)
+		if err != nil {
+			if err := writeError(tw, c.name, err); err != nil {
+				return err
+			}
+			continue
+		}
+		body, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeFile(tw, c.name, body); err != nil {
+			return err
+		}
+	}
+
+	logs, err := h.provisioningLogs(ctx)
+	if err != nil {
+		if err := writeError(tw, "provisioning-logs", err); err != nil {
+			return err
+		}
+	}
+	for _, name := range sortedNames(logs) {
+		if err := writeFile(tw, "provisioning-logs/"+name+".log", logs[name]); err != nil {
+			return err
+		}
+	}
+
+	if err := h.writeReplicas(ctx, tw); err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+type settingInfo struct {
+	Name       string `json:"name"`
+	Value      string `json:"value"`
+	Default    string `json:"default,omitempty"`
+	Source     string `json:"source,omitempty"`
+	Customized bool   `json:"customized"`
+}
+
+func (h *Handler) settings(_ context.Context) (interface{}, error) {
+	list, err := h.Settings.List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var result []settingInfo
+	for _, s := range list.Items {
+		result = append(result, settingInfo{
+			Name:       s.Name,
+			Value:      redact(s.Name, s.Value),
+			Default:    redact(s.Name, s.Default),
+			Source:     s.Source,
+			Customized: s.Value != "",
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// redact hides the value of settings that may hold credentials, and the credentials in URLs.
+func redact(name, value string) string {
+	if value == "" {
+		return value
+	}
+	if sensitiveSetting.MatchString(name) {
+		if _, err := strconv.ParseBool(value); err == nil {
+			return value
+		}
+		if _, err := strconv.Atoi(value); err == nil {
+			return value
+		}
+		return redacted
+	}
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		u.User = url.User(redacted)
+		return u.String()
+	}
+	return value
+}
+
+type featureInfo struct {
+	Name            string   `json:"name"`
+	Enabled         bool     `json:"enabled"`
+	Default         bool     `json:"default"`
+	Locked          bool     `json:"locked"`
+	Rules           int      `json:"rules,omitempty"`
+	EnabledClusters []string `json:"enabledClusters,omitempty"`
+}
+
+func (h *Handler) features(_ context.Context) (interface{}, error) {
+	list, err := h.Features.List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var result []featureInfo
+	for i := range list.Items {
+		f := &list.Items[i]
+		result = append(result, featureInfo{
+			Name:            f.Name,
+			Enabled:         features.IsEnabled(f),
+			Default:         f.Status.Default,
+			Locked:          f.Status.LockedValue != nil,
+			Rules:           len(f.Spec.Rules),
+			EnabledClusters: f.Status.EnabledClusters,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+type clusterInfo struct {
+	Name          string                `json:"name"`
+	DisplayName   string                `json:"displayName,omitempty"`
+	Driver        string                `json:"driver,omitempty"`
+	Version       string                `json:"version,omitempty"`
+	AgentImage    string                `json:"agentImage,omitempty"`
+	TunnelSession bool                  `json:"tunnelSession"`
+	Conditions    []v3.ClusterCondition `json:"conditions,omitempty"`
+}
+
+func (h *Handler) clusters(_ context.Context) (interface{}, error) {
+	list, err := h.Clusters.List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var result []clusterInfo
+	for _, c := range list.Items {
+		info := clusterInfo{
+			Name:        c.Name,
+			DisplayName: c.Spec.DisplayName,
+			Driver:      c.Status.Driver,
+			AgentImage:  c.Status.AgentImage,
+			Conditions:  c.Status.Conditions,
+		}
+		if c.Status.Version != nil {
+			info.Version = c.Status.Version.GitVersion
+		}
+		if h.TunnelServer != nil {
+			info.TunnelSession = h.TunnelServer.HasSession(c.Name)
+		}
+		result = append(result, info)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+type crdInfo struct {
+	Name           string   `json:"name"`
+	Served         []string `json:"served"`
+	Storage        string   `json:"storage"`
+	StoredVersions []string `json:"storedVersions,omitempty"`
+}
+
+func (h *Handler) crds(_ context.Context) (interface{}, error) {
+	list, err := h.CRDs.List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var result []crdInfo
+	for _, crd := range list.Items {
+		if !cattleGroup.MatchString(crd.Spec.Group) {
+			continue
+		}
+		info := crdInfo{
+			Name:           crd.Name,
+			StoredVersions: crd.Status.StoredVersions,
+		}
+		for _, v := range crd.Spec.Versions {
+			if v.Served {
+				info.Served = append(info.Served, v.Name)
+			}
+			if v.Storage {
+				info.Storage = v.Name
+			}
+		}
+		result = append(result, info)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// provisioningLogs returns the provisioning log of each cluster, by the namespace of the cluster.
+func (h *Handler) provisioningLogs(_ context.Context) (map[string][]byte, error) {
+	list, err := h.ConfigMaps.List(metav1.NamespaceAll, metav1.ListOptions{
+		FieldSelector: "metadata.name=" + provisioningLog,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := map[string][]byte{}
+	for _, cm := range list.Items {
+		if log := cm.Data["log"]; log != "" {
+			result[cm.Namespace] = []byte(log)
+		}
+	}
+	return result, nil
+}
+
+// replicaFiles returns the profiles and controller metrics of this replica.
+func (h *Handler) replicaFiles() (map[string][]byte, error) {
+	files := map[string][]byte{}
+	for name, profile := range map[string]struct {
+		name  string
+		debug int
+	}{
+		"goroutines.txt": {"goroutine", 1},
+		"heap.pprof":     {"heap", 0},
+	} {
+		var buf bytes.Buffer
+		if err := pprof.Lookup(profile.name).WriteTo(&buf, profile.debug); err != nil {
+			return nil, err
+		}
+		files[name] = buf.Bytes()
+	}
+
+	families, err := h.Gatherer.Gather()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for _, family := range families {
+		for _, prefix := range metricPrefixes {
+			if strings.HasPrefix(family.GetName(), prefix) {
+				if _, err := expfmt.MetricFamilyToText(&buf, family); err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+	}
+	files["controller-metrics.txt"] = buf.Bytes()
+	return files, nil
+}
+
+// writeReplicas writes the data of this replica, and of the other replicas of the peer service, under replicas/<ID>.
+func (h *Handler) writeReplicas(ctx context.Context, tw *tar.Writer) error {
+	self := h.TunnelServer.PeerID
+	if self == "" {
+		self, _ = os.Hostname()
+	}
+	files, err := h.replicaFiles()
+	if err != nil {
+		if err := writeError(tw, "replicas/"+self, err); err != nil {
+			return err
+		}
+	}
+	for _, name := range sortedNames(files) {
+		if err := writeFile(tw, "replicas/"+self+"/"+name, files[name]); err != nil {
+			return err
+		}
+	}
+
+	peers, err := h.peers()
+	if err != nil {
+		return writeError(tw, "replicas", err)
+	}
+	if len(peers) == 0 {
+		return nil
+	}
+	client, err := h.replicaClient()
+	if err != nil {
+		return writeError(tw, "replicas", err)
+	}
+	for _, peer := range peers {
+		host := peer
+		if utils.IsPlainIPV6(peer) {
+			host = "[" + peer + "]"
+		}
+		if err := h.writePeer(ctx, tw, client, peer, "https://"+host+":"+replicaPort+ReplicaEndpoint); err != nil {
+			if err := writeError(tw, "replicas/"+peer, err); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// peers returns the IPs of the other replicas, from the endpoints of the peer service. It returns none when Rancher
+// runs in single server mode.
+func (h *Handler) peers() ([]string, error) {
+	if h.TunnelServer.PeerID == "" || settings.PeerServices.Get() == "" {
+		return nil, nil
+	}
+	var result []string
+	for _, svc := range strings.Split(settings.PeerServices.Get(), ",") {
+		endpoints, err := h.Endpoints.Get(settings.Namespace.Get(), strings.TrimSpace(svc), metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, subset := range endpoints.Subsets {
+			for _, addr := range subset.Addresses {
+				if addr.IP != h.TunnelServer.PeerID {
+					result = append(result, addr.IP)
+				}
+			}
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// replicaClient returns the client of the other replicas. They are reached by pod IP on their internal listener, whose
+// certificate isn't valid for it, so the certificate is only verified to be signed by the internal CA the replicas
+// share. The peer token is thus only ever sent to replicas.
+func (h *Handler) replicaClient() (*http.Client, error) {
+	secret, err := h.Secrets.Get(namespace.System, internalCAName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(secret.Data[corev1.TLSCertKey]) {
+		return nil, fmt.Errorf("no CA certificate found in secret %s/%s", namespace.System, internalCAName)
+	}
+	return &http.Client{
+		Timeout: replicaTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				// the certificate is verified by verifyReplica instead, which ignores the host name
+				InsecureSkipVerify: true,
+				VerifyConnection: func(state tls.ConnectionState) error {
+					return verifyReplica(roots, state)
+				},
+			},
+		},
+	}, nil
+}
+
+// verifyReplica verifies that the certificate of the replica of the given connection is signed by the given CA.
+func verifyReplica(roots *x509.CertPool, state tls.ConnectionState) error {
+	if len(state.PeerCertificates) == 0 {
+		return fmt.Errorf("replica presented no certificate")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	return err
+}
+
+// writePeer copies the tar of the data of the replica with the given IP, served at the given URL, under
+// replicas/<IP>. It fails if the data is larger than maxReplicaSize.
+func (h *Handler) writePeer(ctx context.Context, tw *tar.Writer, client *http.Client, ip, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(tokenHeader, h.TunnelServer.PeerToken)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("replica responded with %s", resp.Status)
+	}
+
+	body := &io.LimitedReader{R: resp.Body, N: maxReplicaSize + 1}
+	tr := tar.NewReader(body)
+	for {
+		hdr, err := tr.Next()
+		if body.N <= 0 {
+			return fmt.Errorf("replica data is larger than %d bytes", maxReplicaSize)
+		} else if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(tr)
+		if body.N <= 0 {
+			return fmt.Errorf("replica data is larger than %d bytes", maxReplicaSize)
+		} else if err != nil {
+			return err
+		}
+		if err := writeFile(tw, "replicas/"+ip+"/"+hdr.Name, data); err != nil {
+			return err
+		}
+	}
+}
+
+func writeError(tw *tar.Writer, name string, err error) error {
+	logrus.Warnf("[%s] Failed to collect %s: %v", logPrefix, name, err)
+	return writeFile(tw, strings.TrimSuffix(name, ".json")+".error.txt", []byte(err.Error()+"\n"))
+}
+
+func writeFile(tw *tar.Writer, name string, body []byte) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(body)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(body)
+	return err
+}
+
+func sortedNames(files map[string][]byte) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
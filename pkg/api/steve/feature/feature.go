@@ -0,0 +1,27 @@
+package feature
+
+import (
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/rancher/pkg/features"
+	schema2 "github.com/rancher/steve/pkg/schema"
+	steve "github.com/rancher/steve/pkg/server"
+)
+
+// Register adds the value of each feature for the user of the request, which its rules targeting groups can override,
+// to the status of the feature as effectiveValue.
+func Register(server *steve.Server) {
+	server.SchemaFactory.AddTemplate(schema2.Template{
+		Group:     "management.cattle.io",
+		Kind:      "Feature",
+		Formatter: formatter,
+	})
+}
+
+func formatter(request *types.APIRequest, resource *types.RawResource) {
+	data := resource.APIObject.Data()
+	feature := features.GetFeatureByName(data.String("metadata", "name"))
+	if feature == nil {
+		return
+	}
+	data.SetNested(feature.EnabledFor(request.Context()), "status", "effectiveValue")
+}
@@ -0,0 +1,63 @@
+package feature
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/features"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestFormatter(t *testing.T) {
+	feature := features.UISQLCache
+	existingState := feature.Enabled()
+	t.Cleanup(func() {
+		feature.Set(existingState)
+		feature.SetRules(nil)
+	})
+	feature.Set(false)
+	feature.SetRules([]v3.FeatureRule{{Value: true, Groups: []string{"okta_group://beta-testers"}}})
+
+	tests := []struct {
+		name        string
+		featureName string
+		user        user.Info
+		expect      interface{}
+	}{
+		{
+			name:        "member of a targeted group",
+			featureName: feature.Name(),
+			user:        &user.DefaultInfo{Name: "u-beta", Groups: []string{"okta_group://beta-testers"}},
+			expect:      true,
+		},
+		{
+			name:        "other user",
+			featureName: feature.Name(),
+			user:        &user.DefaultInfo{Name: "u-abc"},
+			expect:      false,
+		},
+		{
+			name:        "unknown feature",
+			featureName: "unknown",
+			user:        &user.DefaultInfo{Name: "u-beta", Groups: []string{"okta_group://beta-testers"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/v1/management.cattle.io.features", nil)
+			apiRequest := &types.APIRequest{Request: req.WithContext(request.WithUser(req.Context(), tt.user))}
+			resource := &types.RawResource{APIObject: types.APIObject{Object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": tt.featureName},
+			}}}
+
+			formatter(apiRequest, resource)
+
+			status, _ := resource.APIObject.Data()["status"].(map[string]interface{})
+			assert.Equal(t, tt.expect, status["effectiveValue"])
+		})
+	}
+}
@@ -15,6 +15,10 @@ func Register(server *steve.Server, clients *wrangler.Context) {
 		machines: clients.CAPI.Machine(),
 		secrets:  clients.Core.Secret(),
 	}
+	timeline := &timeline{
+		machines: clients.CAPI.Machine().Cache(),
+		secrets:  clients.Core.Secret(),
+	}
 
 	server.SchemaFactory.AddTemplate(schema2.Template{
 		Group: "cluster.x-k8s.io",
@@ -25,9 +29,13 @@ func Register(server *steve.Server, clients *wrangler.Context) {
 			}
 			schema.LinkHandlers["shell"] = sshClient
 			schema.LinkHandlers["sshkeys"] = sshClient
+			schema.LinkHandlers["timeline"] = timeline
 			schema.Formatter = func(request *types.APIRequest, resource *types.RawResource) {
-				if err := request.AccessControl.CanUpdate(request, types.APIObject{}, request.Schema); err != nil ||
-					resource.APIObject.Data().String("spec", "infrastructureRef", "apiVersion") != capr.RKEMachineAPIVersion {
+				if err := request.AccessControl.CanUpdate(request, types.APIObject{}, request.Schema); err != nil {
+					delete(resource.Links, "timeline")
+					delete(resource.Links, "shell")
+					delete(resource.Links, "sshkeys")
+				} else if resource.APIObject.Data().String("spec", "infrastructureRef", "apiVersion") != capr.RKEMachineAPIVersion {
 					delete(resource.Links, "shell")
 					delete(resource.Links, "sshkeys")
 				}
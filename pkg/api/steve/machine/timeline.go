@@ -0,0 +1,158 @@
+package machine
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/rancher/pkg/capr"
+	"github.com/rancher/rancher/pkg/capr/planner"
+	capicontrollers "github.com/rancher/rancher/pkg/generated/controllers/cluster.x-k8s.io/v1beta1"
+	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+var timelineWatchTimeout int64 = 15 * 60
+
+// timelineResponse is the body returned by the timeline link of a machine.
+type timelineResponse struct {
+	PlanSecret string                  `json:"planSecret"`
+	InSync     bool                    `json:"inSync"`
+	Failed     bool                    `json:"failed"`
+	Healthy    bool                    `json:"healthy"`
+	Entries    []planner.TimelineEntry `json:"entries"`
+}
+
+// timeline serves the provisioning timeline that is recorded in the plan secret of a machine. Websocket requests are
+// streamed the entries as they are recorded, starting with the ones that already exist.
+type timeline struct {
+	machines capicontrollers.MachineCache
+	secrets  corecontrollers.SecretClient
+}
+
+func (t *timeline) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	apiRequest := types.GetAPIContext(req.Context())
+	if err := apiRequest.AccessControl.CanUpdate(apiRequest, types.APIObject{}, apiRequest.Schema); err != nil {
+		apiRequest.WriteError(err)
+		return
+	}
+
+	var err error
+	if websocket.IsWebSocketUpgrade(req) {
+		err = t.stream(apiRequest)
+	} else {
+		err = t.get(apiRequest)
+	}
+	if err != nil {
+		apiRequest.WriteError(err)
+	}
+}
+
+func (t *timeline) planSecretName(namespace, name string) (string, error) {
+	machine, err := t.machines.Get(namespace, name)
+	if err != nil {
+		return "", err
+	}
+	if machine.Spec.Bootstrap.ConfigRef == nil {
+		return "", fmt.Errorf("machine %s/%s does not have a bootstrap config", namespace, name)
+	}
+	return capr.PlanSecretFromBootstrapName(machine.Spec.Bootstrap.ConfigRef.Name), nil
+}
+
+func (t *timeline) get(apiRequest *types.APIRequest) error {
+	secretName, err := t.planSecretName(apiRequest.Namespace, apiRequest.Name)
+	if err != nil {
+		return err
+	}
+	secret, err := t.secrets.Get(apiRequest.Namespace, secretName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	response, err := toTimelineResponse(secret)
+	if err != nil {
+		return err
+	}
+
+	apiRequest.Response.Header().Set("Content-Type", "application/json")
+	apiRequest.Response.Header().Set("Cache-Control", "private")
+	apiRequest.Response.WriteHeader(http.StatusOK)
+	return json.NewEncoder(apiRequest.Response).Encode(response)
+}
+
+func (t *timeline) stream(apiRequest *types.APIRequest) error {
+	secretName, err := t.planSecretName(apiRequest.Namespace, apiRequest.Name)
+	if err != nil {
+		return err
+	}
+
+	conn, err := upgrader.Upgrade(apiRequest.Response, apiRequest.Request, nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	w, err := t.secrets.Watch(apiRequest.Namespace, metav1.ListOptions{
+		TimeoutSeconds: &timelineWatchTimeout,
+		FieldSelector:  "metadata.name=" + secretName,
+	})
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+
+	var last int64
+	for event := range w.ResultChan() {
+		switch event.Type {
+		case watch.Added, watch.Modified:
+		case watch.Deleted:
+			return nil
+		default:
+			continue
+		}
+		secret, ok := event.Object.(*corev1.Secret)
+		if !ok {
+			continue
+		}
+		timeline, err := planner.ParseTimeline(secret)
+		if err != nil {
+			return err
+		}
+		for _, entry := range timeline.Entries {
+			if entry.Sequence <= last {
+				continue
+			}
+			if err := conn.WriteJSON(entry); err != nil {
+				return err
+			}
+			last = entry.Sequence
+		}
+	}
+
+	return nil
+}
+
+func toTimelineResponse(secret *corev1.Secret) (*timelineResponse, error) {
+	timeline, err := planner.ParseTimeline(secret)
+	if err != nil {
+		return nil, err
+	}
+	response := &timelineResponse{
+		PlanSecret: secret.Name,
+		Entries:    timeline.Entries,
+	}
+	node, err := planner.SecretToNode(secret)
+	if err != nil {
+		return nil, err
+	}
+	if node != nil {
+		response.InSync = node.InSync
+		response.Failed = node.Failed
+		response.Healthy = node.Healthy
+	}
+	return response, nil
+}
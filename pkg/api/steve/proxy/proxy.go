@@ -13,10 +13,13 @@
 	v3 "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
 	managementv3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/rancher/pkg/tracing"
 	"github.com/rancher/remotedialer"
 	"github.com/rancher/steve/pkg/auth"
 	"github.com/rancher/steve/pkg/proxy"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	authzv1 "k8s.io/api/authorization/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apiserver/pkg/authentication/user"
@@ -162,6 +165,10 @@ func (h *Handler) matchManagementCRDs() gmux.MatcherFunc {
 
 func (h *Handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	clusterID := gmux.Vars(req)["clusterID"]
+	ctx, span := tracing.Tracer().Start(req.Context(), "cluster proxy", trace.WithAttributes(attribute.String("rancher.cluster", clusterID)))
+	defer span.End()
+	req = req.WithContext(ctx)
+
 	authed := h.userCanAccessCluster(req, clusterID)
 	if !authed {
 		rw.WriteHeader(http.StatusUnauthorized)
@@ -215,13 +222,13 @@ func (h *Handler) dialer(ctx context.Context, network, address string) (net.Conn
 func (h *Handler) next(clusterID, prefix string) (http.Handler, error) {
 	ht := http.DefaultTransport.(*http.Transport).Clone()
 	ht.Proxy = nil
-	ht.DialContext = h.dialer
+	ht.DialContext = tracing.Dialer(clusterID, h.dialer)
 	cfg := &rest.Config{
 		// this is bogus, the dialer will change it to 127.0.0.1:6080, but the clusterID is used to lookup the tunnel
 		// connect
 		Host:      "http://" + clusterID,
 		UserAgent: rest.DefaultKubernetesUserAgent() + " cluster " + clusterID,
-		Transport: ht,
+		Transport: tracing.Transport(ht),
 	}
 
 	next := proxy.ImpersonatingHandler(prefix, cfg)
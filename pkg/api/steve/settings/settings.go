@@ -16,5 +16,10 @@ func Register(server *steve.Server) {
 				data.Set("value", data.String("default"))
 			}
 		},
+		StoreFactory: func(innerStore types.Store) types.Store {
+			return &store{
+				Store: innerStore,
+			}
+		},
 	})
 }
@@ -0,0 +1,121 @@
+package settings
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/wrangler/v3/pkg/data/convert"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	apitypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+// maxPatchSize is the size of the largest patch read, as by the inner store.
+const maxPatchSize = 2 << 20
+
+// store validates the values of the settings written through the API and records their changes in their history.
+type store struct {
+	types.Store
+}
+
+func (s *store) Create(apiOp *types.APIRequest, schema *types.APISchema, data types.APIObject) (types.APIObject, error) {
+	if err := s.recordChange(apiOp, data.Data().String("metadata", "name"), data, &v3.Setting{}); err != nil {
+		return types.APIObject{}, err
+	}
+	return s.Store.Create(apiOp, schema, data)
+}
+
+func (s *store) Update(apiOp *types.APIRequest, schema *types.APISchema, data types.APIObject, id string) (types.APIObject, error) {
+	existing, err := s.Store.ByID(apiOp, schema, id)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	if apiOp.Method == http.MethodPatch {
+		// patches are applied here rather than by the inner store, so that the setting they result in is validated.
+		// It is then updated with the resource version it was patched from, which fails if it changed in between.
+		data, err = applyPatch(apiOp, existing)
+		if err != nil {
+			return types.APIObject{}, apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+		}
+		update := *apiOp
+		update.Method = http.MethodPut
+		apiOp = &update
+	}
+	setting := &v3.Setting{}
+	if err := convert.ToObj(existing.Data(), setting); err != nil {
+		return types.APIObject{}, err
+	}
+	if err := s.recordChange(apiOp, id, data, setting); err != nil {
+		return types.APIObject{}, err
+	}
+	return s.Store.Update(apiOp, schema, data, id)
+}
+
+// applyPatch returns the given setting with the patch of the request applied.
+func applyPatch(apiOp *types.APIRequest, existing types.APIObject) (types.APIObject, error) {
+	patch, err := io.ReadAll(io.LimitReader(apiOp.Request.Body, maxPatchSize))
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	original, err := json.Marshal(existing.Data())
+	if err != nil {
+		return types.APIObject{}, err
+	}
+
+	var patched []byte
+	if apiOp.Request.Header.Get("content-type") == string(apitypes.JSONPatchType) {
+		jsonPatch, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return types.APIObject{}, err
+		}
+		patched, err = jsonPatch.Apply(original)
+		if err != nil {
+			return types.APIObject{}, err
+		}
+	} else {
+		// settings are custom resources, for which strategic merge patches are JSON merge patches
+		patched, err = jsonpatch.MergePatch(original, patch)
+		if err != nil {
+			return types.APIObject{}, err
+		}
+	}
+
+	obj := map[string]interface{}{}
+	if err := json.Unmarshal(patched, &obj); err != nil {
+		return types.APIObject{}, err
+	}
+	return types.APIObject{Type: existing.Type, ID: existing.ID, Object: obj}, nil
+}
+
+// recordChange validates the value of the setting with the given name in data and replaces its history with the one
+// of the existing setting including the change, so that it can't be altered by clients.
+func (s *store) recordChange(apiOp *types.APIRequest, name string, data types.APIObject, existing *v3.Setting) error {
+	obj := data.Data()
+	value := obj.String("value")
+	if err := settings.Validate(name, value); err != nil {
+		return apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+	}
+
+	var userName string
+	if user, ok := request.UserFrom(apiOp.Context()); ok {
+		userName = user.GetName()
+	}
+	settings.RecordChange(existing, value, userName)
+
+	var history []interface{}
+	if err := convert.ToObj(existing.History, &history); err != nil {
+		return err
+	}
+	if len(history) == 0 {
+		delete(obj, "history")
+	} else {
+		obj.Set("history", history)
+	}
+	return nil
+}
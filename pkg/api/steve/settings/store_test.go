@@ -0,0 +1,101 @@
+package settings
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	types.Store
+
+	existing types.APIObject
+	method   string
+	updated  types.APIObject
+}
+
+func (f *fakeStore) ByID(*types.APIRequest, *types.APISchema, string) (types.APIObject, error) {
+	return f.existing, nil
+}
+
+func (f *fakeStore) Update(apiOp *types.APIRequest, _ *types.APISchema, data types.APIObject, _ string) (types.APIObject, error) {
+	f.method = apiOp.Method
+	f.updated = data
+	return data, nil
+}
+
+func TestUpdatePatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		patch       string
+		wantValue   string
+		wantErr     bool
+	}{
+		{
+			name:      "merge patch",
+			patch:     `{"value":"16"}`,
+			wantValue: "16",
+		},
+		{
+			name:        "JSON patch",
+			contentType: "application/json-patch+json",
+			patch:       `[{"op":"replace","path":"/value","value":"20"}]`,
+			wantValue:   "20",
+		},
+		{
+			name:    "invalid value",
+			patch:   `{"value":"1"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid patch",
+			patch:   `{"value":`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &fakeStore{
+				existing: types.APIObject{
+					Type: "management.cattle.io.setting",
+					ID:   settings.PasswordMinLength.Name,
+					Object: map[string]interface{}{
+						"metadata": map[string]interface{}{
+							"name":            settings.PasswordMinLength.Name,
+							"resourceVersion": "42",
+						},
+						"default": settings.PasswordMinLength.Default,
+						"value":   "",
+					},
+				},
+			}
+			s := &store{Store: inner}
+
+			req := httptest.NewRequest(http.MethodPatch, "/v1/management.cattle.io.settings/"+settings.PasswordMinLength.Name, strings.NewReader(tt.patch))
+			if tt.contentType != "" {
+				req.Header.Set("content-type", tt.contentType)
+			}
+			apiOp := &types.APIRequest{Method: http.MethodPatch, Request: req}
+
+			_, err := s.Update(apiOp, nil, types.APIObject{}, settings.PasswordMinLength.Name)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Empty(t, inner.method, "invalid patches must not reach the inner store")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, http.MethodPut, inner.method)
+			assert.Equal(t, tt.wantValue, inner.updated.Data().String("value"))
+			assert.Equal(t, "42", inner.updated.Data().String("metadata", "resourceVersion"))
+			assert.Len(t, inner.updated.Data().Slice("history"), 1)
+		})
+	}
+}
@@ -6,6 +6,7 @@
 	"github.com/rancher/rancher/pkg/api/steve/catalog"
 	"github.com/rancher/rancher/pkg/api/steve/clusters"
 	"github.com/rancher/rancher/pkg/api/steve/disallow"
+	"github.com/rancher/rancher/pkg/api/steve/feature"
 	"github.com/rancher/rancher/pkg/api/steve/machine"
 	"github.com/rancher/rancher/pkg/api/steve/navlinks"
 	"github.com/rancher/rancher/pkg/api/steve/settings"
@@ -22,9 +23,12 @@ func Setup(ctx context.Context, server *steve.Server, config *wrangler.Context)
 	machine.Register(server, config)
 	navlinks.Register(ctx, server)
 	settings.Register(server)
+	feature.Register(server)
 	disallow.Register(server)
 	return catalog.Register(ctx,
 		server,
 		config.HelmOperations,
-		config.CatalogContentManager)
+		config.CatalogContentManager,
+		config.Core.ConfigMap(),
+		config.Catalog.ClusterRepo())
 }
@@ -18,9 +18,113 @@ type App struct {
 	Status ReleaseStatus `json:"status,omitempty"`
 }
 
+// AppEnforceReleaseAnnotation marks an App whose drifted resources are re-applied from the release manifest.
+const AppEnforceReleaseAnnotation = "catalog.cattle.io/enforce-release"
+
+// AppUpgradePolicyAnnotation holds the JSON encoded AppUpgradePolicy of an App that is upgraded automatically when
+// new versions of its chart are added to its ClusterRepo.
+const AppUpgradePolicyAnnotation = "catalog.cattle.io/upgrade-policy"
+
+// AppUpgradePolicy describes which versions of its chart an App is automatically upgraded to, and when.
+type AppUpgradePolicy struct {
+	// VersionRange limits the versions the App is upgraded to. It is patch, minor, major or a semver constraint such
+	// as "~1.2". Defaults to patch.
+	VersionRange string `json:"versionRange,omitempty"`
+	// IncludePrerelease allows upgrades to pre-release versions.
+	IncludePrerelease bool `json:"includePrerelease,omitempty"`
+	// Window restricts when upgrades are applied. Upgrades are applied as soon as they are found if unset.
+	Window *UpgradeWindow `json:"window,omitempty"`
+	// CarryValues are the dotted paths of the values of the release that are carried forward to the new version.
+	// All the values of the release are carried forward if empty.
+	CarryValues []string `json:"carryValues,omitempty"`
+	// HealthTimeout is how long the resources of the release have to become ready after an upgrade. Defaults to 10m.
+	HealthTimeout *metav1.Duration `json:"healthTimeout,omitempty"`
+	// RollbackOnFailure rolls the release back to its previous revision if the upgrade fails or its resources do not
+	// become ready in time.
+	RollbackOnFailure bool `json:"rollbackOnFailure,omitempty"`
+}
+
+// UpgradeWindow is a recurring period of time during which upgrades are applied.
+type UpgradeWindow struct {
+	// Schedule is a cron expression of the start of the window in UTC, for instance "0 2 * * 6" for Saturdays at 2AM.
+	Schedule string `json:"schedule,omitempty"`
+	// Duration is the length of the window. Defaults to 1h.
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
 type ReleaseStatus struct {
 	Summary            Summary `json:"summary,omitempty"`
 	ObservedGeneration int64   `json:"observedGeneration"`
+	// Drift is the result of the last comparison of the live resources of the release with its manifest.
+	Drift *ReleaseDrift `json:"drift,omitempty"`
+	// Upgrade is the state of the automatic upgrades of the release, for Apps with an upgrade policy.
+	Upgrade *ReleaseUpgrade `json:"upgrade,omitempty"`
+}
+
+// UpgradeState is the state of the automatic upgrade of a release.
+type UpgradeState string
+
+const (
+	// UpgradeStateUpToDate indicates that no version allowed by the upgrade policy is newer than the release.
+	UpgradeStateUpToDate UpgradeState = "up-to-date"
+	// UpgradeStatePending indicates that a newer version is waiting for the upgrade window or for the release to be deployed.
+	UpgradeStatePending UpgradeState = "pending"
+	// UpgradeStateUpgrading indicates that the release is being upgraded and its health is being checked.
+	UpgradeStateUpgrading UpgradeState = "upgrading"
+	// UpgradeStateSucceeded indicates that the last upgrade passed its health gate.
+	UpgradeStateSucceeded UpgradeState = "succeeded"
+	// UpgradeStateFailed indicates that the last upgrade failed or did not pass its health gate.
+	UpgradeStateFailed UpgradeState = "failed"
+	// UpgradeStateRolledBack indicates that the last upgrade failed and the release was rolled back.
+	UpgradeStateRolledBack UpgradeState = "rolled-back"
+)
+
+// ReleaseUpgrade describes the pending and applied automatic upgrades of a release.
+type ReleaseUpgrade struct {
+	State UpgradeState `json:"state,omitempty"`
+	// PendingVersion is the newest version of the chart allowed by the upgrade policy that is not installed.
+	PendingVersion string `json:"pendingVersion,omitempty"`
+	// NextWindow is when the next upgrade window opens, if the pending version waits for it.
+	NextWindow *metav1.Time `json:"nextWindow,omitempty"`
+	// FromVersion is the version of the chart before the last upgrade.
+	FromVersion string `json:"fromVersion,omitempty"`
+	// FromRevision is the revision of the release before the last upgrade, which is rolled back to on failure.
+	FromRevision int `json:"fromRevision,omitempty"`
+	// ToVersion is the version of the chart of the last upgrade.
+	ToVersion string `json:"toVersion,omitempty"`
+	// Operation is the namespace and name of the operation of the last upgrade.
+	Operation string `json:"operation,omitempty"`
+	// Started is when the last upgrade started.
+	Started *metav1.Time `json:"started,omitempty"`
+	// Completed is when the last upgrade succeeded, failed or was rolled back.
+	Completed *metav1.Time `json:"completed,omitempty"`
+	// Error is set if the upgrade policy is invalid or the last upgrade failed.
+	Error string `json:"error,omitempty"`
+}
+
+// ReleaseDrift describes the resources of a release that no longer match the release manifest.
+type ReleaseDrift struct {
+	// Drifted is true if at least one resource of the release does not match the release manifest.
+	Drifted bool `json:"drifted,omitempty"`
+	// ReleaseVersion is the version of the release whose manifest was compared.
+	ReleaseVersion int `json:"releaseVersion,omitempty"`
+	// Resources are the resources that do not match the release manifest.
+	Resources []ResourceDrift `json:"resources,omitempty"`
+	// LastChecked is when the live resources were last compared with the release manifest.
+	LastChecked *metav1.Time `json:"lastChecked,omitempty"`
+	// LastCorrected is when drifted resources were last re-applied from the release manifest.
+	LastCorrected *metav1.Time `json:"lastCorrected,omitempty"`
+	// Error is set if the drift of the release could not be determined or corrected.
+	Error string `json:"error,omitempty"`
+}
+
+// ResourceDrift describes how a resource of a release differs from the release manifest.
+type ResourceDrift struct {
+	ReleaseResource `json:",inline"`
+	// Missing is true if the resource no longer exists.
+	Missing bool `json:"missing,omitempty"`
+	// Fields are the paths of the fields whose live value differs from the release manifest.
+	Fields []string `json:"fields,omitempty"`
 }
 
 type Summary struct {
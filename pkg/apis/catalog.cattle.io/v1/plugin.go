@@ -46,6 +46,13 @@ type UIPluginEntry struct {
 	NoAuth bool `json:"noAuth,omitempty"`
 	// Metadata of the plugin.
 	Metadata map[string]string `json:"metadata,omitempty"`
+	// Digests are the expected digests of the files of the plugin fetched from Endpoint, keyed by their path
+	// in its files.txt file, in the "sha256:<hex>" format. If set, the plugin is only cached and served if all
+	// of its files match their digest.
+	Digests map[string]string `json:"digests,omitempty"`
+	// CompressedDigest is the expected digest of the file at CompressedEndpoint, in the "sha256:<hex>" format.
+	// If set, the plugin is only cached and served if the file matches it.
+	CompressedDigest string `json:"compressedDigest,omitempty"`
 }
 
 type UIPluginStatus struct {
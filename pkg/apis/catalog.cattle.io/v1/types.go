@@ -66,6 +66,25 @@ type RepoSpec struct {
 	// GitBranch is the git branch where the helm repository is hosted.
 	GitBranch string `json:"gitBranch,omitempty"`
 
+	// GitTag is the git tag the helm repository is pinned to. It takes precedence over GitBranch.
+	GitTag string `json:"gitTag,omitempty"`
+
+	// GitCommit is the full SHA of the git commit the helm repository is pinned to.
+	// It takes precedence over GitTag and GitBranch.
+	GitCommit string `json:"gitCommit,omitempty"`
+
+	// GitSubDirectory is the directory of the git repo which contains the helm repository.
+	// If unspecified, the whole git repo is searched for charts.
+	GitSubDirectory string `json:"gitSubDirectory,omitempty"`
+
+	// GitCommitVerification if specified requires the commit of the git repo to be signed by
+	// one of the trusted keys before the index of the helm repository is built.
+	GitCommitVerification *GitCommitVerification `json:"gitCommitVerification,omitempty"`
+
+	// Imported is set on the repositories created from an imported catalog bundle. Their index, charts
+	// and icons are stored in the cluster and served by Rancher, for air-gapped environments.
+	Imported bool `json:"imported,omitempty"`
+
 	// RefreshInterval is the interval at which the Helm repository should be refreshed.
 	RefreshInterval int `json:"refreshInterval,omitempty"`
 
@@ -73,6 +92,10 @@ type RepoSpec struct {
 	// 429 TOOMANYREQUESTS response code from the OCI registry.
 	ExponentialBackOffValues *ExponentialBackOffValues `json:"exponentialBackOffValues,omitempty"`
 
+	// OCITagFilter is only valid for OCI URL's and selects the tags of the OCI repositories that are
+	// added to the index of the Helm repository. If unspecified, all the semver tags are added.
+	OCITagFilter *OCITagFilter `json:"ociTagFilter,omitempty"`
+
 	// CABundle is a PEM encoded CA bundle which will be used to validate the repo's certificate.
 	// If unspecified, system trust roots will be used.
 	CABundle []byte `json:"caBundle,omitempty"`
@@ -110,6 +133,82 @@ type RepoSpec struct {
 	// Defaults to false, which keeps the SameOrigin check enabled. Setting this to true is not recommended
 	// in production environments due to the security implications.
 	DisableSameOriginCheck bool `json:"disableSameOriginCheck,omitempty"`
+
+	// Verification is the policy used to verify the signatures of the charts of the Helm repository
+	// before they are installed or upgraded. If unspecified, charts are not verified.
+	Verification *RepoVerification `json:"verification,omitempty"`
+
+	// ValuesPolicies are the rules the values of the charts of the Helm repository must follow to be
+	// installed or upgraded. Installs and upgrades breaking a rule are rejected.
+	ValuesPolicies []ValuesPolicy `json:"valuesPolicies,omitempty"`
+}
+
+// OCITagFilter selects the tags of the repositories of an OCI based Helm repository by their version and name.
+type OCITagFilter struct {
+	// VersionConstraint is a semver constraint, such as ">= 1.2.0 < 2.0.0", the versions of the tags must satisfy.
+	VersionConstraint string `json:"versionConstraint,omitempty"`
+
+	// Include is a regular expression the tags must match.
+	Include string `json:"include,omitempty"`
+
+	// Exclude is a regular expression the tags must not match.
+	Exclude string `json:"exclude,omitempty"`
+}
+
+// ValuesPolicy is a set of rules the values of the charts of a Helm repository must follow.
+type ValuesPolicy struct {
+	// Name identifies the policy in the errors of the rejected installs and upgrades.
+	Name string `json:"name,omitempty"`
+
+	// Charts are the names of the charts the policy applies to. If unspecified, the policy applies
+	// to all the charts of the Helm repository.
+	Charts []string `json:"charts,omitempty"`
+
+	// Rules are the rules of the policy.
+	Rules []ValuesRule `json:"rules,omitempty"`
+}
+
+// ValuesRule restricts the value at a path of the values of a chart. The default values of the chart
+// are taken into account.
+type ValuesRule struct {
+	// Path is the dotted path of the value, for instance "hostNetwork" or "resources.limits".
+	// A "*" segment matches all the keys of a map or all the items of a list.
+	Path string `json:"path"`
+
+	// Required rejects the values where the path is not set.
+	Required bool `json:"required,omitempty"`
+
+	// Forbidden are the values the path must not be set to, in their YAML representation such as "true".
+	Forbidden []string `json:"forbidden,omitempty"`
+
+	// Allowed are the only values the path can be set to, in their YAML representation. If unspecified,
+	// any value that is not forbidden is allowed.
+	Allowed []string `json:"allowed,omitempty"`
+
+	// Message describes the rule in the errors of the rejected installs and upgrades.
+	Message string `json:"message,omitempty"`
+}
+
+// GitCommitVerification describes the keys trusted to sign the commits of a git repo.
+type GitCommitVerification struct {
+	// PublicKeysSecret references a secret whose "gpg" key holds the PGP public keyring and whose "ssh" key
+	// holds the SSH public keys, in the authorized_keys format, trusted to sign the commits of the git repo.
+	PublicKeysSecret *SecretReference `json:"publicKeysSecret,omitempty"`
+}
+
+// RepoVerification describes the keys trusted to sign the charts of a Helm repository.
+type RepoVerification struct {
+	// ProvenanceKeyringSecret references a secret whose "keyring" key holds the PGP public keyring
+	// trusted to sign the provenance (.prov) files of the charts of an HTTP Helm repository.
+	ProvenanceKeyringSecret *SecretReference `json:"provenanceKeyringSecret,omitempty"`
+
+	// CosignPublicKeysSecret references a secret whose values are the PEM encoded cosign public keys
+	// trusted to sign the charts of an OCI Helm repository.
+	CosignPublicKeysSecret *SecretReference `json:"cosignPublicKeysSecret,omitempty"`
+
+	// AllowUnverified if true only reports the verification result of the charts and does not prevent
+	// charts that failed verification from being installed or upgraded. Defaults to false.
+	AllowUnverified bool `json:"allowUnverified,omitempty"`
 }
 
 type RepoCondition string
@@ -24,6 +24,7 @@
 import (
 	genericcondition "github.com/rancher/wrangler/v3/pkg/genericcondition"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -33,7 +34,7 @@ func (in *App) DeepCopyInto(out *App) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -88,6 +89,37 @@ func (in *AppList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppUpgradePolicy) DeepCopyInto(out *AppUpgradePolicy) {
+	*out = *in
+	if in.Window != nil {
+		in, out := &in.Window, &out.Window
+		*out = new(UpgradeWindow)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CarryValues != nil {
+		in, out := &in.CarryValues, &out.CarryValues
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.HealthTimeout != nil {
+		in, out := &in.HealthTimeout, &out.HealthTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppUpgradePolicy.
+func (in *AppUpgradePolicy) DeepCopy() *AppUpgradePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(AppUpgradePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Chart) DeepCopyInto(out *Chart) {
 	*out = *in
@@ -187,6 +219,27 @@ func (in *ExponentialBackOffValues) DeepCopy() *ExponentialBackOffValues {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitCommitVerification) DeepCopyInto(out *GitCommitVerification) {
+	*out = *in
+	if in.PublicKeysSecret != nil {
+		in, out := &in.PublicKeysSecret, &out.PublicKeysSecret
+		*out = new(SecretReference)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitCommitVerification.
+func (in *GitCommitVerification) DeepCopy() *GitCommitVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(GitCommitVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Info) DeepCopyInto(out *Info) {
 	*out = *in
@@ -269,6 +322,22 @@ func (in *Metadata) DeepCopy() *Metadata {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OCITagFilter) DeepCopyInto(out *OCITagFilter) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCITagFilter.
+func (in *OCITagFilter) DeepCopy() *OCITagFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(OCITagFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Operation) DeepCopyInto(out *Operation) {
 	*out = *in
@@ -362,6 +431,37 @@ func (in *OperationStatus) DeepCopy() *OperationStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseDrift) DeepCopyInto(out *ReleaseDrift) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]ResourceDrift, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastChecked != nil {
+		in, out := &in.LastChecked, &out.LastChecked
+		*out = (*in).DeepCopy()
+	}
+	if in.LastCorrected != nil {
+		in, out := &in.LastCorrected, &out.LastCorrected
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseDrift.
+func (in *ReleaseDrift) DeepCopy() *ReleaseDrift {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseDrift)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReleaseResource) DeepCopyInto(out *ReleaseResource) {
 	*out = *in
@@ -414,6 +514,16 @@ func (in *ReleaseSpec) DeepCopy() *ReleaseSpec {
 func (in *ReleaseStatus) DeepCopyInto(out *ReleaseStatus) {
 	*out = *in
 	out.Summary = in.Summary
+	if in.Drift != nil {
+		in, out := &in.Drift, &out.Drift
+		*out = new(ReleaseDrift)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(ReleaseUpgrade)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -427,14 +537,52 @@ func (in *ReleaseStatus) DeepCopy() *ReleaseStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReleaseUpgrade) DeepCopyInto(out *ReleaseUpgrade) {
+	*out = *in
+	if in.NextWindow != nil {
+		in, out := &in.NextWindow, &out.NextWindow
+		*out = (*in).DeepCopy()
+	}
+	if in.Started != nil {
+		in, out := &in.Started, &out.Started
+		*out = (*in).DeepCopy()
+	}
+	if in.Completed != nil {
+		in, out := &in.Completed, &out.Completed
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReleaseUpgrade.
+func (in *ReleaseUpgrade) DeepCopy() *ReleaseUpgrade {
+	if in == nil {
+		return nil
+	}
+	out := new(ReleaseUpgrade)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RepoSpec) DeepCopyInto(out *RepoSpec) {
 	*out = *in
+	if in.GitCommitVerification != nil {
+		in, out := &in.GitCommitVerification, &out.GitCommitVerification
+		*out = new(GitCommitVerification)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ExponentialBackOffValues != nil {
 		in, out := &in.ExponentialBackOffValues, &out.ExponentialBackOffValues
 		*out = new(ExponentialBackOffValues)
 		**out = **in
 	}
+	if in.OCITagFilter != nil {
+		in, out := &in.OCITagFilter, &out.OCITagFilter
+		*out = new(OCITagFilter)
+		**out = **in
+	}
 	if in.CABundle != nil {
 		in, out := &in.CABundle, &out.CABundle
 		*out = make([]byte, len(*in))
@@ -454,6 +602,18 @@ func (in *RepoSpec) DeepCopyInto(out *RepoSpec) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(RepoVerification)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ValuesPolicies != nil {
+		in, out := &in.ValuesPolicies, &out.ValuesPolicies
+		*out = make([]ValuesPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -490,6 +650,54 @@ func (in *RepoStatus) DeepCopy() *RepoStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RepoVerification) DeepCopyInto(out *RepoVerification) {
+	*out = *in
+	if in.ProvenanceKeyringSecret != nil {
+		in, out := &in.ProvenanceKeyringSecret, &out.ProvenanceKeyringSecret
+		*out = new(SecretReference)
+		**out = **in
+	}
+	if in.CosignPublicKeysSecret != nil {
+		in, out := &in.CosignPublicKeysSecret, &out.CosignPublicKeysSecret
+		*out = new(SecretReference)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RepoVerification.
+func (in *RepoVerification) DeepCopy() *RepoVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(RepoVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceDrift) DeepCopyInto(out *ResourceDrift) {
+	*out = *in
+	out.ReleaseResource = in.ReleaseResource
+	if in.Fields != nil {
+		in, out := &in.Fields, &out.Fields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceDrift.
+func (in *ResourceDrift) DeepCopy() *ResourceDrift {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceDrift)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretReference) DeepCopyInto(out *SecretReference) {
 	*out = *in
@@ -560,6 +768,13 @@ func (in *UIPluginEntry) DeepCopyInto(out *UIPluginEntry) {
 			(*out)[key] = val
 		}
 	}
+	if in.Digests != nil {
+		in, out := &in.Digests, &out.Digests
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -639,3 +854,78 @@ func (in *UIPluginStatus) DeepCopy() *UIPluginStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeWindow) DeepCopyInto(out *UpgradeWindow) {
+	*out = *in
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeWindow.
+func (in *UpgradeWindow) DeepCopy() *UpgradeWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ValuesPolicy) DeepCopyInto(out *ValuesPolicy) {
+	*out = *in
+	if in.Charts != nil {
+		in, out := &in.Charts, &out.Charts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]ValuesRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValuesPolicy.
+func (in *ValuesPolicy) DeepCopy() *ValuesPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ValuesPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ValuesRule) DeepCopyInto(out *ValuesRule) {
+	*out = *in
+	if in.Forbidden != nil {
+		in, out := &in.Forbidden, &out.Forbidden
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Allowed != nil {
+		in, out := &in.Allowed, &out.Allowed
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValuesRule.
+func (in *ValuesRule) DeepCopy() *ValuesRule {
+	if in == nil {
+		return nil
+	}
+	out := new(ValuesRule)
+	in.DeepCopyInto(out)
+	return out
+}
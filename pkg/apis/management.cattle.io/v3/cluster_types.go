@@ -195,6 +195,7 @@ type ClusterStatus struct {
 	AgentFeatures              map[string]bool           `json:"agentFeatures,omitempty"`
 	AuthImage                  string                    `json:"authImage"`
 	ComponentStatuses          []ClusterComponentStatus  `json:"componentStatuses,omitempty"`
+	Health                     *ClusterHealth            `json:"health,omitempty" norman:"nocreate,noupdate"`
 	APIEndpoint                string                    `json:"apiEndpoint,omitempty"`
 	ServiceAccountToken        string                    `json:"serviceAccountToken,omitempty"`
 	ServiceAccountTokenSecret  string                    `json:"serviceAccountTokenSecret,omitempty"`
@@ -235,6 +236,30 @@ type ClusterComponentStatus struct {
 	Conditions []v1.ComponentCondition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,2,rep,name=conditions"`
 }
 
+// ClusterHealth is the health of a cluster scored from the results of its health checks.
+type ClusterHealth struct {
+	// Score is the weighted average of the scores of the checks, from 0 (unhealthy) to 100 (healthy).
+	Score int `json:"score"`
+	// State is Healthy, Degraded or Unhealthy, depending on the score.
+	State string `json:"state"`
+	// Checks are the results of the health checks of the cluster.
+	Checks []ClusterHealthCheck `json:"checks,omitempty"`
+}
+
+// ClusterHealthCheck is the result of a health check of a cluster.
+type ClusterHealthCheck struct {
+	// Name of the check.
+	Name string `json:"name"`
+	// Score of the check, from 0 (failing) to 100 (passing).
+	Score int `json:"score"`
+	// Weight of the check in the score of the cluster.
+	Weight int `json:"weight"`
+	// Reason is a machine-readable reason for a score below 100.
+	Reason string `json:"reason,omitempty"`
+	// Message is a human-readable explanation of the reason.
+	Message string `json:"message,omitempty"`
+}
+
 type ClusterCondition struct {
 	// Type of cluster condition.
 	Type ClusterConditionType `json:"type"`
@@ -17,6 +17,17 @@ type Setting struct {
 	Default    string `json:"default" norman:"nocreate,noupdate"`
 	Customized bool   `json:"customized" norman:"nocreate,noupdate"`
 	Source     string `json:"source" norman:"nocreate,noupdate,options=db|default|env"`
+	// History is the list of the most recent changes of the value of the setting, oldest first.
+	History []SettingChange `json:"history,omitempty" norman:"nocreate,noupdate"`
+	// Conditions report the state of the setting, such as its stored value being invalid.
+	Conditions []metav1.Condition `json:"conditions,omitempty" norman:"nocreate,noupdate"`
+}
+
+// SettingChange records who changed the value of a setting, to what and when.
+type SettingChange struct {
+	Value string      `json:"value"`
+	User  string      `json:"user,omitempty"`
+	Time  metav1.Time `json:"time"`
 }
 
 // +genclient
@@ -34,6 +45,23 @@ type Feature struct {
 
 type FeatureSpec struct {
 	Value *bool `json:"value" norman:"required"`
+	// Rules override the value of the feature for the downstream clusters and the groups of users they target. The
+	// first rule that targets a cluster or a group of a user applies. Rules are ignored if the value of the feature is
+	// locked.
+	// +optional
+	Rules []FeatureRule `json:"rules,omitempty"`
+}
+
+// FeatureRule sets the value of a feature for the downstream clusters selected by label, or for the members of groups.
+type FeatureRule struct {
+	// Value is the value of the feature for the targets of the rule.
+	Value bool `json:"value"`
+	// ClusterSelector selects, by label, the downstream clusters the rule applies to.
+	// +optional
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+	// Groups are the principal IDs of the groups whose members the rule applies to.
+	// +optional
+	Groups []string `json:"groups,omitempty"`
 }
 
 type FeatureStatus struct {
@@ -41,6 +69,13 @@ type FeatureStatus struct {
 	Default     bool   `json:"default"`
 	Description string `json:"description"`
 	LockedValue *bool  `json:"lockedValue"`
+	// EnabledClusters are the downstream clusters the feature is effectively enabled for. It is only set if the feature
+	// has rules.
+	// +optional
+	EnabledClusters []string `json:"enabledClusters,omitempty"`
+	// EnabledGroups are the groups targeted by the rules of the feature that it is effectively enabled for.
+	// +optional
+	EnabledGroups []string `json:"enabledGroups,omitempty"`
 }
 
 const (
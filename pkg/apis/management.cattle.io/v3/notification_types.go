@@ -16,3 +16,106 @@ type RancherUserNotification struct {
 	ComponentName string `json:"componentName"`
 	Message       string `json:"message"`
 }
+
+// +genclient
+// +genclient:nonNamespaced
+// +kubebuilder:subresource:status
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:scope=Cluster
+
+// NotificationChannel is a destination that Rancher sends notifications of its events to, for the events matching
+// its rules.
+type NotificationChannel struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard object metadata; More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#metadata.
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec is the specification of the channel.
+	Spec NotificationChannelSpec `json:"spec"`
+
+	// Status is the most recently observed status of the channel.
+	// +optional
+	Status NotificationChannelStatus `json:"status,omitempty"`
+}
+
+// NotificationChannelSpec defines where notifications are sent to, and which events they are sent for. Exactly one
+// of SMTP, Webhook and Slack must be set.
+type NotificationChannelSpec struct {
+	// DisplayName is the human-readable name of the channel.
+	// +optional
+	DisplayName string `json:"displayName,omitempty"`
+	// SMTP sends the notifications by email.
+	// +optional
+	SMTP *SMTPNotificationConfig `json:"smtp,omitempty"`
+	// Webhook posts the notifications as JSON to a URL.
+	// +optional
+	Webhook *WebhookNotificationConfig `json:"webhook,omitempty"`
+	// Slack posts the notifications to a Slack or Microsoft Teams compatible incoming webhook.
+	// +optional
+	Slack *SlackNotificationConfig `json:"slack,omitempty"`
+	// SecretName is the name of a secret in the cattle-global-data namespace holding the credentials of the channel:
+	// the password of the SMTP server in the "password" key, or the URL of a webhook in the "url" key, which takes
+	// precedence over the URL in the spec.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+	// Rules select the events that are sent to the channel. An event is sent if it matches any of the rules.
+	// +optional
+	Rules []NotificationRule `json:"rules,omitempty"`
+}
+
+// SMTPNotificationConfig is the configuration of a channel sending emails.
+type SMTPNotificationConfig struct {
+	// Host of the SMTP server.
+	Host string `json:"host"`
+	// Port of the SMTP server.
+	Port int `json:"port"`
+	// Username authenticating to the SMTP server, with the password of the secret of the channel.
+	// +optional
+	Username string `json:"username,omitempty"`
+	// From is the sender address of the emails.
+	From string `json:"from"`
+	// To are the recipient addresses of the emails.
+	To []string `json:"to"`
+	// TLS connects to the SMTP server over TLS, instead of upgrading the connection with STARTTLS when the server
+	// supports it.
+	// +optional
+	TLS bool `json:"tls,omitempty"`
+}
+
+// WebhookNotificationConfig is the configuration of a channel posting the notifications as JSON.
+type WebhookNotificationConfig struct {
+	// URL the notifications are posted to.
+	// +optional
+	URL string `json:"url,omitempty"`
+}
+
+// SlackNotificationConfig is the configuration of a channel posting the notifications as messages to a Slack or
+// Microsoft Teams compatible incoming webhook.
+type SlackNotificationConfig struct {
+	// URL of the incoming webhook.
+	// +optional
+	URL string `json:"url,omitempty"`
+}
+
+// NotificationRule selects events by their type and cluster.
+type NotificationRule struct {
+	// EventTypes are the types of the events selected by the rule. All types are selected if empty.
+	// +optional
+	EventTypes []string `json:"eventTypes,omitempty"`
+	// ClusterSelector selects the events of the clusters it matches. Events that aren't about a cluster are selected
+	// regardless of it.
+	// +optional
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+}
+
+// NotificationChannelStatus represents the most recently observed status of the channel.
+type NotificationChannelStatus struct {
+	// LastSentTime is the time the last notification was sent successfully.
+	// +optional
+	LastSentTime string `json:"lastSentTime,omitempty"`
+	// LastError is the error of the last notification that failed to be sent, empty if it succeeded.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+}
@@ -587,6 +587,23 @@ func NewNodeTemplate(namespace, name string, obj NodeTemplate) *NodeTemplate {
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
+// NotificationChannelList is a list of NotificationChannel resources
+type NotificationChannelList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []NotificationChannel `json:"items"`
+}
+
+func NewNotificationChannel(namespace, name string, obj NotificationChannel) *NotificationChannel {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("NotificationChannel").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
 // OIDCClientList is a list of OIDCClient resources
 type OIDCClientList struct {
 	metav1.TypeMeta `json:",inline"`
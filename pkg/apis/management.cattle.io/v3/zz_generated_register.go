@@ -61,6 +61,7 @@
 	NodeDriverResourceName                                = "nodedrivers"
 	NodePoolResourceName                                  = "nodepools"
 	NodeTemplateResourceName                              = "nodetemplates"
+	NotificationChannelResourceName                       = "notificationchannels"
 	OIDCClientResourceName                                = "oidcclients"
 	OIDCProviderResourceName                              = "oidcproviders"
 	OpenLdapProviderResourceName                          = "openldapproviders"
@@ -170,6 +171,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&NodePoolList{},
 		&NodeTemplate{},
 		&NodeTemplateList{},
+		&NotificationChannel{},
+		&NotificationChannelList{},
 		&OIDCClient{},
 		&OIDCClientList{},
 		&OIDCProvider{},
@@ -30,6 +30,9 @@ type RKEMachinePool struct {
 	MachineOS                    string                       `json:"machineOS,omitempty"`
 	DynamicSchemaSpec            string                       `json:"dynamicSchemaSpec,omitempty"`
 	HostnameLengthLimit          int                          `json:"hostnameLengthLimit,omitempty"`
+	// OSMaintenance configures operating system updates for the machines of the pool. The machine label selector is
+	// ignored, as it always selects the machines of the pool.
+	OSMaintenance *rkev1.OSMaintenance `json:"osMaintenance,omitempty"`
 }
 
 type RKEMachinePoolRollingUpdate struct {
@@ -400,6 +400,11 @@ func (in *RKEMachinePool) DeepCopyInto(out *RKEMachinePool) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.OSMaintenance != nil {
+		in, out := &in.OSMaintenance, &out.OSMaintenance
+		*out = new(rkecattleiov1.OSMaintenance)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
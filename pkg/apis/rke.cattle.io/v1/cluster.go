@@ -81,6 +81,10 @@ type RKEClusterSpecCommon struct {
 
 	// Increment to force all nodes to re-provision
 	ProvisionGeneration int `json:"provisionGeneration,omitempty"`
+
+	// OSMaintenance configures operating system updates for the machines matched by each entry. If multiple entries
+	// match a machine, the last one wins.
+	OSMaintenance []OSMaintenance `json:"osMaintenance,omitempty"`
 }
 
 type LocalClusterAuthEndpoint struct {
@@ -99,6 +103,24 @@ type RKEProvisioningFiles struct {
 	FileSources          []ProvisioningFileSource `json:"fileSources,omitempty"`
 }
 
+// OSMaintenance updates the operating system packages of the machines it applies to, and reboots them when the update
+// requires it. Machines are processed as part of the regular plan rollout, one tier at a time, honoring the concurrency
+// and drain options of the upgrade strategy.
+type OSMaintenance struct {
+	MachineLabelSelector *metav1.LabelSelector `json:"machineLabelSelector,omitempty"`
+	// Changing the Generation is the only thing required to initiate a maintenance run. A Generation of 0 disables
+	// maintenance.
+	Generation int `json:"generation,omitempty"`
+	// UpdateCommand is a shell command that updates the operating system packages. Defaults to upgrading all packages
+	// with the package manager found on the node.
+	UpdateCommand string `json:"updateCommand,omitempty"`
+	// RebootRequiredCommand is a shell command that exits zero when a reboot is required to complete the update.
+	// Defaults to the check of the package manager found on the node.
+	RebootRequiredCommand string `json:"rebootRequiredCommand,omitempty"`
+	// DisableReboot prevents nodes from being rebooted, even if the update requires it.
+	DisableReboot bool `json:"disableReboot,omitempty"`
+}
+
 type RKEClusterSpec struct {
 	// Not used in anyway, just here to make cluster-api happy
 	ControlPlaneEndpoint *capi.APIEndpoint `json:"controlPlaneEndpoint,omitempty"`
@@ -24,6 +24,11 @@ type ETCDSnapshotS3 struct {
 	Region              string `json:"region,omitempty"`
 	CloudCredentialName string `json:"cloudCredentialName,omitempty"`
 	Folder              string `json:"folder,omitempty"`
+	// EncryptionKeySecretName is the name of a secret in the namespace of the cluster holding the key that snapshots
+	// are encrypted with before being uploaded. The "key" entry of the secret is used for encryption, while the
+	// newline separated keys in the "previousKeys" entry are only used to decrypt snapshots during a restore, which
+	// allows the key to be rotated.
+	EncryptionKeySecretName string `json:"encryptionKeySecretName,omitempty"`
 }
 
 type ETCDSnapshotCreate struct {
@@ -39,6 +44,9 @@ type ETCDSnapshotRestore struct {
 	Generation int `json:"generation,omitempty"`
 	// Set to either none (or empty string), all, or kubernetesVersion
 	RestoreRKEConfig string `json:"restoreRKEConfig,omitempty"`
+	// RestoreRancherObjects reconciles the Rancher objects that belong to the cluster (projects, cluster and project
+	// role template bindings, managed charts and cluster labels) back to the state captured when the snapshot was taken.
+	RestoreRancherObjects bool `json:"restoreRancherObjects,omitempty"`
 }
 
 // +genclient
@@ -67,15 +75,47 @@ type ETCDSnapshotFile struct {
 	S3        *ETCDSnapshotS3 `json:"s3,omitempty"`
 	Status    string          `json:"status,omitempty"`
 	Message   string          `json:"message,omitempty"`
+	// Target is the name of the ETCDSnapshotTarget the snapshot file was stored on. It is empty for local snapshots and
+	// snapshots stored on the default S3 target defined in ETCD.S3.
+	Target string `json:"target,omitempty"`
+	// EncryptionKeyID identifies the key the snapshot file was encrypted with. It is empty for unencrypted snapshots.
+	EncryptionKeyID string `json:"encryptionKeyID,omitempty"`
 }
 
 type ETCDSnapshotStatus struct {
 	Missing bool `json:"missing"`
 }
 
+// ETCDSnapshotRetention defines a tiered retention policy for the snapshots stored on a snapshot target. A snapshot is
+// retained if it is selected by any of the tiers. A policy where every field is zero retains all snapshots.
+type ETCDSnapshotRetention struct {
+	// Count is the number of most recent snapshots to retain.
+	Count int `json:"count,omitempty"`
+	// Hourly is the number of hours for which the most recent snapshot of each hour is retained.
+	Hourly int `json:"hourly,omitempty"`
+	// Daily is the number of days for which the most recent snapshot of each day is retained.
+	Daily int `json:"daily,omitempty"`
+	// Weekly is the number of weeks for which the most recent snapshot of each week is retained.
+	Weekly int `json:"weekly,omitempty"`
+	// Monthly is the number of months for which the most recent snapshot of each month is retained.
+	Monthly int `json:"monthly,omitempty"`
+}
+
+// ETCDSnapshotTarget is an additional destination that a snapshot is saved to whenever an etcd snapshot is created
+// through ETCDSnapshotCreate, as well as on the snapshot schedule of the cluster.
+type ETCDSnapshotTarget struct {
+	// Name uniquely identifies the target within the cluster, and is recorded on the ETCDSnapshot objects of the
+	// snapshots stored on it.
+	Name      string                 `json:"name"`
+	S3        *ETCDSnapshotS3        `json:"s3,omitempty"`
+	Retention *ETCDSnapshotRetention `json:"retention,omitempty"`
+}
+
 type ETCD struct {
 	DisableSnapshots     bool            `json:"disableSnapshots,omitempty"`
 	SnapshotScheduleCron string          `json:"snapshotScheduleCron,omitempty"`
 	SnapshotRetention    int             `json:"snapshotRetention,omitempty"`
 	S3                   *ETCDSnapshotS3 `json:"s3,omitempty"`
+	// Targets are additional snapshot destinations, each with an independent retention policy.
+	Targets []ETCDSnapshotTarget `json:"targets,omitempty"`
 }
@@ -237,6 +237,13 @@ func (in *ETCD) DeepCopyInto(out *ETCD) {
 		*out = new(ETCDSnapshotS3)
 		**out = **in
 	}
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]ETCDSnapshotTarget, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -369,6 +376,22 @@ func (in *ETCDSnapshotRestore) DeepCopy() *ETCDSnapshotRestore {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ETCDSnapshotRetention) DeepCopyInto(out *ETCDSnapshotRetention) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ETCDSnapshotRetention.
+func (in *ETCDSnapshotRetention) DeepCopy() *ETCDSnapshotRetention {
+	if in == nil {
+		return nil
+	}
+	out := new(ETCDSnapshotRetention)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ETCDSnapshotS3) DeepCopyInto(out *ETCDSnapshotS3) {
 	*out = *in
@@ -401,6 +424,32 @@ func (in *ETCDSnapshotSpec) DeepCopy() *ETCDSnapshotSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ETCDSnapshotTarget) DeepCopyInto(out *ETCDSnapshotTarget) {
+	*out = *in
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(ETCDSnapshotS3)
+		**out = **in
+	}
+	if in.Retention != nil {
+		in, out := &in.Retention, &out.Retention
+		*out = new(ETCDSnapshotRetention)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ETCDSnapshotTarget.
+func (in *ETCDSnapshotTarget) DeepCopy() *ETCDSnapshotTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(ETCDSnapshotTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ETCDSnapshotStatus) DeepCopyInto(out *ETCDSnapshotStatus) {
 	*out = *in
@@ -540,6 +589,27 @@ func (in *Networking) DeepCopy() *Networking {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OSMaintenance) DeepCopyInto(out *OSMaintenance) {
+	*out = *in
+	if in.MachineLabelSelector != nil {
+		in, out := &in.MachineLabelSelector, &out.MachineLabelSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OSMaintenance.
+func (in *OSMaintenance) DeepCopy() *OSMaintenance {
+	if in == nil {
+		return nil
+	}
+	out := new(OSMaintenance)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProvisioningFileSource) DeepCopyInto(out *ProvisioningFileSource) {
 	*out = *in
@@ -851,6 +921,13 @@ func (in *RKEClusterSpecCommon) DeepCopyInto(out *RKEClusterSpecCommon) {
 		**out = **in
 	}
 	out.DataDirectories = in.DataDirectories
+	if in.OSMaintenance != nil {
+		in, out := &in.OSMaintenance, &out.OSMaintenance
+		*out = make([]OSMaintenance, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -14,7 +14,6 @@
 	client "github.com/rancher/rancher/pkg/client/generated/management/v3"
 	mgmtv3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
 	managementschema "github.com/rancher/rancher/pkg/schemas/management.cattle.io/v3"
-	"github.com/sirupsen/logrus"
 	"k8s.io/client-go/util/retry"
 )
 
@@ -195,7 +194,7 @@ func (p *ldapProvider) saveLDAPConfig(config *v3.LdapConfig) error {
 
 	config.ServiceAccountPassword = name
 
-	logrus.Debugf("updating %s config", p.providerName)
+	p.log().Debug("updating config")
 	_, err = p.authConfigs.ObjectClient().Update(config.ObjectMeta.Name, config)
 	if err != nil {
 		return err
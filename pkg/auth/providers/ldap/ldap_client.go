@@ -11,14 +11,13 @@
 	"github.com/rancher/norman/httperror"
 	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/auth/providers/common/ldap"
-	"github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var operationalAttrList = []string{"1.1", "+", "*"}
 
 func (p *ldapProvider) loginUser(lConn ldapv3.Client, credentials *v3.BasicLogin, config *v3.LdapConfig) (v3.Principal, []v3.Principal, error) {
-	logrus.Debug("Now generating Ldap token")
+	p.log().Debug("Now generating token")
 
 	if credentials.Password == "" {
 		return v3.Principal{}, nil, httperror.NewAPIError(httperror.MissingRequired, "password not provided")
@@ -64,7 +63,7 @@ func (p *ldapProvider) loginUser(lConn ldapv3.Client, credentials *v3.BasicLogin
 		return v3.Principal{}, nil, httperror.WrapAPIError(err, httperror.Unauthorized, "Unauthorized")
 	}
 
-	logrus.Debug("Binding username password")
+	p.log().Debug("Binding username password")
 	userDN := result.Entries[0].DN // userDN is externalID
 	err = lConn.Bind(userDN, credentials.Password)
 	if err != nil {
@@ -130,17 +129,17 @@ func (p *ldapProvider) getPrincipalsFromSearchResult(result *ldapv3.SearchResult
 		return v3.Principal{}, nil, fmt.Errorf("permission denied")
 	}
 
-	logrus.Debugf("getPrincipals: user attributes: %v ", userAttributes)
+	p.log().Debugf("getPrincipals: user attributes: %v ", userAttributes)
 
 	userMemberAttribute := entry.GetAttributeValues(config.UserMemberAttribute)
 	if len(userMemberAttribute) == 0 {
 		userMemberAttribute = opResult.Entries[0].GetAttributeValues(config.UserMemberAttribute)
 	}
 
-	logrus.Debugf("SearchResult memberOf attribute {%s}", userMemberAttribute)
+	p.log().Debugf("SearchResult memberOf attribute {%s}", userMemberAttribute)
 
 	if !ldap.IsType(userAttributes, config.UserObjectClass) {
-		logrus.Debugf("The objectClass %s was not found in the user attributes", config.UserObjectClass)
+		p.log().Debugf("The objectClass %s was not found in the user attributes", config.UserObjectClass)
 		return v3.Principal{}, nil, nil
 	}
 
@@ -165,7 +164,7 @@ func (p *ldapProvider) getPrincipalsFromSearchResult(result *ldapv3.SearchResult
 		query += ")"
 		query = fmt.Sprintf("(&%s%s)", filter, query)
 		// Pulling user's groups
-		logrus.Debugf("Ldap: Query for pulling user's groups: %s", query)
+		p.log().Debugf("Query for pulling user's groups: %s", query)
 		userMemberGroupPrincipals, err := p.searchLdap(query, groupScope, config, lConn)
 		groupPrincipals = append(groupPrincipals, userMemberGroupPrincipals...)
 		if err != nil {
@@ -205,7 +204,7 @@ func (p *ldapProvider) getPrincipalsFromSearchResult(result *ldapv3.SearchResult
 		// So we run a separate query with the filer: (&(member=uid of user logging in)(objectclass=groupofnames))
 		// This returns all details of a user's groups that we need to create principals, but doesn't return nested membership,
 		// so we derive nested membership using the logic we have for openldap
-		logrus.Debugf("EntryDN attribute not returned, retrieving group membership using the member attribute")
+		p.log().Debugf("EntryDN attribute not returned, retrieving group membership using the member attribute")
 		// didn't get the entrydn as expected, so use query with member attribute and manually gather nested group
 		query := fmt.Sprintf(
 			"(&(%s=%s)(%s=%s))",
@@ -219,7 +218,7 @@ func (p *ldapProvider) getPrincipalsFromSearchResult(result *ldapv3.SearchResult
 			return userPrincipal, groupPrincipals, err
 		}
 
-		logrus.Debugf("Retrieved following groups using member attribute: %v", groupPrincipals)
+		p.log().Debugf("Retrieved following groups using member attribute: %v", groupPrincipals)
 		freeipaNonEntrydnApproach = true
 	}
 	// Handle nestedgroups for openldap, filter operationalAttrList already handles nestedgroups for freeipa
@@ -278,7 +277,7 @@ func (p *ldapProvider) getPrincipal(distinguishedName string, scope string, conf
 	}
 
 	if !ldap.IsType(attribs, scope) && !p.permissionCheck(attribs, config) {
-		logrus.Errorf("Failed to get object %s", distinguishedName)
+		p.log().Errorf("Failed to get object %s", distinguishedName)
 		return nil, nil
 	}
 
@@ -289,7 +288,7 @@ func (p *ldapProvider) getPrincipal(distinguishedName string, scope string, conf
 		filter = fmt.Sprintf("(%s=%s)", ObjectClass, ldap.SanitizeAttr(config.GroupObjectClass))
 	}
 
-	logrus.Debugf("Query for getPrincipal(%s): %s", distinguishedName, filter)
+	p.log().Debugf("Query for getPrincipal(%s): %s", distinguishedName, filter)
 
 	lConn, err := ldap.Connect(config, caPool)
 	if err != nil {
@@ -407,7 +406,7 @@ func (p *ldapProvider) searchUser(name string, config *v3.LdapConfig, lConn ldap
 	// The user search filter will be added as another clause
 	// and is expected to follow ldap syntax and enclosed in parentheses.
 	query += srchAttrs + ")" + config.UserSearchFilter + ")"
-	logrus.Debugf("%s searchUser query: %s", p.providerName, query)
+	p.log().Debugf("searchUser query: %s", query)
 	return p.searchLdap(query, p.userScope, config, lConn)
 }
 
@@ -434,7 +433,7 @@ func (p *ldapProvider) searchGroup(name string, config *v3.LdapConfig, lConn lda
 		config.GroupSearchFilter,
 	)
 
-	logrus.Debugf("%s searchGroup query: %s scope: %s", p.providerName, query, p.groupScope)
+	p.log().Debugf("searchGroup query: %s scope: %s", query, p.groupScope)
 	return p.searchLdap(query, p.groupScope, config, lConn)
 }
 
@@ -17,6 +17,7 @@
 	"github.com/rancher/rancher/pkg/auth/providers/common/ldap"
 	client "github.com/rancher/rancher/pkg/client/generated/management/v3"
 	mgmtv3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/logging"
 	"github.com/rancher/rancher/pkg/types/config"
 	wcorev1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
 	"github.com/sirupsen/logrus"
@@ -42,6 +43,8 @@ func (e ErrorNotConfigured) Error() string {
 }
 
 var (
+	logger = logging.For("auth/providers/ldap")
+
 	testAndApplyInputTypes = map[string]string{
 		FreeIpaName:  client.FreeIpaTestAndApplyInputType,
 		OpenLdapName: client.OpenLdapTestAndApplyInputType,
@@ -80,6 +83,11 @@ type ldapProvider struct {
 	groupScope            string
 }
 
+// log returns the logger of the provider.
+func (p *ldapProvider) log() *logrus.Entry {
+	return logger.WithField(logging.FieldProvider, p.providerName)
+}
+
 func Configure(ctx context.Context, mgmtCtx *config.ScaledContext, userMGR userManager, tokenMGR tokenManager, providerName string) common.AuthProvider {
 	return &ldapProvider{
 		ctx:                   ctx,
@@ -175,13 +183,13 @@ func (p *ldapProvider) SearchPrincipals(searchKey, principalType string, myToken
 		if IsNotConfigured(err) {
 			return principals, err
 		}
-		logrus.Warnf("ldap search principals failed to get ldap config: %s\n", err)
+		p.log().Warnf("search principals failed to get config: %s", err)
 		return principals, nil
 	}
 
 	lConn, err := ldap.Connect(config, caPool)
 	if err != nil {
-		logrus.Warnf("ldap search principals failed to connect to ldap: %s\n", err)
+		p.log().Warnf("search principals failed to connect to the server: %s", err)
 		return principals, nil
 	}
 	defer lConn.Close()
@@ -304,7 +312,7 @@ func (p *ldapProvider) getLDAPConfig(genericClient objectclient.GenericClient) (
 func (p *ldapProvider) CanAccessWithGroupProviders(userPrincipalID string, groupPrincipals []v3.Principal) (bool, error) {
 	config, _, err := p.getLDAPConfig(p.authConfigs.ObjectClient().UnstructuredClient())
 	if err != nil {
-		logrus.Errorf("Error fetching ldap config: %v", err)
+		p.log().Errorf("Error fetching config: %v", err)
 		return false, err
 	}
 	allowed, err := p.userMGR.CheckAccess(config.AccessMode, config.AllowedPrincipalIDs, userPrincipalID, groupPrincipals)
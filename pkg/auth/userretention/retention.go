@@ -7,6 +7,7 @@
 
 	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	mgmtcontrollers "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/notifier"
 	"github.com/rancher/rancher/pkg/wrangler"
 	"github.com/sirupsen/logrus"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -176,7 +177,10 @@ func (r *Retention) Run(ctx context.Context) error {
 			}
 		}
 
-		var userGetTry int
+		var (
+			userGetTry  int
+			userUpdated bool
+		)
 		err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
 			defer func() { userGetTry++ }()
 
@@ -218,6 +222,7 @@ func (r *Retention) Run(ctx context.Context) error {
 
 				return err
 			}
+			userUpdated = true
 
 			return nil
 		})
@@ -225,12 +230,26 @@ func (r *Retention) Run(ctx context.Context) error {
 			// Log the error and move on.
 			logrus.Errorf("userretention: error updating user %s: %v", user.Name, err)
 			errCount++
+		} else if userUpdated && disableUser {
+			notifier.Notify(notifier.Event{
+				Type:    notifier.EventUserDisabled,
+				Subject: user.Name,
+				Title:   fmt.Sprintf("User %s was disabled", userDisplayName(user)),
+				Message: fmt.Sprintf("User %s was disabled for inactivity, last seen on %s.", userDisplayName(user), lastLogin.UTC().Format(time.RFC3339)),
+			})
 		}
 	}
 
 	return nil
 }
 
+func userDisplayName(user *v3.User) string {
+	if user.Username != "" {
+		return user.Username
+	}
+	return user.Name
+}
+
 func isSubjectToRetention(user *v3.User) bool {
 	return !user.IsDefaultAdmin() && !user.IsSystem()
 }
@@ -0,0 +1,114 @@
+package capr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/v3/pkg/name"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// ClusterObjectsLabel is set on the secrets holding a captured ClusterObjects manifest of a cluster.
+	ClusterObjectsLabel = "rke.cattle.io/cluster-objects"
+	// ClusterObjectsCapturedAtAnnotation is set on the secrets holding a captured ClusterObjects manifest, with the
+	// RFC3339 time from which the manifest reflected the Rancher objects of the cluster.
+	ClusterObjectsCapturedAtAnnotation = "rke.cattle.io/cluster-objects-captured-at"
+	// ClusterObjectsHashAnnotation is set on etcd snapshots with the hash of the ClusterObjects manifest that was
+	// current when the snapshot was taken, and on the secrets holding a manifest with its hash.
+	ClusterObjectsHashAnnotation = "rke.cattle.io/cluster-objects-hash"
+	// ClusterObjectsRestoredAnnotation is set on the provisioning cluster once the Rancher objects of a snapshot restore
+	// have been reconciled, with the name and generation of the restore as value.
+	ClusterObjectsRestoredAnnotation = "rke.cattle.io/cluster-objects-restored"
+	// ClusterObjectsRestoreLabel is set on the role template bindings created when restoring the Rancher objects of a
+	// cluster, with the name of the etcd snapshot they were restored from. Only those bindings are removed by a later
+	// restore, so that bindings created by users or by Rancher itself are left in place.
+	ClusterObjectsRestoreLabel = "rke.cattle.io/cluster-objects-restore"
+	// ClusterObjectsSecretKey is the key of the secret data that holds the compressed ClusterObjects manifest.
+	ClusterObjectsSecretKey = "objects"
+)
+
+// ClusterObjects is a manifest of the Rancher objects that belong to a cluster, captured alongside etcd snapshots so
+// they can be restored to the state they were in when the snapshot was taken.
+type ClusterObjects struct {
+	Labels                      map[string]string               `json:"labels,omitempty"`
+	Projects                    []v3.Project                    `json:"projects,omitempty"`
+	ClusterRoleTemplateBindings []v3.ClusterRoleTemplateBinding `json:"clusterRoleTemplateBindings,omitempty"`
+	ProjectRoleTemplateBindings []v3.ProjectRoleTemplateBinding `json:"projectRoleTemplateBindings,omitempty"`
+	ManagedCharts               []v3.ManagedChart               `json:"managedCharts,omitempty"`
+}
+
+// ClusterObjectsHash returns the hash identifying the given compressed ClusterObjects manifest.
+func ClusterObjectsHash(compressed string) string {
+	sum := sha256.Sum256([]byte(compressed))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// ClusterObjectsSecretName returns the name of the secret holding the ClusterObjects manifest of the given cluster with
+// the given hash.
+func ClusterObjectsSecretName(clusterName, hash string) string {
+	return name.SafeConcatName(clusterName, "cluster-objects", hash)
+}
+
+// ClusterObjectsCapturedAt returns the time from which the manifest of the given secret reflected the Rancher objects
+// of its cluster.
+func ClusterObjectsCapturedAt(secret *corev1.Secret) time.Time {
+	t, _ := time.Parse(time.RFC3339, secret.Annotations[ClusterObjectsCapturedAtAnnotation])
+	return t
+}
+
+// ListClusterObjectsSecrets returns the secrets holding the captured ClusterObjects manifests of the given cluster.
+func ListClusterObjectsSecrets(secretCache corecontrollers.SecretCache, namespace, clusterName string) ([]*corev1.Secret, error) {
+	return secretCache.List(namespace, labels.SelectorFromSet(labels.Set{
+		ClusterNameLabel:    clusterName,
+		ClusterObjectsLabel: "true",
+	}))
+}
+
+// GetClusterObjectsHashAt returns the hash of the ClusterObjects manifest of the given cluster that was current at the
+// given time, or an empty string if none was captured yet.
+func GetClusterObjectsHashAt(secretCache corecontrollers.SecretCache, namespace, clusterName string, at time.Time) (string, error) {
+	secrets, err := ListClusterObjectsSecrets(secretCache, namespace, clusterName)
+	if err != nil {
+		return "", err
+	}
+	var (
+		hash   string
+		latest time.Time
+	)
+	for _, secret := range secrets {
+		capturedAt := ClusterObjectsCapturedAt(secret)
+		if capturedAt.After(at) || (hash != "" && !capturedAt.After(latest)) {
+			continue
+		}
+		hash, latest = secret.Annotations[ClusterObjectsHashAnnotation], capturedAt
+	}
+	return hash, nil
+}
+
+// GetSnapshotClusterObjects returns the ClusterObjects manifest that was current when the given etcd snapshot was
+// taken. If it cannot be found, it returns an error.
+func GetSnapshotClusterObjects(secretCache corecontrollers.SecretCache, snapshot *rkev1.ETCDSnapshot) (*ClusterObjects, error) {
+	if snapshot == nil {
+		return nil, fmt.Errorf("snapshot was nil")
+	}
+	hash := snapshot.Annotations[ClusterObjectsHashAnnotation]
+	if hash == "" {
+		return nil, fmt.Errorf("no Rancher cluster objects were captured for snapshot %s/%s", snapshot.Namespace, snapshot.Name)
+	}
+	secret, err := secretCache.Get(snapshot.Namespace, ClusterObjectsSecretName(snapshot.Spec.ClusterName, hash))
+	if err != nil {
+		return nil, fmt.Errorf("unable to find Rancher cluster objects %s for snapshot %s/%s: %w", hash, snapshot.Namespace, snapshot.Name, err)
+	}
+	objects := &ClusterObjects{}
+	if err := DecompressInterface(string(secret.Data[ClusterObjectsSecretKey]), objects); err != nil {
+		return nil, fmt.Errorf("unable to decode Rancher cluster objects %s for snapshot %s/%s: %w", hash, snapshot.Namespace, snapshot.Name, err)
+	}
+	return objects, nil
+}
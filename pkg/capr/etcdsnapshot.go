@@ -0,0 +1,240 @@
+package capr
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ETCDSnapshotTargetLabel is set on ETCDSnapshot objects stored on an additional snapshot target, with the name of
+	// the target as value.
+	ETCDSnapshotTargetLabel = "rke.cattle.io/etcd-snapshot-target"
+	// ETCDSnapshotTargetNamePrefix is the prefix of the name given to snapshots uploaded to an additional snapshot target.
+	ETCDSnapshotTargetNamePrefix = "etcd-snapshot-target-"
+
+	// ETCDSnapshotEncryptionKey is the entry of the encryption key secret that holds the key new snapshots are
+	// encrypted with.
+	ETCDSnapshotEncryptionKey = "key"
+	// ETCDSnapshotPreviousEncryptionKeys is the entry of the encryption key secret that holds the newline separated
+	// keys that were previously used to encrypt snapshots.
+	ETCDSnapshotPreviousEncryptionKeys = "previousKeys"
+	// ETCDSnapshotEncryptedSuffix is appended to the name of a snapshot when it is encrypted before being uploaded.
+	ETCDSnapshotEncryptedSuffix = ".enc"
+	// ETCDSnapshotEncryptedUploadInstructionName is the name of the periodic instruction that encrypts and uploads the
+	// snapshots of etcd nodes.
+	ETCDSnapshotEncryptedUploadInstructionName = "etcd-snapshot-encrypted-upload"
+	// ETCDSnapshotEncryptedUploadedPrefix prefixes the lines of the output of the encrypted upload instruction that
+	// list the snapshots present in S3, followed by the name of the snapshot and the ID of the key it was encrypted
+	// with.
+	ETCDSnapshotEncryptedUploadedPrefix = "uploaded-snapshot"
+)
+
+// ETCDSnapshotTargetSnapshotName returns the name passed to the distribution when saving a snapshot for the given
+// target. The distribution appends the node name and a timestamp to it.
+func ETCDSnapshotTargetSnapshotName(target string) string {
+	return ETCDSnapshotTargetNamePrefix + target
+}
+
+// GetETCDSnapshotTarget returns the snapshot target with the given name, or nil if no such target is defined.
+func GetETCDSnapshotTarget(etcd *rkev1.ETCD, name string) *rkev1.ETCDSnapshotTarget {
+	if etcd == nil || name == "" {
+		return nil
+	}
+	for i := range etcd.Targets {
+		if etcd.Targets[i].Name == name {
+			return &etcd.Targets[i]
+		}
+	}
+	return nil
+}
+
+// ETCDSnapshotTargetFromSnapshotName returns the name of the snapshot target that the snapshot with the given name was
+// uploaded to, or an empty string if the snapshot does not belong to any of the defined targets. If multiple target
+// names match, the longest one wins, as target names may be prefixes of each other.
+func ETCDSnapshotTargetFromSnapshotName(etcd *rkev1.ETCD, snapshotName string) string {
+	if etcd == nil || !strings.HasPrefix(snapshotName, ETCDSnapshotTargetNamePrefix) {
+		return ""
+	}
+	var result string
+	for _, target := range etcd.Targets {
+		if target.Name == "" || len(target.Name) <= len(result) {
+			continue
+		}
+		if strings.HasPrefix(snapshotName, ETCDSnapshotTargetSnapshotName(target.Name)+"-") {
+			result = target.Name
+		}
+	}
+	return result
+}
+
+// ValidateETCDSnapshotTargets ensures every snapshot target has a unique name and an enabled S3 configuration.
+func ValidateETCDSnapshotTargets(etcd *rkev1.ETCD) error {
+	if etcd == nil {
+		return nil
+	}
+	seen := map[string]struct{}{}
+	for _, target := range etcd.Targets {
+		if target.Name == "" {
+			return fmt.Errorf("etcd snapshot target name must not be empty")
+		}
+		if _, ok := seen[target.Name]; ok {
+			return fmt.Errorf("etcd snapshot target %s is defined more than once", target.Name)
+		}
+		seen[target.Name] = struct{}{}
+		if target.S3 == nil {
+			return fmt.Errorf("etcd snapshot target %s does not define a storage location", target.Name)
+		}
+		if ETCDSnapshotS3Encrypted(target.S3) {
+			return fmt.Errorf("etcd snapshot target %s: snapshot encryption is only supported for the default S3 target", target.Name)
+		}
+		if target.Retention != nil && (target.Retention.Count < 0 || target.Retention.Hourly < 0 || target.Retention.Daily < 0 ||
+			target.Retention.Weekly < 0 || target.Retention.Monthly < 0) {
+			return fmt.Errorf("etcd snapshot target %s has a negative retention", target.Name)
+		}
+	}
+	return nil
+}
+
+// ETCDSnapshotsToPrune returns the snapshots that are not retained by the given retention policy. Snapshots without a
+// creation time are always retained, as are all snapshots if the policy is nil or empty.
+func ETCDSnapshotsToPrune(retention *rkev1.ETCDSnapshotRetention, snapshots []*rkev1.ETCDSnapshot) []*rkev1.ETCDSnapshot {
+	if retention == nil || *retention == (rkev1.ETCDSnapshotRetention{}) {
+		return nil
+	}
+
+	var candidates []*rkev1.ETCDSnapshot
+	for _, snapshot := range snapshots {
+		if snapshot.SnapshotFile.CreatedAt != nil {
+			candidates = append(candidates, snapshot)
+		}
+	}
+
+	// newest first, so the first snapshot found in each period is the one retained for it
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[j].SnapshotFile.CreatedAt.Before(candidates[i].SnapshotFile.CreatedAt)
+	})
+
+	keep := make(map[*rkev1.ETCDSnapshot]bool, len(candidates))
+	for i := 0; i < retention.Count && i < len(candidates); i++ {
+		keep[candidates[i]] = true
+	}
+
+	tiers := []struct {
+		count  int
+		period func(time.Time) string
+	}{
+		{retention.Hourly, func(t time.Time) string { return t.Format("2006-01-02T15") }},
+		{retention.Daily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{retention.Weekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%d", year, week)
+		}},
+		{retention.Monthly, func(t time.Time) string { return t.Format("2006-01") }},
+	}
+	for _, tier := range tiers {
+		if tier.count <= 0 {
+			continue
+		}
+		periods := map[string]struct{}{}
+		for _, snapshot := range candidates {
+			period := tier.period(snapshot.SnapshotFile.CreatedAt.UTC())
+			if _, ok := periods[period]; ok {
+				continue
+			}
+			if len(periods) == tier.count {
+				break
+			}
+			periods[period] = struct{}{}
+			keep[snapshot] = true
+		}
+	}
+
+	var result []*rkev1.ETCDSnapshot
+	for _, snapshot := range candidates {
+		if !keep[snapshot] {
+			result = append(result, snapshot)
+		}
+	}
+	return result
+}
+
+// ETCDSnapshotS3Encrypted returns true if snapshots uploaded to the given S3 configuration are encrypted.
+func ETCDSnapshotS3Encrypted(s3 *rkev1.ETCDSnapshotS3) bool {
+	return s3 != nil && s3.EncryptionKeySecretName != ""
+}
+
+// ETCDSnapshotEncryptionKeyID returns an identifier for the given encryption key that does not reveal the key itself.
+func ETCDSnapshotEncryptionKeyID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// GetETCDSnapshotEncryptionKeys returns the key that snapshots are currently encrypted with, along with every key held
+// by the given encryption key secret indexed by its ID.
+func GetETCDSnapshotEncryptionKeys(secretCache corecontrollers.SecretCache, namespace, name string) (string, map[string]string, error) {
+	secret, err := secretCache.Get(namespace, name)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to lookup etcd snapshot encryption key secret %s/%s: %w", namespace, name, err)
+	}
+
+	current := strings.TrimSpace(string(secret.Data[ETCDSnapshotEncryptionKey]))
+	if current == "" {
+		return "", nil, fmt.Errorf("etcd snapshot encryption key secret %s/%s does not contain a %s entry", namespace, name, ETCDSnapshotEncryptionKey)
+	}
+
+	keys := map[string]string{
+		ETCDSnapshotEncryptionKeyID(current): current,
+	}
+	for _, key := range strings.Split(string(secret.Data[ETCDSnapshotPreviousEncryptionKeys]), "\n") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys[ETCDSnapshotEncryptionKeyID(key)] = key
+		}
+	}
+	return current, keys, nil
+}
+
+// ETCDSnapshotEncryptedUploads returns the encrypted snapshots that the node of the given machine plan secret confirmed
+// to be present in S3, indexed by snapshot name, with the ID of the key each snapshot was encrypted with. The upload
+// instruction lists every snapshot it uploaded that was not pruned since, so the output of its last run is complete.
+func ETCDSnapshotEncryptedUploads(secret *corev1.Secret) (map[string]string, error) {
+	result := map[string]string{}
+	data := secret.Data["applied-periodic-output"]
+	if len(data) == 0 {
+		return result, nil
+	}
+	gz, err := gzip.NewReader(bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	data, err = io.ReadAll(gz)
+	if err != nil {
+		return nil, err
+	}
+	outputs := map[string]plan.PeriodicInstructionOutput{}
+	if err := json.Unmarshal(data, &outputs); err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewBuffer(outputs[ETCDSnapshotEncryptedUploadInstructionName].Stdout))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 3 && fields[0] == ETCDSnapshotEncryptedUploadedPrefix {
+			result[fields[1]] = fields[2]
+		}
+	}
+	return result, scanner.Err()
+}
@@ -0,0 +1,168 @@
+package capr
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"testing"
+	"time"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func snapshotAt(name string, createdAt time.Time) *rkev1.ETCDSnapshot {
+	t := metav1.NewTime(createdAt)
+	return &rkev1.ETCDSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		SnapshotFile: rkev1.ETCDSnapshotFile{
+			Name:      name,
+			CreatedAt: &t,
+		},
+	}
+}
+
+func snapshotNames(snapshots []*rkev1.ETCDSnapshot) []string {
+	var names []string
+	for _, snapshot := range snapshots {
+		names = append(names, snapshot.Name)
+	}
+	return names
+}
+
+func TestETCDSnapshotsToPrune(t *testing.T) {
+	base := time.Date(2024, time.March, 15, 12, 30, 0, 0, time.UTC)
+
+	hourly := []*rkev1.ETCDSnapshot{
+		snapshotAt("h0", base),
+		snapshotAt("h0-early", base.Add(-10*time.Minute)),
+		snapshotAt("h1", base.Add(-time.Hour)),
+		snapshotAt("h2", base.Add(-2*time.Hour)),
+		snapshotAt("h3", base.Add(-3*time.Hour)),
+	}
+
+	daily := []*rkev1.ETCDSnapshot{
+		snapshotAt("d0", base),
+		snapshotAt("d1", base.AddDate(0, 0, -1)),
+		snapshotAt("d2", base.AddDate(0, 0, -2)),
+		snapshotAt("d40", base.AddDate(0, 0, -40)),
+		snapshotAt("d70", base.AddDate(0, 0, -70)),
+	}
+
+	tests := []struct {
+		name      string
+		retention *rkev1.ETCDSnapshotRetention
+		snapshots []*rkev1.ETCDSnapshot
+		expected  []string
+	}{
+		{
+			name:      "nil policy retains everything",
+			retention: nil,
+			snapshots: hourly,
+		},
+		{
+			name:      "empty policy retains everything",
+			retention: &rkev1.ETCDSnapshotRetention{},
+			snapshots: hourly,
+		},
+		{
+			name:      "count",
+			retention: &rkev1.ETCDSnapshotRetention{Count: 2},
+			snapshots: hourly,
+			expected:  []string{"h1", "h2", "h3"},
+		},
+		{
+			name:      "hourly keeps newest per hour",
+			retention: &rkev1.ETCDSnapshotRetention{Hourly: 3},
+			snapshots: hourly,
+			expected:  []string{"h0-early", "h3"},
+		},
+		{
+			name:      "daily and monthly",
+			retention: &rkev1.ETCDSnapshotRetention{Daily: 2, Monthly: 3},
+			snapshots: daily,
+			expected:  []string{"d2"},
+		},
+		{
+			name:      "tiers are combined",
+			retention: &rkev1.ETCDSnapshotRetention{Count: 1, Weekly: 1},
+			snapshots: daily,
+			expected:  []string{"d1", "d2", "d40", "d70"},
+		},
+		{
+			name:      "snapshots without creation time are retained",
+			retention: &rkev1.ETCDSnapshotRetention{Count: 1},
+			snapshots: []*rkev1.ETCDSnapshot{
+				snapshotAt("new", base),
+				snapshotAt("old", base.Add(-time.Hour)),
+				{ObjectMeta: metav1.ObjectMeta{Name: "unknown"}},
+			},
+			expected: []string{"old"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, snapshotNames(ETCDSnapshotsToPrune(tt.retention, tt.snapshots)))
+		})
+	}
+}
+
+func TestETCDSnapshotTargetFromSnapshotName(t *testing.T) {
+	etcd := &rkev1.ETCD{
+		Targets: []rkev1.ETCDSnapshotTarget{
+			{Name: "east"},
+			{Name: "east-backup"},
+		},
+	}
+
+	assert.Equal(t, "east", ETCDSnapshotTargetFromSnapshotName(etcd, "etcd-snapshot-target-east-node1-1710505800"))
+	assert.Equal(t, "east-backup", ETCDSnapshotTargetFromSnapshotName(etcd, "etcd-snapshot-target-east-backup-node1-1710505800"))
+	assert.Equal(t, "", ETCDSnapshotTargetFromSnapshotName(etcd, "etcd-snapshot-node1-1710505800"))
+	assert.Equal(t, "", ETCDSnapshotTargetFromSnapshotName(nil, "etcd-snapshot-target-east-node1-1710505800"))
+}
+
+func TestValidateETCDSnapshotTargets(t *testing.T) {
+	s3 := &rkev1.ETCDSnapshotS3{Bucket: "bucket"}
+
+	assert.NoError(t, ValidateETCDSnapshotTargets(nil))
+	assert.NoError(t, ValidateETCDSnapshotTargets(&rkev1.ETCD{Targets: []rkev1.ETCDSnapshotTarget{{Name: "a", S3: s3}, {Name: "b", S3: s3}}}))
+	assert.Error(t, ValidateETCDSnapshotTargets(&rkev1.ETCD{Targets: []rkev1.ETCDSnapshotTarget{{S3: s3}}}))
+	assert.Error(t, ValidateETCDSnapshotTargets(&rkev1.ETCD{Targets: []rkev1.ETCDSnapshotTarget{{Name: "a", S3: s3}, {Name: "a", S3: s3}}}))
+	assert.Error(t, ValidateETCDSnapshotTargets(&rkev1.ETCD{Targets: []rkev1.ETCDSnapshotTarget{{Name: "a"}}}))
+	assert.Error(t, ValidateETCDSnapshotTargets(&rkev1.ETCD{Targets: []rkev1.ETCDSnapshotTarget{{Name: "a", S3: s3, Retention: &rkev1.ETCDSnapshotRetention{Daily: -1}}}}))
+}
+
+func TestETCDSnapshotEncryptedUploads(t *testing.T) {
+	data, err := json.Marshal(map[string]plan.PeriodicInstructionOutput{
+		ETCDSnapshotEncryptedUploadInstructionName: {
+			Stdout: []byte("Deleted pruned snapshot etcd-snapshot-node-1\n" +
+				"Uploaded snapshot etcd-snapshot-node-3\n" +
+				"uploaded-snapshot etcd-snapshot-node-2 0123456789abcdef\n" +
+				"uploaded-snapshot etcd-snapshot-node-3 fedcba9876543210\n"),
+		},
+	})
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err = gz.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	uploads, err := ETCDSnapshotEncryptedUploads(&corev1.Secret{Data: map[string][]byte{"applied-periodic-output": buf.Bytes()}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"etcd-snapshot-node-2": "0123456789abcdef",
+		"etcd-snapshot-node-3": "fedcba9876543210",
+	}, uploads)
+
+	uploads, err = ETCDSnapshotEncryptedUploads(&corev1.Secret{})
+	require.NoError(t, err)
+	assert.Empty(t, uploads)
+}
@@ -9,7 +9,6 @@
 	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
 	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
 	"github.com/rancher/rancher/pkg/capr"
-	"github.com/sirupsen/logrus"
 )
 
 // rotateCertificates checks if there is a need to rotate any certificates and updates the plan accordingly.
@@ -20,11 +19,11 @@ func (p *Planner) rotateCertificates(controlPlane *rkev1.RKEControlPlane, status
 
 	found, joinServer, _, err := p.findInitNode(controlPlane, clusterPlan)
 	if err != nil {
-		logrus.Errorf("[planner] rkecluster %s/%s: error encountered while searching for init node during certificate rotation: %v", controlPlane.Namespace, controlPlane.Name, err)
+		controlPlaneLogger(controlPlane).Errorf("error encountered while searching for init node during certificate rotation: %v", err)
 		return status, err
 	}
 	if !found || joinServer == "" {
-		logrus.Warnf("[planner] rkecluster %s/%s: skipping certificate creation as cluster does not have an init node", controlPlane.Namespace, controlPlane.Name)
+		controlPlaneLogger(controlPlane).Warn("skipping certificate creation as cluster does not have an init node")
 		return status, nil
 	}
 
@@ -76,7 +75,7 @@ func shouldRotate(cp *rkev1.RKEControlPlane) bool {
 
 	// The controlplane must be initialized before we rotate anything
 	if cp.Status.Initialized != true {
-		logrus.Warnf("[planner] rkecluster %s/%s: skipping certificate rotation as cluster was not initialized", cp.Namespace, cp.Name)
+		controlPlaneLogger(cp).Warn("skipping certificate rotation as cluster was not initialized")
 		return false
 	}
 
@@ -23,7 +23,6 @@
 	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
 	"github.com/rancher/wrangler/v3/pkg/kv"
 	"github.com/rancher/wrangler/v3/pkg/yaml"
-	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -48,7 +47,9 @@ func (p *Planner) addETCD(config map[string]interface{}, controlPlane *rkev1.RKE
 		config["etcd-snapshot-schedule-cron"] = controlPlane.Spec.ETCD.SnapshotScheduleCron
 	}
 
-	if renderS3 {
+	// If snapshots are encrypted, the distribution must not upload them itself, they are uploaded by the periodic
+	// encrypted upload instruction instead.
+	if renderS3 && !capr.ETCDSnapshotS3Encrypted(controlPlane.Spec.ETCD.S3) {
 		args, _, files, err := p.etcdS3Args.ToArgs(controlPlane.Spec.ETCD.S3, controlPlane, "etcd-", false)
 		if err != nil {
 			return nil, err
@@ -146,14 +147,14 @@ func addRoleConfig(config map[string]interface{}, controlPlane *rkev1.RKEControl
 	// If this is a control-plane node, then we need to set arguments/(and for RKE2, volume mounts) to allow probes
 	// to run.
 	if isControlPlane(entry) {
-		logrus.Debug("addRoleConfig rendering arguments and mounts for kube-controller-manager")
+		controlPlaneLogger(controlPlane).Debug("addRoleConfig rendering arguments and mounts for kube-controller-manager")
 		certDirArg, certDirMount := renderArgAndMount(config[KubeControllerManagerArg], config[KubeControllerManagerExtraMount], controlPlane, DefaultKubeControllerManagerDefaultSecurePort, DefaultKubeControllerManagerCertDir)
 		config[KubeControllerManagerArg] = certDirArg
 		if runtime == capr.RuntimeRKE2 {
 			config[KubeControllerManagerExtraMount] = certDirMount
 		}
 
-		logrus.Debug("addRoleConfig rendering arguments and mounts for kube-scheduler")
+		controlPlaneLogger(controlPlane).Debug("addRoleConfig rendering arguments and mounts for kube-scheduler")
 		certDirArg, certDirMount = renderArgAndMount(config[KubeSchedulerArg], config[KubeSchedulerExtraMount], controlPlane, DefaultKubeSchedulerDefaultSecurePort, DefaultKubeSchedulerCertDir)
 		config[KubeSchedulerArg] = certDirArg
 		if runtime == capr.RuntimeRKE2 {
@@ -199,7 +200,7 @@ func (p *Planner) addManifests(nodePlan plan.NodePlan, controlPlane *rkev1.RKECo
 	}
 
 	if len(bootstrapManifests) > 0 {
-		logrus.Debugf("[planner] adding pre-bootstrap manifests")
+		controlPlaneLogger(controlPlane).Debug("adding pre-bootstrap manifests")
 		nodePlan.Files = append(nodePlan.Files, bootstrapManifests...)
 		return nodePlan, err
 	}
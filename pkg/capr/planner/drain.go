@@ -26,11 +26,15 @@ func getRestartStamp(plan *plan.NodePlan) string {
 }
 
 // shouldDrain determines whether the node should be drained based on the plans provided. If the oldPlan doesn't exist,
-// then it will not be drained, otherwise, it compares the restart stamps to determine whether the engine will be restarted
+// then it will not be drained, otherwise, it compares the restart stamps to determine whether the engine will be restarted,
+// and the OS maintenance stamps to determine whether a new OS maintenance generation will be applied.
 func shouldDrain(oldPlan *plan.NodePlan, newPlan plan.NodePlan) bool {
 	if oldPlan == nil {
 		return false
 	}
+	if osMaintenanceStamp := getOSMaintenanceStamp(&newPlan); osMaintenanceStamp != "" && osMaintenanceStamp != getOSMaintenanceStamp(oldPlan) {
+		return true
+	}
 	return getRestartStamp(oldPlan) != getRestartStamp(&newPlan)
 }
 
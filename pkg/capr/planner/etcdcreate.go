@@ -3,6 +3,7 @@
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
@@ -10,10 +11,17 @@
 	"github.com/rancher/rancher/pkg/capr"
 	"github.com/rancher/rancher/pkg/controllers/capr/managesystemagent"
 	"github.com/rancher/wrangler/v3/pkg/merr"
-	"github.com/sirupsen/logrus"
+	"github.com/robfig/cron"
 	"k8s.io/apimachinery/pkg/api/equality"
 )
 
+const (
+	// etcdSnapshotDefaultScheduleCron is the snapshot schedule of the distributions when none is configured.
+	etcdSnapshotDefaultScheduleCron = "0 */12 * * *"
+
+	etcdSnapshotTargetScheduleInstructionPrefix = "etcd-snapshot-scheduled-"
+)
+
 func (p *Planner) setEtcdSnapshotCreateState(status rkev1.RKEControlPlaneStatus, create *rkev1.ETCDSnapshotCreate, phase rkev1.ETCDSnapshotPhase) (rkev1.RKEControlPlaneStatus, error) {
 	if status.ETCDSnapshotCreatePhase != phase || !equality.Semantic.DeepEqual(status.ETCDSnapshotCreate, create) {
 		status.ETCDSnapshotCreatePhase = phase
@@ -95,11 +103,55 @@ func (p *Planner) runEtcdSnapshotManagementServiceStart(controlPlane *rkev1.RKEC
 
 // generateEtcdSnapshotCreatePlan generates a plan that contains an instruction to create an etcd snapshot.
 func (p *Planner) generateEtcdSnapshotCreatePlan(controlPlane *rkev1.RKEControlPlane, tokensSecret plan.Secret, entry *planEntry, joinServer string) (plan.NodePlan, string, error) {
-	v, err := semver.NewVersion(controlPlane.Spec.KubernetesVersion)
+	args, err := etcdSnapshotSaveArgs(controlPlane)
 	if err != nil {
 		return plan.NodePlan{}, "", err
 	}
 
+	createPlan, _, joinedServer, err := p.generatePlanWithConfigFiles(controlPlane, tokensSecret, entry, joinServer, true)
+	if err != nil {
+		return createPlan, joinedServer, err
+	}
+
+	encrypted := controlPlane.Spec.ETCD != nil && capr.ETCDSnapshotS3Encrypted(controlPlane.Spec.ETCD.S3)
+	createArgs := args
+	if encrypted {
+		// the snapshot is saved locally, and encrypted before being uploaded
+		createArgs = append(append([]string{}, args...), "--etcd-s3=false")
+	}
+
+	createPlan.Instructions = append(createPlan.Instructions, p.generateInstallInstructionWithSkipStart(controlPlane, entry),
+		plan.OneTimeInstruction{
+			Name:    "create",
+			Command: capr.GetRuntimeCommand(controlPlane.Spec.KubernetesVersion),
+			Args:    createArgs,
+		})
+
+	if encrypted {
+		files, instruction, err := p.generateEtcdSnapshotEncryptedUploadInstruction(controlPlane)
+		if err != nil {
+			return createPlan, joinedServer, err
+		}
+		createPlan.Files = append(createPlan.Files, files...)
+		createPlan.Instructions = append(createPlan.Instructions, instruction)
+	}
+
+	targetInstructions, targetFiles, err := p.generateEtcdSnapshotTargetInstructions(controlPlane, args)
+	if err != nil {
+		return createPlan, joinedServer, err
+	}
+	createPlan.Instructions = append(createPlan.Instructions, targetInstructions...)
+	createPlan.Files = append(createPlan.Files, targetFiles...)
+	return createPlan, joinedServer, nil
+}
+
+// etcdSnapshotSaveArgs returns the arguments of the distribution command that saves an etcd snapshot.
+func etcdSnapshotSaveArgs(controlPlane *rkev1.RKEControlPlane) ([]string, error) {
+	v, err := semver.NewVersion(controlPlane.Spec.KubernetesVersion)
+	if err != nil {
+		return nil, err
+	}
+
 	args := []string{
 		"etcd-snapshot",
 	}
@@ -108,15 +160,150 @@ func (p *Planner) generateEtcdSnapshotCreatePlan(controlPlane *rkev1.RKEControlP
 	if v.GreaterThan(managesystemagent.Kubernetes125) {
 		args = append(args, "save")
 	}
+	return args, nil
+}
 
-	createPlan, _, joinedServer, err := p.generatePlanWithConfigFiles(controlPlane, tokensSecret, entry, joinServer, true)
-	createPlan.Instructions = append(createPlan.Instructions, p.generateInstallInstructionWithSkipStart(controlPlane, entry),
-		plan.OneTimeInstruction{
-			Name:    "create",
+// addEtcdSnapshotTargetSchedule adds a periodic instruction per additional snapshot target to etcd nodes, which saves a
+// snapshot to the target at the interval of the snapshot schedule of the cluster. The distribution only uploads its
+// scheduled snapshots to the default S3 target, so this is how scheduled snapshots end up on the additional targets.
+func (p *Planner) addEtcdSnapshotTargetSchedule(nodePlan plan.NodePlan, controlPlane *rkev1.RKEControlPlane, entry *planEntry) (plan.NodePlan, error) {
+	if !isEtcd(entry) || controlPlane.Spec.ETCD == nil || controlPlane.Spec.ETCD.DisableSnapshots || len(controlPlane.Spec.ETCD.Targets) == 0 {
+		return nodePlan, nil
+	}
+
+	period, err := etcdSnapshotSchedulePeriod(controlPlane.Spec.ETCD.SnapshotScheduleCron)
+	if err != nil {
+		return nodePlan, err
+	}
+	args, err := etcdSnapshotSaveArgs(controlPlane)
+	if err != nil {
+		return nodePlan, err
+	}
+	instructions, files, err := p.generateEtcdSnapshotTargetInstructions(controlPlane, args)
+	if err != nil {
+		return nodePlan, err
+	}
+
+	for _, instruction := range instructions {
+		nodePlan.PeriodicInstructions = append(nodePlan.PeriodicInstructions, plan.PeriodicInstruction{
+			Name:          etcdSnapshotTargetScheduleInstructionPrefix + instruction.Name,
+			Command:       instruction.Command,
+			Args:          instruction.Args,
+			Env:           instruction.Env,
+			PeriodSeconds: period,
+		})
+	}
+	nodePlan.Files = append(nodePlan.Files, files...)
+	return nodePlan, nil
+}
+
+// etcdSnapshotSchedulePeriod returns the number of seconds between two runs of the given snapshot schedule, or of the
+// default schedule of the distribution if it is empty. Schedules that don't run at regular intervals, such as
+// "0 2,5 * * *", get the largest gap between two of their runs.
+func etcdSnapshotSchedulePeriod(scheduleCron string) (int, error) {
+	if scheduleCron == "" {
+		scheduleCron = etcdSnapshotDefaultScheduleCron
+	}
+	schedule, err := cron.ParseStandard(scheduleCron)
+	if err != nil {
+		return 0, fmt.Errorf("parsing etcd snapshot schedule %q: %w", scheduleCron, err)
+	}
+	var period time.Duration
+	switch s := schedule.(type) {
+	case cron.ConstantDelaySchedule:
+		period = s.Delay
+	case *cron.SpecSchedule:
+		period = specSchedulePeriod(s)
+	}
+	if period <= 0 {
+		return 0, fmt.Errorf("etcd snapshot schedule %q never runs", scheduleCron)
+	}
+	return int(period.Seconds()), nil
+}
+
+// specSchedulePeriod returns the largest gap between two runs of the given schedule over a full cycle of the calendar,
+// or 0 if it never runs. The cycle starts at a fixed point in time so that the plan does not change between
+// reconciliations. A schedule runs at the same times on every day it runs, so only the runs of its first day are
+// listed and the other days are compared by their first and last runs.
+func specSchedulePeriod(s *cron.SpecSchedule) time.Duration {
+	start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	first := s.Next(start.Add(-time.Second))
+	if first.IsZero() {
+		return 0
+	}
+
+	day := first.Truncate(24 * time.Hour)
+	var runs []time.Duration
+	for t := first; !t.IsZero() && t.Before(day.AddDate(0, 0, 1)); t = s.Next(t) {
+		runs = append(runs, t.Sub(day))
+	}
+	var period time.Duration
+	for i := 1; i < len(runs); i++ {
+		period = max(period, runs[i]-runs[i-1])
+	}
+
+	// the Gregorian calendar repeats its days of the week and leap years every 28 years
+	end := start.AddDate(28, 0, 0)
+	for !day.After(end) {
+		next := s.Next(day.AddDate(0, 0, 1).Add(-time.Second))
+		if next.IsZero() {
+			break
+		}
+		nextDay := next.Truncate(24 * time.Hour)
+		period = max(period, nextDay.Add(runs[0]).Sub(day.Add(runs[len(runs)-1])))
+		day = nextDay
+	}
+	return period
+}
+
+// generateEtcdSnapshotTargetInstructions generates an instruction per additional snapshot target that saves a snapshot
+// and uploads it to the target. The snapshot is named after the target so that the resulting ETCDSnapshot objects can
+// be associated with it. Arguments passed on the command line take precedence over the S3 configuration of the default
+// target rendered into the config file.
+func (p *Planner) generateEtcdSnapshotTargetInstructions(controlPlane *rkev1.RKEControlPlane, saveArgs []string) ([]plan.OneTimeInstruction, []plan.File, error) {
+	if controlPlane.Spec.ETCD == nil || len(controlPlane.Spec.ETCD.Targets) == 0 {
+		return nil, nil, nil
+	}
+	if err := capr.ValidateETCDSnapshotTargets(controlPlane.Spec.ETCD); err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		instructions []plan.OneTimeInstruction
+		files        []plan.File
+	)
+	for _, target := range controlPlane.Spec.ETCD.Targets {
+		s3Args, s3Env, s3Files, err := p.etcdS3Args.ToArgs(target.S3, controlPlane, "etcd-", true)
+		if err != nil {
+			return nil, nil, fmt.Errorf("generating arguments for etcd snapshot target %s: %w", target.Name, err)
+		}
+		if len(s3Args) == 0 {
+			return nil, nil, fmt.Errorf("etcd snapshot target %s does not define a storage location", target.Name)
+		}
+		args := append(append([]string{}, saveArgs...), fmt.Sprintf("--name=%s", capr.ETCDSnapshotTargetSnapshotName(target.Name)))
+		if retention := etcdSnapshotTargetRetention(target.Retention); retention > 0 {
+			args = append(args, fmt.Sprintf("--etcd-snapshot-retention=%d", retention))
+		}
+		instructions = append(instructions, plan.OneTimeInstruction{
+			Name:    "create-" + target.Name,
 			Command: capr.GetRuntimeCommand(controlPlane.Spec.KubernetesVersion),
-			Args:    args,
+			Args:    append(args, s3Args...),
+			Env:     s3Env,
 		})
-	return createPlan, joinedServer, err
+		files = append(files, s3Files...)
+	}
+	return instructions, files, nil
+}
+
+// etcdSnapshotTargetRetention returns the retention count passed to the distribution for a target with a tiered
+// retention policy. As the tiers are pruned by Rancher after each snapshot, the target never holds more than one
+// snapshot in addition to the ones retained by each tier, so the distribution must not prune below that. Zero is
+// returned if the target has no policy, in which case the cluster wide snapshot retention applies.
+func etcdSnapshotTargetRetention(retention *rkev1.ETCDSnapshotRetention) int {
+	if retention == nil || *retention == (rkev1.ETCDSnapshotRetention{}) {
+		return 0
+	}
+	return retention.Count + retention.Hourly + retention.Daily + retention.Weekly + retention.Monthly + 1
 }
 
 func (p *Planner) createEtcdSnapshot(controlPlane *rkev1.RKEControlPlane, status rkev1.RKEControlPlaneStatus, tokensSecret plan.Secret, clusterPlan *plan.Plan) (rkev1.RKEControlPlaneStatus, error) {
@@ -128,7 +315,7 @@ func (p *Planner) createEtcdSnapshot(controlPlane *rkev1.RKEControlPlane, status
 
 	// Don't create an etcd snapshot if the cluster is not initialized or bootstrapped.
 	if !status.Initialized || !capr.Bootstrapped.IsTrue(&status) {
-		logrus.Warnf("[planner] rkecluster %s/%s: skipping etcd snapshot creation as cluster has not yet been initialized or bootstrapped", controlPlane.Namespace, controlPlane.Name)
+		controlPlaneLogger(controlPlane).Warn("skipping etcd snapshot creation as cluster has not yet been initialized or bootstrapped")
 		return status, nil
 	}
 
@@ -144,11 +331,11 @@ func (p *Planner) createEtcdSnapshot(controlPlane *rkev1.RKEControlPlane, status
 		var finErrs []error
 		found, joinServer, _, err := p.findInitNode(controlPlane, clusterPlan)
 		if err != nil {
-			logrus.Errorf("[planner] rkecluster %s/%s: error encountered while searching for init node during etcd snapshot creation: %v", controlPlane.Namespace, controlPlane.Name, err)
+			controlPlaneLogger(controlPlane).Errorf("error encountered while searching for init node during etcd snapshot creation: %v", err)
 			return status, err
 		}
 		if !found || joinServer == "" {
-			logrus.Warnf("[planner] rkecluster %s/%s: skipping etcd snapshot creation as cluster does not have an init node", controlPlane.Namespace, controlPlane.Name)
+			controlPlaneLogger(controlPlane).Warn("skipping etcd snapshot creation as cluster does not have an init node")
 			return status, nil
 		}
 		if errs := p.runEtcdSnapshotCreate(controlPlane, tokensSecret, clusterPlan, joinServer); len(errs) > 0 {
@@ -0,0 +1,79 @@
+package planner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEtcdSnapshotSchedulePeriod(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule string
+		expected int
+		err      bool
+	}{
+		{
+			name:     "default schedule",
+			schedule: "",
+			expected: 12 * 60 * 60,
+		},
+		{
+			name:     "hourly",
+			schedule: "0 * * * *",
+			expected: 60 * 60,
+		},
+		{
+			name:     "every five minutes",
+			schedule: "*/5 * * * *",
+			expected: 5 * 60,
+		},
+		{
+			name:     "descriptor",
+			schedule: "@daily",
+			expected: 24 * 60 * 60,
+		},
+		{
+			name:     "irregular hours",
+			schedule: "0 2,5 * * *",
+			expected: 21 * 60 * 60,
+		},
+		{
+			name:     "weekdays",
+			schedule: "30 1 * * 1-5",
+			expected: 3 * 24 * 60 * 60,
+		},
+		{
+			name:     "monthly",
+			schedule: "0 0 1 * *",
+			expected: 31 * 24 * 60 * 60,
+		},
+		{
+			name:     "interval",
+			schedule: "@every 90m",
+			expected: 90 * 60,
+		},
+		{
+			name:     "never",
+			schedule: "0 0 30 2 *",
+			err:      true,
+		},
+		{
+			name:     "invalid",
+			schedule: "not a schedule",
+			err:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			period, err := etcdSnapshotSchedulePeriod(tt.schedule)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, period)
+		})
+	}
+}
@@ -14,7 +14,6 @@
 	"github.com/rancher/rancher/pkg/controllers/capr/managesystemagent"
 	"github.com/rancher/rancher/pkg/utils"
 	"github.com/rancher/wrangler/v3/pkg/name"
-	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	capi "sigs.k8s.io/cluster-api/api/v1beta1"
@@ -326,13 +325,25 @@ func (p *Planner) generateEtcdSnapshotRestorePlan(controlPlane *rkev1.RKEControl
 		"--etcd-disable-snapshots=false",                                                 // this is a workaround for https://github.com/k3s-io/k3s/issues/8031
 	}
 
-	var env []string
+	var (
+		env                 []string
+		decryptInstructions []plan.OneTimeInstruction
+	)
 
 	if snapshot == nil {
 		// If the snapshot is nil, then we will assume the passed in snapshot name is a local snapshot.
 		args = append(args, fmt.Sprintf("--cluster-reset-restore-path=db/snapshots/%s", snapshotName), "--etcd-s3=false")
 	} else if snapshot.SnapshotFile.S3 == nil {
 		args = append(args, fmt.Sprintf("--cluster-reset-restore-path=db/snapshots/%s", snapshot.SnapshotFile.Name), "--etcd-s3=false")
+	} else if capr.ETCDSnapshotS3Encrypted(snapshot.SnapshotFile.S3) {
+		// Encrypted snapshots are downloaded and decrypted into the local snapshot directory, and restored from there.
+		files, instruction, err := p.generateEtcdSnapshotDecryptInstruction(controlPlane, snapshot)
+		if err != nil {
+			return plan.NodePlan{}, "", err
+		}
+		args = append(args, fmt.Sprintf("--cluster-reset-restore-path=db/snapshots/%s", snapshot.SnapshotFile.Name), "--etcd-s3=false")
+		nodePlan.Files = append(nodePlan.Files, files...)
+		decryptInstructions = append(decryptInstructions, instruction)
 	} else {
 		args = append(args, fmt.Sprintf("--cluster-reset-restore-path=%s", snapshot.SnapshotFile.Name))
 		s3, s3Env, s3Files, err := p.etcdS3Args.ToArgs(snapshot.SnapshotFile.S3, controlPlane, "etcd-", true)
@@ -374,7 +385,9 @@ func (p *Planner) generateEtcdSnapshotRestorePlan(controlPlane *rkev1.RKEControl
 				Args: []string{
 					"-rf",
 					path.Join(capr.GetDistroDataDir(controlPlane), "server/db/etcd"),
-				}}),
+				}}))
+	nodePlan.Instructions = append(nodePlan.Instructions, decryptInstructions...)
+	nodePlan.Instructions = append(nodePlan.Instructions,
 		idempotentInstruction(
 			controlPlane,
 			"etcd-restore/restore",
@@ -616,12 +629,12 @@ func (p *Planner) runEtcdRestoreInitNodeElection(controlPlane *rkev1.RKEControlP
 		if snapshot.SnapshotFile.S3 == nil {
 			// If the snapshot is not an S3 snapshot, then designate the init node by machine ID defined.
 			if id, ok := snapshot.Labels[capr.MachineIDLabel]; ok {
-				logrus.Infof("[planner] rkecluster %s/%s: designating init node with machine ID: %s for local snapshot %s/%s restoration", controlPlane.Namespace, controlPlane.Name, id, snapshot.Namespace, snapshot.Name)
+				controlPlaneLogger(controlPlane).Infof("designating init node with machine ID: %s for local snapshot %s/%s restoration", id, snapshot.Namespace, snapshot.Name)
 				return p.designateInitNodeByMachineID(controlPlane, clusterPlan, id)
 			}
 			return "", fmt.Errorf("unable to designate machine as label %s on snapshot %s/%s did not exist", capr.MachineIDLabel, snapshot.Namespace, snapshot.Name)
 		}
-		logrus.Infof("[planner] rkecluster %s/%s: electing init node for S3 snapshot %s/%s restoration", controlPlane.Namespace, controlPlane.Name, snapshot.Namespace, snapshot.Name)
+		controlPlaneLogger(controlPlane).Infof("electing init node for S3 snapshot %s/%s restoration", snapshot.Namespace, snapshot.Name)
 		return p.electInitNode(controlPlane, clusterPlan, true)
 	}
 	// make sure that we only have one suitable init node, and elect it.
@@ -631,7 +644,7 @@ func (p *Planner) runEtcdRestoreInitNodeElection(controlPlane *rkev1.RKEControlP
 	} else if count > 1 {
 		return "", fmt.Errorf("more than one init node existed and no corresponding etcd snapshot CR found, no assumption can be made for the machine that contains the snapshot")
 	}
-	logrus.Infof("[planner] rkecluster %s/%s: electing init node for local snapshot with no associated CR", controlPlane.Namespace, controlPlane.Name)
+	controlPlaneLogger(controlPlane).Info("electing init node for local snapshot with no associated CR")
 	return p.electInitNode(controlPlane, clusterPlan, true)
 }
 
@@ -726,18 +739,18 @@ func (p *Planner) forceDeleteAllDeletingEtcdMachines(cp *rkev1.RKEControlPlane,
 	etcdDeleting := collect(plan, roleAnd(isEtcd, isDeleting))
 	for _, deletingEtcdNode := range etcdDeleting {
 		if deletingEtcdNode.Machine == nil {
-			logrus.Warnf("[planner] rkecluster %s/%s: did not find CAPI machine for entry when deleting etcd nodes", cp.Namespace, cp.Name)
+			controlPlaneLogger(cp).Warn("did not find CAPI machine for entry when deleting etcd nodes")
 			continue
 		}
 		if deletingEtcdNode.Machine.Spec.Bootstrap.ConfigRef == nil {
-			logrus.Warnf("[planner] rkecluster %s/%s: did not find a corresponding CAPI machine for %s/%s", cp.Namespace, cp.Name, deletingEtcdNode.Machine.Namespace, deletingEtcdNode.Machine.Name)
+			controlPlaneLogger(cp).Warnf("did not find a corresponding CAPI machine for %s/%s", deletingEtcdNode.Machine.Namespace, deletingEtcdNode.Machine.Name)
 			continue
 		}
 		if !strings.Contains(deletingEtcdNode.Machine.Spec.Bootstrap.ConfigRef.APIVersion, "rke.cattle.io") {
-			logrus.Warnf("[planner] rkecluster %s/%s: CAPI machine %s/%s had a bootstrap ref with an unexpected API version: %s", cp.Namespace, cp.Name, deletingEtcdNode.Machine.Namespace, deletingEtcdNode.Machine.Name, deletingEtcdNode.Machine.Spec.Bootstrap.ConfigRef.APIVersion)
+			controlPlaneLogger(cp).Warnf("CAPI machine %s/%s had a bootstrap ref with an unexpected API version: %s", deletingEtcdNode.Machine.Namespace, deletingEtcdNode.Machine.Name, deletingEtcdNode.Machine.Spec.Bootstrap.ConfigRef.APIVersion)
 			continue
 		}
-		logrus.Infof("[planner] rkecluster %s/%s: force deleting etcd machine %s/%s as cluster was not sane and machine was deleting", cp.Namespace, cp.Name, deletingEtcdNode.Machine.Namespace, deletingEtcdNode.Machine.Name)
+		controlPlaneLogger(cp).Infof("force deleting etcd machine %s/%s as cluster was not sane and machine was deleting", deletingEtcdNode.Machine.Namespace, deletingEtcdNode.Machine.Name)
 		// If the etcd plane has been replaced, there will not be a functional apiserver to point to. When deleting
 		// machines, CAPI will attempt to both drain and detach volumes. If the apiserver is unreachable and the
 		// machine's spec.nodeDrainTimeout and spec.nodeVolumeDetachTimeout are nil or 0, CAPI will attempt these
@@ -798,7 +811,7 @@ func (p *Planner) restoreEtcdSnapshot(cp *rkev1.RKEControlPlane, status rkev1.RK
 	if snapshot != nil {
 		clusterSpec, err := capr.ParseSnapshotClusterSpecOrError(snapshot)
 		if err != nil || clusterSpec == nil {
-			logrus.Errorf("[planner] rkecluster %s/%s: error parsing snapshot cluster spec for snapshot %s/%s during etcd restoration: %v", cp.Namespace, cp.Name, snapshot.Namespace, snapshot.Name, err)
+			controlPlaneLogger(cp).Errorf("error parsing snapshot cluster spec for snapshot %s/%s during etcd restoration: %v", snapshot.Namespace, snapshot.Name, err)
 		} else {
 			snapshotK8sVersion, err := semver.NewVersion(clusterSpec.KubernetesVersion)
 			if err != nil {
@@ -815,7 +828,7 @@ func (p *Planner) restoreEtcdSnapshot(cp *rkev1.RKEControlPlane, status rkev1.RK
 		if status.Initialized || status.Ready {
 			status.Initialized = false
 			status.Ready = false
-			logrus.Debugf("[planner] rkecluster %s/%s: setting controlplane ready/initialized to false during etcd restore", cp.Namespace, cp.Name)
+			controlPlaneLogger(cp).Debug("setting controlplane ready/initialized to false during etcd restore")
 		}
 		status, _ = p.setEtcdSnapshotRestoreState(status, cp.Spec.ETCDSnapshotRestore, rkev1.ETCDSnapshotPhaseShutdown)
 		return status, errWaitingf("shutting down cluster")
@@ -843,7 +856,7 @@ func (p *Planner) restoreEtcdSnapshot(cp *rkev1.RKEControlPlane, status rkev1.RK
 		if err := p.pauseCAPICluster(cp, false); err != nil {
 			return status, err
 		}
-		logrus.Infof("[planner] rkecluster %s/%s: running full reconcile during etcd restore to initially restart cluster", cp.Namespace, cp.Name)
+		controlPlaneLogger(cp).Info("running full reconcile during etcd restore to initially restart cluster")
 		// Run a full reconcile of the cluster at this point, ignoring drain and concurrency.
 		if status, err := p.fullReconcile(cp, status, tokensSecret, clusterPlan, true); err != nil {
 			return status, err
@@ -858,7 +871,7 @@ func (p *Planner) restoreEtcdSnapshot(cp *rkev1.RKEControlPlane, status rkev1.RK
 		if err := p.pauseCAPICluster(cp, false); err != nil {
 			return status, err
 		}
-		logrus.Infof("[planner] rkecluster %s/%s: running full reconcile during etcd restore to restart cluster", cp.Namespace, cp.Name)
+		controlPlaneLogger(cp).Info("running full reconcile during etcd restore to restart cluster")
 		// Run a full reconcile of the cluster at this point, ignoring drain and concurrency.
 		if status, err := p.fullReconcile(cp, status, tokensSecret, clusterPlan, true); err != nil {
 			return status, err
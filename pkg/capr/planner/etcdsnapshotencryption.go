@@ -0,0 +1,299 @@
+package planner
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path"
+	"strings"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/rancher/rancher/pkg/capr"
+	"github.com/rancher/wrangler/v3/pkg/kv"
+)
+
+const (
+	etcdSnapshotEncryptionBinPrefix = "capr/etcd-snapshot-encryption/bin"
+
+	etcdSnapshotEncryptedUploadPeriodSeconds = 300
+
+	// etcdSnapshotCommonScript is sourced by the upload and download scripts, and sets up the encryption and S3 client
+	// arguments from the environment variables rendered by s3Args.ToEnv.
+	etcdSnapshotCommonPath   = "common.sh"
+	etcdSnapshotCommonScript = `
+#!/bin/sh
+
+if [ -z "$ETCD_SNAPSHOT_ENCRYPTION_KEY" ]; then
+	echo "Must define ETCD_SNAPSHOT_ENCRYPTION_KEY environment variable"
+	exit 1
+fi
+
+if [ -z "$S3_BUCKET" ]; then
+	echo "Must define S3_BUCKET environment variable"
+	exit 1
+fi
+
+S3_URL="https://${S3_ENDPOINT:-s3.amazonaws.com}/${S3_BUCKET}"
+if [ -n "$S3_FOLDER" ]; then
+	S3_URL="${S3_URL}/${S3_FOLDER}"
+fi
+
+set -- --fail --silent --show-error --aws-sigv4 "aws:amz:${S3_REGION:-us-east-1}:s3" --config -
+if [ -n "$S3_ENDPOINT_CA" ]; then
+	set -- "$@" --cacert "$S3_ENDPOINT_CA"
+fi
+if [ "$S3_SKIP_SSL_VERIFY" = "true" ]; then
+	set -- "$@" --insecure
+fi
+
+# s3_curl passes the credentials through stdin so that they are not visible in the process list.
+s3_curl() {
+	printf 'user = "%s:%s"\n' "$S3_ACCESS_KEY" "$AWS_SECRET_ACCESS_KEY" | curl "$@"
+}
+
+OPENSSL_ARGS="-aes-256-cbc -salt -pbkdf2 -iter 100000 -md sha256 -pass env:ETCD_SNAPSHOT_ENCRYPTION_KEY"
+`
+
+	// etcdSnapshotEncryptedUploadScript encrypts and uploads every snapshot in the given directory that was not yet
+	// uploaded, and deletes the uploaded snapshots that were pruned from the directory by the distribution, so that S3
+	// holds as many snapshots as the snapshot retention. A marker file holding the ID of the encryption key is kept
+	// outside the snapshot directory for each uploaded snapshot, as the distribution treats every file in the snapshot
+	// directory as a snapshot. The markers are listed once done, which is how Rancher learns that a snapshot was
+	// uploaded.
+	etcdSnapshotEncryptedUploadPath   = "upload.sh"
+	etcdSnapshotEncryptedUploadScript = `
+#!/bin/sh
+
+SNAPSHOT_DIR="$1"
+if [ ! -d "$SNAPSHOT_DIR" ]; then
+	exit 0
+fi
+
+MARKER_DIR="$(dirname "$0")/../uploaded"
+mkdir -p "$MARKER_DIR"
+
+. "$(dirname "$0")/common.sh"
+
+TMPENCRYPTED=$(mktemp)
+trap 'rm -f "$TMPENCRYPTED"' EXIT
+
+RC=0
+
+# delete the snapshots that were pruned by the distribution
+for MARKER in "$MARKER_DIR"/*; do
+	NAME=$(basename "$MARKER")
+	if [ ! -f "$MARKER" ] || [ -f "${SNAPSHOT_DIR}/${NAME}" ]; then
+		continue
+	fi
+	if ! s3_curl "$@" --request DELETE "${S3_URL}/${NAME}${ETCD_SNAPSHOT_ENCRYPTED_SUFFIX}"; then
+		echo "Error deleting pruned snapshot ${NAME}"
+		RC=1
+		continue
+	fi
+	rm -f "$MARKER"
+	echo "Deleted pruned snapshot ${NAME}"
+done
+
+for SNAPSHOT in "$SNAPSHOT_DIR"/*; do
+	NAME=$(basename "$SNAPSHOT")
+	if [ ! -f "$SNAPSHOT" ] || [ -f "${MARKER_DIR}/${NAME}" ]; then
+		continue
+	fi
+	if ! openssl enc -e $OPENSSL_ARGS -in "$SNAPSHOT" -out "$TMPENCRYPTED"; then
+		echo "Error encrypting snapshot ${NAME}"
+		RC=1
+		continue
+	fi
+	if ! s3_curl "$@" --upload-file "$TMPENCRYPTED" "${S3_URL}/${NAME}${ETCD_SNAPSHOT_ENCRYPTED_SUFFIX}"; then
+		echo "Error uploading snapshot ${NAME}"
+		RC=1
+		continue
+	fi
+	echo "$ETCD_SNAPSHOT_ENCRYPTION_KEY_ID" > "${MARKER_DIR}/${NAME}"
+	echo "Uploaded snapshot ${NAME}"
+done
+
+for MARKER in "$MARKER_DIR"/*; do
+	if [ -f "$MARKER" ]; then
+		echo "${ETCD_SNAPSHOT_UPLOADED_PREFIX} $(basename "$MARKER") $(cat "$MARKER")"
+	fi
+done
+exit $RC
+`
+
+	// etcdSnapshotEncryptedDownloadScript downloads the given encrypted snapshot and decrypts it to the given path.
+	etcdSnapshotEncryptedDownloadPath   = "download.sh"
+	etcdSnapshotEncryptedDownloadScript = `
+#!/bin/sh
+
+OBJECT="$1"
+DESTINATION="$2"
+if [ -z "$OBJECT" ] || [ -z "$DESTINATION" ]; then
+	echo "Must define object and destination"
+	exit 1
+fi
+
+. "$(dirname "$0")/common.sh"
+
+TMPENCRYPTED=$(mktemp)
+trap 'rm -f "$TMPENCRYPTED"' EXIT
+
+if ! s3_curl "$@" --output "$TMPENCRYPTED" "${S3_URL}/${OBJECT}"; then
+	echo "Error downloading snapshot ${OBJECT}"
+	exit 1
+fi
+
+mkdir -p "$(dirname "$DESTINATION")"
+if ! openssl enc -d $OPENSSL_ARGS -in "$TMPENCRYPTED" -out "$DESTINATION"; then
+	echo "Error decrypting snapshot ${OBJECT}: the encryption key does not match the key the snapshot was encrypted with"
+	rm -f "$DESTINATION"
+	exit 1
+fi
+`
+)
+
+func etcdSnapshotEncryptionScriptPath(controlPlane *rkev1.RKEControlPlane, file string) string {
+	return path.Join(capr.GetDistroDataDir(controlPlane), etcdSnapshotEncryptionBinPrefix, file)
+}
+
+func etcdSnapshotDir(controlPlane *rkev1.RKEControlPlane) string {
+	return path.Join(capr.GetDistroDataDir(controlPlane), "server/db/snapshots")
+}
+
+// generateEtcdSnapshotEncryptionFiles returns the scripts used to encrypt and upload, or download and decrypt
+// snapshots.
+func generateEtcdSnapshotEncryptionFiles(controlPlane *rkev1.RKEControlPlane) []plan.File {
+	return []plan.File{
+		{
+			Content: base64.StdEncoding.EncodeToString([]byte(etcdSnapshotCommonScript)),
+			Path:    etcdSnapshotEncryptionScriptPath(controlPlane, etcdSnapshotCommonPath),
+			Dynamic: true,
+		},
+		{
+			Content: base64.StdEncoding.EncodeToString([]byte(etcdSnapshotEncryptedUploadScript)),
+			Path:    etcdSnapshotEncryptionScriptPath(controlPlane, etcdSnapshotEncryptedUploadPath),
+			Dynamic: true,
+		},
+		{
+			Content: base64.StdEncoding.EncodeToString([]byte(etcdSnapshotEncryptedDownloadScript)),
+			Path:    etcdSnapshotEncryptionScriptPath(controlPlane, etcdSnapshotEncryptedDownloadPath),
+			Dynamic: true,
+		},
+	}
+}
+
+// etcdSnapshotEncryptionEnv renders the environment variables required by the encryption scripts for the given S3
+// configuration and encryption key.
+func (p *Planner) etcdSnapshotEncryptionEnv(controlPlane *rkev1.RKEControlPlane, s3 *rkev1.ETCDSnapshotS3, key string) ([]string, []plan.File, error) {
+	env, files, err := p.etcdS3Args.ToEnv(s3, controlPlane)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(env,
+		fmt.Sprintf("ETCD_SNAPSHOT_ENCRYPTION_KEY=%s", key),
+		fmt.Sprintf("ETCD_SNAPSHOT_ENCRYPTION_KEY_ID=%s", capr.ETCDSnapshotEncryptionKeyID(key)),
+		fmt.Sprintf("ETCD_SNAPSHOT_ENCRYPTED_SUFFIX=%s", capr.ETCDSnapshotEncryptedSuffix),
+		fmt.Sprintf("ETCD_SNAPSHOT_UPLOADED_PREFIX=%s", capr.ETCDSnapshotEncryptedUploadedPrefix),
+	), files, nil
+}
+
+// addEtcdSnapshotEncryptedUpload adds a periodic instruction to etcd nodes that encrypts and uploads snapshots, if
+// encryption is enabled for the S3 configuration of the cluster. As the distribution is not configured with S3 in that
+// case, this is how scheduled snapshots end up in S3.
+func (p *Planner) addEtcdSnapshotEncryptedUpload(nodePlan plan.NodePlan, controlPlane *rkev1.RKEControlPlane, entry *planEntry) (plan.NodePlan, error) {
+	if !isEtcd(entry) || controlPlane.Spec.ETCD == nil || !capr.ETCDSnapshotS3Encrypted(controlPlane.Spec.ETCD.S3) {
+		return nodePlan, nil
+	}
+
+	env, files, err := p.currentEtcdSnapshotEncryptionEnv(controlPlane)
+	if err != nil {
+		return nodePlan, err
+	}
+
+	nodePlan.Files = append(nodePlan.Files, generateEtcdSnapshotEncryptionFiles(controlPlane)...)
+	nodePlan.Files = append(nodePlan.Files, files...)
+	nodePlan.PeriodicInstructions = append(nodePlan.PeriodicInstructions, plan.PeriodicInstruction{
+		Name:          capr.ETCDSnapshotEncryptedUploadInstructionName,
+		Command:       "/bin/sh",
+		Args:          []string{etcdSnapshotEncryptionScriptPath(controlPlane, etcdSnapshotEncryptedUploadPath), etcdSnapshotDir(controlPlane)},
+		Env:           env,
+		PeriodSeconds: etcdSnapshotEncryptedUploadPeriodSeconds,
+	})
+	return nodePlan, nil
+}
+
+// generateEtcdSnapshotEncryptedUploadInstruction returns an instruction that immediately encrypts and uploads the
+// snapshots that were not uploaded yet, used after an on-demand snapshot was created.
+func (p *Planner) generateEtcdSnapshotEncryptedUploadInstruction(controlPlane *rkev1.RKEControlPlane) ([]plan.File, plan.OneTimeInstruction, error) {
+	env, files, err := p.currentEtcdSnapshotEncryptionEnv(controlPlane)
+	if err != nil {
+		return nil, plan.OneTimeInstruction{}, err
+	}
+	return append(generateEtcdSnapshotEncryptionFiles(controlPlane), files...), plan.OneTimeInstruction{
+		Name:    "encrypt-and-upload",
+		Command: "/bin/sh",
+		Args:    []string{etcdSnapshotEncryptionScriptPath(controlPlane, etcdSnapshotEncryptedUploadPath), etcdSnapshotDir(controlPlane)},
+		Env:     env,
+	}, nil
+}
+
+func (p *Planner) currentEtcdSnapshotEncryptionEnv(controlPlane *rkev1.RKEControlPlane) ([]string, []plan.File, error) {
+	s3 := controlPlane.Spec.ETCD.S3
+	key, _, err := capr.GetETCDSnapshotEncryptionKeys(p.secretCache, controlPlane.Namespace, s3.EncryptionKeySecretName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p.etcdSnapshotEncryptionEnv(controlPlane, s3, key)
+}
+
+// generateEtcdSnapshotDecryptInstruction returns an instruction that downloads the given encrypted snapshot and
+// decrypts it into the local snapshot directory, so that it can be restored as a local snapshot. An error is returned
+// if the key the snapshot was encrypted with is not present in the encryption key secret.
+func (p *Planner) generateEtcdSnapshotDecryptInstruction(controlPlane *rkev1.RKEControlPlane, snapshot *rkev1.ETCDSnapshot) ([]plan.File, plan.OneTimeInstruction, error) {
+	s3 := snapshot.SnapshotFile.S3
+	_, keys, err := capr.GetETCDSnapshotEncryptionKeys(p.secretCache, controlPlane.Namespace, s3.EncryptionKeySecretName)
+	if err != nil {
+		return nil, plan.OneTimeInstruction{}, fmt.Errorf("unable to restore encrypted etcd snapshot %s/%s: %w", snapshot.Namespace, snapshot.Name, err)
+	}
+	key, ok := keys[snapshot.SnapshotFile.EncryptionKeyID]
+	if !ok {
+		return nil, plan.OneTimeInstruction{}, fmt.Errorf("unable to restore encrypted etcd snapshot %s/%s: it was encrypted with key %s which is not present in secret %s/%s",
+			snapshot.Namespace, snapshot.Name, snapshot.SnapshotFile.EncryptionKeyID, controlPlane.Namespace, s3.EncryptionKeySecretName)
+	}
+
+	env, files, err := p.etcdSnapshotEncryptionEnv(controlPlane, s3, key)
+	if err != nil {
+		return nil, plan.OneTimeInstruction{}, err
+	}
+	return append(generateEtcdSnapshotEncryptionFiles(controlPlane), files...), idempotentInstruction(
+		controlPlane,
+		"etcd-restore/download-and-decrypt",
+		fmt.Sprintf("%v", controlPlane.Status.ETCDSnapshotRestore),
+		"/bin/sh",
+		[]string{
+			etcdSnapshotEncryptionScriptPath(controlPlane, etcdSnapshotEncryptedDownloadPath),
+			snapshot.SnapshotFile.Name + capr.ETCDSnapshotEncryptedSuffix,
+			path.Join(etcdSnapshotDir(controlPlane), snapshot.SnapshotFile.Name),
+		},
+		env), nil
+}
+
+// ToEnv renders the S3 configuration as environment variables for the snapshot encryption scripts, as well as the
+// endpoint CA file if one is required.
+func (s *s3Args) ToEnv(s3 *rkev1.ETCDSnapshotS3, controlPlane *rkev1.RKEControlPlane) ([]string, []plan.File, error) {
+	args, env, files, err := s.ToArgs(s3, controlPlane, "", true)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, arg := range args {
+		k, v := kv.Split(strings.TrimPrefix(arg, "--"), "=")
+		if k == "s3" {
+			continue
+		}
+		if v == "" {
+			v = "true"
+		}
+		env = append(env, fmt.Sprintf("%s=%s", strings.ToUpper(strings.ReplaceAll(k, "-", "_")), v))
+	}
+	return env, files, nil
+}
@@ -0,0 +1,108 @@
+package planner
+
+import (
+	"testing"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/capr"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestGenerateEtcdSnapshotDecryptInstruction(t *testing.T) {
+	controlPlane := &rkev1.RKEControlPlane{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "fleet-default",
+			Name:      "test",
+		},
+		Spec: rkev1.RKEControlPlaneSpec{
+			KubernetesVersion: "v1.30.4+rke2r1",
+		},
+	}
+
+	encryptedSnapshot := func(keyID string) *rkev1.ETCDSnapshot {
+		return &rkev1.ETCDSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "fleet-default",
+				Name:      "test-etcd-snapshot-node1-1710505800-s3",
+			},
+			SnapshotFile: rkev1.ETCDSnapshotFile{
+				Name: "etcd-snapshot-node1-1710505800",
+				S3: &rkev1.ETCDSnapshotS3{
+					Bucket:                  "snapshots",
+					Region:                  "eu-west-1",
+					EncryptionKeySecretName: "snapshot-key",
+				},
+				EncryptionKeyID: keyID,
+			},
+		}
+	}
+
+	keySecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "fleet-default",
+			Name:      "snapshot-key",
+		},
+		Data: map[string][]byte{
+			capr.ETCDSnapshotEncryptionKey:          []byte("new-key"),
+			capr.ETCDSnapshotPreviousEncryptionKeys: []byte("old-key\nolder-key\n"),
+		},
+	}
+
+	tests := []struct {
+		name        string
+		snapshot    *rkev1.ETCDSnapshot
+		secret      *corev1.Secret
+		secretErr   error
+		expectedKey string
+		expectedErr string
+	}{
+		{
+			name:        "current key",
+			snapshot:    encryptedSnapshot(capr.ETCDSnapshotEncryptionKeyID("new-key")),
+			secret:      keySecret,
+			expectedKey: "new-key",
+		},
+		{
+			name:        "rotated key",
+			snapshot:    encryptedSnapshot(capr.ETCDSnapshotEncryptionKeyID("older-key")),
+			secret:      keySecret,
+			expectedKey: "older-key",
+		},
+		{
+			name:        "unknown key",
+			snapshot:    encryptedSnapshot(capr.ETCDSnapshotEncryptionKeyID("lost-key")),
+			secret:      keySecret,
+			expectedErr: "it was encrypted with key " + capr.ETCDSnapshotEncryptionKeyID("lost-key") + " which is not present in secret fleet-default/snapshot-key",
+		},
+		{
+			name:        "missing secret",
+			snapshot:    encryptedSnapshot(capr.ETCDSnapshotEncryptionKeyID("new-key")),
+			secretErr:   apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, "snapshot-key"),
+			expectedErr: "failed to lookup etcd snapshot encryption key secret fleet-default/snapshot-key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mp := newMockPlanner(t, InfoFunctions{})
+			mp.secretCache.EXPECT().Get("fleet-default", "snapshot-key").Return(tt.secret, tt.secretErr).AnyTimes()
+
+			files, instruction, err := mp.planner.generateEtcdSnapshotDecryptInstruction(controlPlane, tt.snapshot)
+			if tt.expectedErr != "" {
+				assert.ErrorContains(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, files, 3)
+			assert.Contains(t, instruction.Env, "ETCD_SNAPSHOT_ENCRYPTION_KEY="+tt.expectedKey)
+			assert.Contains(t, instruction.Env, "S3_BUCKET=snapshots")
+			assert.Contains(t, instruction.Env, "S3_REGION=eu-west-1")
+			assert.Contains(t, instruction.Args, "etcd-snapshot-node1-1710505800"+capr.ETCDSnapshotEncryptedSuffix)
+			assert.Contains(t, instruction.Args, "/var/lib/rancher/rke2/server/db/snapshots/etcd-snapshot-node1-1710505800")
+		})
+	}
+}
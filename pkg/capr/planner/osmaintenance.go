@@ -0,0 +1,175 @@
+package planner
+
+import (
+	"encoding/base64"
+	"path"
+	"strconv"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/rancher/rancher/pkg/capr"
+	"github.com/rancher/wrangler/v3/pkg/kv"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	osMaintenanceInstructionName = "os-maintenance"
+	// osMaintenanceStampEnv is set on the OS maintenance instruction with the generation being applied, and is compared
+	// between plans to determine whether the node must be drained.
+	osMaintenanceStampEnv = "OS_MAINTENANCE_STAMP"
+
+	// osMaintenanceScript runs the update command once per generation. If a reboot is required, it records the boot ID,
+	// reboots the node and fails, so the plan is retried by the system-agent and only reported as applied once the node
+	// is back and the script has observed the new boot ID.
+	osMaintenanceScript = `#!/bin/sh
+set -e
+
+stamp="${OS_MAINTENANCE_STATE_DIR}/${OS_MAINTENANCE_STAMP}"
+bootID=$(cat /proc/sys/kernel/random/boot_id)
+
+if [ -f "${stamp}.done" ]; then
+	echo "os maintenance generation ${OS_MAINTENANCE_STAMP} was already applied"
+	exit 0
+fi
+
+reboot_node() {
+	echo "${bootID}" > "${stamp}.rebooting"
+	sync
+	echo "rebooting node to complete os maintenance generation ${OS_MAINTENANCE_STAMP}"
+	if command -v systemctl >/dev/null 2>&1; then
+		systemctl reboot
+	else
+		reboot
+	fi
+	# the plan is retried until the script runs after the reboot
+	echo "waiting for the node to reboot" >&2
+	exit 1
+}
+
+if [ -f "${stamp}.rebooting" ]; then
+	if [ "$(cat "${stamp}.rebooting")" != "${bootID}" ]; then
+		mv "${stamp}.rebooting" "${stamp}.done"
+		echo "node rebooted, os maintenance generation ${OS_MAINTENANCE_STAMP} was applied"
+		exit 0
+	fi
+	reboot_node
+fi
+
+mkdir -p "${OS_MAINTENANCE_STATE_DIR}"
+
+if [ -n "${OS_MAINTENANCE_UPDATE_COMMAND}" ]; then
+	sh -c "${OS_MAINTENANCE_UPDATE_COMMAND}"
+elif command -v transactional-update >/dev/null 2>&1; then
+	transactional-update --non-interactive up
+elif command -v zypper >/dev/null 2>&1; then
+	zypper --non-interactive update
+elif command -v dnf >/dev/null 2>&1; then
+	dnf -y upgrade
+elif command -v yum >/dev/null 2>&1; then
+	yum -y update
+elif command -v apt-get >/dev/null 2>&1; then
+	apt-get update
+	DEBIAN_FRONTEND=noninteractive apt-get -y -o Dpkg::Options::=--force-confold upgrade
+else
+	echo "unable to find a supported package manager, set an update command" >&2
+	exit 1
+fi
+
+rebootRequired=false
+if [ -n "${OS_MAINTENANCE_REBOOT_REQUIRED_COMMAND}" ]; then
+	if sh -c "${OS_MAINTENANCE_REBOOT_REQUIRED_COMMAND}"; then
+		rebootRequired=true
+	fi
+elif command -v transactional-update >/dev/null 2>&1; then
+	# a transactional update is only activated by a reboot
+	rebootRequired=true
+elif command -v zypper >/dev/null 2>&1; then
+	zypper needs-rebooting >/dev/null 2>&1 || rebootRequired=true
+elif command -v needs-restarting >/dev/null 2>&1; then
+	needs-restarting -r >/dev/null 2>&1 || rebootRequired=true
+elif [ -f /var/run/reboot-required ]; then
+	rebootRequired=true
+fi
+
+if [ "${rebootRequired}" = "true" ] && [ "${OS_MAINTENANCE_REBOOT}" = "true" ]; then
+	reboot_node
+fi
+
+if [ "${rebootRequired}" = "true" ]; then
+	echo "a reboot is required to complete os maintenance generation ${OS_MAINTENANCE_STAMP}, but reboots are disabled"
+fi
+touch "${stamp}.done"
+`
+)
+
+func osMaintenanceScriptPath(controlPlane *rkev1.RKEControlPlane) string {
+	return path.Join(capr.GetProvisioningDataDir(&controlPlane.Spec.RKEClusterSpecCommon), "os-maintenance/bin/os-maintenance.sh")
+}
+
+// osMaintenanceForEntry returns the OS maintenance configuration that applies to the given machine, or nil if there is
+// none. The last matching configuration wins.
+func osMaintenanceForEntry(controlPlane *rkev1.RKEControlPlane, entry *planEntry) (*rkev1.OSMaintenance, error) {
+	var result *rkev1.OSMaintenance
+	for i, maintenance := range controlPlane.Spec.OSMaintenance {
+		sel, err := metav1.LabelSelectorAsSelector(maintenance.MachineLabelSelector)
+		if err != nil {
+			return nil, err
+		}
+		if maintenance.MachineLabelSelector != nil && !sel.Matches(labels.Set(entry.Machine.Labels)) {
+			continue
+		}
+		result = &controlPlane.Spec.OSMaintenance[i]
+	}
+	return result, nil
+}
+
+// addOSMaintenance adds the OS maintenance instruction to the plan of the given machine if OS maintenance is configured
+// for it. As the instruction carries the generation being applied, a new generation is a plan change that is rolled out
+// with the concurrency and drain options of the tier the machine belongs to, and the machine is only considered done
+// once it has rebooted (if needed) and its probes are healthy again.
+func (p *Planner) addOSMaintenance(nodePlan plan.NodePlan, controlPlane *rkev1.RKEControlPlane, entry *planEntry) (plan.NodePlan, error) {
+	if windows(entry) {
+		return nodePlan, nil
+	}
+
+	maintenance, err := osMaintenanceForEntry(controlPlane, entry)
+	if err != nil || maintenance == nil || maintenance.Generation == 0 {
+		return nodePlan, err
+	}
+
+	nodePlan.Files = append(nodePlan.Files, plan.File{
+		Content: base64.StdEncoding.EncodeToString([]byte(osMaintenanceScript)),
+		Path:    osMaintenanceScriptPath(controlPlane),
+		Dynamic: true,
+		Minor:   true,
+	})
+	nodePlan.Instructions = append(nodePlan.Instructions, plan.OneTimeInstruction{
+		Name:    osMaintenanceInstructionName,
+		Command: "sh",
+		Args:    []string{osMaintenanceScriptPath(controlPlane)},
+		Env: []string{
+			osMaintenanceStampEnv + "=" + strconv.Itoa(maintenance.Generation),
+			"OS_MAINTENANCE_STATE_DIR=" + path.Join(capr.GetProvisioningDataDir(&controlPlane.Spec.RKEClusterSpecCommon), "os-maintenance/state"),
+			"OS_MAINTENANCE_UPDATE_COMMAND=" + maintenance.UpdateCommand,
+			"OS_MAINTENANCE_REBOOT_REQUIRED_COMMAND=" + maintenance.RebootRequiredCommand,
+			"OS_MAINTENANCE_REBOOT=" + strconv.FormatBool(!maintenance.DisableReboot),
+		},
+		SaveOutput: true,
+	})
+	return nodePlan, nil
+}
+
+func getOSMaintenanceStamp(plan *plan.NodePlan) string {
+	for _, instr := range plan.Instructions {
+		if instr.Name != osMaintenanceInstructionName {
+			continue
+		}
+		for _, env := range instr.Env {
+			if k, v := kv.Split(env, "="); k == osMaintenanceStampEnv {
+				return v
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,112 @@
+package planner
+
+import (
+	"testing"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/rancher/rancher/pkg/capr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capi "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+func TestAddOSMaintenance(t *testing.T) {
+	poolSelector := func(pool string) *metav1.LabelSelector {
+		return &metav1.LabelSelector{MatchLabels: map[string]string{capr.RKEMachinePoolNameLabel: pool}}
+	}
+	entry := func(pool, os string) *planEntry {
+		return &planEntry{
+			Machine: &capi.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{capr.RKEMachinePoolNameLabel: pool},
+				},
+			},
+			Metadata: &plan.Metadata{
+				Labels: map[string]string{capr.CattleOSLabel: os},
+			},
+		}
+	}
+	controlPlane := &rkev1.RKEControlPlane{
+		Spec: rkev1.RKEControlPlaneSpec{
+			RKEClusterSpecCommon: rkev1.RKEClusterSpecCommon{
+				OSMaintenance: []rkev1.OSMaintenance{
+					{Generation: 1},
+					{MachineLabelSelector: poolSelector("workers"), Generation: 3, UpdateCommand: "apt-get -y dist-upgrade", DisableReboot: true},
+					{MachineLabelSelector: poolSelector("paused"), Generation: 0},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		entry         *planEntry
+		expectedStamp string
+		expectEnv     []string
+	}{
+		{
+			name:          "cluster wide configuration",
+			entry:         entry("control-plane", capr.DefaultMachineOS),
+			expectedStamp: "1",
+			expectEnv:     []string{"OS_MAINTENANCE_REBOOT=true"},
+		},
+		{
+			name:          "machine pool configuration wins",
+			entry:         entry("workers", capr.DefaultMachineOS),
+			expectedStamp: "3",
+			expectEnv:     []string{"OS_MAINTENANCE_REBOOT=false", "OS_MAINTENANCE_UPDATE_COMMAND=apt-get -y dist-upgrade"},
+		},
+		{
+			name:  "generation zero disables maintenance",
+			entry: entry("paused", capr.DefaultMachineOS),
+		},
+		{
+			name:  "windows is not supported",
+			entry: entry("control-plane", capr.WindowsMachineOS),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Planner{}
+			nodePlan, err := p.addOSMaintenance(plan.NodePlan{}, controlPlane, tt.entry)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStamp, getOSMaintenanceStamp(&nodePlan))
+			if tt.expectedStamp == "" {
+				assert.Empty(t, nodePlan.Instructions)
+				assert.Empty(t, nodePlan.Files)
+				return
+			}
+			require.Len(t, nodePlan.Instructions, 1)
+			for _, env := range tt.expectEnv {
+				assert.Contains(t, nodePlan.Instructions[0].Env, env)
+			}
+		})
+	}
+}
+
+func TestShouldDrainOSMaintenance(t *testing.T) {
+	withStamp := func(generation string) plan.NodePlan {
+		nodePlan := plan.NodePlan{
+			Instructions: []plan.OneTimeInstruction{{Name: "install", Env: []string{"RESTART_STAMP=abc"}}},
+		}
+		if generation != "" {
+			nodePlan.Instructions = append(nodePlan.Instructions, plan.OneTimeInstruction{
+				Name: osMaintenanceInstructionName,
+				Env:  []string{osMaintenanceStampEnv + "=" + generation},
+			})
+		}
+		return nodePlan
+	}
+
+	unchanged := withStamp("1")
+	assert.False(t, shouldDrain(&unchanged, withStamp("1")))
+	assert.True(t, shouldDrain(&unchanged, withStamp("2")))
+
+	none := withStamp("")
+	assert.True(t, shouldDrain(&none, withStamp("1")))
+	// removing the maintenance configuration does not require a drain
+	assert.False(t, shouldDrain(&unchanged, withStamp("")))
+}
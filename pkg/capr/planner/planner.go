@@ -25,6 +25,7 @@
 	mgmtcontrollers "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
 	ranchercontrollers "github.com/rancher/rancher/pkg/generated/controllers/provisioning.cattle.io/v1"
 	rkecontrollers "github.com/rancher/rancher/pkg/generated/controllers/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/logging"
 	"github.com/rancher/rancher/pkg/wrangler"
 	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
 	"github.com/rancher/wrangler/v3/pkg/name"
@@ -96,6 +97,8 @@
 )
 
 var (
+	logger = logging.For("capr/planner")
+
 	fileParams = []string{
 		auditPolicyArg,
 		cloudProviderConfigArg,
@@ -107,6 +110,15 @@
 	}
 )
 
+// controlPlaneLogger returns the logger of the planner for the given control plane.
+func controlPlaneLogger(cp *rkev1.RKEControlPlane) *logrus.Entry {
+	return logger.WithFields(logrus.Fields{
+		logging.FieldController: "planner",
+		logging.FieldCluster:    cp.Spec.ManagementClusterName,
+		logging.FieldKey:        cp.Namespace + "/" + cp.Name,
+	})
+}
+
 type Planner struct {
 	ctx                           context.Context
 	store                         *PlanStore
@@ -213,12 +225,13 @@ func (p *Planner) setMachineConditionStatus(clusterPlan *plan.Plan, machineNames
 }
 
 func (p *Planner) Process(cp *rkev1.RKEControlPlane, status rkev1.RKEControlPlaneStatus) (rkev1.RKEControlPlaneStatus, error) {
-	logrus.Debugf("[planner] rkecluster %s/%s: attempting to lock %s for processing", cp.Namespace, cp.Name, string(cp.UID))
+	log := controlPlaneLogger(cp)
+	log.Debugf("attempting to lock %s for processing", string(cp.UID))
 	p.locker.Lock(string(cp.UID))
-	defer func(namespace, name, uid string) {
-		logrus.Debugf("[planner] rkecluster %s/%s: unlocking %s", namespace, name, uid)
+	defer func(uid string) {
+		log.Debugf("unlocking %s", uid)
 		_ = p.locker.Unlock(uid)
-	}(cp.Namespace, cp.Name, string(cp.UID))
+	}(string(cp.UID))
 
 	currentVersion, err := semver.NewVersion(cp.Spec.KubernetesVersion)
 	if err != nil {
@@ -248,10 +261,10 @@ func (p *Planner) Process(cp *rkev1.RKEControlPlane, status rkev1.RKEControlPlan
 		if capiannotations.IsPaused(capiCluster, cp) {
 			err = p.pauseCAPICluster(cp, false)
 			if err != nil {
-				logrus.Errorf("error unpausing CAPI cluster during deletion: %s", err)
+				log.Errorf("error unpausing CAPI cluster during deletion: %s", err)
 			}
 		}
-		logrus.Infof("[planner] %s/%s: reconciliation stopped: CAPI cluster is deleting", cp.Namespace, cp.Name)
+		log.Info("reconciliation stopped: CAPI cluster is deleting")
 		return status, nil
 	}
 
@@ -277,7 +290,7 @@ func (p *Planner) Process(cp *rkev1.RKEControlPlane, status rkev1.RKEControlPlan
 		if status.Initialized || status.Ready {
 			status.Initialized = false
 			status.Ready = false
-			logrus.Debugf("[planner] rkecluster %s/%s: setting controlplane ready/initialized to false as cluster was not sane", cp.Namespace, cp.Name)
+			log.Debug("setting controlplane ready/initialized to false as cluster was not sane")
 			return status, errWaitingf("uninitializing rkecontrolplane %s/%s", cp.Namespace, cp.Name)
 		}
 
@@ -447,7 +460,7 @@ func getLowestMachineKubeletVersion(plan *plan.Plan) *semver.Version {
 		if machine.Status.NodeInfo != nil {
 			ver, err := semver.NewVersion(machine.Status.NodeInfo.KubeletVersion)
 			if err != nil {
-				logrus.Errorf("error while parsing node kubelet version (%s): %v", machine.Status.NodeInfo.KubeletVersion, err)
+				logger.Errorf("error while parsing node kubelet version (%s): %v", machine.Status.NodeInfo.KubeletVersion, err)
 				continue
 			}
 			if lowestVersion == nil {
@@ -488,7 +501,7 @@ func calculateJoinURL(cp *rkev1.RKEControlPlane, entry *planEntry, plan *plan.Pl
 	}
 
 	scaled := int(ck) * len(entries) / math.MaxUint32
-	logrus.Debugf("[planner] %s/%s: For machine %s/%s, determined join URL: %s (calculation of index: (%v * %v) / %v = [%v])", cp.Namespace, cp.Name, entry.Machine.Namespace, entry.Machine.Name, entries[scaled].Metadata.Annotations[capr.JoinURLAnnotation], ck, uint32(len(entries)), math.MaxUint32, scaled)
+	controlPlaneLogger(cp).Debugf("for machine %s/%s, determined join URL: %s (calculation of index: (%v * %v) / %v = [%v])", entry.Machine.Namespace, entry.Machine.Name, entries[scaled].Metadata.Annotations[capr.JoinURLAnnotation], ck, uint32(len(entries)), math.MaxUint32, scaled)
 	return entries[scaled].Metadata.Annotations[capr.JoinURLAnnotation]
 }
 
@@ -516,7 +529,7 @@ func determineJoinURL(cp *rkev1.RKEControlPlane, entry *planEntry, plan *plan.Pl
 			if entry.Plan != nil {
 				joinedTo = entry.Plan.JoinedTo
 			}
-			logrus.Infof("[planner] rkecluster %s/%s - machine %s/%s - previous join server (%s) was not valid, using new join server (%s)", cp.Namespace, cp.Name, entry.Machine.Namespace, entry.Machine.Name, joinedTo, joinURL)
+			controlPlaneLogger(cp).Infof("machine %s/%s - previous join server (%s) was not valid, using new join server (%s)", entry.Machine.Namespace, entry.Machine.Name, joinedTo, joinURL)
 			if joinURL == "" {
 				return "", fmt.Errorf("no suitable join URL found to join machine %s/%s in rkecluster %s/%s to", entry.Machine.Namespace, entry.Machine.Name, cp.Namespace, cp.Name)
 			}
@@ -667,13 +680,13 @@ func splitArgKeyVal(val string, delim string) (string, string) {
 // getArgValue will search the passed in interface (arg) for a key that matches the searchArg. If a match is found, it
 // returns the value of the argument, otherwise it returns an empty string.
 func getArgValue(arg interface{}, searchArg string, delim string) string {
-	logrus.Tracef("getArgValue (searchArg: %s, delim: %s) type of %v is %T", searchArg, delim, arg, arg)
+	logger.Tracef("getArgValue (searchArg: %s, delim: %s) type of %v is %T", searchArg, delim, arg, arg)
 	switch arg := arg.(type) {
 	case []interface{}:
-		logrus.Tracef("getArgValue (searchArg: %s, delim: %s) encountered interface slice %v", searchArg, delim, arg)
+		logger.Tracef("getArgValue (searchArg: %s, delim: %s) encountered interface slice %v", searchArg, delim, arg)
 		return getArgValue(convertInterfaceSliceToStringSlice(arg), searchArg, delim)
 	case []string:
-		logrus.Tracef("getArgValue (searchArg: %s, delim: %s) found string array: %v", searchArg, delim, arg)
+		logger.Tracef("getArgValue (searchArg: %s, delim: %s) found string array: %v", searchArg, delim, arg)
 		for _, v := range arg {
 			argKey, argVal := splitArgKeyVal(v, delim)
 			if argKey == searchArg {
@@ -681,13 +694,13 @@ func getArgValue(arg interface{}, searchArg string, delim string) string {
 			}
 		}
 	case string:
-		logrus.Tracef("getArgValue (searchArg: %s, delim: %s) found string: %v", searchArg, delim, arg)
+		logger.Tracef("getArgValue (searchArg: %s, delim: %s) found string: %v", searchArg, delim, arg)
 		argKey, argVal := splitArgKeyVal(arg, delim)
 		if argKey == searchArg {
 			return argVal
 		}
 	}
-	logrus.Tracef("getArgValue (searchArg: %s, delim: %s) did not find searchArg in: %v", searchArg, delim, arg)
+	logger.Tracef("getArgValue (searchArg: %s, delim: %s) did not find searchArg in: %v", searchArg, delim, arg)
 	return ""
 }
 
@@ -772,16 +785,16 @@ func renderArgAndMount(existingArg interface{}, existingMount interface{}, contr
 		}
 	}
 	if certDirArg != "" {
-		logrus.Debugf("renderArgAndMount adding %s to component arguments", certDirArg)
+		logger.Debugf("renderArgAndMount adding %s to component arguments", certDirArg)
 		retArg = appendToInterface(existingArg, certDirArg)
 	}
 	if securePortArg != "" {
-		logrus.Debugf("renderArgAndMount adding %s to component arguments", securePortArg)
+		logger.Debugf("renderArgAndMount adding %s to component arguments", securePortArg)
 		retArg = appendToInterface(retArg, securePortArg)
 	}
 	if capr.GetRuntime(controlPlane.Spec.KubernetesVersion) == capr.RuntimeRKE2 {
 		// todo: make sure the certDirMount is not already set by the user to some custom value before we set it for the static pod extraMount
-		logrus.Debugf("renderArgAndMount adding %s to component mounts", certDirMount)
+		logger.Debugf("renderArgAndMount adding %s to component mounts", certDirMount)
 		retMount = appendToInterface(existingMount, certDirMount)
 	}
 	return retArg, retMount
@@ -865,7 +878,7 @@ func (p *Planner) reconcile(controlPlane *rkev1.RKEControlPlane, tokensSecret pl
 			return err
 		}
 
-		logrus.Debugf("[planner] rkecluster %s/%s reconcile tier %s - rendering desired plan for machine %s/%s with join URL: (%s)", controlPlane.Namespace, controlPlane.Name, tierName, entry.Machine.Namespace, entry.Machine.Name, joinURL)
+		controlPlaneLogger(controlPlane).Debugf("reconcile tier %s - rendering desired plan for machine %s/%s with join URL: (%s)", tierName, entry.Machine.Namespace, entry.Machine.Name, joinURL)
 		plan, joinedURL, err := p.desiredPlan(controlPlane, tokensSecret, entry, joinURL)
 		if err != nil {
 			return err
@@ -891,10 +904,10 @@ func (p *Planner) reconcile(controlPlane *rkev1.RKEControlPlane, tokensSecret pl
 	}
 
 	for _, r := range reconcilables {
-		logrus.Tracef("[planner] rkecluster %s/%s reconcile tier %s - processing machine entry: %s/%s", controlPlane.Namespace, controlPlane.Name, tierName, r.entry.Machine.Namespace, r.entry.Machine.Name)
+		controlPlaneLogger(controlPlane).Tracef("reconcile tier %s - processing machine entry: %s/%s", tierName, r.entry.Machine.Namespace, r.entry.Machine.Name)
 		// we exclude here and not in collect to ensure that include matched at least one node
 		if exclude(r.entry) {
-			logrus.Tracef("[planner] rkecluster %s/%s reconcile tier %s - excluding machine entry: %s/%s", controlPlane.Namespace, controlPlane.Name, tierName, r.entry.Machine.Namespace, r.entry.Machine.Name)
+			controlPlaneLogger(controlPlane).Tracef("reconcile tier %s - excluding machine entry: %s/%s", tierName, r.entry.Machine.Namespace, r.entry.Machine.Name)
 			continue
 		}
 
@@ -914,21 +927,21 @@ func (p *Planner) reconcile(controlPlane *rkev1.RKEControlPlane, tokensSecret pl
 		messages[r.entry.Machine.Name] = summary.Message
 
 		if r.entry.Plan == nil {
-			logrus.Debugf("[planner] rkecluster %s/%s reconcile tier %s - setting initial plan for machine %s/%s", controlPlane.Namespace, controlPlane.Name, tierName, r.entry.Machine.Namespace, r.entry.Machine.Name)
-			logrus.Tracef("[planner] rkecluster %s/%s reconcile tier %s - initial plan for machine %s/%s new: %+v", controlPlane.Namespace, controlPlane.Name, tierName, r.entry.Machine.Namespace, r.entry.Machine.Name, r.desiredPlan)
+			controlPlaneLogger(controlPlane).Debugf("reconcile tier %s - setting initial plan for machine %s/%s", tierName, r.entry.Machine.Namespace, r.entry.Machine.Name)
+			controlPlaneLogger(controlPlane).Tracef("reconcile tier %s - initial plan for machine %s/%s new: %+v", tierName, r.entry.Machine.Namespace, r.entry.Machine.Name, r.desiredPlan)
 			outOfSync = append(outOfSync, r.entry.Machine.Name)
 			if err := p.store.UpdatePlan(r.entry, r.desiredPlan, r.joinedURL, maxFailures, failureThreshold); err != nil {
 				return err
 			}
 		} else if r.minorChange {
-			logrus.Debugf("[planner] rkecluster %s/%s reconcile tier %s - minor plan change detected for machine %s/%s, updating plan immediately", controlPlane.Namespace, controlPlane.Name, tierName, r.entry.Machine.Namespace, r.entry.Machine.Name)
-			logrus.Tracef("[planner] rkecluster %s/%s reconcile tier %s - minor plan change for machine %s/%s old: %+v, new: %+v", controlPlane.Namespace, controlPlane.Name, tierName, r.entry.Machine.Namespace, r.entry.Machine.Name, r.entry.Plan.Plan, r.desiredPlan)
+			controlPlaneLogger(controlPlane).Debugf("reconcile tier %s - minor plan change detected for machine %s/%s, updating plan immediately", tierName, r.entry.Machine.Namespace, r.entry.Machine.Name)
+			controlPlaneLogger(controlPlane).Tracef("reconcile tier %s - minor plan change for machine %s/%s old: %+v, new: %+v", tierName, r.entry.Machine.Namespace, r.entry.Machine.Name, r.entry.Plan.Plan, r.desiredPlan)
 			outOfSync = append(outOfSync, r.entry.Machine.Name)
 			if err := p.store.UpdatePlan(r.entry, r.desiredPlan, r.joinedURL, maxFailures, failureThreshold); err != nil {
 				return err
 			}
 		} else if r.change {
-			logrus.Debugf("[planner] rkecluster %s/%s reconcile tier %s - plan for machine %s/%s did not match, appending to outOfSync", controlPlane.Namespace, controlPlane.Name, tierName, r.entry.Machine.Namespace, r.entry.Machine.Name)
+			controlPlaneLogger(controlPlane).Debugf("reconcile tier %s - plan for machine %s/%s did not match, appending to outOfSync", tierName, r.entry.Machine.Namespace, r.entry.Machine.Name)
 			outOfSync = append(outOfSync, r.entry.Machine.Name)
 			// Conditions
 			// 1. If the node is already draining then the plan is out of sync.  There is no harm in updating it if
@@ -937,7 +950,7 @@ func (p *Planner) reconcile(controlPlane *rkev1.RKEControlPlane, tokensSecret pl
 			// 3. concurrency == 0 which means infinite concurrency.
 			// 4. unavailable < concurrency meaning we have capacity to make something unavailable
 			// 5. If the plan was successful in application but the probes never went healthy
-			logrus.Debugf("[planner] rkecluster %s/%s reconcile tier %s - concurrency: %d, unavailable: %d", controlPlane.Namespace, controlPlane.Name, tierName, concurrency, unavailable)
+			controlPlaneLogger(controlPlane).Debugf("reconcile tier %s - concurrency: %d, unavailable: %d", tierName, concurrency, unavailable)
 			if isInDrain(r.entry) || r.entry.Plan.Failed || concurrency == 0 || unavailable < concurrency || planAppliedButProbesNeverHealthy(r.entry) {
 				if !isUnavailable(r) {
 					unavailable++
@@ -946,8 +959,8 @@ func (p *Planner) reconcile(controlPlane *rkev1.RKEControlPlane, tokensSecret pl
 					return err
 				} else if ok && err == nil {
 					// Drain is done (or didn't need to be done) and there are no errors, so the plan should be updated to enact the reason the node was drained.
-					logrus.Debugf("[planner] rkecluster %s/%s reconcile tier %s - major plan change for machine %s/%s", controlPlane.Namespace, controlPlane.Name, tierName, r.entry.Machine.Namespace, r.entry.Machine.Name)
-					logrus.Tracef("[planner] rkecluster %s/%s reconcile tier %s - major plan change for machine %s/%s old: %+v, new: %+v", controlPlane.Namespace, controlPlane.Name, tierName, r.entry.Machine.Namespace, r.entry.Machine.Name, r.entry.Plan.Plan, r.desiredPlan)
+					controlPlaneLogger(controlPlane).Debugf("reconcile tier %s - major plan change for machine %s/%s", tierName, r.entry.Machine.Namespace, r.entry.Machine.Name)
+					controlPlaneLogger(controlPlane).Tracef("reconcile tier %s - major plan change for machine %s/%s old: %+v, new: %+v", tierName, r.entry.Machine.Namespace, r.entry.Machine.Name, r.entry.Plan.Plan, r.desiredPlan)
 					if err = p.store.UpdatePlan(r.entry, r.desiredPlan, r.joinedURL, maxFailures, failureThreshold); err != nil {
 						return err
 					} else if r.entry.Metadata.Annotations[capr.DrainDoneAnnotation] != "" {
@@ -1112,6 +1125,21 @@ func (p *Planner) desiredPlan(controlPlane *rkev1.RKEControlPlane, tokensSecret
 	}
 	nodePlan.Probes = probes
 
+	nodePlan, err = p.addEtcdSnapshotEncryptedUpload(nodePlan, controlPlane, entry)
+	if err != nil {
+		return nodePlan, joinedTo, err
+	}
+
+	nodePlan, err = p.addEtcdSnapshotTargetSchedule(nodePlan, controlPlane, entry)
+	if err != nil {
+		return nodePlan, joinedTo, err
+	}
+
+	nodePlan, err = p.addOSMaintenance(nodePlan, controlPlane, entry)
+	if err != nil {
+		return nodePlan, joinedTo, err
+	}
+
 	// Add instruction last because it hashes config content
 	nodePlan, err = p.addInstallInstructionWithRestartStamp(nodePlan, controlPlane, entry)
 	if err != nil {
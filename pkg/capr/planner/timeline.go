@@ -0,0 +1,311 @@
+package planner
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// TimelineSecretKey is the key of the machine plan secret that holds the gzipped JSON provisioning timeline of the
+	// node.
+	TimelineSecretKey = "timeline"
+
+	TimelinePlanDelivered       TimelineEntryType = "PlanDelivered"
+	TimelinePlanApplied         TimelineEntryType = "PlanApplied"
+	TimelinePlanFailed          TimelineEntryType = "PlanFailed"
+	TimelineInstruction         TimelineEntryType = "Instruction"
+	TimelinePeriodicInstruction TimelineEntryType = "PeriodicInstruction"
+	TimelineProbe               TimelineEntryType = "Probe"
+
+	// maxTimelineEntries is the number of entries retained in the timeline, older entries are dropped first.
+	maxTimelineEntries = 100
+	// maxTimelineOutputLength is the number of bytes of instruction output retained per timeline entry. The end of the
+	// output is kept, as that is where errors are usually reported.
+	maxTimelineOutputLength = 4096
+)
+
+type TimelineEntryType string
+
+// TimelineEntry is a single event that was observed while a plan was delivered to and applied by a node.
+type TimelineEntry struct {
+	// Sequence numbers the entries of a timeline in the order they were recorded, as several entries can be recorded
+	// at the same time.
+	Sequence     int64             `json:"sequence"`
+	Time         time.Time         `json:"time"`
+	Type         TimelineEntryType `json:"type"`
+	PlanChecksum string            `json:"planChecksum,omitempty"`
+	Name         string            `json:"name,omitempty"`
+	// Start and End are set for one-time instructions, and bound the window in which the instruction ran: from the
+	// time the plan was delivered until the system-agent last applied it. ExitCode is not set for the instruction that
+	// failed a plan, as the system-agent does not report it.
+	Start    *time.Time `json:"start,omitempty"`
+	End      *time.Time `json:"end,omitempty"`
+	ExitCode *int       `json:"exitCode,omitempty"`
+	Output   string     `json:"output,omitempty"`
+	Healthy  *bool      `json:"healthy,omitempty"`
+	Message  string     `json:"message,omitempty"`
+}
+
+// Timeline is the provisioning timeline of a node, along with the last observed state of its plan secret which is used
+// to detect transitions.
+type Timeline struct {
+	Entries      []TimelineEntry `json:"entries,omitempty"`
+	LastSequence int64           `json:"lastSequence,omitempty"`
+
+	PlanChecksum      string          `json:"planChecksum,omitempty"`
+	PlanDeliveredAt   *time.Time      `json:"planDeliveredAt,omitempty"`
+	AppliedChecksum   string          `json:"appliedChecksum,omitempty"`
+	FailureCount      string          `json:"failureCount,omitempty"`
+	ProbeHealth       map[string]bool `json:"probeHealth,omitempty"`
+	PeriodicExitCodes map[string]int  `json:"periodicExitCodes,omitempty"`
+
+	modified bool
+}
+
+// ParseTimeline returns the provisioning timeline stored in the given machine plan secret. An empty timeline is returned
+// if none has been recorded yet.
+func ParseTimeline(secret *corev1.Secret) (*Timeline, error) {
+	timeline := &Timeline{}
+	if secret == nil || len(secret.Data[TimelineSecretKey]) == 0 {
+		return timeline, nil
+	}
+	gz, err := gzip.NewReader(bytes.NewBuffer(secret.Data[TimelineSecretKey]))
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, timeline); err != nil {
+		return nil, err
+	}
+	return timeline, nil
+}
+
+// UpdateTimeline records the transitions between the last observed state of the machine plan secret and the given node
+// into the timeline of the secret. It returns true if the secret was modified. The secret is modified in place.
+func UpdateTimeline(secret *corev1.Secret, node *plan.Node, now time.Time) (bool, error) {
+	if secret == nil || node == nil {
+		return false, nil
+	}
+
+	timeline, err := ParseTimeline(secret)
+	if err != nil {
+		return false, err
+	}
+	now = now.UTC()
+
+	planChecksum := PlanHash(secret.Data["plan"])
+	if timeline.PlanChecksum != planChecksum {
+		timeline.add(TimelineEntry{
+			Time:         now,
+			Type:         TimelinePlanDelivered,
+			PlanChecksum: planChecksum,
+			Message: fmt.Sprintf("%d files, %d instructions, %d periodic instructions, %d probes", len(node.Plan.Files),
+				len(node.Plan.Instructions), len(node.Plan.PeriodicInstructions), len(node.Plan.Probes)),
+		})
+		timeline.PlanChecksum = planChecksum
+		timeline.PlanDeliveredAt = &now
+		timeline.FailureCount = ""
+	}
+
+	if string(secret.Data["applied-checksum"]) == planChecksum && timeline.AppliedChecksum != planChecksum {
+		timeline.addInstructions(node.Plan.Instructions, node.Output, planChecksum, applyTime(secret, now), false)
+		timeline.add(TimelineEntry{
+			Time:         now,
+			Type:         TimelinePlanApplied,
+			PlanChecksum: planChecksum,
+		})
+		timeline.AppliedChecksum = planChecksum
+	}
+
+	if failureCount := string(secret.Data["failure-count"]); string(secret.Data["failed-checksum"]) == planChecksum &&
+		failureCount != "" && failureCount != timeline.FailureCount {
+		output, err := decodeOutput(secret.Data["failed-output"])
+		if err != nil {
+			return false, err
+		}
+		timeline.addInstructions(node.Plan.Instructions, output, planChecksum, applyTime(secret, now), true)
+		timeline.add(TimelineEntry{
+			Time:         now,
+			Type:         TimelinePlanFailed,
+			PlanChecksum: planChecksum,
+			Message:      fmt.Sprintf("failed attempt %s of %s", failureCount, maxFailuresString(secret)),
+		})
+		timeline.FailureCount = failureCount
+	}
+
+	for _, name := range sortedKeys(node.PeriodicOutput) {
+		output := node.PeriodicOutput[name]
+		if previous, ok := timeline.PeriodicExitCodes[name]; ok && previous == output.ExitCode {
+			continue
+		}
+		if timeline.PeriodicExitCodes == nil {
+			timeline.PeriodicExitCodes = map[string]int{}
+		}
+		timeline.PeriodicExitCodes[name] = output.ExitCode
+		exitCode := output.ExitCode
+		captured := output.Stdout
+		if exitCode != 0 {
+			captured = output.Stderr
+		}
+		timeline.add(TimelineEntry{
+			Time:     now,
+			Type:     TimelinePeriodicInstruction,
+			Name:     name,
+			ExitCode: &exitCode,
+			Output:   truncateOutput(captured),
+			Message:  output.LastSuccessfulRunTime,
+		})
+	}
+
+	for _, name := range sortedKeys(node.ProbeStatus) {
+		status := node.ProbeStatus[name]
+		if previous, ok := timeline.ProbeHealth[name]; ok && previous == status.Healthy {
+			continue
+		}
+		if timeline.ProbeHealth == nil {
+			timeline.ProbeHealth = map[string]bool{}
+		}
+		timeline.ProbeHealth[name] = status.Healthy
+		healthy := status.Healthy
+		timeline.add(TimelineEntry{
+			Time:    now,
+			Type:    TimelineProbe,
+			Name:    name,
+			Healthy: &healthy,
+			Message: fmt.Sprintf("%d successes, %d failures", status.SuccessCount, status.FailureCount),
+		})
+	}
+
+	if !timeline.modified {
+		return false, nil
+	}
+
+	data, err := json.Marshal(timeline)
+	if err != nil {
+		return false, err
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return false, err
+	}
+	if err := gz.Close(); err != nil {
+		return false, err
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[TimelineSecretKey] = buf.Bytes()
+	return true, nil
+}
+
+// add appends the given entry to the timeline, dropping the oldest entries once the timeline is full.
+func (t *Timeline) add(entry TimelineEntry) {
+	t.modified = true
+	t.LastSequence++
+	entry.Sequence = t.LastSequence
+	t.Entries = append(t.Entries, entry)
+	if len(t.Entries) > maxTimelineEntries {
+		t.Entries = t.Entries[len(t.Entries)-maxTimelineEntries:]
+	}
+}
+
+// addInstructions records the one-time instructions that ran when the system-agent last applied the plan, which ended
+// at the given time. The system-agent runs the instructions in order and only reports a plan as applied once all of
+// them exited 0. When the plan failed, it stops at the failed instruction, which is the last one with output.
+func (t *Timeline) addInstructions(instructions []plan.OneTimeInstruction, output map[string][]byte, planChecksum string, end time.Time, failed bool) {
+	ran := instructions
+	if failed {
+		ran = nil
+		for i, instruction := range instructions {
+			if _, ok := output[instruction.Name]; ok {
+				ran = instructions[:i+1]
+			}
+		}
+	}
+	for i, instruction := range ran {
+		entry := TimelineEntry{
+			Time:         end,
+			Type:         TimelineInstruction,
+			PlanChecksum: planChecksum,
+			Name:         instruction.Name,
+			Start:        t.PlanDeliveredAt,
+			End:          &end,
+			Output:       truncateOutput(output[instruction.Name]),
+		}
+		if failed && i == len(ran)-1 {
+			entry.Message = "instruction failed"
+		} else {
+			exitCode := 0
+			entry.ExitCode = &exitCode
+		}
+		t.add(entry)
+	}
+}
+
+// applyTime returns the time at which the system-agent last applied the plan of the given secret, or the given time if
+// it was not recorded.
+func applyTime(secret *corev1.Secret, now time.Time) time.Time {
+	value := string(secret.Data["last-apply-time"])
+	for _, layout := range []string{time.RFC3339, time.UnixDate} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC()
+		}
+	}
+	return now
+}
+
+// decodeOutput returns the output of the one-time instructions from the given gzipped JSON, as written by the
+// system-agent.
+func decodeOutput(data []byte) (map[string][]byte, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	gz, err := gzip.NewReader(bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	data, err = io.ReadAll(gz)
+	if err != nil {
+		return nil, err
+	}
+	output := map[string][]byte{}
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func truncateOutput(output []byte) string {
+	if len(output) > maxTimelineOutputLength {
+		output = output[len(output)-maxTimelineOutputLength:]
+	}
+	return string(output)
+}
+
+func maxFailuresString(secret *corev1.Secret) string {
+	if maxFailures := string(secret.Data["max-failures"]); maxFailures != "" && maxFailures != "-1" {
+		return maxFailures
+	}
+	return "unlimited"
+}
@@ -0,0 +1,132 @@
+package planner
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestUpdateTimeline(t *testing.T) {
+	planData := []byte(`{"instructions":[{"name":"install"}]}`)
+	secret := &corev1.Secret{
+		Data: map[string][]byte{
+			"plan": planData,
+		},
+	}
+	node := &plan.Node{
+		Plan: plan.NodePlan{
+			Instructions: []plan.OneTimeInstruction{{Name: "install"}},
+		},
+	}
+	delivered := time.Date(2024, time.March, 15, 12, 0, 0, 0, time.UTC)
+
+	changed, err := UpdateTimeline(secret, node, delivered)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	// nothing new was observed
+	changed, err = UpdateTimeline(secret, node, delivered.Add(time.Second))
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	// the plan failed once, then was applied
+	secret.Data["failed-checksum"] = []byte(PlanHash(planData))
+	secret.Data["failure-count"] = []byte("1")
+	secret.Data["failed-output"] = gzipJSON(t, map[string][]byte{"install": []byte("boom")})
+	_, err = UpdateTimeline(secret, node, delivered.Add(time.Minute))
+	require.NoError(t, err)
+
+	applied := delivered.Add(2 * time.Minute)
+	secret.Data["applied-checksum"] = []byte(PlanHash(planData))
+	secret.Data["last-apply-time"] = []byte(applied.Add(-time.Second).Format(time.UnixDate))
+	node.Output = map[string][]byte{"install": []byte("done")}
+	node.ProbeStatus = map[string]plan.ProbeStatus{"kubelet": {Healthy: false, FailureCount: 1}}
+	node.PeriodicOutput = map[string]plan.PeriodicInstructionOutput{"etcd-name": {ExitCode: 1, Stderr: []byte("boom")}}
+	_, err = UpdateTimeline(secret, node, applied)
+	require.NoError(t, err)
+
+	node.ProbeStatus["kubelet"] = plan.ProbeStatus{Healthy: true, SuccessCount: 1}
+	_, err = UpdateTimeline(secret, node, applied.Add(time.Minute))
+	require.NoError(t, err)
+
+	timeline, err := ParseTimeline(secret)
+	require.NoError(t, err)
+
+	var types []TimelineEntryType
+	for _, entry := range timeline.Entries {
+		types = append(types, entry.Type)
+	}
+	assert.Equal(t, []TimelineEntryType{
+		TimelinePlanDelivered,
+		TimelineInstruction,
+		TimelinePlanFailed,
+		TimelineInstruction,
+		TimelinePlanApplied,
+		TimelinePeriodicInstruction,
+		TimelineProbe,
+		TimelineProbe,
+	}, types)
+
+	for i, entry := range timeline.Entries {
+		assert.Equal(t, int64(i+1), entry.Sequence)
+	}
+
+	failed := timeline.Entries[1]
+	assert.Equal(t, "install", failed.Name)
+	assert.Equal(t, "boom", failed.Output)
+	assert.Nil(t, failed.ExitCode)
+	assert.Equal(t, "instruction failed", failed.Message)
+
+	instruction := timeline.Entries[3]
+	assert.Equal(t, "install", instruction.Name)
+	assert.Equal(t, "done", instruction.Output)
+	assert.True(t, delivered.Equal(*instruction.Start))
+	assert.True(t, applied.Add(-time.Second).Equal(*instruction.End))
+	assert.Equal(t, 0, *instruction.ExitCode)
+
+	periodic := timeline.Entries[5]
+	assert.Equal(t, 1, *periodic.ExitCode)
+	assert.Equal(t, "boom", periodic.Output)
+
+	assert.False(t, *timeline.Entries[6].Healthy)
+	assert.True(t, *timeline.Entries[7].Healthy)
+}
+
+func TestTimelineFailedInstructions(t *testing.T) {
+	timeline := &Timeline{}
+	instructions := []plan.OneTimeInstruction{{Name: "pull"}, {Name: "install"}, {Name: "restart"}}
+	timeline.addInstructions(instructions, map[string][]byte{"pull": nil, "install": []byte("boom")}, "abc", time.Now(), true)
+
+	require.Len(t, timeline.Entries, 2)
+	assert.Equal(t, "pull", timeline.Entries[0].Name)
+	assert.Equal(t, 0, *timeline.Entries[0].ExitCode)
+	assert.Equal(t, "install", timeline.Entries[1].Name)
+	assert.Nil(t, timeline.Entries[1].ExitCode)
+}
+
+func gzipJSON(t *testing.T, value any) []byte {
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err = gz.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestTimelineIsBounded(t *testing.T) {
+	timeline := &Timeline{}
+	for i := 0; i < maxTimelineEntries+10; i++ {
+		timeline.add(TimelineEntry{Name: string(rune('a' + i%26))})
+	}
+	assert.Len(t, timeline.Entries, maxTimelineEntries)
+	assert.Equal(t, string(rune('a'+10%26)), timeline.Entries[0].Name)
+}
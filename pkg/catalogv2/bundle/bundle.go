@@ -0,0 +1,117 @@
+/*
+Package bundle exports helm repositories into catalog bundles and imports them back, so that the charts of a catalog
+can be mirrored into air-gapped environments.
+
+A bundle is a gzipped tar archive with the following layout:
+
+	manifest.json                 the repositories of the bundle
+	repos/<repo>/charts/<file>    the chart archives of a repository
+	repos/<repo>/icons/<file>     the chart icons of a repository
+	repos/<repo>/index.yaml       the index of a repository, with URLs relative to the repository directory
+	images.txt                    the images referenced by the charts, one per line
+
+Imported repositories are stored in config maps and served by Rancher through ClusterRepos that are marked as imported.
+*/
+package bundle
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rancher/wrangler/v3/pkg/name"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	ManifestFile = "manifest.json"
+	ImagesFile   = "images.txt"
+	IndexFile    = "index.yaml"
+
+	reposDir  = "repos"
+	chartsDir = "charts"
+	iconsDir  = "icons"
+
+	// RepoLabel is set on the config maps holding the files of an imported repository.
+	RepoLabel = "catalog.cattle.io/bundle-repo"
+	// FileAnnotation is set on the config maps holding the files of an imported repository to the path of the file.
+	FileAnnotation = "catalog.cattle.io/bundle-file"
+	// GenerationLabel is set on the config maps holding the files of an imported repository to the generation of the
+	// import they were stored by.
+	GenerationLabel = "catalog.cattle.io/bundle-generation"
+	// GenerationAnnotation is set on imported ClusterRepos to the generation of the import whose files they serve.
+	GenerationAnnotation = "catalog.cattle.io/bundle-generation"
+	// nextAnnotation is set on each chunk of a file to the name of the config map holding the next chunk.
+	nextAnnotation = "catalog.cattle.io/next"
+
+	// maxChunkSize is the number of bytes of a file stored per config map.
+	maxChunkSize = 500_000
+)
+
+var errInvalidPath = errors.New("invalid path in catalog bundle")
+
+// Manifest describes the content of a catalog bundle.
+type Manifest struct {
+	Created time.Time `json:"created"`
+	Repos   []string  `json:"repos"`
+}
+
+// ConfigMapGetter gets the config map with the given namespace and name.
+type ConfigMapGetter func(namespace, name string) (*corev1.ConfigMap, error)
+
+// ReadFile returns the content of the file at the given path of the given generation of an imported repository,
+// relative to the repository directory.
+func ReadFile(get ConfigMapGetter, namespace, repoName, generation, filePath string) ([]byte, error) {
+	if err := validatePath(filePath); err != nil {
+		return nil, err
+	}
+
+	cm, err := get(namespace, FileConfigMapName(repoName, generation, filePath, 0))
+	if err != nil {
+		return nil, err
+	}
+	if cm.Labels[RepoLabel] != repoName || cm.Labels[GenerationLabel] != generation || cm.Annotations[FileAnnotation] != filePath {
+		return nil, fmt.Errorf("config map %s/%s does not hold file %s of repository %s", cm.Namespace, cm.Name, filePath, repoName)
+	}
+
+	data := cm.BinaryData["content"]
+	for next := cm.Annotations[nextAnnotation]; next != ""; next = cm.Annotations[nextAnnotation] {
+		cm, err = get(namespace, next)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, cm.BinaryData["content"]...)
+	}
+	return data, nil
+}
+
+// FileConfigMapName returns the name of the config map holding the given chunk of the file at the given path of the
+// given generation of an imported repository.
+func FileConfigMapName(repoName, generation, filePath string, chunk int) string {
+	hash := sha256.Sum256([]byte(filePath))
+	return name.SafeConcatName("bundle", repoName, generation, hex.EncodeToString(hash[:])[:12], fmt.Sprint(chunk))
+}
+
+// IsBundlePath returns true if the given chart or icon URL refers to a file of an imported repository.
+func IsBundlePath(u string) bool {
+	return strings.HasPrefix(u, chartsDir+"/") || strings.HasPrefix(u, iconsDir+"/")
+}
+
+// validatePath checks that the given path is a file of a repository directory.
+func validatePath(filePath string) error {
+	if filePath != IndexFile && !IsBundlePath(filePath) {
+		return fmt.Errorf("%w: %s", errInvalidPath, filePath)
+	}
+	if path.Clean(filePath) != filePath || strings.Contains(filePath, "..") || strings.Count(filePath, "/") > 1 {
+		return fmt.Errorf("%w: %s", errInvalidPath, filePath)
+	}
+	return nil
+}
+
+func repoDir(repoName string) string {
+	return path.Join(reposDir, repoName)
+}
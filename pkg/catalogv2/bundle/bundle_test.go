@@ -0,0 +1,261 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/rancher/rancher/pkg/api/steve/catalog/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/repo"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+type testSource struct {
+	index  *repo.IndexFile
+	charts map[string][]byte
+}
+
+func (s *testSource) Index(string) (*repo.IndexFile, error) {
+	return s.index, nil
+}
+
+func (s *testSource) Chart(_ string, chart *repo.ChartVersion) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(s.charts[chart.Version])), nil
+}
+
+func (s *testSource) Icon(_ string, chart *repo.ChartVersion) (io.ReadCloser, string, error) {
+	if chart.Icon == "" {
+		return nil, "", nil
+	}
+	return io.NopCloser(bytes.NewReader([]byte("icon " + chart.Version))), ".png", nil
+}
+
+type testStore struct {
+	repos     []string
+	files     map[string][]byte
+	committed []string
+	aborted   []string
+}
+
+func (s *testStore) AddRepo(repoName string) error {
+	s.repos = append(s.repos, repoName)
+	return nil
+}
+
+func (s *testStore) AddFile(repoName, filePath string, data []byte) error {
+	s.files[repoName+"/"+filePath] = data
+	return nil
+}
+
+func (s *testStore) Commit(repoName string) error {
+	s.committed = append(s.committed, repoName)
+	return nil
+}
+
+func (s *testStore) Abort(repoName string) error {
+	s.aborted = append(s.aborted, repoName)
+	return nil
+}
+
+func newTestSource(t *testing.T) *testSource {
+	source := &testSource{
+		index:  repo.NewIndexFile(),
+		charts: map[string][]byte{},
+	}
+	for _, version := range []string{"1.0.0", "2.0.0"} {
+		testChart := &chart.Chart{
+			Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "test", Version: version},
+			Raw: []*chart.File{{
+				Name: chartutil.ValuesfileName,
+				Data: []byte("image:\n  repository: rancher/test\n  tag: " + version + "\n"),
+			}},
+		}
+		archive, err := chartutil.Save(testChart, t.TempDir())
+		require.NoError(t, err)
+		source.charts[version], err = os.ReadFile(archive)
+		require.NoError(t, err)
+		require.NoError(t, source.index.MustAdd(testChart.Metadata, "test-"+version+".tgz", "https://charts.example.com", "sha256:"+version))
+	}
+	source.index.SortEntries()
+	source.index.Entries["test"][0].Icon = "https://charts.example.com/icon.png"
+	return source
+}
+
+func TestExportImport(t *testing.T) {
+	tests := []struct {
+		name           string
+		repo           types.ExportBundleRepo
+		expectVersions []string
+		expectImages   []string
+		expectErr      string
+	}{
+		{
+			name:           "all charts",
+			repo:           types.ExportBundleRepo{Name: "test-repo"},
+			expectVersions: []string{"2.0.0", "1.0.0"},
+			expectImages:   []string{"rancher/test:1.0.0", "rancher/test:2.0.0"},
+		},
+		{
+			name:           "latest only",
+			repo:           types.ExportBundleRepo{Name: "test-repo", Charts: []string{"test"}, LatestOnly: true},
+			expectVersions: []string{"2.0.0"},
+			expectImages:   []string{"rancher/test:2.0.0"},
+		},
+		{
+			name:      "unknown chart",
+			repo:      types.ExportBundleRepo{Name: "test-repo", Charts: []string{"missing"}},
+			expectErr: "chart missing not found",
+		},
+		{
+			name:      "invalid repository name",
+			repo:      types.ExportBundleRepo{Name: "../test"},
+			expectErr: "invalid repository name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := newTestSource(t)
+			buf := &bytes.Buffer{}
+			err := Export(buf, source, []types.ExportBundleRepo{tt.repo})
+			if tt.expectErr != "" {
+				assert.ErrorContains(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+
+			store := &testStore{files: map[string][]byte{}}
+			manifest, images, err := Import(buf, store)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"test-repo"}, manifest.Repos)
+			assert.Equal(t, []string{"test-repo"}, store.repos)
+			assert.Equal(t, []string{"test-repo"}, store.committed)
+			assert.Equal(t, tt.expectImages, images)
+
+			index := &repo.IndexFile{}
+			require.NoError(t, yaml.Unmarshal(store.files["test-repo/index.yaml"], index))
+			var versions []string
+			for _, version := range index.Entries["test"] {
+				versions = append(versions, version.Version)
+				require.Len(t, version.URLs, 1)
+				assert.Equal(t, "charts/test-"+version.Version+".tgz", version.URLs[0])
+				assert.Equal(t, source.charts[version.Version], store.files["test-repo/"+version.URLs[0]])
+			}
+			assert.Equal(t, tt.expectVersions, versions)
+			assert.Equal(t, "icons/test-2.0.0.png", index.Entries["test"][0].Icon)
+			assert.Equal(t, []byte("icon 2.0.0"), store.files["test-repo/icons/test-2.0.0.png"])
+		})
+	}
+}
+
+func TestImportInvalidBundle(t *testing.T) {
+	tests := []struct {
+		name          string
+		files         map[string]string
+		order         []string
+		expectErr     string
+		expectAborted []string
+	}{
+		{
+			name:      "no manifest",
+			order:     []string{"repos/test/index.yaml"},
+			files:     map[string]string{"repos/test/index.yaml": ""},
+			expectErr: "the manifest must be the first file",
+		},
+		{
+			name:          "path traversal",
+			order:         []string{ManifestFile, "repos/test/charts/../../../etc/passwd"},
+			files:         map[string]string{ManifestFile: `{"repos":["test"]}`, "repos/test/charts/../../../etc/passwd": ""},
+			expectErr:     "invalid path",
+			expectAborted: []string{"test"},
+		},
+		{
+			name:          "unknown repository",
+			order:         []string{ManifestFile, "repos/other/index.yaml"},
+			files:         map[string]string{ManifestFile: `{"repos":["test"]}`, "repos/other/index.yaml": ""},
+			expectErr:     "not part of the catalog bundle manifest",
+			expectAborted: []string{"test"},
+		},
+		{
+			name:          "missing index",
+			order:         []string{ManifestFile},
+			files:         map[string]string{ManifestFile: `{"repos":["test"]}`},
+			expectErr:     "has no index",
+			expectAborted: []string{"test"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			gz := gzip.NewWriter(buf)
+			tw := tar.NewWriter(gz)
+			for _, name := range tt.order {
+				require.NoError(t, writeFile(tw, name, []byte(tt.files[name]), metav1.Now().Time))
+			}
+			require.NoError(t, tw.Close())
+			require.NoError(t, gz.Close())
+
+			store := &testStore{files: map[string][]byte{}}
+			_, _, err := Import(buf, store)
+			assert.ErrorContains(t, err, tt.expectErr)
+			assert.Equal(t, tt.expectAborted, store.aborted)
+			assert.Empty(t, store.committed)
+		})
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	data := bytes.Repeat([]byte("a"), 2*maxChunkSize+10)
+	configMaps := map[string]*corev1.ConfigMap{}
+	for i := 0; i < 3; i++ {
+		next := ""
+		if i < 2 {
+			next = FileConfigMapName("test", "gen1", "charts/test-1.0.0.tgz", i+1)
+		}
+		end := (i + 1) * maxChunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        FileConfigMapName("test", "gen1", "charts/test-1.0.0.tgz", i),
+				Labels:      map[string]string{RepoLabel: "test", GenerationLabel: "gen1"},
+				Annotations: map[string]string{FileAnnotation: "charts/test-1.0.0.tgz", nextAnnotation: next},
+			},
+			BinaryData: map[string][]byte{"content": data[i*maxChunkSize : end]},
+		}
+		configMaps[cm.Name] = cm
+	}
+	get := func(_, name string) (*corev1.ConfigMap, error) {
+		if cm, ok := configMaps[name]; ok {
+			return cm, nil
+		}
+		return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, name)
+	}
+
+	result, err := ReadFile(get, "cattle-system", "test", "gen1", "charts/test-1.0.0.tgz")
+	require.NoError(t, err)
+	assert.Equal(t, data, result)
+
+	_, err = ReadFile(get, "cattle-system", "other", "gen1", "charts/test-1.0.0.tgz")
+	assert.True(t, apierrors.IsNotFound(err), fmt.Sprint(err))
+
+	_, err = ReadFile(get, "cattle-system", "test", "gen2", "charts/test-1.0.0.tgz")
+	assert.True(t, apierrors.IsNotFound(err), fmt.Sprint(err))
+
+	_, err = ReadFile(get, "cattle-system", "test", "gen1", "../index.yaml")
+	assert.ErrorIs(t, err, errInvalidPath)
+}
@@ -0,0 +1,194 @@
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rancher/rancher/pkg/api/steve/catalog/types"
+	"github.com/rancher/rancher/pkg/image"
+	"github.com/sirupsen/logrus"
+	"helm.sh/helm/v3/pkg/repo"
+	"sigs.k8s.io/yaml"
+)
+
+// Source provides the content of the repositories that are exported.
+type Source interface {
+	// Index returns the index of the given repository.
+	Index(repoName string) (*repo.IndexFile, error)
+	// Chart returns the archive of the given chart version.
+	Chart(repoName string, chart *repo.ChartVersion) (io.ReadCloser, error)
+	// Icon returns the icon of the given chart version and its file extension, or a nil reader if it has none.
+	Icon(repoName string, chart *repo.ChartVersion) (io.ReadCloser, string, error)
+}
+
+// Export writes a catalog bundle with the selected charts of the given repositories to w.
+func Export(w io.Writer, source Source, repos []types.ExportBundleRepo) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	now := time.Now().UTC()
+
+	manifest := Manifest{Created: now}
+	for _, r := range repos {
+		manifest.Repos = append(manifest.Repos, r.Name)
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(tw, ManifestFile, data, now); err != nil {
+		return err
+	}
+
+	images := map[string]bool{}
+	for _, r := range repos {
+		if err := exportRepo(tw, source, r, images, now); err != nil {
+			return fmt.Errorf("failed to export repository %s: %w", r.Name, err)
+		}
+	}
+
+	sortedImages := make([]string, 0, len(images))
+	for img := range images {
+		sortedImages = append(sortedImages, img)
+	}
+	sort.Strings(sortedImages)
+	imagesData := strings.Join(sortedImages, "\n")
+	if len(sortedImages) > 0 {
+		imagesData += "\n"
+	}
+	if err := writeFile(tw, ImagesFile, []byte(imagesData), now); err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// exportRepo writes the selected charts and icons of a repository, followed by its index with the URLs of the charts
+// and icons rewritten to their path in the bundle.
+func exportRepo(tw *tar.Writer, source Source, r types.ExportBundleRepo, images map[string]bool, now time.Time) error {
+	if err := validateRepoName(r.Name); err != nil {
+		return err
+	}
+
+	index, err := source.Index(r.Name)
+	if err != nil {
+		return err
+	}
+
+	selected := map[string]bool{}
+	for _, chartName := range r.Charts {
+		selected[chartName] = true
+	}
+
+	result := repo.NewIndexFile()
+	result.Generated = now
+	for chartName, versions := range index.Entries {
+		if len(selected) > 0 && !selected[chartName] {
+			continue
+		}
+		if r.LatestOnly && len(versions) > 1 {
+			versions = versions[:1]
+		}
+		for _, version := range versions {
+			exported, err := exportChart(tw, source, r.Name, version, images, now)
+			if err != nil {
+				return fmt.Errorf("failed to export chart %s version %s: %w", version.Name, version.Version, err)
+			}
+			result.Entries[chartName] = append(result.Entries[chartName], exported)
+		}
+	}
+	for chartName := range selected {
+		if _, ok := result.Entries[chartName]; !ok {
+			return fmt.Errorf("chart %s not found", chartName)
+		}
+	}
+	result.SortEntries()
+
+	data, err := yaml.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return writeFile(tw, path.Join(repoDir(r.Name), IndexFile), data, now)
+}
+
+// exportChart writes the archive and icon of a chart version, and returns its index entry pointing to them.
+func exportChart(tw *tar.Writer, source Source, repoName string, version *repo.ChartVersion, images map[string]bool, now time.Time) (*repo.ChartVersion, error) {
+	chart, err := source.Chart(repoName, version)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(chart)
+	chart.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	exported := *version
+	fileName := sanitizeFileName(fmt.Sprintf("%s-%s", version.Name, version.Version))
+	exported.URLs = []string{path.Join(chartsDir, fileName+".tgz")}
+	if err := writeFile(tw, path.Join(repoDir(repoName), exported.URLs[0]), data, now); err != nil {
+		return nil, err
+	}
+
+	chartImages, err := image.ChartImages(data, fmt.Sprintf("%s:%s", version.Name, version.Version), image.Linux)
+	if err != nil {
+		logrus.Warnf("[catalog bundle] failed to find the images of chart %s version %s: %v", version.Name, version.Version, err)
+	}
+	for _, img := range chartImages {
+		images[img] = true
+	}
+
+	icon, ext, err := source.Icon(repoName, version)
+	if err != nil {
+		// a missing icon is replaced by the default icon in the UI
+		logrus.Warnf("[catalog bundle] failed to get the icon of chart %s version %s: %v", version.Name, version.Version, err)
+		return &exported, nil
+	}
+	if icon == nil {
+		return &exported, nil
+	}
+	iconData, err := io.ReadAll(icon)
+	icon.Close()
+	if err != nil {
+		return nil, err
+	}
+	exported.Icon = path.Join(iconsDir, fileName+ext)
+	if err := writeFile(tw, path.Join(repoDir(repoName), exported.Icon), iconData, now); err != nil {
+		return nil, err
+	}
+
+	return &exported, nil
+}
+
+func writeFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  modTime,
+		Typeflag: tar.TypeReg,
+	}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// sanitizeFileName replaces the characters that are not allowed in the file names of a bundle.
+func sanitizeFileName(fileName string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' {
+			return '-'
+		}
+		return r
+	}, strings.ReplaceAll(fileName, "..", "-"))
+}
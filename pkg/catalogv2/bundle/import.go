@@ -0,0 +1,170 @@
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// maxFileSize is the maximum size of a file of an imported bundle. The files are stored in config maps, so they
+	// must stay small enough for etcd.
+	maxFileSize = 16 << 20
+	// maxBundleSize is the maximum size of the files of an imported bundle.
+	maxBundleSize = 64 << 20
+)
+
+// Store persists the repositories of an imported bundle.
+type Store interface {
+	// AddRepo is called for each repository of the bundle, before its files are added.
+	AddRepo(repoName string) error
+	// AddFile adds a file of a repository, the path is relative to the repository directory.
+	AddFile(repoName, filePath string, data []byte) error
+	// Commit is called for each repository of the bundle once all the files of the bundle were added, so that the
+	// repository serves its new files instead of the ones of its previous import.
+	Commit(repoName string) error
+	// Abort is called for each repository that was added but not committed when the import fails, so that it is
+	// left as it was before the import.
+	Abort(repoName string) error
+}
+
+// Import reads a catalog bundle and adds its repositories to the given store. The list of images of the bundle is
+// returned along with its manifest, so that they can be mirrored to a private registry. The repositories are left as
+// they were if the import fails.
+func Import(r io.Reader, store Store) (_ *Manifest, _ []string, err error) {
+	var added []string
+	defer func() {
+		if err == nil {
+			return
+		}
+		for _, repoName := range added {
+			if abortErr := store.Abort(repoName); abortErr != nil {
+				err = errors.Join(err, fmt.Errorf("reverting repository %s: %w", repoName, abortErr))
+			}
+		}
+	}()
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	var (
+		manifest *Manifest
+		images   []string
+		total    int64
+		indexed  = map[string]bool{}
+	)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if header.Size > maxFileSize {
+			return nil, nil, fmt.Errorf("file %s of catalog bundle is larger than %d bytes", header.Name, maxFileSize)
+		}
+		if total += header.Size; total > maxBundleSize {
+			return nil, nil, fmt.Errorf("catalog bundle is larger than %d bytes", maxBundleSize)
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, maxFileSize))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch {
+		case header.Name == ManifestFile:
+			if manifest != nil {
+				return nil, nil, errors.New("catalog bundle has more than one manifest")
+			}
+			manifest = &Manifest{}
+			if err := json.Unmarshal(data, manifest); err != nil {
+				return nil, nil, fmt.Errorf("invalid catalog bundle manifest: %w", err)
+			}
+			for _, repoName := range manifest.Repos {
+				if err := validateRepoName(repoName); err != nil {
+					return nil, nil, err
+				}
+				if err := store.AddRepo(repoName); err != nil {
+					return nil, nil, err
+				}
+				added = append(added, repoName)
+			}
+		case header.Name == ImagesFile:
+			for _, line := range strings.Split(string(data), "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					images = append(images, line)
+				}
+			}
+		default:
+			if manifest == nil {
+				return nil, nil, errors.New("the manifest must be the first file of a catalog bundle")
+			}
+			repoName, filePath, err := splitRepoPath(header.Name, manifest)
+			if err != nil {
+				return nil, nil, err
+			}
+			if err := store.AddFile(repoName, filePath, data); err != nil {
+				return nil, nil, err
+			}
+			if filePath == IndexFile {
+				indexed[repoName] = true
+			}
+		}
+	}
+
+	if manifest == nil {
+		return nil, nil, errors.New("catalog bundle has no manifest")
+	}
+	for _, repoName := range manifest.Repos {
+		if !indexed[repoName] {
+			return nil, nil, fmt.Errorf("repository %s of catalog bundle has no index", repoName)
+		}
+	}
+	for len(added) > 0 {
+		if err := store.Commit(added[0]); err != nil {
+			return nil, nil, err
+		}
+		added = added[1:]
+	}
+	return manifest, images, nil
+}
+
+// splitRepoPath returns the repository of the given archive path and the path of the file relative to the repository
+// directory.
+func splitRepoPath(archivePath string, manifest *Manifest) (string, string, error) {
+	parts := strings.SplitN(archivePath, "/", 3)
+	if len(parts) != 3 || parts[0] != reposDir {
+		return "", "", fmt.Errorf("%w: %s", errInvalidPath, archivePath)
+	}
+	repoName, filePath := parts[1], parts[2]
+
+	known := false
+	for _, name := range manifest.Repos {
+		known = known || name == repoName
+	}
+	if !known {
+		return "", "", fmt.Errorf("repository %s of file %s is not part of the catalog bundle manifest", repoName, archivePath)
+	}
+	return repoName, filePath, validatePath(filePath)
+}
+
+// validateRepoName checks that the given repository name can be used as the name of a ClusterRepo.
+func validateRepoName(repoName string) error {
+	if errs := validation.IsDNS1123Subdomain(repoName); len(errs) > 0 {
+		return fmt.Errorf("invalid repository name %q: %s", repoName, strings.Join(errs, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package bundle
+
+import (
+	"fmt"
+
+	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	catalogcontrollers "github.com/rancher/rancher/pkg/generated/controllers/catalog.cattle.io/v1"
+	corev1controllers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/rand"
+)
+
+// ClusterStore stores the repositories of an imported bundle in config maps of the given namespace, owned by
+// ClusterRepos marked as imported.
+//
+// The files of each import are stored under a new generation, which the ClusterRepo only switches to once the whole
+// bundle was stored. The files of the previous generation are deleted afterwards, so that a failed import leaves the
+// repository serving its previous files.
+type ClusterStore struct {
+	namespace    string
+	configMaps   corev1controllers.ConfigMapClient
+	clusterRepos catalogcontrollers.ClusterRepoClient
+	repos        map[string]*catalog.ClusterRepo
+	generations  map[string]string
+	// created are the ClusterRepos created by this import
+	created map[string]bool
+}
+
+func NewClusterStore(namespace string, configMaps corev1controllers.ConfigMapClient, clusterRepos catalogcontrollers.ClusterRepoClient) *ClusterStore {
+	return &ClusterStore{
+		namespace:    namespace,
+		configMaps:   configMaps,
+		clusterRepos: clusterRepos,
+		repos:        map[string]*catalog.ClusterRepo{},
+		generations:  map[string]string{},
+		created:      map[string]bool{},
+	}
+}
+
+// AddRepo creates the imported ClusterRepo with the given name, or removes the files left by failed imports of an
+// existing one. Repositories that were not imported are never overwritten.
+func (s *ClusterStore) AddRepo(repoName string) error {
+	clusterRepo, err := s.clusterRepos.Get(repoName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		clusterRepo, err = s.clusterRepos.Create(&catalog.ClusterRepo{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: repoName,
+			},
+			Spec: catalog.RepoSpec{
+				Imported: true,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		s.created[repoName] = true
+	} else if err != nil {
+		return err
+	} else if !clusterRepo.Spec.Imported {
+		return fmt.Errorf("repository %s already exists and was not imported from a catalog bundle", repoName)
+	}
+
+	if err := s.deleteFiles(repoName, clusterRepo.Annotations[GenerationAnnotation]); err != nil {
+		return err
+	}
+
+	s.repos[repoName] = clusterRepo
+	s.generations[repoName] = rand.String(5)
+	return nil
+}
+
+// AddFile stores a file of an imported repository in as many config maps as needed, each one pointing to the config
+// map holding the next chunk of the file.
+func (s *ClusterStore) AddFile(repoName, filePath string, data []byte) error {
+	clusterRepo, ok := s.repos[repoName]
+	if !ok {
+		return fmt.Errorf("repository %s was not added before its files", repoName)
+	}
+	generation := s.generations[repoName]
+	owner := metav1.OwnerReference{
+		APIVersion: catalog.SchemeGroupVersion.String(),
+		Kind:       "ClusterRepo",
+		Name:       clusterRepo.Name,
+		UID:        clusterRepo.UID,
+	}
+
+	for i := 0; ; i++ {
+		chunk := data
+		if len(chunk) > maxChunkSize {
+			chunk = chunk[:maxChunkSize]
+		}
+		data = data[len(chunk):]
+
+		next := ""
+		if len(data) > 0 {
+			next = FileConfigMapName(repoName, generation, filePath, i+1)
+		}
+		_, err := s.configMaps.Create(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            FileConfigMapName(repoName, generation, filePath, i),
+				Namespace:       s.namespace,
+				OwnerReferences: []metav1.OwnerReference{owner},
+				Labels: map[string]string{
+					RepoLabel:       repoName,
+					GenerationLabel: generation,
+				},
+				Annotations: map[string]string{
+					FileAnnotation: filePath,
+					nextAnnotation: next,
+				},
+			},
+			BinaryData: map[string][]byte{
+				"content": chunk,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if next == "" {
+			break
+		}
+	}
+
+	return nil
+}
+
+// Commit switches the imported ClusterRepo to the files of this import and forces it to reload its index, then deletes
+// the files of its previous imports.
+func (s *ClusterStore) Commit(repoName string) error {
+	generation, ok := s.generations[repoName]
+	if !ok {
+		return fmt.Errorf("repository %s was not added before being committed", repoName)
+	}
+
+	clusterRepo, err := s.clusterRepos.Get(repoName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	clusterRepo = clusterRepo.DeepCopy()
+	if clusterRepo.Annotations == nil {
+		clusterRepo.Annotations = map[string]string{}
+	}
+	clusterRepo.Annotations[GenerationAnnotation] = generation
+	now := metav1.Now()
+	clusterRepo.Spec.ForceUpdate = &now
+	if _, err := s.clusterRepos.Update(clusterRepo); err != nil {
+		return err
+	}
+
+	return s.deleteFiles(repoName, generation)
+}
+
+// Abort deletes the ClusterRepo if it was created by this import, or else the files stored by this import, so that the
+// repository keeps serving the files of its previous import.
+func (s *ClusterStore) Abort(repoName string) error {
+	clusterRepo, ok := s.repos[repoName]
+	if !ok {
+		return nil
+	}
+	if s.created[repoName] {
+		// the files are deleted along with the ClusterRepo owning them
+		if err := s.clusterRepos.Delete(repoName, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+	return s.deleteFiles(repoName, clusterRepo.Annotations[GenerationAnnotation])
+}
+
+// deleteFiles deletes the files of the given imported repository, except for the ones of the given generation.
+func (s *ClusterStore) deleteFiles(repoName, keepGeneration string) error {
+	files, err := s.configMaps.List(s.namespace, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{RepoLabel: repoName}).String(),
+	})
+	if err != nil {
+		return err
+	}
+	for _, cm := range files.Items {
+		if keepGeneration != "" && cm.Labels[GenerationLabel] == keepGeneration {
+			continue
+		}
+		if err := s.configMaps.Delete(cm.Namespace, cm.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package bundle
+
+import (
+	"testing"
+
+	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClusterStoreGenerations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	file := func(name, generation string) corev1.ConfigMap {
+		return corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "cattle-system",
+			Name:      name,
+			Labels:    map[string]string{RepoLabel: "test", GenerationLabel: generation},
+		}}
+	}
+	clusterRepo := &catalog.ClusterRepo{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Annotations: map[string]string{GenerationAnnotation: "current"}},
+		Spec:       catalog.RepoSpec{Imported: true},
+	}
+
+	clusterRepos := fake.NewMockNonNamespacedClientInterface[*catalog.ClusterRepo, *catalog.ClusterRepoList](ctrl)
+	clusterRepos.EXPECT().Get("test", gomock.Any()).Return(clusterRepo, nil).Times(2)
+	configMaps := fake.NewMockClientInterface[*corev1.ConfigMap, *corev1.ConfigMapList](ctrl)
+	store := NewClusterStore("cattle-system", configMaps, clusterRepos)
+
+	// the files of a failed import are deleted, the files being served are kept
+	configMaps.EXPECT().List("cattle-system", gomock.Any()).Return(&corev1.ConfigMapList{Items: []corev1.ConfigMap{
+		file("served", "current"),
+		file("failed", "failed"),
+	}}, nil)
+	configMaps.EXPECT().Delete("cattle-system", "failed", gomock.Any()).Return(nil)
+	require.NoError(t, store.AddRepo("test"))
+
+	generation := store.generations["test"]
+	require.NotEmpty(t, generation)
+	assert.NotEqual(t, "current", generation)
+
+	configMaps.EXPECT().Create(gomock.Any()).DoAndReturn(func(cm *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+		assert.Equal(t, FileConfigMapName("test", generation, IndexFile, 0), cm.Name)
+		assert.Equal(t, generation, cm.Labels[GenerationLabel])
+		return cm, nil
+	})
+	require.NoError(t, store.AddFile("test", IndexFile, []byte("apiVersion: v1")))
+
+	// the repository switches to the new files before the previous ones are deleted
+	gomock.InOrder(
+		clusterRepos.EXPECT().Update(gomock.Any()).DoAndReturn(func(cr *catalog.ClusterRepo) (*catalog.ClusterRepo, error) {
+			assert.Equal(t, generation, cr.Annotations[GenerationAnnotation])
+			assert.NotNil(t, cr.Spec.ForceUpdate)
+			return cr, nil
+		}),
+		configMaps.EXPECT().List("cattle-system", gomock.Any()).Return(&corev1.ConfigMapList{Items: []corev1.ConfigMap{
+			file("served", "current"),
+			file("new", generation),
+		}}, nil),
+		configMaps.EXPECT().Delete("cattle-system", "served", gomock.Any()).Return(nil),
+	)
+	require.NoError(t, store.Commit("test"))
+	assert.Equal(t, "current", clusterRepo.Annotations[GenerationAnnotation], "the cached object must not be modified")
+}
+
+func TestClusterStoreAbort(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	clusterRepos := fake.NewMockNonNamespacedClientInterface[*catalog.ClusterRepo, *catalog.ClusterRepoList](ctrl)
+	configMaps := fake.NewMockClientInterface[*corev1.ConfigMap, *corev1.ConfigMapList](ctrl)
+	store := NewClusterStore("cattle-system", configMaps, clusterRepos)
+
+	// a created repository is deleted, along with its files
+	clusterRepos.EXPECT().Get("created", gomock.Any()).Return(nil, apierrors.NewNotFound(catalog.Resource("clusterrepos"), "created"))
+	clusterRepos.EXPECT().Create(gomock.Any()).DoAndReturn(func(cr *catalog.ClusterRepo) (*catalog.ClusterRepo, error) {
+		return cr, nil
+	})
+	configMaps.EXPECT().List("cattle-system", gomock.Any()).Return(&corev1.ConfigMapList{}, nil)
+	require.NoError(t, store.AddRepo("created"))
+	clusterRepos.EXPECT().Delete("created", gomock.Any()).Return(nil)
+	require.NoError(t, store.Abort("created"))
+
+	// an existing repository keeps the files being served
+	clusterRepos.EXPECT().Get("existing", gomock.Any()).Return(&catalog.ClusterRepo{
+		ObjectMeta: metav1.ObjectMeta{Name: "existing", Annotations: map[string]string{GenerationAnnotation: "current"}},
+		Spec:       catalog.RepoSpec{Imported: true},
+	}, nil)
+	configMaps.EXPECT().List("cattle-system", gomock.Any()).Return(&corev1.ConfigMapList{}, nil)
+	require.NoError(t, store.AddRepo("existing"))
+	configMaps.EXPECT().List("cattle-system", gomock.Any()).Return(&corev1.ConfigMapList{Items: []corev1.ConfigMap{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "cattle-system", Name: "served", Labels: map[string]string{RepoLabel: "existing", GenerationLabel: "current"}}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "cattle-system", Name: "aborted", Labels: map[string]string{RepoLabel: "existing", GenerationLabel: store.generations["existing"]}}},
+	}}, nil)
+	configMaps.EXPECT().Delete("cattle-system", "aborted", gomock.Any()).Return(nil)
+	require.NoError(t, store.Abort("existing"))
+}
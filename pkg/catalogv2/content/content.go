@@ -23,6 +23,7 @@
 	"fmt"
 	"io"
 	"net/url"
+	"path"
 	"strings"
 	"sync"
 
@@ -30,11 +31,13 @@
 	"github.com/rancher/rancher/pkg/api/steve/catalog/types"
 	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
 	"github.com/rancher/rancher/pkg/catalogv2"
+	"github.com/rancher/rancher/pkg/catalogv2/bundle"
 	"github.com/rancher/rancher/pkg/catalogv2/git"
 	"github.com/rancher/rancher/pkg/catalogv2/helm"
 	helmhttp "github.com/rancher/rancher/pkg/catalogv2/http"
 	"github.com/rancher/rancher/pkg/catalogv2/oci"
 	catalogcontrollers "github.com/rancher/rancher/pkg/generated/controllers/catalog.cattle.io/v1"
+	namespaces "github.com/rancher/rancher/pkg/namespace"
 	"github.com/rancher/rancher/pkg/settings"
 	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
 	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
@@ -53,12 +56,13 @@
 // The Manager struct uses clientsets provided by the wrangler library
 // to interact with the Kubernetes API and quickly fetch instances of ConfigMaps, Secrets, and ClusterRepos.
 type Manager struct {
-	configMaps   corecontrollers.ConfigMapCache      // clientset cache for ConfigMaps.
-	secrets      corecontrollers.SecretCache         // clientset cache for Secrets.
-	clusterRepos catalogcontrollers.ClusterRepoCache // clientset cache for ClusterRepo custom resources.
-	discovery    discovery.DiscoveryInterface        // An interface to the Kubernetes Discovery API. Provides information about the Kubernetes API server.
-	IndexCache   map[string]indexCache               // cache for Helm repository index files. Used to store and retrieve index files for faster access.
-	lock         sync.RWMutex                        // read-write mutex used to ensure that some Manager's operations are thread-safe.
+	configMaps    corecontrollers.ConfigMapCache      // clientset cache for ConfigMaps.
+	secrets       corecontrollers.SecretCache         // clientset cache for Secrets.
+	clusterRepos  catalogcontrollers.ClusterRepoCache // clientset cache for ClusterRepo custom resources.
+	discovery     discovery.DiscoveryInterface        // An interface to the Kubernetes Discovery API. Provides information about the Kubernetes API server.
+	IndexCache    map[string]indexCache               // cache for Helm repository index files. Used to store and retrieve index files for faster access.
+	verifications map[string]verification             // cache for the verification results of charts. Used to surface them in the index entries.
+	lock          sync.RWMutex                        // read-write mutex used to ensure that some Manager's operations are thread-safe.
 }
 
 // indexCache - used to cache helm chart indexes
@@ -82,11 +86,12 @@ func NewManager(
 	secrets corecontrollers.SecretCache,
 	clusterRepos catalogcontrollers.ClusterRepoCache) *Manager {
 	return &Manager{
-		discovery:    discovery,
-		configMaps:   configMaps,
-		secrets:      secrets,
-		clusterRepos: clusterRepos,
-		IndexCache:   map[string]indexCache{},
+		discovery:     discovery,
+		configMaps:    configMaps,
+		secrets:       secrets,
+		clusterRepos:  clusterRepos,
+		IndexCache:    map[string]indexCache{},
+		verifications: map[string]verification{},
 	}
 }
 
@@ -121,7 +126,9 @@ func (c *Manager) Index(namespace, name, targetK8sVersion string, skipFilter boo
 	if cache, ok := c.IndexCache[fmt.Sprintf("%s/%s", r.status.IndexConfigMapNamespace, r.status.IndexConfigMapName)]; ok {
 		if cm.ResourceVersion == cache.revision {
 			c.lock.RUnlock()
-			return c.filterReleases(deepCopyIndex(cache.index), k8sVersion, skipFilter), nil
+			index := c.filterReleases(deepCopyIndex(cache.index), k8sVersion, skipFilter)
+			c.annotateVerifications(r, index)
+			return index, nil
 		}
 	}
 	c.lock.RUnlock()
@@ -159,7 +166,9 @@ func (c *Manager) Index(namespace, name, targetK8sVersion string, skipFilter boo
 	}
 	c.lock.Unlock()
 
-	return c.filterReleases(deepCopyIndex(index), k8sVersion, skipFilter), nil
+	index = c.filterReleases(deepCopyIndex(index), k8sVersion, skipFilter)
+	c.annotateVerifications(r, index)
+	return index, nil
 }
 
 // Icon Returns an io.ReadCloser and the icon's MIME type for the chart.
@@ -182,6 +191,18 @@ func (c *Manager) Icon(namespace, name, chartName, version string) (io.ReadClose
 		return nil, "", err
 	}
 
+	// Repositories imported from a catalog bundle serve the icons stored with their index.
+	if repo.spec.Imported {
+		if !bundle.IsBundlePath(chart.Icon) {
+			return nil, "", nil
+		}
+		data, err := c.bundleFile(repo, chart.Icon)
+		if err != nil {
+			return nil, "", err
+		}
+		return data, path.Ext(chart.Icon), nil
+	}
+
 	// If the chart icon is not an HTTP URL and the repository has a commit status,
 	// attempt to get the icon from the git repository.
 	if !isHTTP(chart.Icon) && repo.status.Commit != "" {
@@ -233,6 +254,14 @@ func (c *Manager) Chart(namespace, name, chartName, version string, skipFilter b
 		return nil, err
 	}
 
+	// Repositories imported from a catalog bundle serve the charts stored with their index.
+	if repo.spec.Imported {
+		if chart == nil || len(chart.URLs) == 0 {
+			return nil, errors.New("chart has no urls specified")
+		}
+		return c.bundleFile(repo, chart.URLs[0])
+	}
+
 	// If the commit status of the repository is not an empty string
 	// Return the Chart through Git without checking the secret
 	if repo.status.Commit != "" {
@@ -263,6 +292,15 @@ func (c *Manager) Chart(namespace, name, chartName, version string, skipFilter b
 	}
 }
 
+// bundleFile returns a file of a repository imported from a catalog bundle.
+func (c *Manager) bundleFile(r repoDef, filePath string) (io.ReadCloser, error) {
+	data, err := bundle.ReadFile(c.configMaps.Get, namespaces.System, r.metadata.Name, r.metadata.Annotations[bundle.GenerationAnnotation], filePath)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
 // Info retrieves detailed information about a specific Helm chart from a Helm repository.
 //
 // The function uses the Chart method to get the content of the Helm chart.
@@ -271,6 +309,8 @@ func (c *Manager) Chart(namespace, name, chartName, version string, skipFilter b
 //
 // Once the chart content is retrieved, the function uses the InfoFromTarball method to extract detailed information.
 //
+// If the repository has a verification policy, the chart is verified and the result is added to the information.
+//
 // The function returns a types.ChartInfo pointer which represents the detailed information
 // about the Helm chart and can be used by the Steve API.
 func (c *Manager) Info(namespace, name, chartName, version string) (*types.ChartInfo, error) {
@@ -278,9 +318,25 @@ func (c *Manager) Info(namespace, name, chartName, version string) (*types.Chart
 	if err != nil {
 		return nil, err
 	}
-	defer chart.Close()
+	chartData, err := io.ReadAll(chart)
+	chart.Close()
+	if err != nil {
+		return nil, err
+	}
 
-	return helm.InfoFromTarball(chart)
+	info, err := helm.InfoFromTarball(bytes.NewReader(chartData))
+	if err != nil {
+		return nil, err
+	}
+
+	// The result is reported even if the policy does not allow unverified charts,
+	// the chart information is only read and not installed.
+	result, err := c.Verify(namespace, name, chartName, version, chartData)
+	if result == nil {
+		return nil, err
+	}
+	info.Verification = result
+	return info, nil
 }
 
 // getRepo returns a cluster repository based on the name
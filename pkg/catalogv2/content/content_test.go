@@ -9,12 +9,16 @@
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/rancher/rancher/pkg/catalogv2/git"
+	"github.com/rancher/rancher/pkg/catalogv2/verify"
 	"github.com/rancher/rancher/pkg/settings"
 	"github.com/stretchr/testify/assert"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/repo"
 
 	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -614,3 +618,67 @@ func deleteDir(dir string) error {
 	}
 	return nil
 }
+
+func TestAnnotateVerifications(t *testing.T) {
+	r := repoDef{
+		metadata: &metav1.ObjectMeta{UID: "repo-uid", Generation: 2},
+		spec:     &v1.RepoSpec{Verification: &v1.RepoVerification{}},
+	}
+	shared := map[string]string{"catalog.cattle.io/display-name": "Test"}
+	verified := &repo.ChartVersion{Metadata: &chart.Metadata{Name: "test", Version: "1.0.0", Annotations: shared}, Digest: "abc"}
+	unknown := &repo.ChartVersion{Metadata: &chart.Metadata{Name: "test", Version: "2.0.0", Annotations: shared}, Digest: "def"}
+	index := &repo.IndexFile{Entries: map[string]repo.ChartVersions{"test": {verified, unknown}}}
+
+	c := &Manager{verifications: map[string]verification{
+		verificationKey(r, verified): {digest: "sha256:abc", result: &verify.Result{Status: verify.Verified}},
+	}}
+	c.annotateVerifications(r, index)
+
+	assert.Equal(t, string(verify.Verified), verified.Annotations[verify.Annotation])
+	assert.Equal(t, "Test", verified.Annotations["catalog.cattle.io/display-name"])
+	assert.NotContains(t, unknown.Annotations, verify.Annotation)
+	// the annotations may be shared with the cached index and must not be modified in place
+	assert.NotContains(t, shared, verify.Annotation)
+
+	// results are not reused once the repository changed
+	r.metadata.Generation = 3
+	delete(verified.Annotations, verify.Annotation)
+	c.annotateVerifications(r, index)
+	assert.NotContains(t, verified.Annotations, verify.Annotation)
+}
+
+func TestVerifyCached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	secrets := fake.NewMockCacheInterface[*corev1.Secret](ctrl)
+
+	r := repoDef{
+		metadata: &metav1.ObjectMeta{Namespace: "", UID: "repo-uid", Generation: 1},
+		spec: &v1.RepoSpec{Verification: &v1.RepoVerification{
+			ProvenanceKeyringSecret: &v1.SecretReference{Namespace: "cattle-system", Name: "keyring"},
+		}},
+		status: &v1.RepoStatus{URL: "https://charts.example.com"},
+	}
+	chartVersion := &repo.ChartVersion{
+		Metadata: &chart.Metadata{Name: "test", Version: "1.0.0"},
+		URLs:     []string{"https://charts.example.com/test-1.0.0.tgz"},
+	}
+	chartData := []byte("chart")
+	c := &Manager{secrets: secrets}
+
+	// a transient error is reported in the result, and is not cached
+	secrets.EXPECT().Get("cattle-system", "keyring").Return(nil, fmt.Errorf("connection refused"))
+	result := c.verifyCached(r, chartVersion, chartData)
+	assert.Equal(t, verify.Failed, result.Status)
+	assert.Contains(t, result.Message, "connection refused")
+	assert.Empty(t, c.verifications)
+
+	// a missing keyring is cached for the digest of the chart archive
+	secrets.EXPECT().Get("cattle-system", "keyring").Return(&corev1.Secret{}, nil)
+	result = c.verifyCached(r, chartVersion, chartData)
+	assert.Equal(t, "no provenance keyring is trusted", result.Message)
+	assert.Same(t, result, c.verifyCached(r, chartVersion, chartData))
+
+	// a different archive published under the same version is verified again
+	secrets.EXPECT().Get("cattle-system", "keyring").Return(&corev1.Secret{}, nil)
+	assert.NotSame(t, result, c.verifyCached(r, chartVersion, []byte("other chart")))
+}
@@ -0,0 +1,208 @@
+package content
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path"
+	"sort"
+
+	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/catalogv2"
+	helmhttp "github.com/rancher/rancher/pkg/catalogv2/http"
+	"github.com/rancher/rancher/pkg/catalogv2/oci"
+	"github.com/rancher/rancher/pkg/catalogv2/verify"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// Verify verifies the given chart archive of a chart version against the verification policy of the Helm repository.
+//
+// The result is cached so that it can be surfaced in the entries of the repository index.
+// An error is returned if the chart could not be verified and the policy does not allow unverified charts.
+func (c *Manager) Verify(namespace, name, chartName, version string, chartData []byte) (*verify.Result, error) {
+	index, err := c.Index(namespace, name, "", true)
+	if err != nil {
+		return nil, err
+	}
+
+	chart, err := index.Get(chartName, version)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := c.getRepo(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := r.spec.Verification
+	if policy == nil {
+		return &verify.Result{Status: verify.Unverified}, nil
+	}
+
+	result := c.verifyCached(r, chart, chartData)
+	if err := result.Err(); err != nil && !policy.AllowUnverified {
+		return result, fmt.Errorf("chart %s version %s of repository %s: %w", chartName, version, name, err)
+	}
+	return result, nil
+}
+
+// verifyCached returns the verification result of the given chart archive, which is cached per repository, chart
+// version and archive digest. Errors fetching the signatures or the trusted keys may be transient, so they are reported
+// in a result that is not cached.
+func (c *Manager) verifyCached(r repoDef, chart *repo.ChartVersion, chartData []byte) *verify.Result {
+	key := verificationKey(r, chart)
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256(chartData))
+
+	c.lock.RLock()
+	cached, ok := c.verifications[key]
+	c.lock.RUnlock()
+	if ok && cached.digest == digest {
+		return cached.result
+	}
+
+	result, err := c.verify(r, chart, chartData)
+	if err != nil {
+		return &verify.Result{
+			Status:  verify.Failed,
+			Message: fmt.Sprintf("unable to verify chart: %v", err),
+		}
+	}
+
+	c.lock.Lock()
+	if c.verifications == nil {
+		c.verifications = map[string]verification{}
+	}
+	c.verifications[key] = verification{digest: digest, result: result}
+	c.lock.Unlock()
+	return result
+}
+
+func (c *Manager) verify(r repoDef, chart *repo.ChartVersion, chartData []byte) (*verify.Result, error) {
+	policy := r.spec.Verification
+
+	// Charts of git repositories are packaged by Rancher from the repository content, so they have
+	// no provenance file or signature to verify.
+	if r.status.Commit != "" {
+		return &verify.Result{
+			Status:  verify.Failed,
+			Message: "charts of git repositories cannot be verified",
+		}, nil
+	}
+	if len(chart.URLs) == 0 {
+		return &verify.Result{
+			Status:  verify.Failed,
+			Message: "chart has no urls specified",
+		}, nil
+	}
+
+	secret, err := catalogv2.GetSecret(c.secrets, r.spec, r.metadata.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if registry.IsOCI(chart.URLs[0]) {
+		publicKeys, err := c.secretValues(policy.CosignPublicKeysSecret, r.metadata.Namespace, "")
+		if err != nil {
+			return nil, err
+		}
+		signed, err := oci.ChartSignatures(secret, chart, *r.spec)
+		if err != nil {
+			return nil, err
+		}
+		return verify.Cosign(publicKeys, chartData, signed), nil
+	}
+
+	keyring, err := c.secretValues(policy.ProvenanceKeyringSecret, r.metadata.Namespace, verify.KeyringSecretKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(keyring) == 0 {
+		return &verify.Result{
+			Status:  verify.Failed,
+			Method:  verify.ProvenanceMethod,
+			Message: "no provenance keyring is trusted",
+		}, nil
+	}
+	u, err := helmhttp.ChartURL(r.status.URL, chart)
+	if err != nil {
+		return nil, err
+	}
+	prov, err := helmhttp.Provenance(secret, r.status.URL, r.spec.CABundle, r.spec.InsecureSkipTLSverify, r.spec.DisableSameOriginCheck, chart)
+	if err != nil {
+		return nil, err
+	}
+	return verify.Provenance(keyring[0], path.Base(u.Path), chartData, prov), nil
+}
+
+// secretValues returns the values of the referenced secret, or only the value of the given key if it is not empty.
+func (c *Manager) secretValues(ref *v1.SecretReference, repoNamespace, key string) ([][]byte, error) {
+	if ref == nil {
+		return nil, nil
+	}
+	ns := ref.Namespace
+	if repoNamespace != "" {
+		ns = repoNamespace
+	}
+	secret, err := c.secrets.Get(ns, ref.Name)
+	if err != nil {
+		return nil, err
+	}
+	if key != "" {
+		if value, ok := secret.Data[key]; ok {
+			return [][]byte{value}, nil
+		}
+		return nil, nil
+	}
+	var values [][]byte
+	for _, k := range sortedKeys(secret.Data) {
+		values = append(values, secret.Data[k])
+	}
+	return values, nil
+}
+
+func sortedKeys(m map[string][]byte) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// annotateVerifications sets the verification annotation on the entries of the index of the given repository
+// whose verification result is known.
+func (c *Manager) annotateVerifications(r repoDef, index *repo.IndexFile) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if r.spec.Verification == nil || len(c.verifications) == 0 {
+		return
+	}
+	for _, versions := range index.Entries {
+		for _, version := range versions {
+			cached, ok := c.verifications[verificationKey(r, version)]
+			if !ok {
+				continue
+			}
+			annotations := make(map[string]string, len(version.Annotations)+1)
+			for k, v := range version.Annotations {
+				annotations[k] = v
+			}
+			annotations[verify.Annotation] = string(cached.result.Status)
+			version.Annotations = annotations
+		}
+	}
+}
+
+// verification is the cached verification result of a chart archive.
+type verification struct {
+	digest string // sha256 digest of the chart archive that was verified.
+	result *verify.Result
+}
+
+// verificationKey identifies a chart version of a repository. The digest is part of the key, so that results are
+// not reused for a different chart archive published under the same version.
+func verificationKey(r repoDef, chart *repo.ChartVersion) string {
+	return fmt.Sprintf("%s/%d/%s/%s/%s", r.metadata.UID, r.metadata.Generation, chart.Name, chart.Version, chart.Digest)
+}
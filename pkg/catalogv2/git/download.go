@@ -9,8 +9,8 @@
 )
 
 // Ensure runs git clone, clean DIRTY contents and fetch the latest commit
-func Ensure(secret *corev1.Secret, namespace, name, gitURL, commit string, insecureSkipTLS bool, caBundle []byte) error {
-	git, err := gitForRepo(secret, namespace, name, gitURL, insecureSkipTLS, caBundle)
+func Ensure(secret *corev1.Secret, namespace, name, gitURL, commit string, insecureSkipTLS bool, caBundle []byte, keys *CommitKeys) error {
+	git, err := gitForRepo(secret, namespace, name, gitURL, insecureSkipTLS, caBundle, keys)
 	if err != nil {
 		return fmt.Errorf("ensure failure: %w", err)
 	}
@@ -35,13 +35,23 @@ func Ensure(secret *corev1.Secret, namespace, name, gitURL, commit string, insec
 	return nil
 }
 
-// Head runs git clone on directory(if not exist), reset dirty content and return the HEAD commit
-func Head(secret *corev1.Secret, namespace, name, gitURL, branch string, insecureSkipTLS bool, caBundle []byte) (string, error) {
-	git, err := gitForRepo(secret, namespace, name, gitURL, insecureSkipTLS, caBundle)
+// Head runs git clone on directory(if not exist), reset dirty content and return the HEAD commit.
+// The branch may also be a tag, or a commit SHA in which case the repo is reset to that commit.
+// If keys is not nil, the commit must be signed with one of them before the working tree is reset to it.
+func Head(secret *corev1.Secret, namespace, name, gitURL, branch string, insecureSkipTLS bool, caBundle []byte, keys *CommitKeys) (string, error) {
+	git, err := gitForRepo(secret, namespace, name, gitURL, insecureSkipTLS, caBundle, keys)
 	if err != nil {
 		return "", fmt.Errorf("head failure: %w", err)
 	}
 
+	if isCommitSHA(branch) {
+		commit, err := git.checkout(branch)
+		if err != nil {
+			return "", fmt.Errorf("head failure: %w", err)
+		}
+		return commit, nil
+	}
+
 	if err := git.clone(branch); err != nil {
 		return "", fmt.Errorf("head failure: %w", err)
 	}
@@ -61,13 +71,15 @@ func Head(secret *corev1.Secret, namespace, name, gitURL, branch string, insecur
 // Update updates git repo if remote sha has changed. It also skips the update if in bundled mode and the git dir has a certain prefix stateDir(utils.go).
 // If there is an error updating the repo especially stateDir(utils.go) repositories, it ignores the error and returns the current commit in the local pod directory.
 // except when the error is `branch not found`. It specifically checks for `couldn't find remote ref` & `Could not find remote branch` in the error message.
-func Update(secret *corev1.Secret, namespace, name, gitURL, branch string, insecureSkipTLS bool, caBundle []byte) (string, error) {
-	git, err := gitForRepo(secret, namespace, name, gitURL, insecureSkipTLS, caBundle)
+// The branch may also be a tag, or a commit SHA in which case the repo is pinned to that commit.
+// If keys is not nil, the fetched commit must be signed with one of them before the working tree is reset to it.
+func Update(secret *corev1.Secret, namespace, name, gitURL, branch string, insecureSkipTLS bool, caBundle []byte, keys *CommitKeys) (string, error) {
+	git, err := gitForRepo(secret, namespace, name, gitURL, insecureSkipTLS, caBundle, keys)
 	if err != nil {
 		return "", fmt.Errorf("update failure: %w", err)
 	}
 	if IsBundled(git.Directory) && settings.SystemCatalog.Get() == "bundled" {
-		return Head(secret, namespace, name, gitURL, branch, insecureSkipTLS, caBundle)
+		return Head(secret, namespace, name, gitURL, branch, insecureSkipTLS, caBundle, keys)
 	}
 
 	commit, err := git.Update(branch)
@@ -78,12 +90,12 @@ func Update(secret *corev1.Secret, namespace, name, gitURL, branch string, insec
 		if checkInvalidBranch(err) {
 			return "", err
 		}
-		return Head(secret, namespace, name, gitURL, branch, insecureSkipTLS, caBundle)
+		return Head(secret, namespace, name, gitURL, branch, insecureSkipTLS, caBundle, keys)
 	}
 	return commit, err
 }
 
-func gitForRepo(secret *corev1.Secret, namespace, name, gitURL string, insecureSkipTLS bool, caBundle []byte) (*git, error) {
+func gitForRepo(secret *corev1.Secret, namespace, name, gitURL string, insecureSkipTLS bool, caBundle []byte, keys *CommitKeys) (*git, error) {
 	err := validateURL(gitURL)
 	if err != nil {
 		return nil, fmt.Errorf("%w: only http(s) or ssh:// supported", err)
@@ -104,6 +116,7 @@ func gitForRepo(secret *corev1.Secret, namespace, name, gitURL string, insecureS
 		Headers:           headers,
 		InsecureTLSVerify: insecureSkipTLS,
 		CABundle:          caBundle,
+		CommitKeys:        keys,
 	})
 }
 
@@ -70,7 +70,7 @@ func Test_Ensure(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			err := Ensure(tc.secret, tc.namespace, tc.name, tc.gitURL, tc.commit, tc.insecureSkipTLS, tc.caBundle)
+			err := Ensure(tc.secret, tc.namespace, tc.name, tc.gitURL, tc.commit, tc.insecureSkipTLS, tc.caBundle, nil)
 			// Check the error
 			if tc.expectedError == nil && tc.expectedError != err {
 				t.Errorf("Expected error: %v |But got: %v", tc.expectedError, err)
@@ -117,7 +117,7 @@ func Test_Head(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			commit, err := Head(tc.secret, tc.namespace, tc.name, tc.gitURL, tc.branch, tc.insecureSkipTLS, tc.caBundle)
+			commit, err := Head(tc.secret, tc.namespace, tc.name, tc.gitURL, tc.branch, tc.insecureSkipTLS, tc.caBundle, nil)
 			// Check the error
 			if tc.expectedError == nil && tc.expectedError != err {
 				t.Errorf("Expected error: %v |But got: %v", tc.expectedError, err)
@@ -183,7 +183,7 @@ func Test_Update(t *testing.T) {
 				assert.NoError(t, err)
 			}
 
-			commit, err := Update(tc.secret, tc.namespace, tc.name, tc.gitURL, tc.branch, tc.insecureSkipTLS, tc.caBundle)
+			commit, err := Update(tc.secret, tc.namespace, tc.name, tc.gitURL, tc.branch, tc.insecureSkipTLS, tc.caBundle, nil)
 			if tc.expectedError != "" {
 				assert.Contains(t, err.Error(), tc.expectedError)
 			} else {
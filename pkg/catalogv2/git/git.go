@@ -17,6 +17,7 @@
 
 	"github.com/pkg/errors"
 	"github.com/rancher/rancher/pkg/catalogv2/roundtripper"
+	"github.com/rancher/rancher/pkg/logging"
 	"github.com/rancher/rancher/pkg/settings"
 	"github.com/rancher/wrangler/v3/pkg/randomtoken"
 	"github.com/sirupsen/logrus"
@@ -31,6 +32,8 @@ type Options struct {
 	CABundle          []byte
 	InsecureTLSVerify bool
 	Headers           map[string]string
+	// CommitKeys are the keys trusted to sign the commits the git repo is reset to. If nil, commits are not verified.
+	CommitKeys *CommitKeys
 }
 
 type git struct {
@@ -42,6 +45,7 @@ type git struct {
 	insecureTLSVerify bool
 	secret            *corev1.Secret
 	headers           map[string]string
+	commitKeys        *CommitKeys
 	knownHosts        []byte
 }
 
@@ -57,6 +61,7 @@ func newGit(directory, url string, opts *Options) (*git, error) {
 		insecureTLSVerify: opts.InsecureTLSVerify,
 		secret:            opts.Credential,
 		headers:           opts.Headers,
+		commitKeys:        opts.CommitKeys,
 	}
 	return g, g.setCredential(opts.Credential)
 }
@@ -225,7 +230,12 @@ func (g *git) clone(branch string) error {
 }
 
 // Update updates git repo if remote sha has changed.
+// If branch is a commit SHA, the git repo is reset to that commit instead.
 func (g *git) Update(branch string) (string, error) {
+	if isCommitSHA(branch) {
+		return g.checkout(branch)
+	}
+
 	if err := g.clone(branch); err != nil {
 		return "", err
 	}
@@ -250,6 +260,21 @@ func (g *git) Update(branch string) (string, error) {
 	return g.currentCommit()
 }
 
+// checkout resets the git repo to the given commit, fetching it if it is not available locally.
+func (g *git) checkout(commit string) (string, error) {
+	if err := g.clone(""); err != nil {
+		return "", err
+	}
+
+	if err := g.reset(commit); err != nil {
+		if err := g.fetchAndReset(commit); err != nil {
+			return "", err
+		}
+	}
+
+	return g.currentCommit()
+}
+
 func (g *git) fetchAndReset(rev string) error {
 	if err := g.git("-C", g.Directory, "fetch", "origin", "--", rev); err != nil {
 		return err
@@ -257,7 +282,12 @@ func (g *git) fetchAndReset(rev string) error {
 	return g.reset("FETCH_HEAD")
 }
 
+// reset resets the git repo to the given revision, once it is verified. The working tree is left unchanged if the
+// revision is not trusted.
 func (g *git) reset(rev string) error {
+	if err := g.verify(rev); err != nil {
+		return err
+	}
 	return g.git("-C", g.Directory, "reset", "--hard", rev)
 }
 
@@ -265,6 +295,10 @@ func (g *git) currentCommit() (string, error) {
 	return g.gitOutput("-C", g.Directory, "rev-parse", "HEAD")
 }
 
+func (g *git) rawCommit(commit string) (string, error) {
+	return g.gitOutput("-C", g.Directory, "cat-file", "commit", commit)
+}
+
 func (g *git) remoteSHAChanged(branch, sha string) (bool, error) {
 	formattedURL := formatGitURL(g.URL, branch)
 	if formattedURL == "" {
@@ -310,7 +344,7 @@ func (g *git) remoteSHAChanged(branch, sha string) (bool, error) {
 
 func (g *git) git(args ...string) error {
 	var output io.Writer
-	if logrus.IsLevelEnabled(logrus.DebugLevel) {
+	if logging.Level() >= logrus.DebugLevel {
 		output = os.Stdout
 	}
 	return g.gitCmd(output, args...)
@@ -2,20 +2,29 @@
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/rancher/rancher/pkg/catalogv2/chart"
 	"helm.sh/helm/v3/pkg/provenance"
 	"helm.sh/helm/v3/pkg/repo"
 )
 
-func BuildOrGetIndex(namespace, name, gitURL string) (*repo.IndexFile, error) {
+// BuildOrGetIndex returns the index of the helm repository found in the given subdirectory of the git repo,
+// or in the whole git repo if subDir is empty. The chart URLs of the index are relative to the git repo.
+func BuildOrGetIndex(namespace, name, gitURL, subDir string) (*repo.IndexFile, error) {
 	dir := RepoDir(namespace, name, gitURL)
-	return buildOrGetIndex(dir)
+	return buildOrGetIndex(dir, subDir)
 }
 
-func buildOrGetIndex(dir string) (*repo.IndexFile, error) {
+func buildOrGetIndex(repoDir, subDir string) (*repo.IndexFile, error) {
+	dir, err := subDirectory(repoDir, subDir)
+	if err != nil {
+		return nil, err
+	}
 	if err := ensureNoSymlinks(dir); err != nil {
 		return nil, err
 	}
@@ -26,7 +35,7 @@ func buildOrGetIndex(dir string) (*repo.IndexFile, error) {
 		builtIndex    = repo.NewIndexFile()
 	)
 
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if info.Name() == "index.yaml" {
 			if indexPath == "" || len(path) < len(indexPath) {
 				if index, err := repo.LoadIndexFile(path); err == nil {
@@ -55,7 +64,7 @@ func buildOrGetIndex(dir string) (*repo.IndexFile, error) {
 			return err
 		}
 
-		rel, err := filepath.Rel(dir, path)
+		rel, err := filepath.Rel(repoDir, path)
 		if err != nil {
 			return fmt.Errorf("building path for chart at %s: %w", dir, err)
 		}
@@ -68,12 +77,32 @@ func buildOrGetIndex(dir string) (*repo.IndexFile, error) {
 	}
 
 	if existingIndex != nil {
-		return existingIndex, nil
+		return withURLPrefix(existingIndex, subDir), nil
 	}
 
 	return builtIndex, nil
 }
 
+// withURLPrefix prefixes the relative chart URLs of an index found in a subdirectory of the git repo
+// with the subdirectory, so that they are relative to the git repo.
+func withURLPrefix(index *repo.IndexFile, subDir string) *repo.IndexFile {
+	prefix := strings.Trim(filepath.ToSlash(filepath.Clean(subDir)), "/")
+	if subDir == "" || prefix == "." {
+		return index
+	}
+	for _, versions := range index.Entries {
+		for _, version := range versions {
+			for i, u := range version.URLs {
+				if parsed, err := url.Parse(u); err != nil || parsed.IsAbs() || strings.HasPrefix(u, "/") {
+					continue
+				}
+				version.URLs[i] = path.Join(prefix, u)
+			}
+		}
+	}
+	return index
+}
+
 func ensureNoSymlinks(dir string) error {
 	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil || info == nil {
@@ -91,6 +91,26 @@ func validateURL(gitURL string) error {
 	return nil
 }
 
+var commitSHARegexp = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// isCommitSHA checks if the revision is a full SHA-1 or SHA-256 commit hash.
+func isCommitSHA(revision string) bool {
+	return commitSHARegexp.MatchString(revision)
+}
+
+// subDirectory returns the path of the given subdirectory of the git repo directory.
+// An error is returned if the subdirectory is not within the git repo directory.
+func subDirectory(dir, subDir string) (string, error) {
+	if subDir == "" {
+		return dir, nil
+	}
+	rel := filepath.Clean(filepath.FromSlash(strings.TrimPrefix(subDir, "/")))
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return "", fmt.Errorf("invalid git subdirectory: %s", subDir)
+	}
+	return filepath.Join(dir, rel), nil
+}
+
 // Hash returns a hash of the git URL.
 func Hash(gitURL string) string {
 	b := sha256.Sum256([]byte(gitURL))
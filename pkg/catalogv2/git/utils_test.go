@@ -4,6 +4,8 @@
 	"testing"
 
 	assertlib "github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
 )
 
 func Test_isGitSSH(t *testing.T) {
@@ -54,3 +56,53 @@ func Test_gitDir(t *testing.T) {
 		assert.Equalf(tc.expected, actual, "testcase: %v", tc)
 	}
 }
+
+func Test_isCommitSHA(t *testing.T) {
+	assert := assertlib.New(t)
+	assert.True(isCommitSHA("0123456789abcdef0123456789abcdef01234567"))
+	assert.True(isCommitSHA("0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"))
+	assert.False(isCommitSHA("main"))
+	assert.False(isCommitSHA("v1.0.0"))
+	assert.False(isCommitSHA("0123456"))
+	assert.False(isCommitSHA("0123456789ABCDEF0123456789ABCDEF01234567"))
+}
+
+func Test_subDirectory(t *testing.T) {
+	assert := assertlib.New(t)
+	testCases := []struct {
+		subDir   string
+		expected string
+		err      bool
+	}{
+		{"", "repo", false},
+		{"charts", "repo/charts", false},
+		{"/charts/", "repo/charts", false},
+		{"charts/../stable", "repo/stable", false},
+		{"..", "", true},
+		{"../other", "", true},
+		{"charts/../../other", "", true},
+	}
+	for _, tc := range testCases {
+		actual, err := subDirectory("repo", tc.subDir)
+		if tc.err {
+			assert.Errorf(err, "testcase: %v", tc)
+			continue
+		}
+		assert.NoErrorf(err, "testcase: %v", tc)
+		assert.Equalf(tc.expected, actual, "testcase: %v", tc)
+	}
+}
+
+func Test_withURLPrefix(t *testing.T) {
+	assert := assertlib.New(t)
+	index := repo.NewIndexFile()
+	index.Entries["test"] = repo.ChartVersions{
+		{
+			Metadata: &chart.Metadata{Name: "test", Version: "1.0.0"},
+			URLs:     []string{"assets/test-1.0.0.tgz", "https://example.com/test-1.0.0.tgz"},
+		},
+	}
+
+	withURLPrefix(index, "/charts/stable/")
+	assert.Equal([]string{"charts/stable/assets/test-1.0.0.tgz", "https://example.com/test-1.0.0.tgz"}, index.Entries["test"][0].URLs)
+}
@@ -0,0 +1,227 @@
+package git
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/openpgp"       //nolint:staticcheck // commit signatures are verified with the same package as helm provenance files
+	"golang.org/x/crypto/openpgp/armor" //nolint:staticcheck // commit signatures are verified with the same package as helm provenance files
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	sshSignatureMagic     = "SSHSIG"
+	sshSignatureNamespace = "git"
+)
+
+// CommitKeys are the keys trusted to sign the commits of a git repo.
+type CommitKeys struct {
+	// GPG is an armored or binary PGP public keyring.
+	GPG []byte
+	// SSH is a list of SSH public keys in the authorized_keys format.
+	SSH []byte
+}
+
+// verify verifies that the given revision of the git repo was signed with one of the trusted commit keys, if any.
+func (g *git) verify(rev string) error {
+	if g.commitKeys == nil {
+		return nil
+	}
+
+	commit, err := g.gitOutput("-C", g.Directory, "rev-parse", "--verify", rev+"^{commit}")
+	if err != nil {
+		return fmt.Errorf("verify commit failure: %w", err)
+	}
+
+	raw, err := g.rawCommit(commit)
+	if err != nil {
+		return fmt.Errorf("verify commit failure: %w", err)
+	}
+
+	signer, err := verifyCommitSignature([]byte(raw+"\n"), *g.commitKeys)
+	if err != nil {
+		return fmt.Errorf("commit %s is not trusted: %w", commit, err)
+	}
+	logrus.Debugf("verified git commit %s of %s signed by %s", commit, g.URL, signer)
+	return nil
+}
+
+// verifyCommitSignature verifies the signature of a raw git commit object, as printed by git cat-file.
+func verifyCommitSignature(raw []byte, keys CommitKeys) (string, error) {
+	payload, signature := splitCommitSignature(raw)
+	if len(signature) == 0 {
+		return "", errors.New("commit is not signed")
+	}
+
+	if block, _ := pem.Decode(signature); block != nil && block.Type == "SSH SIGNATURE" {
+		return verifySSHSignature(payload, block.Bytes, keys.SSH)
+	}
+	return verifyGPGSignature(payload, signature, keys.GPG)
+}
+
+// splitCommitSignature returns the raw commit without its signature header, and the signature.
+func splitCommitSignature(raw []byte) ([]byte, []byte) {
+	var (
+		payload   bytes.Buffer
+		signature bytes.Buffer
+		inHeaders = true
+		inSig     = false
+	)
+
+	lines := strings.SplitAfter(string(raw), "\n")
+	for _, line := range lines {
+		if inHeaders && line == "\n" {
+			inHeaders = false
+		}
+		if inSig && strings.HasPrefix(line, " ") {
+			signature.WriteString(strings.TrimPrefix(line, " "))
+			continue
+		}
+		inSig = false
+		if inHeaders && (strings.HasPrefix(line, "gpgsig ") || strings.HasPrefix(line, "gpgsig-sha256 ")) {
+			_, value, _ := strings.Cut(line, " ")
+			signature.WriteString(value)
+			inSig = true
+			continue
+		}
+		payload.WriteString(line)
+	}
+
+	return payload.Bytes(), signature.Bytes()
+}
+
+func verifyGPGSignature(payload, signature, keyring []byte) (string, error) {
+	if len(keyring) == 0 {
+		return "", errors.New("commit has a GPG signature, but no GPG keys are trusted")
+	}
+
+	var (
+		ring openpgp.EntityList
+		err  error
+	)
+	if block, armorErr := armor.Decode(bytes.NewReader(keyring)); armorErr == nil {
+		ring, err = openpgp.ReadKeyRing(block.Body)
+	} else {
+		ring, err = openpgp.ReadKeyRing(bytes.NewReader(keyring))
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read GPG keys: %w", err)
+	}
+
+	entity, err := openpgp.CheckArmoredDetachedSignature(ring, bytes.NewReader(payload), bytes.NewReader(signature))
+	if err != nil {
+		return "", fmt.Errorf("invalid GPG signature: %w", err)
+	}
+
+	var names []string
+	for name := range entity.Identities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) > 0 {
+		return names[0], nil
+	}
+	return fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint), nil
+}
+
+// sshSignature is the blob of an SSH signature, after the magic preamble.
+// See https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.sshsig
+type sshSignature struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Signature     []byte
+}
+
+// sshSignedData is the data that is signed by an SSH signature, after the magic preamble.
+type sshSignedData struct {
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Hash          []byte
+}
+
+func verifySSHSignature(payload, blob, authorizedKeys []byte) (string, error) {
+	if len(authorizedKeys) == 0 {
+		return "", errors.New("commit has an SSH signature, but no SSH keys are trusted")
+	}
+
+	if !bytes.HasPrefix(blob, []byte(sshSignatureMagic)) {
+		return "", errors.New("invalid SSH signature preamble")
+	}
+	sig := sshSignature{}
+	if err := ssh.Unmarshal(blob[len(sshSignatureMagic):], &sig); err != nil {
+		return "", fmt.Errorf("invalid SSH signature: %w", err)
+	}
+	if sig.Version != 1 {
+		return "", fmt.Errorf("unsupported SSH signature version %d", sig.Version)
+	}
+	if sig.Namespace != sshSignatureNamespace {
+		return "", fmt.Errorf("unexpected SSH signature namespace %q", sig.Namespace)
+	}
+
+	publicKey, err := ssh.ParsePublicKey(sig.PublicKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid SSH signature public key: %w", err)
+	}
+	comment, trusted := trustedSSHKey(publicKey, authorizedKeys)
+	if !trusted {
+		return "", fmt.Errorf("SSH key %s is not trusted", ssh.FingerprintSHA256(publicKey))
+	}
+
+	var h hash.Hash
+	switch sig.HashAlgorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return "", fmt.Errorf("unsupported SSH signature hash algorithm %q", sig.HashAlgorithm)
+	}
+	h.Write(payload)
+
+	signature := &ssh.Signature{}
+	if err := ssh.Unmarshal(sig.Signature, signature); err != nil {
+		return "", fmt.Errorf("invalid SSH signature: %w", err)
+	}
+	signed := append([]byte(sshSignatureMagic), ssh.Marshal(sshSignedData{
+		Namespace:     sig.Namespace,
+		Reserved:      sig.Reserved,
+		HashAlgorithm: sig.HashAlgorithm,
+		Hash:          h.Sum(nil),
+	})...)
+	if err := publicKey.Verify(signed, signature); err != nil {
+		return "", fmt.Errorf("invalid SSH signature: %w", err)
+	}
+
+	if comment != "" {
+		return comment, nil
+	}
+	return ssh.FingerprintSHA256(publicKey), nil
+}
+
+// trustedSSHKey returns the comment of the given key if it is one of the authorized keys.
+func trustedSSHKey(key ssh.PublicKey, authorizedKeys []byte) (string, bool) {
+	rest := authorizedKeys
+	for len(rest) > 0 {
+		authorizedKey, comment, _, next, err := ssh.ParseAuthorizedKey(rest)
+		if err != nil {
+			return "", false
+		}
+		if bytes.Equal(authorizedKey.Marshal(), key.Marshal()) {
+			return comment, true
+		}
+		rest = next
+	}
+	return "", false
+}
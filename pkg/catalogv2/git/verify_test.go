@@ -0,0 +1,148 @@
+package git
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/pem"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"       //nolint:staticcheck
+	"golang.org/x/crypto/openpgp/armor" //nolint:staticcheck
+	"golang.org/x/crypto/ssh"
+)
+
+const testCommitHeaders = "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n" +
+	"author Test <test@example.com> 1700000000 +0000\n" +
+	"committer Test <test@example.com> 1700000000 +0000\n"
+
+const testCommitMessage = "\nadd charts\n"
+
+func signedCommit(signature string) []byte {
+	lines := strings.Split(strings.TrimSuffix(signature, "\n"), "\n")
+	return []byte(testCommitHeaders + "gpgsig " + strings.Join(lines, "\n ") + "\n" + testCommitMessage)
+}
+
+func Test_verifyCommitSignatureGPG(t *testing.T) {
+	trusted, err := openpgp.NewEntity("Trusted", "", "trusted@example.com", nil)
+	require.NoError(t, err)
+	untrusted, err := openpgp.NewEntity("Untrusted", "", "untrusted@example.com", nil)
+	require.NoError(t, err)
+
+	keyring := &bytes.Buffer{}
+	w, err := armor.Encode(keyring, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, trusted.Serialize(w))
+	require.NoError(t, w.Close())
+
+	sign := func(entity *openpgp.Entity) []byte {
+		sig := &bytes.Buffer{}
+		require.NoError(t, openpgp.ArmoredDetachSign(sig, entity, strings.NewReader(testCommitHeaders+testCommitMessage), nil))
+		return signedCommit(sig.String())
+	}
+
+	signer, err := verifyCommitSignature(sign(trusted), CommitKeys{GPG: keyring.Bytes()})
+	require.NoError(t, err)
+	assert.Equal(t, "Trusted <trusted@example.com>", signer)
+
+	_, err = verifyCommitSignature(sign(untrusted), CommitKeys{GPG: keyring.Bytes()})
+	assert.Error(t, err)
+
+	tampered := bytes.Replace(sign(trusted), []byte("add charts"), []byte("add chart"), 1)
+	_, err = verifyCommitSignature(tampered, CommitKeys{GPG: keyring.Bytes()})
+	assert.Error(t, err)
+
+	_, err = verifyCommitSignature([]byte(testCommitHeaders+testCommitMessage), CommitKeys{GPG: keyring.Bytes()})
+	assert.EqualError(t, err, "commit is not signed")
+}
+
+func Test_verifyCommitSignatureSSH(t *testing.T) {
+	newSigner := func() ssh.Signer {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		signer, err := ssh.NewSignerFromKey(key)
+		require.NoError(t, err)
+		return signer
+	}
+	trusted := newSigner()
+	untrusted := newSigner()
+
+	sign := func(signer ssh.Signer, namespace string) []byte {
+		h := sha512.Sum512([]byte(testCommitHeaders + testCommitMessage))
+		signed := append([]byte(sshSignatureMagic), ssh.Marshal(sshSignedData{
+			Namespace:     namespace,
+			HashAlgorithm: "sha512",
+			Hash:          h[:],
+		})...)
+		sig, err := signer.Sign(rand.Reader, signed)
+		require.NoError(t, err)
+		blob := append([]byte(sshSignatureMagic), ssh.Marshal(sshSignature{
+			Version:       1,
+			PublicKey:     signer.PublicKey().Marshal(),
+			Namespace:     namespace,
+			HashAlgorithm: "sha512",
+			Signature:     ssh.Marshal(sig),
+		})...)
+		return signedCommit(string(pem.EncodeToMemory(&pem.Block{Type: "SSH SIGNATURE", Bytes: blob})))
+	}
+
+	authorizedKeys := []byte("# trusted signers\n")
+	authorizedKeys = append(authorizedKeys, bytes.TrimSpace(ssh.MarshalAuthorizedKey(trusted.PublicKey()))...)
+	authorizedKeys = append(authorizedKeys, []byte(" release@example.com\n")...)
+
+	signer, err := verifyCommitSignature(sign(trusted, "git"), CommitKeys{SSH: authorizedKeys})
+	require.NoError(t, err)
+	assert.Equal(t, "release@example.com", signer)
+
+	_, err = verifyCommitSignature(sign(untrusted, "git"), CommitKeys{SSH: authorizedKeys})
+	assert.ErrorContains(t, err, "is not trusted")
+
+	_, err = verifyCommitSignature(sign(trusted, "file"), CommitKeys{SSH: authorizedKeys})
+	assert.ErrorContains(t, err, "namespace")
+
+	_, err = verifyCommitSignature(sign(trusted, "git"), CommitKeys{})
+	assert.ErrorContains(t, err, "no SSH keys are trusted")
+}
+
+func Test_resetUntrustedCommit(t *testing.T) {
+	origin := t.TempDir()
+	commit := func() {
+		t.Helper()
+		cmd := exec.Command("git", "-C", origin, "-c", "user.name=Test", "-c", "user.email=test@example.com",
+			"commit", "--allow-empty", "--no-gpg-sign", "-m", "add charts")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	out, err := exec.Command("git", "init", "-q", origin).CombinedOutput()
+	require.NoError(t, err, string(out))
+	commit()
+
+	dir := filepath.Join(t.TempDir(), "repo")
+	out, err = exec.Command("git", "clone", "-q", origin, dir).CombinedOutput()
+	require.NoError(t, err, string(out))
+	commit()
+
+	g, err := newGit(dir, origin, &Options{CommitKeys: &CommitKeys{}})
+	require.NoError(t, err)
+	head, err := g.currentCommit()
+	require.NoError(t, err)
+
+	err = g.fetchAndReset("HEAD")
+	assert.ErrorContains(t, err, "is not trusted")
+	// the fetched commit was verified before the working tree was reset to it
+	current, err := g.currentCommit()
+	require.NoError(t, err)
+	assert.Equal(t, head, current)
+
+	g.commitKeys = nil
+	require.NoError(t, g.fetchAndReset("HEAD"))
+	current, err = g.currentCommit()
+	require.NoError(t, err)
+	assert.NotEqual(t, head, current)
+}
@@ -0,0 +1,138 @@
+package helm
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/rancher/rancher/pkg/api/steve/catalog/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	DiffCreate    = "create"
+	DiffUpdate    = "update"
+	DiffDelete    = "delete"
+	DiffUnchanged = "unchanged"
+
+	redacted = "[redacted]"
+	// resourcePolicyAnnotation is set to "keep" on the resources that helm does not delete with their release.
+	resourcePolicyAnnotation = "helm.sh/resource-policy"
+)
+
+// DiffManifests compares the objects of the manifest of the current release with the objects of a rendered
+// manifest, and returns the change that installing the rendered manifest would make to each resource. Resources
+// of the current release that are not part of the rendered manifest are deleted, unless helm is told to keep them.
+func DiffManifests(current, desired []*unstructured.Unstructured) ([]types.ResourceDiff, error) {
+	currentByKey := map[string]*unstructured.Unstructured{}
+	for _, obj := range current {
+		currentByKey[objectKey(obj)] = obj
+	}
+
+	result := []types.ResourceDiff{}
+	for _, obj := range desired {
+		diff := newResourceDiff(obj)
+		old, ok := currentByKey[objectKey(obj)]
+		delete(currentByKey, objectKey(obj))
+		if !ok {
+			diff.Action = DiffCreate
+			result = append(result, diff)
+			continue
+		}
+
+		oldContent, err := normalize(driftObject(old))
+		if err != nil {
+			return nil, err
+		}
+		newContent, err := normalize(driftObject(obj))
+		if err != nil {
+			return nil, err
+		}
+		changes(&diff.Changes, "", oldContent, newContent, obj.GetKind() == "Secret")
+		diff.Action = DiffUnchanged
+		if len(diff.Changes) > 0 {
+			diff.Action = DiffUpdate
+		}
+		result = append(result, diff)
+	}
+
+	for _, obj := range current {
+		if _, ok := currentByKey[objectKey(obj)]; !ok || obj.GetAnnotations()[resourcePolicyAnnotation] == "keep" {
+			continue
+		}
+		diff := newResourceDiff(obj)
+		diff.Action = DiffDelete
+		result = append(result, diff)
+	}
+
+	return result, nil
+}
+
+func newResourceDiff(obj *unstructured.Unstructured) types.ResourceDiff {
+	diff := types.ResourceDiff{
+		Name:      obj.GetName(),
+		Namespace: obj.GetNamespace(),
+	}
+	diff.APIVersion, diff.Kind = obj.GroupVersionKind().ToAPIVersionAndKind()
+	return diff
+}
+
+func objectKey(obj *unstructured.Unstructured) string {
+	gvk := obj.GroupVersionKind()
+	return strings.Join([]string{gvk.Group, gvk.Kind, obj.GetNamespace(), obj.GetName()}, "/")
+}
+
+// changes appends the changes between the old and new value at the given path. The values of secret data are
+// redacted.
+func changes(result *[]types.FieldChange, path string, old, new interface{}, secret bool) {
+	oldMap, oldIsMap := old.(map[string]interface{})
+	newMap, newIsMap := new.(map[string]interface{})
+	if (oldIsMap || old == nil) && (newIsMap || new == nil) && (oldIsMap || newIsMap) {
+		keys := map[string]bool{}
+		for k := range oldMap {
+			keys[k] = true
+		}
+		for k := range newMap {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			changes(result, joinPath(path, k), oldMap[k], newMap[k], secret)
+		}
+		return
+	}
+
+	oldSlice, oldIsSlice := old.([]interface{})
+	newSlice, newIsSlice := new.([]interface{})
+	if oldIsSlice && newIsSlice && len(oldSlice) == len(newSlice) {
+		for i := range oldSlice {
+			changes(result, fmt.Sprintf("%s[%d]", path, i), oldSlice[i], newSlice[i], secret)
+		}
+		return
+	}
+
+	if reflect.DeepEqual(old, new) {
+		return
+	}
+	change := types.FieldChange{
+		Path: path,
+		Old:  old,
+		New:  new,
+	}
+	if secret && strings.HasPrefix(path, "data.") {
+		change.Old, change.New = redactedValue(old), redactedValue(new)
+	}
+	*result = append(*result, change)
+}
+
+func redactedValue(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	return redacted
+}
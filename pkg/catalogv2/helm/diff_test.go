@@ -0,0 +1,125 @@
+package helm
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/rancher/rancher/pkg/api/steve/catalog/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestRenderAndDiffManifests(t *testing.T) {
+	testChart := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "test", Version: "1.0.0"},
+		Values:   map[string]interface{}{"replicas": 1, "password": "secret"},
+		Templates: []*chart.File{
+			{Name: "templates/deployment.yaml", Data: []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Release.Name }}
+spec:
+  replicas: {{ .Values.replicas }}
+`)},
+			{Name: "templates/secret.yaml", Data: []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: {{ .Release.Name }}
+stringData:
+  password: {{ .Values.password }}
+`)},
+		},
+	}
+	archive, err := chartutil.Save(testChart, t.TempDir())
+	require.NoError(t, err)
+	chartData, err := os.ReadFile(archive)
+	require.NoError(t, err)
+
+	opts := RenderOptions{
+		ReleaseName:  "release",
+		Namespace:    "test-ns",
+		KubeVersion:  "v1.31.0",
+		IsNamespaced: func(schema.GroupVersionKind) bool { return true },
+	}
+	desired, err := Render(context.Background(), chartData, map[string]interface{}{"replicas": 3, "password": "changed"}, opts)
+	require.NoError(t, err)
+	require.Len(t, desired, 2)
+	assert.Equal(t, "test-ns", desired[0].GetNamespace())
+
+	current, err := objectsFromManifest("test-ns", `
+apiVersion: v1
+kind: Secret
+metadata:
+  name: release
+stringData:
+  password: secret
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: release
+spec:
+  replicas: 1
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: removed
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: kept
+  annotations:
+    helm.sh/resource-policy: keep
+`, opts.IsNamespaced)
+	require.NoError(t, err)
+
+	diffs, err := DiffManifests(current, desired)
+	require.NoError(t, err)
+
+	byKind := map[string]types.ResourceDiff{}
+	for _, diff := range diffs {
+		byKind[diff.Kind+"/"+diff.Name] = diff
+	}
+	require.Len(t, byKind, 3)
+
+	deployment := byKind["Deployment/release"]
+	assert.Equal(t, DiffUpdate, deployment.Action)
+	assert.Equal(t, []types.FieldChange{{Path: "spec.replicas", Old: float64(1), New: float64(3)}}, deployment.Changes)
+
+	secret := byKind["Secret/release"]
+	assert.Equal(t, DiffUpdate, secret.Action)
+	assert.Equal(t, []types.FieldChange{{Path: "data.password", Old: redacted, New: redacted}}, secret.Changes)
+
+	assert.Equal(t, DiffDelete, byKind["ConfigMap/removed"].Action)
+
+	diffs, err = DiffManifests(nil, desired)
+	require.NoError(t, err)
+	for _, diff := range diffs {
+		assert.Equal(t, DiffCreate, diff.Action)
+	}
+}
+
+func TestRenderLimits(t *testing.T) {
+	_, err := Render(context.Background(), make([]byte, maxRenderChartSize+1), nil, RenderOptions{})
+	assert.ErrorContains(t, err, "exceeds the limit")
+
+	// renderings wait for a free slot until the context is done
+	for i := 0; i < maxConcurrentRenders; i++ {
+		renderSlots <- struct{}{}
+	}
+	defer func() {
+		for i := 0; i < maxConcurrentRenders; i++ {
+			<-renderSlots
+		}
+	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Render(ctx, []byte("chart"), nil, RenderOptions{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
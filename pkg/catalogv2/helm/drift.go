@@ -0,0 +1,182 @@
+package helm
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// maxDriftedFields is the number of drifted fields reported per resource.
+const maxDriftedFields = 20
+
+// DriftedFields compares the live object with the desired object of a release manifest and returns
+// the paths of the fields of the desired object whose live value differs. Fields that are only set
+// on the live object, such as defaults and the status, are not considered drift. Only the labels
+// and annotations of the object metadata are compared.
+func DriftedFields(desired, live *unstructured.Unstructured) ([]string, error) {
+	want, err := normalize(driftObject(desired))
+	if err != nil {
+		return nil, err
+	}
+	got, err := normalize(driftObject(live))
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []string
+	diff("", want, got, &fields)
+	if len(fields) > maxDriftedFields {
+		fields = fields[:maxDriftedFields]
+	}
+	return fields, nil
+}
+
+// CorrectionPatch returns a JSON merge patch that resets the fields of a live object to the desired
+// object of a release manifest.
+func CorrectionPatch(desired *unstructured.Unstructured) ([]byte, error) {
+	return json.Marshal(driftObject(desired))
+}
+
+// driftObject returns the content of the object that is compared for drift. The data of secrets is
+// merged with their string data, as the latter is only ever written.
+func driftObject(obj *unstructured.Unstructured) map[string]interface{} {
+	result := map[string]interface{}{}
+	for k, v := range obj.Object {
+		switch k {
+		case "apiVersion", "kind", "status", "metadata", "stringData":
+		default:
+			result[k] = v
+		}
+	}
+
+	metadata := map[string]interface{}{}
+	if labels := obj.GetLabels(); len(labels) > 0 {
+		metadata["labels"] = labels
+	}
+	if annotations := obj.GetAnnotations(); len(annotations) > 0 {
+		metadata["annotations"] = annotations
+	}
+	if len(metadata) > 0 {
+		result["metadata"] = metadata
+	}
+
+	if stringData, ok := obj.Object["stringData"].(map[string]interface{}); ok && obj.GetKind() == "Secret" {
+		data, _ := result["data"].(map[string]interface{})
+		merged := make(map[string]interface{}, len(data)+len(stringData))
+		for k, v := range data {
+			merged[k] = v
+		}
+		for k, v := range stringData {
+			if s, ok := v.(string); ok {
+				merged[k] = base64.StdEncoding.EncodeToString([]byte(s))
+			}
+		}
+		result["data"] = merged
+	}
+
+	return result
+}
+
+// normalize converts the object to its JSON representation so that values decoded from YAML and
+// from the API server are of the same types.
+func normalize(obj map[string]interface{}) (interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var result interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// diff appends the paths of the values of want that are not found in got to fields.
+func diff(path string, want, got interface{}, fields *[]string) {
+	if isEmpty(want) {
+		return
+	}
+
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok && got != nil {
+			*fields = append(*fields, path)
+			return
+		}
+		keys := make([]string, 0, len(w))
+		for k := range w {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			diff(joinPath(path, k), w[k], g[k], fields)
+		}
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok || len(g) != len(w) {
+			*fields = append(*fields, path)
+			return
+		}
+		for i := range w {
+			diff(fmt.Sprintf("%s[%d]", path, i), w[i], g[i], fields)
+		}
+	default:
+		if !equalValues(want, got) {
+			*fields = append(*fields, path)
+		}
+	}
+}
+
+// equalValues compares two scalar values. Quantities are compared by value, as the API server
+// stores them in their canonical form.
+func equalValues(want, got interface{}) bool {
+	if reflect.DeepEqual(want, got) {
+		return true
+	}
+	wantQuantity, ok := toQuantity(want)
+	if !ok {
+		return false
+	}
+	gotQuantity, ok := toQuantity(got)
+	return ok && wantQuantity.Cmp(gotQuantity) == 0
+}
+
+func toQuantity(v interface{}) (resource.Quantity, bool) {
+	var s string
+	switch t := v.(type) {
+	case string:
+		s = t
+	case float64:
+		s = strconv.FormatFloat(t, 'f', -1, 64)
+	default:
+		return resource.Quantity{}, false
+	}
+	q, err := resource.ParseQuantity(s)
+	return q, err == nil
+}
+
+func isEmpty(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(t) == 0
+	case []interface{}:
+		return len(t) == 0
+	}
+	return false
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,83 @@
+package helm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestDriftedFields(t *testing.T) {
+	objects, err := objectsFromManifest("default", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+  labels:
+    app: test
+  annotations: {}
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: test
+        image: test:1.0
+        resources:
+          limits:
+            cpu: 500m
+            memory: 1Gi
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: test
+stringData:
+  password: secret
+`, func(schema.GroupVersionKind) bool { return true })
+	require.NoError(t, err)
+	require.Len(t, objects, 2)
+	deployment, secret := objects[0], objects[1]
+	assert.Equal(t, "default", deployment.GetNamespace())
+
+	live := deployment.DeepCopy()
+	live.SetResourceVersion("10")
+	live.SetLabels(map[string]string{"app": "test", "app.kubernetes.io/managed-by": "Helm"})
+	require.NoError(t, unstructured.SetNestedField(live.Object, map[string]interface{}{"readyReplicas": int64(2)}, "status"))
+	require.NoError(t, unstructured.SetNestedField(live.Object, int64(2), "spec", "replicas"))
+	require.NoError(t, unstructured.SetNestedField(live.Object, "Always", "spec", "template", "spec", "restartPolicy"))
+	containers, _, _ := unstructured.NestedSlice(live.Object, "spec", "template", "spec", "containers")
+	require.NoError(t, unstructured.SetNestedField(containers[0].(map[string]interface{}), "0.5", "resources", "limits", "cpu"))
+	require.NoError(t, unstructured.SetNestedSlice(live.Object, containers, "spec", "template", "spec", "containers"))
+
+	fields, err := DriftedFields(deployment, live)
+	require.NoError(t, err)
+	assert.Empty(t, fields, "defaults, status and canonical quantities are not drift")
+
+	require.NoError(t, unstructured.SetNestedField(live.Object, int64(5), "spec", "replicas"))
+	containers[0].(map[string]interface{})["image"] = "test:2.0"
+	require.NoError(t, unstructured.SetNestedSlice(live.Object, containers, "spec", "template", "spec", "containers"))
+	live.SetLabels(nil)
+
+	fields, err = DriftedFields(deployment, live)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"metadata.labels.app", "spec.replicas", "spec.template.spec.containers[0].image"}, fields)
+
+	liveSecret := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]interface{}{"name": "test", "namespace": "default"},
+		"data":       map[string]interface{}{"password": "c2VjcmV0"},
+		"type":       "Opaque",
+	}}
+	fields, err = DriftedFields(secret, liveSecret)
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+
+	liveSecret.Object["data"] = map[string]interface{}{"password": "b3RoZXI="}
+	fields, err = DriftedFields(secret, liveSecret)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"data.password"}, fields)
+}
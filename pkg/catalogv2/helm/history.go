@@ -0,0 +1,50 @@
+package helm
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/rancher/rancher/pkg/api/steve/catalog/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// History receives the helm3 release secrets of a release and returns its revisions, newest first.
+// Secrets that are not helm3 releases are skipped.
+func History(secrets []corev1.Secret) ([]types.ReleaseRevision, error) {
+	revisions := []types.ReleaseRevision{}
+	for i := range secrets {
+		if !isHelm3(secrets[i].Labels) {
+			continue
+		}
+
+		release, err := decodeHelm3(string(secrets[i].Data["release"]))
+		if errors.Is(err, ErrNotHelmRelease) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		revision := types.ReleaseRevision{
+			Revision: release.Version,
+		}
+		if release.Info != nil {
+			revision.Status = string(release.Info.Status)
+			revision.Description = release.Info.Description
+			if !release.Info.LastDeployed.IsZero() {
+				revision.Updated = &metav1.Time{Time: release.Info.LastDeployed.Time}
+			}
+		}
+		if release.Chart != nil && release.Chart.Metadata != nil {
+			revision.ChartName = release.Chart.Metadata.Name
+			revision.ChartVersion = release.Chart.Metadata.Version
+			revision.AppVersion = release.Chart.Metadata.AppVersion
+		}
+		revisions = append(revisions, revision)
+	}
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision > revisions[j].Revision
+	})
+	return revisions, nil
+}
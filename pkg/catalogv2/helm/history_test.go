@@ -0,0 +1,68 @@
+package helm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+	helmtime "helm.sh/helm/v3/pkg/time"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHistory(t *testing.T) {
+	deployed := time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)
+	releaseSecret := func(version int, status release.Status) corev1.Secret {
+		rel := &release.Release{
+			Name:    "test",
+			Version: version,
+			Info: &release.Info{
+				Status:       status,
+				Description:  "revision description",
+				LastDeployed: helmtime.Time{Time: deployed.Add(time.Duration(version) * time.Hour)},
+			},
+			Chart: &chart.Chart{
+				Metadata: &chart.Metadata{Name: "test-chart", Version: "1.0.0", AppVersion: "2.0.0"},
+			},
+		}
+		data, err := json.Marshal(rel)
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		_, err = gz.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, gz.Close())
+		return corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{"owner": "helm", "name": "test"},
+			},
+			Data: map[string][]byte{
+				"release": []byte(base64.StdEncoding.EncodeToString(buf.Bytes())),
+			},
+		}
+	}
+
+	revisions, err := History([]corev1.Secret{
+		releaseSecret(1, release.StatusSuperseded),
+		releaseSecret(3, release.StatusDeployed),
+		{ObjectMeta: metav1.ObjectMeta{Name: "not-a-release"}},
+		releaseSecret(2, release.StatusSuperseded),
+	})
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+
+	assert.Equal(t, []int{3, 2, 1}, []int{revisions[0].Revision, revisions[1].Revision, revisions[2].Revision})
+	assert.Equal(t, "deployed", revisions[0].Status)
+	assert.Equal(t, "test-chart", revisions[0].ChartName)
+	assert.Equal(t, "1.0.0", revisions[0].ChartVersion)
+	assert.Equal(t, "2.0.0", revisions[0].AppVersion)
+	assert.Equal(t, "revision description", revisions[0].Description)
+	assert.True(t, deployed.Add(3*time.Hour).Equal(revisions[0].Updated.Time))
+}
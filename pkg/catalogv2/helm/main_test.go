@@ -0,0 +1,17 @@
+package helm
+
+import (
+	"os"
+	"testing"
+
+	"github.com/docker/docker/pkg/reexec"
+)
+
+func TestMain(m *testing.M) {
+	// charts are rendered by the test binary executed as the render command
+	RegisterRenderCommand()
+	if reexec.Init() {
+		return
+	}
+	os.Exit(m.Run())
+}
@@ -10,6 +10,7 @@
 
 	"github.com/rancher/wrangler/v3/pkg/data"
 	"github.com/rancher/wrangler/v3/pkg/yaml"
+	"helm.sh/helm/v3/pkg/release"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	meta2 "k8s.io/apimachinery/pkg/api/meta"
@@ -124,6 +125,52 @@ func getReleaseDataAndKind(obj runtime.Object) (string, error) {
 // uses the wrangler yaml functions to convert it to a list of runtime.Objects.
 // It then converts each object to a v1.ReleaseResource and returns that list.
 func resourcesFromManifest(namespace string, manifest string, isNamespaced IsNamespaced) (result []v1.ReleaseResource, err error) {
+	objs, err := objectsFromManifest(namespace, manifest, isNamespaced)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, obj := range objs {
+		r := v1.ReleaseResource{
+			Name:      obj.GetName(),
+			Namespace: obj.GetNamespace(),
+		}
+		r.APIVersion, r.Kind = obj.GroupVersionKind().ToAPIVersionAndKind()
+		result = append(result, r)
+	}
+
+	return result, nil
+}
+
+// ManifestObjects returns the objects of the rendered manifest of the helm 3 release stored in the given
+// secret. Namespaced objects without a namespace are defaulted to the namespace of the release.
+func ManifestObjects(secret *corev1.Secret, isNamespaced IsNamespaced) ([]*unstructured.Unstructured, error) {
+	release, err := DecodeRelease(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return ReleaseObjects(release, isNamespaced)
+}
+
+// ReleaseObjects returns the objects of the rendered manifest of the given helm 3 release. Namespaced objects
+// without a namespace are defaulted to the namespace of the release.
+func ReleaseObjects(release *release.Release, isNamespaced IsNamespaced) ([]*unstructured.Unstructured, error) {
+	return objectsFromManifest(release.Namespace, release.Manifest, isNamespaced)
+}
+
+// DecodeRelease returns the helm 3 release stored in the given secret.
+func DecodeRelease(secret *corev1.Secret) (*release.Release, error) {
+	if secret == nil || !isHelm3(secret.Labels) {
+		return nil, ErrNotHelmRelease
+	}
+
+	return decodeHelm3(string(secret.Data["release"]))
+}
+
+// objectsFromManifest receives the rendered manifest template as a string and converts it to
+// a list of unstructured objects, defaulting the namespace of the namespaced ones.
+func objectsFromManifest(namespace string, manifest string, isNamespaced IsNamespaced) (result []*unstructured.Unstructured, err error) {
 	objs, err := yaml.ToObjects(bytes.NewReader([]byte(manifest)))
 	if err != nil {
 		return nil, err
@@ -134,16 +181,20 @@ func resourcesFromManifest(namespace string, manifest string, isNamespaced IsNam
 		if err != nil {
 			return nil, err
 		}
-		r := v1.ReleaseResource{
-			Name:      meta.GetName(),
-			Namespace: meta.GetNamespace(),
-		}
 		gvk := obj.GetObjectKind().GroupVersionKind()
-		if isNamespaced != nil && isNamespaced(gvk) && r.Namespace == "" {
-			r.Namespace = namespace
+		if isNamespaced != nil && isNamespaced(gvk) && meta.GetNamespace() == "" {
+			meta.SetNamespace(namespace)
 		}
-		r.APIVersion, r.Kind = gvk.ToAPIVersionAndKind()
-		result = append(result, r)
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			data, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+			if err != nil {
+				return nil, err
+			}
+			u = &unstructured.Unstructured{Object: data}
+			u.SetGroupVersionKind(gvk)
+		}
+		result = append(result, u)
 	}
 
 	return result, nil
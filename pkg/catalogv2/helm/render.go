@@ -0,0 +1,168 @@
+package helm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/sirupsen/logrus"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// maxRenderChartSize is the size of the largest chart archive that is rendered.
+	maxRenderChartSize = 10 << 20
+	// maxRenderManifestSize is the size of the largest manifest a chart may render to.
+	maxRenderManifestSize = 10 << 20
+	// renderTimeout bounds the time spent rendering a chart, after which the process rendering it is killed.
+	renderTimeout = 30 * time.Second
+	// maxConcurrentRenders is the number of charts that are rendered at the same time.
+	maxConcurrentRenders = 2
+	// renderCommand is the name the Rancher binary is executed with to render a chart.
+	renderCommand = "helm-render"
+)
+
+// renderSlots limits the number of charts being rendered.
+var renderSlots = make(chan struct{}, maxConcurrentRenders)
+
+// RenderOptions are the options used to render a chart as it would be installed in a cluster.
+type RenderOptions struct {
+	ReleaseName string
+	Namespace   string
+	// KubeVersion is the version of the cluster, used for the .Capabilities.KubeVersion of the templates.
+	KubeVersion string
+	// APIVersions are the API versions available in the cluster, used for the .Capabilities.APIVersions of the templates.
+	APIVersions []string
+	// IsNamespaced is used to default the namespace of the rendered namespaced objects.
+	IsNamespaced IsNamespaced `json:"-"`
+}
+
+// renderRequest is sent to the process rendering a chart on its standard input.
+type renderRequest struct {
+	Chart   []byte                 `json:"chart"`
+	Values  map[string]interface{} `json:"values,omitempty"`
+	Options RenderOptions          `json:"options"`
+}
+
+// RegisterRenderCommand registers the command rendering charts in a child process of Rancher.
+func RegisterRenderCommand() {
+	reexec.Register(renderCommand, renderMain)
+}
+
+// Render renders the given chart archive with the given values as helm would install it, without contacting
+// the cluster, and returns the objects of the rendered manifest. Hooks are not part of the manifest.
+//
+// Charts are rendered in a child process of Rancher, which is killed if the rendering takes too long or the context
+// is done. The size of the chart and of its manifest and the number of charts rendered at the same time are limited.
+func Render(ctx context.Context, chartData []byte, values map[string]interface{}, opts RenderOptions) ([]*unstructured.Unstructured, error) {
+	if len(chartData) > maxRenderChartSize {
+		return nil, fmt.Errorf("chart archive of %d bytes exceeds the limit of %d bytes for rendering", len(chartData), maxRenderChartSize)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, renderTimeout)
+	defer cancel()
+
+	select {
+	case renderSlots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("too many charts are being rendered: %w", ctx.Err())
+	}
+	defer func() { <-renderSlots }()
+
+	request, err := json.Marshal(renderRequest{Chart: chartData, Values: values, Options: opts})
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := runRender(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return objectsFromManifest(opts.Namespace, manifest, opts.IsNamespaced)
+}
+
+// runRender runs the render command with the given request and returns the rendered manifest.
+func runRender(ctx context.Context, request []byte) (string, error) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := reexec.Command(renderCommand)
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return "", errors.New(msg)
+			}
+			return "", fmt.Errorf("rendering the chart failed: %w", err)
+		}
+		return stdout.String(), nil
+	case <-ctx.Done():
+		_ = cmd.Process.Kill()
+		<-done
+		return "", fmt.Errorf("rendering the chart did not complete: %w", ctx.Err())
+	}
+}
+
+// renderMain renders the chart of the request read from the standard input and writes its manifest to the standard
+// output, or the error to the standard error.
+func renderMain() {
+	request := renderRequest{}
+	err := json.NewDecoder(io.LimitReader(os.Stdin, 2*maxRenderChartSize)).Decode(&request)
+	var manifest string
+	if err == nil {
+		manifest, err = render(request.Chart, request.Values, request.Options)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Fprint(os.Stdout, manifest)
+}
+
+func render(chartData []byte, values map[string]interface{}, opts RenderOptions) (string, error) {
+	chart, err := loader.LoadArchive(bytes.NewReader(chartData))
+	if err != nil {
+		return "", err
+	}
+
+	install := action.NewInstall(&action.Configuration{Log: logrus.Debugf})
+	install.DryRun = true
+	install.ClientOnly = true
+	install.Replace = true
+	install.ReleaseName = opts.ReleaseName
+	install.Namespace = opts.Namespace
+	install.APIVersions = opts.APIVersions
+	if opts.KubeVersion != "" {
+		install.KubeVersion, err = chartutil.ParseKubeVersion(opts.KubeVersion)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	release, err := install.Run(chart, values)
+	if err != nil {
+		return "", err
+	}
+	if len(release.Manifest) > maxRenderManifestSize {
+		return "", fmt.Errorf("rendered manifest of %d bytes exceeds the limit of %d bytes", len(release.Manifest), maxRenderManifestSize)
+	}
+	return release.Manifest, nil
+}
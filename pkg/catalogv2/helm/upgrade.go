@@ -0,0 +1,183 @@
+package helm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/robfig/cron"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+const (
+	UpgradeRangePatch = "patch"
+	UpgradeRangeMinor = "minor"
+	UpgradeRangeMajor = "major"
+
+	defaultUpgradeWindowDuration = time.Hour
+	defaultUpgradeHealthTimeout  = 10 * time.Minute
+)
+
+// UpgradePolicy returns the upgrade policy of the given app, or nil if it is not upgraded automatically.
+func UpgradePolicy(app *v1.App) (*v1.AppUpgradePolicy, error) {
+	value, ok := app.Annotations[v1.AppUpgradePolicyAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	policy := &v1.AppUpgradePolicy{}
+	if strings.TrimSpace(value) != "" {
+		if err := json.Unmarshal([]byte(value), policy); err != nil {
+			return nil, fmt.Errorf("invalid upgrade policy: %w", err)
+		}
+	}
+	if policy.Window != nil {
+		if _, err := cron.ParseStandard(policy.Window.Schedule); err != nil {
+			return nil, fmt.Errorf("invalid upgrade window schedule %q: %w", policy.Window.Schedule, err)
+		}
+	}
+	return policy, nil
+}
+
+// UpgradeHealthTimeout returns how long the resources of a release have to become ready after an upgrade.
+func UpgradeHealthTimeout(policy *v1.AppUpgradePolicy) time.Duration {
+	if policy.HealthTimeout != nil && policy.HealthTimeout.Duration > 0 {
+		return policy.HealthTimeout.Duration
+	}
+	return defaultUpgradeHealthTimeout
+}
+
+// UpgradeCandidate returns the newest version of the given chart in the index that is newer than the current version
+// and allowed by the upgrade policy, or nil if there is none.
+func UpgradeCandidate(policy *v1.AppUpgradePolicy, index *repo.IndexFile, chartName, currentVersion string) (*repo.ChartVersion, error) {
+	current, err := semver.NewVersion(currentVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version %q of chart %s: %w", currentVersion, chartName, err)
+	}
+
+	constraint, err := upgradeConstraint(policy.VersionRange, current)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		candidate        *repo.ChartVersion
+		candidateVersion *semver.Version
+	)
+	for _, chartVersion := range index.Entries[chartName] {
+		version, err := semver.NewVersion(chartVersion.Version)
+		if err != nil || !version.GreaterThan(current) {
+			continue
+		}
+		if version.Prerelease() != "" && !policy.IncludePrerelease {
+			continue
+		}
+		// constraints never match pre-release versions unless they are compared without them
+		compared := version
+		if version.Prerelease() != "" {
+			withoutPrerelease, err := version.SetPrerelease("")
+			if err != nil {
+				continue
+			}
+			compared = &withoutPrerelease
+		}
+		if !constraint.Check(compared) {
+			continue
+		}
+		if candidateVersion == nil || version.GreaterThan(candidateVersion) {
+			candidate, candidateVersion = chartVersion, version
+		}
+	}
+	return candidate, nil
+}
+
+// upgradeConstraint returns the constraint of the versions a release of the given version can be upgraded to.
+func upgradeConstraint(versionRange string, current *semver.Version) (*semver.Constraints, error) {
+	switch versionRange {
+	case "", UpgradeRangePatch:
+		versionRange = fmt.Sprintf("~%d.%d.%d", current.Major(), current.Minor(), current.Patch())
+	case UpgradeRangeMinor:
+		versionRange = fmt.Sprintf("^%d.%d.%d", current.Major(), current.Minor(), current.Patch())
+		if current.Major() == 0 {
+			// caret ranges of 0.x versions only allow patch upgrades
+			versionRange = fmt.Sprintf(">=%s, <1.0.0", current.String())
+		}
+	case UpgradeRangeMajor:
+		versionRange = ">=" + current.String()
+	}
+
+	constraint, err := semver.NewConstraint(versionRange)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upgrade version range %q: %w", versionRange, err)
+	}
+	return constraint, nil
+}
+
+// UpgradeWindowOpen returns whether the given upgrade window is open at the given time. If it is not, the time at
+// which it opens next is returned.
+func UpgradeWindowOpen(window *v1.UpgradeWindow, now time.Time) (bool, time.Time, error) {
+	if window == nil {
+		return true, now, nil
+	}
+
+	schedule, err := cron.ParseStandard(window.Schedule)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	duration := defaultUpgradeWindowDuration
+	if window.Duration != nil && window.Duration.Duration > 0 {
+		duration = window.Duration.Duration
+	}
+
+	now = now.UTC()
+	// the window is open if it started less than its duration ago
+	if start := schedule.Next(now.Add(-duration)); !start.After(now) {
+		return true, start, nil
+	}
+	return false, schedule.Next(now), nil
+}
+
+// CarriedValues returns the values at the given dotted paths of the values of a release, which are carried forward
+// to the next version of its chart. All the values are carried forward if no path is given.
+func CarriedValues(values map[string]interface{}, paths []string) map[string]interface{} {
+	if len(paths) == 0 {
+		return values
+	}
+
+	result := map[string]interface{}{}
+	for _, path := range paths {
+		keys := strings.Split(path, ".")
+		value, ok := lookupValue(values, keys)
+		if !ok {
+			continue
+		}
+		target := result
+		for _, key := range keys[:len(keys)-1] {
+			next, ok := target[key].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				target[key] = next
+			}
+			target = next
+		}
+		target[keys[len(keys)-1]] = value
+	}
+	return result
+}
+
+func lookupValue(values map[string]interface{}, keys []string) (interface{}, bool) {
+	var value interface{} = values
+	for _, key := range keys {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
@@ -0,0 +1,123 @@
+package helm
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpgradeCandidate(t *testing.T) {
+	index := repo.NewIndexFile()
+	for _, version := range []string{"1.2.3", "1.2.4", "1.2.5-rc1", "1.3.0", "1.4.2", "2.0.0", "not-semver"} {
+		index.Entries["test"] = append(index.Entries["test"], &repo.ChartVersion{
+			Metadata: &chart.Metadata{Name: "test", Version: version},
+		})
+	}
+
+	tests := []struct {
+		name    string
+		policy  v1.AppUpgradePolicy
+		current string
+		want    string
+		wantErr string
+	}{
+		{name: "patch by default", current: "1.2.3", want: "1.2.4"},
+		{name: "patch with pre-release", policy: v1.AppUpgradePolicy{IncludePrerelease: true}, current: "1.2.3", want: "1.2.5-rc1"},
+		{name: "minor", policy: v1.AppUpgradePolicy{VersionRange: "minor"}, current: "1.2.3", want: "1.4.2"},
+		{name: "major", policy: v1.AppUpgradePolicy{VersionRange: "major"}, current: "1.2.3", want: "2.0.0"},
+		{name: "constraint", policy: v1.AppUpgradePolicy{VersionRange: "~1.3"}, current: "1.2.3", want: "1.3.0"},
+		{name: "up to date", policy: v1.AppUpgradePolicy{VersionRange: "major"}, current: "2.0.0"},
+		{name: "invalid constraint", policy: v1.AppUpgradePolicy{VersionRange: "sometimes"}, current: "1.2.3", wantErr: "invalid upgrade version range"},
+		{name: "invalid current version", current: "latest", wantErr: "invalid version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidate, err := UpgradeCandidate(&tt.policy, index, "test", tt.current)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.want == "" {
+				assert.Nil(t, candidate)
+				return
+			}
+			require.NotNil(t, candidate)
+			assert.Equal(t, tt.want, candidate.Version)
+		})
+	}
+}
+
+func TestUpgradeWindowOpen(t *testing.T) {
+	// Saturdays from 2AM to 4AM
+	window := &v1.UpgradeWindow{Schedule: "0 2 * * 6", Duration: &metav1.Duration{Duration: 2 * time.Hour}}
+	saturday := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		wantOpen bool
+		wantNext time.Time
+	}{
+		{name: "before", now: saturday.Add(time.Hour), wantNext: saturday.Add(2 * time.Hour)},
+		{name: "start", now: saturday.Add(2 * time.Hour), wantOpen: true},
+		{name: "during", now: saturday.Add(3 * time.Hour), wantOpen: true},
+		{name: "after", now: saturday.Add(5 * time.Hour), wantNext: saturday.Add(7*24*time.Hour + 2*time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			open, next, err := UpgradeWindowOpen(window, tt.now)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantOpen, open)
+			if !tt.wantOpen {
+				assert.Equal(t, tt.wantNext, next)
+			}
+		})
+	}
+
+	open, _, err := UpgradeWindowOpen(nil, saturday)
+	require.NoError(t, err)
+	assert.True(t, open)
+}
+
+func TestUpgradePolicy(t *testing.T) {
+	app := &v1.App{}
+	policy, err := UpgradePolicy(app)
+	require.NoError(t, err)
+	assert.Nil(t, policy)
+
+	app.Annotations = map[string]string{v1.AppUpgradePolicyAnnotation: ""}
+	policy, err = UpgradePolicy(app)
+	require.NoError(t, err)
+	assert.Equal(t, &v1.AppUpgradePolicy{}, policy)
+	assert.Equal(t, 10*time.Minute, UpgradeHealthTimeout(policy))
+
+	app.Annotations[v1.AppUpgradePolicyAnnotation] = `{"versionRange":"minor","window":{"schedule":"0 2 * * 6"},"healthTimeout":"5m"}`
+	policy, err = UpgradePolicy(app)
+	require.NoError(t, err)
+	assert.Equal(t, "minor", policy.VersionRange)
+	assert.Equal(t, 5*time.Minute, UpgradeHealthTimeout(policy))
+
+	app.Annotations[v1.AppUpgradePolicyAnnotation] = `{"window":{"schedule":"every saturday"}}`
+	_, err = UpgradePolicy(app)
+	assert.ErrorContains(t, err, "invalid upgrade window schedule")
+}
+
+func TestCarriedValues(t *testing.T) {
+	values := map[string]interface{}{
+		"image":    map[string]interface{}{"repository": "test", "tag": "1.0"},
+		"replicas": 3,
+	}
+
+	assert.Equal(t, values, CarriedValues(values, nil))
+	assert.Equal(t, map[string]interface{}{
+		"image":    map[string]interface{}{"repository": "test"},
+		"replicas": 3,
+	}, CarriedValues(values, []string{"image.repository", "replicas", "missing.path"}))
+}
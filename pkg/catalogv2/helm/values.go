@@ -0,0 +1,362 @@
+package helm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rancher/rancher/pkg/api/steve/catalog/types"
+	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/xeipuuv/gojsonschema"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"sigs.k8s.io/yaml"
+)
+
+// ValuesError is returned when the values of a chart are invalid or break a values policy of its repository.
+type ValuesError struct {
+	Errors []types.ValuesFieldError
+}
+
+func (e *ValuesError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fieldError := range e.Errors {
+		message := fieldError.Message
+		if fieldError.Path != "" {
+			message = fieldError.Path + ": " + message
+		}
+		messages = append(messages, message)
+	}
+	return "invalid values: " + strings.Join(messages, "; ")
+}
+
+// question is an entry of the questions.yaml file of a chart, which describes the values the UI asks for.
+type question struct {
+	Variable          string     `json:"variable"`
+	Label             string     `json:"label,omitempty"`
+	Type              string     `json:"type,omitempty"`
+	Required          bool       `json:"required,omitempty"`
+	Options           []string   `json:"options,omitempty"`
+	Min               *int64     `json:"min,omitempty"`
+	Max               *int64     `json:"max,omitempty"`
+	MinLength         *int       `json:"min_length,omitempty"`
+	MaxLength         *int       `json:"max_length,omitempty"`
+	ValidChars        string     `json:"valid_chars,omitempty"`
+	InvalidChars      string     `json:"invalid_chars,omitempty"`
+	ShowIf            string     `json:"show_if,omitempty"`
+	ShowSubquestionIf string     `json:"show_subquestion_if,omitempty"`
+	Subquestions      []question `json:"subquestions,omitempty"`
+}
+
+// ValidateValues validates the given values of a chart, merged with its default values, against the JSON schemas
+// of the chart and its dependencies, its questions.yaml file and the given values policies of its repository.
+// A ValuesError with all the invalid values is returned if they are not valid.
+func ValidateValues(chartData []byte, values map[string]interface{}, policies []v1.ValuesPolicy) error {
+	chrt, err := loader.LoadArchive(bytes.NewReader(chartData))
+	if err != nil {
+		return err
+	}
+
+	coalesced, err := chartutil.CoalesceValues(chrt, values)
+	if err != nil {
+		return err
+	}
+	// round trip through JSON so that the values have the types of JSON documents
+	normalized := map[string]interface{}{}
+	data, err := json.Marshal(coalesced)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return err
+	}
+
+	var fieldErrors []types.ValuesFieldError
+	schemaErrors, err := validateSchemas(chrt, normalized, "")
+	if err != nil {
+		return err
+	}
+	fieldErrors = append(fieldErrors, schemaErrors...)
+
+	questionErrors, err := validateQuestions(chrt, normalized)
+	if err != nil {
+		return err
+	}
+	fieldErrors = append(fieldErrors, questionErrors...)
+
+	fieldErrors = append(fieldErrors, validatePolicies(chrt.Name(), normalized, policies)...)
+
+	if len(fieldErrors) > 0 {
+		for i := range fieldErrors {
+			fieldErrors[i].Chart = chrt.Name()
+		}
+		return &ValuesError{Errors: fieldErrors}
+	}
+	return nil
+}
+
+// validateSchemas validates the values of a chart and of its dependencies against their values.schema.json file.
+func validateSchemas(chrt *chart.Chart, values map[string]interface{}, prefix string) ([]types.ValuesFieldError, error) {
+	var result []types.ValuesFieldError
+	if len(chrt.Schema) > 0 {
+		schema, err := yaml.YAMLToJSON(chrt.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("invalid values schema of chart %s: %w", chrt.Name(), err)
+		}
+		validation, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(schema), gojsonschema.NewGoLoader(values))
+		if err != nil {
+			return nil, fmt.Errorf("invalid values schema of chart %s: %w", chrt.Name(), err)
+		}
+		for _, resultError := range validation.Errors() {
+			field := resultError.Field()
+			if field == gojsonschema.STRING_CONTEXT_ROOT {
+				field = ""
+			}
+			result = append(result, types.ValuesFieldError{
+				Path:    joinPath(prefix, field),
+				Message: resultError.Description(),
+			})
+		}
+	}
+
+	for _, dependency := range chrt.Dependencies() {
+		dependencyValues, _ := values[dependency.Name()].(map[string]interface{})
+		if dependencyValues == nil {
+			dependencyValues = map[string]interface{}{}
+		}
+		dependencyErrors, err := validateSchemas(dependency, dependencyValues, joinPath(prefix, dependency.Name()))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, dependencyErrors...)
+	}
+	return result, nil
+}
+
+// validateQuestions validates the values of a chart against the questions of its questions.yaml file.
+func validateQuestions(chrt *chart.Chart, values map[string]interface{}) ([]types.ValuesFieldError, error) {
+	var questions struct {
+		Questions []question `json:"questions"`
+	}
+	for _, file := range chrt.Files {
+		if file.Name != "questions.yaml" && file.Name != "questions.yml" {
+			continue
+		}
+		if err := yaml.Unmarshal(file.Data, &questions); err != nil {
+			return nil, fmt.Errorf("invalid questions of chart %s: %w", chrt.Name(), err)
+		}
+	}
+
+	var result []types.ValuesFieldError
+	for _, q := range questions.Questions {
+		result = append(result, validateQuestion(q, values)...)
+	}
+	return result, nil
+}
+
+func validateQuestion(q question, values map[string]interface{}) []types.ValuesFieldError {
+	if q.Variable == "" || !conditionMet(q.ShowIf, values) {
+		return nil
+	}
+
+	fieldError := func(format string, args ...interface{}) []types.ValuesFieldError {
+		return []types.ValuesFieldError{{Path: q.Variable, Message: fmt.Sprintf(format, args...)}}
+	}
+
+	value, ok := lookupValue(values, strings.Split(q.Variable, "."))
+	if !ok || value == nil || value == "" {
+		if q.Required {
+			return fieldError("a value is required")
+		}
+		return nil
+	}
+
+	switch q.Type {
+	case "boolean":
+		if _, err := strconv.ParseBool(valueString(value)); err != nil {
+			return fieldError("%q is not a boolean", valueString(value))
+		}
+	case "int":
+		n, err := strconv.ParseInt(valueString(value), 10, 64)
+		if err != nil {
+			return fieldError("%q is not an integer", valueString(value))
+		}
+		if q.Min != nil && n < *q.Min {
+			return fieldError("%d is less than the minimum %d", n, *q.Min)
+		}
+		if q.Max != nil && n > *q.Max {
+			return fieldError("%d is greater than the maximum %d", n, *q.Max)
+		}
+	case "enum":
+		if len(q.Options) > 0 && !containsString(q.Options, valueString(value)) {
+			return fieldError("%q is not one of %s", valueString(value), strings.Join(q.Options, ", "))
+		}
+	case "string", "password", "multiline", "hostname", "":
+		s := valueString(value)
+		if q.MinLength != nil && len(s) < *q.MinLength {
+			return fieldError("the value is shorter than %d characters", *q.MinLength)
+		}
+		if q.MaxLength != nil && len(s) > *q.MaxLength {
+			return fieldError("the value is longer than %d characters", *q.MaxLength)
+		}
+		if q.ValidChars != "" {
+			if re, err := regexp.Compile(q.ValidChars); err == nil && !re.MatchString(s) {
+				return fieldError("the value contains invalid characters")
+			}
+		}
+		if q.InvalidChars != "" {
+			if re, err := regexp.Compile(q.InvalidChars); err == nil && re.MatchString(s) {
+				return fieldError("the value contains invalid characters")
+			}
+		}
+	}
+
+	if len(q.Subquestions) == 0 || q.ShowSubquestionIf != valueString(value) {
+		return nil
+	}
+	var result []types.ValuesFieldError
+	for _, subquestion := range q.Subquestions {
+		result = append(result, validateQuestion(subquestion, values)...)
+	}
+	return result
+}
+
+// conditionMet evaluates the show_if condition of a question, such as "enabled=true&&mode=advanced".
+func conditionMet(condition string, values map[string]interface{}) bool {
+	if condition == "" {
+		return true
+	}
+	for _, clause := range strings.Split(condition, "&&") {
+		key, expected, ok := strings.Cut(clause, "=")
+		if !ok {
+			continue
+		}
+		value, _ := lookupValue(values, strings.Split(strings.TrimSpace(key), "."))
+		if valueString(value) != strings.TrimSpace(expected) {
+			return false
+		}
+	}
+	return true
+}
+
+// validatePolicies validates the values of the given chart against the values policies that apply to it.
+func validatePolicies(chartName string, values map[string]interface{}, policies []v1.ValuesPolicy) []types.ValuesFieldError {
+	var result []types.ValuesFieldError
+	for _, policy := range policies {
+		if len(policy.Charts) > 0 && !containsString(policy.Charts, chartName) {
+			continue
+		}
+		for _, rule := range policy.Rules {
+			for _, fieldError := range validateRule(rule, values) {
+				fieldError.Policy = policy.Name
+				if rule.Message != "" {
+					fieldError.Message = rule.Message
+				}
+				result = append(result, fieldError)
+			}
+		}
+	}
+	return result
+}
+
+func validateRule(rule v1.ValuesRule, values map[string]interface{}) []types.ValuesFieldError {
+	keys := strings.Split(rule.Path, ".")
+	var result []types.ValuesFieldError
+
+	if rule.Required {
+		// the keys after the last wildcard must be set in every value matched by the wildcard
+		wildcard := 0
+		for i, key := range keys {
+			if key == "*" {
+				wildcard = i + 1
+			}
+		}
+		parents := map[string]interface{}{"": values}
+		if wildcard > 0 {
+			parents = matchValues(values, keys[:wildcard], "")
+		}
+		for parentPath, parent := range parents {
+			if len(matchValues(parent, keys[wildcard:], parentPath)) == 0 {
+				path := parentPath
+				if wildcard < len(keys) {
+					path = joinPath(parentPath, strings.Join(keys[wildcard:], "."))
+				}
+				result = append(result, types.ValuesFieldError{Path: path, Message: "a value is required"})
+			}
+		}
+	}
+
+	for path, value := range matchValues(values, keys, "") {
+		s := valueString(value)
+		if containsString(rule.Forbidden, s) {
+			result = append(result, types.ValuesFieldError{Path: path, Message: fmt.Sprintf("the value %q is forbidden", s)})
+		} else if len(rule.Allowed) > 0 && !containsString(rule.Allowed, s) {
+			result = append(result, types.ValuesFieldError{Path: path, Message: fmt.Sprintf("the value %q is not one of %s", s, strings.Join(rule.Allowed, ", "))})
+		}
+	}
+	return result
+}
+
+// matchValues returns the non-empty values matching the given keys by their path. A "*" key matches all the keys of
+// a map or all the items of a list.
+func matchValues(value interface{}, keys []string, path string) map[string]interface{} {
+	if len(keys) == 0 {
+		if isEmpty(value) {
+			return nil
+		}
+		return map[string]interface{}{path: value}
+	}
+
+	result := map[string]interface{}{}
+	add := func(child interface{}, childPath string) {
+		for k, v := range matchValues(child, keys[1:], childPath) {
+			result[k] = v
+		}
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if keys[0] == "*" {
+			for key, child := range v {
+				add(child, joinPath(path, key))
+			}
+		} else if child, ok := v[keys[0]]; ok {
+			add(child, joinPath(path, keys[0]))
+		}
+	case []interface{}:
+		if keys[0] == "*" {
+			for i, child := range v {
+				add(child, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+	return result
+}
+
+// valueString returns the YAML representation of a scalar value.
+func valueString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
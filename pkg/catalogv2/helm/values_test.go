@@ -0,0 +1,125 @@
+package helm
+
+import (
+	"os"
+	"testing"
+
+	"github.com/rancher/rancher/pkg/api/steve/catalog/types"
+	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+func TestValidateValues(t *testing.T) {
+	testChart := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "test", Version: "1.0.0"},
+		Raw: []*chart.File{
+			{Name: chartutil.ValuesfileName, Data: []byte(`replicas: 1
+mode: simple
+hostNetwork: false
+containers:
+  app:
+    resources:
+      limits:
+        cpu: 100m
+`)},
+		},
+		Schema: []byte(`{
+  "type": "object",
+  "properties": {
+    "replicas": {"type": "integer", "minimum": 1}
+  }
+}`),
+		Files: []*chart.File{
+			{Name: "questions.yaml", Data: []byte(`questions:
+- variable: mode
+  type: enum
+  options: [simple, advanced]
+  show_subquestion_if: advanced
+  subquestions:
+  - variable: workers
+    type: int
+    required: true
+    min: 1
+- variable: name
+  type: string
+  max_length: 5
+`)},
+		},
+	}
+	archive, err := chartutil.Save(testChart, t.TempDir())
+	require.NoError(t, err)
+	chartData, err := os.ReadFile(archive)
+	require.NoError(t, err)
+
+	policies := []v1.ValuesPolicy{
+		{
+			Name: "restricted",
+			Rules: []v1.ValuesRule{
+				{Path: "hostNetwork", Forbidden: []string{"true"}, Message: "host network is not allowed"},
+				{Path: "containers.*.resources.limits", Required: true},
+			},
+		},
+		{
+			Name:   "other-chart",
+			Charts: []string{"other"},
+			Rules:  []v1.ValuesRule{{Path: "replicas", Allowed: []string{"5"}}},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		values map[string]interface{}
+		want   []types.ValuesFieldError
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name:   "schema",
+			values: map[string]interface{}{"replicas": 0},
+			want:   []types.ValuesFieldError{{Chart: "test", Path: "replicas", Message: "Must be greater than or equal to 1"}},
+		},
+		{
+			name:   "question options",
+			values: map[string]interface{}{"mode": "expert"},
+			want:   []types.ValuesFieldError{{Chart: "test", Path: "mode", Message: `"expert" is not one of simple, advanced`}},
+		},
+		{
+			name:   "subquestions",
+			values: map[string]interface{}{"mode": "advanced"},
+			want:   []types.ValuesFieldError{{Chart: "test", Path: "workers", Message: "a value is required"}},
+		},
+		{
+			name:   "question length",
+			values: map[string]interface{}{"name": "too-long"},
+			want:   []types.ValuesFieldError{{Chart: "test", Path: "name", Message: "the value is longer than 5 characters"}},
+		},
+		{
+			name:   "forbidden value",
+			values: map[string]interface{}{"hostNetwork": true},
+			want:   []types.ValuesFieldError{{Chart: "test", Path: "hostNetwork", Message: "host network is not allowed", Policy: "restricted"}},
+		},
+		{
+			name: "required value",
+			values: map[string]interface{}{"containers": map[string]interface{}{
+				"sidecar": map[string]interface{}{"image": "sidecar"},
+			}},
+			want: []types.ValuesFieldError{{Chart: "test", Path: "containers.sidecar.resources.limits", Message: "a value is required", Policy: "restricted"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateValues(chartData, tt.values, policies)
+			if len(tt.want) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			var valuesErr *ValuesError
+			require.ErrorAs(t, err, &valuesErr)
+			assert.Equal(t, tt.want, valuesErr.Errors)
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package helmop
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/rancher/apiserver/pkg/types"
+	types2 "github.com/rancher/rancher/pkg/api/steve/catalog/types"
+	"github.com/rancher/rancher/pkg/catalogv2/helm"
+	"helm.sh/helm/v3/pkg/release"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DryRun gets the install or upgrade commands using the given namespace, name and options, renders their charts
+// with their values and returns how the resources of the targeted releases would change. No operation is created
+// and nothing is written to the cluster: the deployed releases are read with the permissions of the user making
+// the request.
+func (s *Operations) DryRun(apiRequest *types.APIRequest, namespace, name string, options io.Reader, upgrade bool) (*types2.ChartDryRunOutput, error) {
+	getCommands := s.getInstallCommand
+	if upgrade {
+		getCommands = s.getUpgradeCommand
+	}
+	status, cmds, err := getCommands(namespace, name, options)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := s.cg.K8sInterface(apiRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	renderOptions, err := clusterRenderOptions(client.Discovery())
+	if err != nil {
+		return nil, err
+	}
+
+	output := &types2.ChartDryRunOutput{
+		Releases: []types2.ReleaseDiff{},
+	}
+	for _, cmd := range cmds {
+		diff, err := diffRelease(apiRequest.Context(), client, status.Namespace, cmd, renderOptions)
+		if err != nil {
+			return nil, err
+		}
+		output.Releases = append(output.Releases, *diff)
+	}
+
+	return output, nil
+}
+
+// diffRelease renders the chart of the given command and compares it with the deployed release it targets.
+func diffRelease(ctx context.Context, client kubernetes.Interface, namespace string, cmd Command, opts helm.RenderOptions) (*types2.ReleaseDiff, error) {
+	chartName, chartVersion, resetValues := commandChart(cmd)
+	result := &types2.ReleaseDiff{
+		Release:   cmd.ReleaseName,
+		Namespace: namespace,
+		ChartName: chartName,
+		Version:   chartVersion,
+	}
+	if result.Release == "" {
+		// the name of a generated release is not known until it is installed
+		result.Release = chartName
+	}
+
+	current, err := deployedRelease(ctx, client, namespace, result.Release)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]interface{}{}
+	if len(cmd.Values) > 0 {
+		if err := json.Unmarshal(cmd.Values, &values); err != nil {
+			return nil, err
+		}
+	} else if current != nil && !resetValues {
+		// helm reuses the values of the deployed release when upgrading without values
+		values = current.Config
+	}
+
+	opts.ReleaseName = result.Release
+	opts.Namespace = namespace
+	desired, err := helm.Render(ctx, cmd.Chart, values, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var currentObjects []*unstructured.Unstructured
+	if current != nil {
+		if current.Chart != nil && current.Chart.Metadata != nil {
+			result.CurrentVersion = current.Chart.Metadata.Version
+		}
+		currentObjects, err = helm.ReleaseObjects(current, opts.IsNamespaced)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result.Resources, err = helm.DiffManifests(currentObjects, desired)
+	return result, err
+}
+
+// commandChart returns the name and version of the chart of the given command, and whether the values of the
+// deployed release must be reset.
+func commandChart(cmd Command) (string, string, bool) {
+	for _, arg := range cmd.ArgObjects {
+		switch chart := arg.(type) {
+		case types2.ChartInstall:
+			return chart.ChartName, chart.Version, false
+		case types2.ChartUpgrade:
+			return chart.ChartName, chart.Version, chart.ResetValues
+		}
+	}
+	return "", "", false
+}
+
+// deployedRelease returns the deployed revision of the given release, or nil if it is not installed.
+func deployedRelease(ctx context.Context, client kubernetes.Interface, namespace, name string) (*release.Release, error) {
+	secrets, err := client.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{
+			"owner":  "helm",
+			"name":   name,
+			"status": string(release.StatusDeployed),
+		}).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *release.Release
+	for i := range secrets.Items {
+		rel, err := helm.DecodeRelease(&secrets.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		if latest == nil || rel.Version > latest.Version {
+			latest = rel
+		}
+	}
+	return latest, nil
+}
+
+// clusterRenderOptions returns the version and API versions of the cluster, so that charts are rendered with the
+// capabilities they would be installed with.
+func clusterRenderOptions(client discovery.DiscoveryInterface) (helm.RenderOptions, error) {
+	version, err := client.ServerVersion()
+	if err != nil {
+		return helm.RenderOptions{}, err
+	}
+
+	_, resourceLists, err := client.ServerGroupsAndResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return helm.RenderOptions{}, err
+	}
+
+	namespaced := map[schema.GroupVersionKind]bool{}
+	apiVersions := map[string]bool{}
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			continue
+		}
+		apiVersions[gv.String()] = true
+		for _, resource := range resourceList.APIResources {
+			if strings.Contains(resource.Name, "/") {
+				continue
+			}
+			apiVersions[gv.String()+"/"+resource.Kind] = true
+			namespaced[gv.WithKind(resource.Kind)] = resource.Namespaced
+		}
+	}
+
+	opts := helm.RenderOptions{
+		KubeVersion: version.GitVersion,
+		IsNamespaced: func(gvk schema.GroupVersionKind) bool {
+			return namespaced[gvk]
+		},
+	}
+	for apiVersion := range apiVersions {
+		opts.APIVersions = append(opts.APIVersions, apiVersion)
+	}
+	return opts, nil
+}
@@ -18,6 +18,7 @@
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -27,9 +28,11 @@
 	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
 	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/catalogv2/content"
+	"github.com/rancher/rancher/pkg/catalogv2/helm"
 	catalogcontrollers "github.com/rancher/rancher/pkg/generated/controllers/catalog.cattle.io/v1"
 	namespaces "github.com/rancher/rancher/pkg/namespace"
 	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/rancher/pkg/tracing"
 	"github.com/rancher/steve/pkg/podimpersonation"
 	"github.com/rancher/steve/pkg/stores/proxy"
 	data2 "github.com/rancher/wrangler/v3/pkg/data"
@@ -38,10 +41,14 @@
 	rbacv1controllers "github.com/rancher/wrangler/v3/pkg/generated/controllers/rbac/v1"
 	"github.com/rancher/wrangler/v3/pkg/name"
 	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/client-go/kubernetes"
@@ -251,6 +258,65 @@ func (s *Operations) Install(ctx context.Context, user user.Info, namespace, nam
 	return s.createOperation(ctx, user, status, cmds, imageOverride)
 }
 
+// Rollback gets the rollback commands using the given namespace, name and options and gets the user information using the isApp flag as true.
+// Returns a catalog.Operation that represents the helm operation to be created
+func (s *Operations) Rollback(ctx context.Context, user user.Info, namespace, name string, options io.Reader, imageOverride string) (*catalog.Operation, error) {
+	status, cmds, err := s.getRollbackArgs(namespace, name, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if status.AutomaticCPTolerations {
+		status.Tolerations, err = s.AddCpTaintsToTolerations(status.Tolerations)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add tolerations for CP nodes: %w", err)
+		}
+	}
+
+	user, err = s.getUser(user, namespace, name, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.createOperation(ctx, user, status, cmds, imageOverride)
+}
+
+// History returns the revisions of the release of the given app. The release secrets are listed
+// with the permissions of the user making the request, so the history is only returned if the
+// user can read the secrets of the release namespace.
+func (s *Operations) History(apiRequest *types.APIRequest, namespace, name string) (*types2.ReleaseHistory, error) {
+	rel, err := s.apps.Get(namespace, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := s.cg.K8sInterface(apiRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets, err := client.CoreV1().Secrets(rel.Namespace).List(apiRequest.Context(), metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{
+			"owner": "helm",
+			"name":  rel.Spec.Name,
+		}).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := helm.History(secrets.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types2.ReleaseHistory{
+		Release:   rel.Spec.Name,
+		Namespace: rel.Namespace,
+		Revisions: revisions,
+	}, nil
+}
+
 // decodeParams decodes the request using its url and v1 group version into the target object
 func decodeParams(req *http.Request, target runtime.Object) error {
 	return podOptionsCodec.DecodeParameters(req.URL.Query(), corev1.SchemeGroupVersion, target)
@@ -429,6 +495,55 @@ func (s *Operations) getUninstallArgs(appNamespace, appName string, body io.Read
 	return status, Commands{cmd}, nil
 }
 
+// getRollbackArgs receives the app namespace, app name and body of the request.
+// Returns a rollback Command according to the input received and also returns the status of the operation that will be created
+// to run the command
+func (s *Operations) getRollbackArgs(appNamespace, appName string, body io.Reader) (catalog.OperationStatus, Commands, error) {
+	rel, err := s.apps.Get(appNamespace, appName, metav1.GetOptions{})
+	if err != nil {
+		return catalog.OperationStatus{}, nil, err
+	}
+
+	rollbackArgs := &types2.ChartRollbackAction{}
+	if err := json.NewDecoder(body).Decode(rollbackArgs); err != nil {
+		return catalog.OperationStatus{}, nil, err
+	}
+
+	if rollbackArgs.Revision < 0 {
+		return catalog.OperationStatus{}, nil, validation.ErrorCode{
+			Code:   validation.InvalidBodyContent.Code,
+			Status: validation.InvalidBodyContent.Status,
+		}
+	}
+	if rollbackArgs.Revision > 0 && rel.Spec.Version > 0 && rollbackArgs.Revision >= rel.Spec.Version {
+		return catalog.OperationStatus{}, nil, fmt.Errorf("revision %d is not a previous revision of release %s, the current revision is %d",
+			rollbackArgs.Revision, rel.Spec.Name, rel.Spec.Version)
+	}
+	if rollbackArgs.MaxHistory == 0 {
+		rollbackArgs.MaxHistory = 5
+	}
+
+	cmd := Command{
+		Operation: "rollback",
+		ArgObjects: []interface{}{
+			rollbackArgs,
+		},
+		ReleaseName:      rel.Spec.Name,
+		ReleaseNamespace: rel.Namespace,
+		Revision:         rollbackArgs.Revision,
+	}
+
+	status := catalog.OperationStatus{
+		Action:                 cmd.Operation,
+		Release:                rel.Spec.Name,
+		Namespace:              appNamespace,
+		Tolerations:            rollbackArgs.OperationTolerations,
+		AutomaticCPTolerations: rollbackArgs.AutomaticCPTolerations,
+	}
+
+	return status, Commands{cmd}, nil
+}
+
 // getUpgradeCommand receives the repository namespace and name and body of the request.
 // Returns the status of the operation that will be created and a list of Command to upgrade the charts received in the request
 func (s *Operations) getUpgradeCommand(repoNamespace, repoName string, body io.Reader) (catalog.OperationStatus, Commands, error) {
@@ -454,7 +569,16 @@ func (s *Operations) getUpgradeCommand(repoNamespace, repoName string, body io.R
 	}
 
 	for _, chartUpgrade := range upgradeArgs.Charts {
-		cmd, err := s.getChartCommand(repoNamespace, repoName, chartUpgrade.ChartName, chartUpgrade.Version, true, chartUpgrade.Annotations, chartUpgrade.Values)
+		// Helm reuses the values of the release for upgrades without values, unless they are reset to the defaults
+		applied := chartUpgrade.Values
+		if len(applied) == 0 && !chartUpgrade.ResetValues {
+			applied, err = s.releaseValues(status.Namespace, chartUpgrade.ReleaseName)
+			if err != nil {
+				return status, nil, err
+			}
+		}
+
+		cmd, err := s.getChartCommand(repoNamespace, repoName, chartUpgrade.ChartName, chartUpgrade.Version, true, chartUpgrade.Annotations, chartUpgrade.Values, applied)
 		if err != nil {
 			return status, nil, err
 		}
@@ -487,6 +611,7 @@ type Command struct {
 	Chart            []byte        // content of the chart file
 	ReleaseName      string        // name of the release
 	ReleaseNamespace string        // namespace of the release
+	Revision         int           // revision of the release to roll back to, the previous one if zero
 	Kustomize        bool          // flag to inform if it should use kustomize.sh
 }
 
@@ -581,6 +706,7 @@ func (c Command) renderArgs() ([]string, error) {
 	delete(dataMap, "projectId")
 	delete(dataMap, "operationTolerations")
 	delete(dataMap, "automaticCPTolerations")
+	delete(dataMap, "revision")
 	if v, ok := dataMap["disableOpenAPIValidation"]; ok {
 		delete(dataMap, "disableOpenAPIValidation")
 		dataMap["disableOpenapiValidation"] = v
@@ -618,6 +744,9 @@ func (c Command) renderArgs() ([]string, error) {
 	if c.ReleaseName != "" {
 		args = append(args, c.ReleaseName)
 	}
+	if c.Revision > 0 {
+		args = append(args, strconv.Itoa(c.Revision))
+	}
 	if len(c.Chart) > 0 {
 		args = append(args, filepath.Join(runPath, c.ChartFile))
 	}
@@ -731,10 +860,10 @@ func (s *Operations) enableKustomize(annotations map[string]string, upgrade bool
 	return true
 }
 
-// getChartCommand gets the chart based on the input, inject the annotations into it
+// getChartCommand gets the chart based on the input, verifies it against the verification policy of the repository, inject the annotations into it
 // and then creates and return a Command containing the name of the values file, name of the chart file, the chart data
-// and if the command should use kustomize.sh
-func (s *Operations) getChartCommand(namespace, name, chartName, chartVersion string, upgrade bool, annotations map[string]string, values map[string]interface{}) (Command, error) {
+// and if the command should use kustomize.sh. The applied values are the ones the release will have, which are validated.
+func (s *Operations) getChartCommand(namespace, name, chartName, chartVersion string, upgrade bool, annotations map[string]string, values, applied map[string]interface{}) (Command, error) {
 	chart, err := s.contentManager.Chart(namespace, name, chartName, chartVersion, true)
 	if err != nil {
 		return Command{}, err
@@ -745,6 +874,15 @@ func (s *Operations) getChartCommand(namespace, name, chartName, chartVersion st
 		return Command{}, err
 	}
 
+	// Verify the chart before the annotations are injected, as they change its digest
+	if _, err := s.contentManager.Verify(namespace, name, chartName, chartVersion, chartData); err != nil {
+		return Command{}, err
+	}
+
+	if err := s.validateValues(namespace, name, chartData, applied); err != nil {
+		return Command{}, err
+	}
+
 	chartData, err = injectAnnotation(chartData, annotations)
 	if err != nil {
 		return Command{}, err
@@ -770,6 +908,28 @@ func (s *Operations) getChartCommand(namespace, name, chartName, chartVersion st
 	return c, nil
 }
 
+// validateValues validates the values of a chart against its values schema, its questions and the values policies of
+// its repository, whether it is namespaced or not.
+func (s *Operations) validateValues(namespace, name string, chartData []byte, values map[string]interface{}) error {
+	repoSpec, err := s.getSpec(namespace, name, false)
+	if err != nil {
+		return err
+	}
+	return helm.ValidateValues(chartData, values, repoSpec.ValuesPolicies)
+}
+
+// releaseValues returns the values of the given release, which are reused by the upgrades without values. A release that
+// does not exist yet has none.
+func (s *Operations) releaseValues(namespace, name string) (map[string]interface{}, error) {
+	rel, err := s.apps.Get(namespace, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return rel.Spec.Values, nil
+}
+
 // getInstallCommand receives the repository namespace, name, and body of the request.
 // It decodes the request to get chart information for creating the `helm install` command
 // along with args. It returns the catalog.OperationStatus struct and a slice of commands
@@ -790,7 +950,7 @@ func (s *Operations) getInstallCommand(repoNamespace, repoName string, body io.R
 	// and then the actual helm chart. So, we need a for loop and the last index of the array
 	// would be the main chart.
 	for _, chartInstall := range installArgs.Charts {
-		cmd, err := s.getChartCommand(repoNamespace, repoName, chartInstall.ChartName, chartInstall.Version, false, chartInstall.Annotations, chartInstall.Values)
+		cmd, err := s.getChartCommand(repoNamespace, repoName, chartInstall.ChartName, chartInstall.Version, false, chartInstall.Annotations, chartInstall.Values, chartInstall.Values)
 		if err != nil {
 			return status, nil, err
 		}
@@ -843,7 +1003,14 @@ func namespace(ns string) string {
 // Uses the Operations.Impersonator and Operations.ops to do it.
 // Returns the created catalog.Operation struct
 func (s *Operations) createOperation(ctx context.Context, user user.Info, status catalog.OperationStatus, cmds Commands, imageOverride string) (*catalog.Operation, error) {
-	if status.Action != "uninstall" {
+	ctx, span := tracing.Tracer().Start(ctx, "helm operation", trace.WithAttributes(
+		attribute.String("helm.action", status.Action),
+		attribute.String("helm.release.namespace", status.Namespace),
+		attribute.String("helm.release.name", status.Release),
+	))
+	defer span.End()
+
+	if status.Action != "uninstall" && status.Action != "rollback" {
 		_, err := s.createNamespace(ctx, status.Namespace, status.ProjectID)
 		if err != nil {
 			return nil, err
@@ -880,8 +1047,10 @@ func (s *Operations) createOperation(ctx context.Context, user user.Info, status
 
 	op := &catalog.Operation{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:            pod.Name,
-			Namespace:       status.Namespace,
+			Name:      pod.Name,
+			Namespace: status.Namespace,
+			// the run of the pod is recorded as part of the trace of the operation once it completes
+			Annotations:     tracing.Annotations(ctx),
 			OwnerReferences: pod.OwnerReferences,
 		},
 	}
@@ -7,7 +7,13 @@
 	"strings"
 	"testing"
 
+	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 type testCase struct {
@@ -156,6 +162,24 @@ func Test_Render(t *testing.T) {
 			},
 			failMsg: "operation toleration test case failed",
 		},
+		{
+			commands: Commands{
+				Command{
+					Operation:   "rollback",
+					ReleaseName: "test7",
+					Revision:    3,
+					ArgObjects: []interface{}{types.ChartRollbackAction{
+						Revision:   3,
+						Wait:       true,
+						MaxHistory: 5,
+					}},
+				},
+			},
+			expected: map[string][]byte{
+				"operation000": []byte(strings.Join([]string{"rollback", "--history-max=5", "--wait=true", "test7", "3"}, "\x00")),
+			},
+			failMsg: "rollback test case failed",
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -327,3 +351,23 @@ func Test_mergeTolerations(t *testing.T) {
 		asserts.ElementsMatch(resp, t.expected, t.name)
 	}
 }
+
+func Test_releaseValues(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	apps := fake.NewMockClientInterface[*catalog.App, *catalog.AppList](ctrl)
+	apps.EXPECT().Get("cattle-monitoring-system", "rancher-monitoring", metav1.GetOptions{}).Return(&catalog.App{
+		Spec: catalog.ReleaseSpec{
+			Values: map[string]interface{}{"prometheus": map[string]interface{}{"enabled": true}},
+		},
+	}, nil)
+	apps.EXPECT().Get("cattle-monitoring-system", "missing", metav1.GetOptions{}).Return(nil, apierrors.NewNotFound(schema.GroupResource{Group: "catalog.cattle.io", Resource: "apps"}, "missing"))
+	s := &Operations{apps: apps}
+
+	values, err := s.releaseValues("cattle-monitoring-system", "rancher-monitoring")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"prometheus": map[string]interface{}{"enabled": true}}, values)
+
+	values, err = s.releaseValues("cattle-monitoring-system", "missing")
+	assert.NoError(t, err)
+	assert.Nil(t, values)
+}
@@ -11,6 +11,7 @@
 	"strings"
 	"time"
 
+	"github.com/opencontainers/go-digest"
 	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 	catalogv1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
@@ -18,6 +19,7 @@
 	"github.com/rancher/rancher/pkg/catalogv2/roundtripper"
 	"github.com/rancher/rancher/pkg/settings"
 	"github.com/sirupsen/logrus"
+	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
 	helmregistry "helm.sh/helm/v3/pkg/registry"
 	"helm.sh/helm/v3/pkg/repo"
@@ -35,6 +37,9 @@
 // maxHelmChartTar defines what is the max size of helm chart we support.
 const maxHelmChartTarSize int64 = 20 * 1024 * 1024 // 20 MiB
 
+// maxHelmChartConfigSize defines what is the max size of the config of a helm chart manifest we support.
+const maxHelmChartConfigSize int64 = 1024 * 1024 // 1 MiB
+
 // Client is an OCI client that manages Helm charts in OCI based Helm registries.
 type Client struct {
 	// URL refers to the OCI url provided by the user ie. dp.apps.rancher.io/charts/etcd:1.0.2
@@ -199,6 +204,67 @@ func (o *Client) fetchChart(orasRepository *remote.Repository) (string, error) {
 	return tempFile.Name(), fmt.Errorf("the oci artifact %s is not a helm chart. The OCI URL must contain only helm charts", ociURL)
 }
 
+// fetchChartMetadata fetches the metadata of the chart specified by the oras repository from the config of its
+// manifest, which Helm sets to the content of the Chart.yaml file of the chart, and returns it along with the digest
+// of the chart from the descriptor of its layer. This avoids downloading the whole chart to index it. No metadata is
+// returned if the config does not contain valid chart metadata.
+func (o *Client) fetchChartMetadata(orasRepository *remote.Repository) (*chart.Metadata, string, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ociURL := fmt.Sprintf("%s/%s:%s", o.registry, o.repository, o.tag)
+
+	manifestDesc, manifestReader, err := orasRepository.FetchReference(ctx, o.tag)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to fetch the manifest of %s: %w", ociURL, err)
+	}
+	defer manifestReader.Close()
+	if manifestDesc.MediaType != ocispecv1.MediaTypeImageManifest {
+		return nil, "", fmt.Errorf("the oci artifact %s is not a helm chart. The OCI URL must contain only helm charts", ociURL)
+	}
+	if manifestDesc.Size > maxHelmChartTarSize {
+		return nil, "", fmt.Errorf("the manifest of %s has size more than %d which is not supported", ociURL, maxHelmChartTarSize)
+	}
+	manifestBlob, err := content.ReadAll(manifestReader, manifestDesc)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to read the manifest of %s: %w", ociURL, err)
+	}
+	var manifest ocispecv1.Manifest
+	if err := json.Unmarshal(manifestBlob, &manifest); err != nil {
+		return nil, "", fmt.Errorf("unable to unmarshal manifest blob of %s: %w", ociURL, err)
+	}
+
+	if manifest.ArtifactType != helmregistry.ConfigMediaType && manifest.Config.MediaType != helmregistry.ConfigMediaType {
+		return nil, "", fmt.Errorf("the oci artifact %s is not a helm chart. The OCI URL must contain only helm charts", ociURL)
+	}
+	var chartLayer *ocispecv1.Descriptor
+	for i := range manifest.Layers {
+		if manifest.Layers[i].MediaType == helmregistry.ChartLayerMediaType {
+			chartLayer = &manifest.Layers[i]
+			break
+		}
+	}
+	if chartLayer == nil {
+		return nil, "", fmt.Errorf("the oci artifact %s is not a helm chart. The OCI URL must contain only helm charts", ociURL)
+	}
+	// The digest of a chart in a helm repo index is the sha256 digest of its tar
+	if chartLayer.Digest.Algorithm() != digest.SHA256 ||
+		manifest.Config.MediaType != helmregistry.ConfigMediaType || manifest.Config.Size > maxHelmChartConfigSize {
+		return nil, "", nil
+	}
+
+	configBlob, err := content.FetchAll(ctx, orasRepository, manifest.Config)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to fetch the config blob of %s: %w", ociURL, err)
+	}
+	metadata := &chart.Metadata{}
+	if err := json.Unmarshal(configBlob, metadata); err != nil || metadata.Validate() != nil {
+		logrus.Debugf("the config of %s does not contain valid chart metadata", ociURL)
+		return nil, "", nil
+	}
+
+	return metadata, chartLayer.Digest.Encoded(), nil
+}
+
 // getAuthClient creates an oras auth client that can be used
 // in creating an oras registry client or oras repository client.
 func (o *Client) SetAuthClient() error {
@@ -306,6 +372,21 @@ func (o *Client) addToIndex(indexFile *repo.IndexFile, chartTarFilePath string)
 	return nil
 }
 
+// addMetadataToIndex adds the helm chart entry with the given metadata and digest into the helm repo index provided.
+func (o *Client) addMetadataToIndex(indexFile *repo.IndexFile, metadata *chart.Metadata, digest string) error {
+	err := indexFile.MustAdd(metadata, fmt.Sprintf("oci://%s/%s:%s", o.registry, o.repository, o.tag), "", digest)
+	if err != nil {
+		return fmt.Errorf("failed to add entry %s to indexfile: %w", metadata.Name, err)
+	}
+
+	// For OCI repositories, the created date is not exposed and so Helm library defaults to time.Now()
+	// This is misleading and so emptying the created date field
+	indexFile.Entries[metadata.Name][len(indexFile.Entries[metadata.Name])-1].Created = time.Time{}
+
+	logrus.Debugf("Added chart %s %s to index", metadata.Name, metadata.Version)
+	return nil
+}
+
 // IsOrasRepository checks if the repository is actually an oci artifact or not.
 // The check is done by finding tags and if we find tags then it is valid repo.
 func (o *Client) IsOrasRepository() (bool, error) {
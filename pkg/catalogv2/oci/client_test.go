@@ -1,6 +1,7 @@
 package oci
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -16,6 +17,7 @@
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
 	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/registry"
 	"helm.sh/helm/v3/pkg/repo"
 	corev1 "k8s.io/api/core/v1"
@@ -40,7 +42,14 @@ func spinRegistry(layerSize int, chartMediaType, helmManifest bool, testcaseName
 		layerDesc.MediaType = ocispec.MediaTypeImageLayer
 	}
 
-	configBlob := []byte("config")
+	// Helm sets the config of the manifest to the content of the Chart.yaml file
+	helmChart, err := loader.LoadArchive(bytes.NewReader(helmChartTar))
+	assert.NoError(t, err)
+	configBlob, err := json.Marshal(helmChart.Metadata)
+	assert.NoError(t, err)
+	if testcaseName == "returns no metadata if the config is not chart metadata" {
+		configBlob = []byte("config")
+	}
 	configDesc := ocispec.Descriptor{
 		MediaType: registry.ConfigMediaType,
 		Digest:    digest.FromBytes(configBlob),
@@ -85,7 +94,7 @@ func spinRegistry(layerSize int, chartMediaType, helmManifest bool, testcaseName
 			t := `{"tags": ["0.1.0"]}`
 			w.Write([]byte(t))
 		case "/v2/testingchart/blobs/" + configDesc.Digest.String():
-			t.FailNow()
+			w.Write(configBlob)
 		case "/v2/testingchart/blobs/" + layerDesc.Digest.String():
 			http.ServeFile(w, r, testingHelmChartPath)
 		case "/v2/testingchart/manifests/0.1.0":
@@ -369,6 +378,59 @@ type testcase struct {
 	}
 }
 
+func TestFetchChartMetadata(t *testing.T) {
+	helmChartTar, err := os.ReadFile("../../../tests/testdata/testingchart-0.1.0.tgz")
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name             string
+		helmManifest     bool
+		expectedErr      string
+		expectedMetadata bool
+	}{
+		{
+			name:             "fetches the metadata of a chart from the config of its manifest",
+			helmManifest:     true,
+			expectedMetadata: true,
+		},
+		{
+			name:         "returns no metadata if the config is not chart metadata",
+			helmManifest: true,
+		},
+		{
+			name:         "if the oci artifact is not helm manifest mediatype, we throw an error",
+			helmManifest: false,
+			expectedErr:  "is not a helm chart",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := spinRegistry(0, true, tc.helmManifest, tc.name, t)
+			defer ts.Close()
+
+			ociClient, err := NewClient(fmt.Sprintf("%s/testingchart:0.1.0", strings.Replace(ts.URL, "http", "oci", 1)), v1.RepoSpec{}, nil)
+			assert.NoError(t, err)
+			orasRepository, err := ociClient.GetOrasRepository()
+			assert.NoError(t, err)
+			orasRepository.PlainHTTP = true
+
+			metadata, chartDigest, err := ociClient.fetchChartMetadata(orasRepository)
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			if !tc.expectedMetadata {
+				assert.Nil(t, metadata)
+				return
+			}
+			assert.Equal(t, "testingchart", metadata.Name)
+			assert.Equal(t, "0.1.0", metadata.Version)
+			assert.Equal(t, digest.FromBytes(helmChartTar).Encoded(), chartDigest)
+		})
+	}
+}
+
 func TestGetOrasRegistry(t *testing.T) {
 	testCases := []struct {
 		name              string
@@ -8,8 +8,10 @@
 	"io"
 	"net/http"
 	"os"
+	"regexp"
 	"strings"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/hashicorp/go-version"
 	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
@@ -21,15 +23,20 @@
 	"oras.land/oras-go/v2"
 	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/errdef"
 	"oras.land/oras-go/v2/registry/remote"
 	"oras.land/oras-go/v2/registry/remote/errcode"
 
 	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/catalogv2/verify"
 )
 
 // maxHelmRepoIndexSize defines what is the max size of helm repo index file we support.
 var maxHelmRepoIndexSize = 30 * 1024 * 1024 // 30 MiB
 
+// maxCosignPayloadSize defines what is the max size of a cosign signature payload we support.
+const maxCosignPayloadSize int64 = 1024 * 1024 // 1 MiB
+
 // Chart returns an io.ReadCloser of the chart tar that is requested.
 // It uses oras Go library to download the manifest of the OCI artifact
 // checks if it is a Helm chart and then return the chart tar layer.
@@ -104,6 +111,89 @@ func Chart(credentialSecret *corev1.Secret, chart *repo.ChartVersion, clusterRep
 	return nil, fmt.Errorf("unable to find the required chart tar file for %s", chartURL)
 }
 
+// ChartSignatures returns the digests of the chart that is requested along with the cosign
+// signatures of its manifest. The signatures are looked up using the tag based scheme of cosign,
+// a chart without signatures is returned if the signature tag does not exist.
+func ChartSignatures(credentialSecret *corev1.Secret, chart *repo.ChartVersion, clusterRepoSpec v1.RepoSpec) (*verify.SignedChart, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chartURL := chart.URLs[0]
+
+	ociClient, err := NewClient(chartURL, clusterRepoSpec, credentialSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create an OCI client for url %s: %w", chartURL, err)
+	}
+
+	orasRepository, err := ociClient.GetOrasRepository()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create an OCI repository for url %s: %w", chartURL, err)
+	}
+
+	manifestDesc, err := orasRepository.Resolve(ctx, ociClient.tag)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve the remote OCI artifact %s: %w", chartURL, err)
+	}
+	var manifest ocispecv1.Manifest
+	if err := fetchJSON(ctx, orasRepository, manifestDesc, &manifest); err != nil {
+		return nil, fmt.Errorf("unable to fetch the manifest of %s: %w", chartURL, err)
+	}
+
+	signed := &verify.SignedChart{
+		ManifestDigest: manifestDesc.Digest.String(),
+	}
+	for _, layer := range manifest.Layers {
+		if layer.MediaType == registry.ChartLayerMediaType {
+			signed.ChartDigest = layer.Digest.String()
+			break
+		}
+	}
+
+	signatureTag := fmt.Sprintf("%s-%s.sig", manifestDesc.Digest.Algorithm(), manifestDesc.Digest.Encoded())
+	signatureDesc, err := orasRepository.Resolve(ctx, signatureTag)
+	if errors.Is(err, errdef.ErrNotFound) {
+		return signed, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to resolve the cosign signatures of %s: %w", chartURL, err)
+	}
+	var signatureManifest ocispecv1.Manifest
+	if err := fetchJSON(ctx, orasRepository, signatureDesc, &signatureManifest); err != nil {
+		return nil, fmt.Errorf("unable to fetch the cosign signatures of %s: %w", chartURL, err)
+	}
+
+	for _, layer := range signatureManifest.Layers {
+		signature, ok := layer.Annotations[verify.CosignSignatureAnnotation]
+		if layer.MediaType != verify.CosignSimpleSigningMediaType || !ok {
+			continue
+		}
+		if layer.Size > maxCosignPayloadSize {
+			return nil, fmt.Errorf("the cosign signature payload of %s has size more than %d which is not supported", chartURL, maxCosignPayloadSize)
+		}
+		payload, err := content.FetchAll(ctx, orasRepository, layer)
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch the cosign signature payload of %s: %w", chartURL, err)
+		}
+		signed.Signatures = append(signed.Signatures, verify.CosignSignature{
+			Payload:   payload,
+			Signature: signature,
+		})
+	}
+
+	return signed, nil
+}
+
+// fetchJSON fetches the manifest described by desc and unmarshals it into v.
+func fetchJSON(ctx context.Context, fetcher content.Fetcher, desc ocispecv1.Descriptor, v interface{}) error {
+	if desc.Size > maxHelmChartTarSize {
+		return fmt.Errorf("the manifest %s has size more than %d which is not supported", desc.Digest, maxHelmChartTarSize)
+	}
+	data, err := content.FetchAll(ctx, fetcher, desc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
 // GenerateIndex creates a Helm repo index from the OCI url provided
 // by fetching the repositories and then the tags according to the url.
 // Lastly, adds the chart entry to the Helm repo index using the oras library.
@@ -119,6 +209,11 @@ func GenerateIndex(ociClient *Client, URL string, credentialSecret *corev1.Secre
 		return nil, err
 	}
 
+	filter, err := newTagFilter(clusterRepoSpec.OCITagFilter)
+	if err != nil {
+		return nil, err
+	}
+
 	var maxTag string
 	var chartName string
 
@@ -128,11 +223,16 @@ func GenerateIndex(ociClient *Client, URL string, credentialSecret *corev1.Secre
 		var maxSemver *version.Version
 
 		for i := len(tags) - 1; i >= 0; i-- {
-			existingTags[tags[i]] = true
-			// Check if the tag is a valid semver version or not. If yes, then proceed.
 			// Change underscore (_) back to plus (+) same as Helm does
 			// See https://github.com/helm/helm/issues/10166
-			semverTag, err := version.NewVersion(strings.ReplaceAll(tags[i], "_", "+"))
+			tagVersion := strings.ReplaceAll(tags[i], "_", "+")
+			// Tags that are filtered out are removed from the index along with the tags that no longer exist
+			if !filter.matches(tags[i], tagVersion) {
+				continue
+			}
+			existingTags[tags[i]] = true
+			// Check if the tag is a valid semver version or not. If yes, then proceed.
+			semverTag, err := version.NewVersion(tagVersion)
 			if err != nil {
 				// skipping the tag since it is not semver
 				continue
@@ -301,13 +401,6 @@ func addToHelmRepoIndex(ociClient Client, indexFile *repo.IndexFile, orasReposit
 		}
 	}
 
-	// Fetch the helm chart tar to get the Chart.yaml
-	filePath, err = ociClient.fetchChart(orasRepository)
-	if err != nil {
-		err = fmt.Errorf("failed to fetch the helm chart %s: %w", ociURL, err)
-		return
-	}
-
 	// We load index into memory and so we should set a limit
 	// to the size of the index file that is being created.
 	indexFileBytes, err = json.Marshal(indexFile)
@@ -319,6 +412,21 @@ func addToHelmRepoIndex(ociClient Client, indexFile *repo.IndexFile, orasReposit
 		return
 	}
 
+	// Read the Chart.yaml from the config of the manifest if possible, and
+	// only fetch the helm chart tar to get it otherwise.
+	metadata, digest, err := ociClient.fetchChartMetadata(orasRepository)
+	if err != nil {
+		err = fmt.Errorf("failed to fetch the metadata of helm chart %s: %w", ociURL, err)
+		return
+	}
+	if metadata == nil {
+		filePath, err = ociClient.fetchChart(orasRepository)
+		if err != nil {
+			err = fmt.Errorf("failed to fetch the helm chart %s: %w", ociURL, err)
+			return
+		}
+	}
+
 	// Remove the entry from the indexfile since the next function addToIndex
 	// will add. This is done to avoid duplication.
 	for index, entry := range indexFile.Entries[chartName] {
@@ -328,10 +436,64 @@ func addToHelmRepoIndex(ociClient Client, indexFile *repo.IndexFile, orasReposit
 	}
 
 	// Add the chart to the index
-	err = ociClient.addToIndex(indexFile, filePath)
+	if metadata != nil {
+		err = ociClient.addMetadataToIndex(indexFile, metadata, digest)
+	} else {
+		err = ociClient.addToIndex(indexFile, filePath)
+	}
 	if err != nil {
 		err = fmt.Errorf("unable to add helm chart %s to index: %w", ociURL, err)
 	}
 
 	return
 }
+
+// tagFilter selects the tags of OCI repositories that are added to the helm repo index.
+type tagFilter struct {
+	constraint *semver.Constraints
+	include    *regexp.Regexp
+	exclude    *regexp.Regexp
+}
+
+// newTagFilter compiles the tag filter of a ClusterRepo. All the tags are selected if it is nil.
+func newTagFilter(filter *v1.OCITagFilter) (*tagFilter, error) {
+	result := &tagFilter{}
+	if filter == nil {
+		return result, nil
+	}
+
+	var err error
+	if filter.VersionConstraint != "" {
+		if result.constraint, err = semver.NewConstraint(filter.VersionConstraint); err != nil {
+			return nil, fmt.Errorf("invalid tag version constraint %q: %w", filter.VersionConstraint, err)
+		}
+	}
+	if filter.Include != "" {
+		if result.include, err = regexp.Compile(filter.Include); err != nil {
+			return nil, fmt.Errorf("invalid tag include expression %q: %w", filter.Include, err)
+		}
+	}
+	if filter.Exclude != "" {
+		if result.exclude, err = regexp.Compile(filter.Exclude); err != nil {
+			return nil, fmt.Errorf("invalid tag exclude expression %q: %w", filter.Exclude, err)
+		}
+	}
+	return result, nil
+}
+
+// matches returns true if the given tag, with the given semver version, is selected by the filter.
+func (f *tagFilter) matches(tag, version string) bool {
+	if f.include != nil && !f.include.MatchString(tag) {
+		return false
+	}
+	if f.exclude != nil && f.exclude.MatchString(tag) {
+		return false
+	}
+	if f.constraint != nil {
+		v, err := semver.NewVersion(version)
+		if err != nil || !f.constraint.Check(v) {
+			return false
+		}
+	}
+	return true
+}
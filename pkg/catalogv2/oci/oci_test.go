@@ -244,3 +244,52 @@ func TestGenerateIndex(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateIndexTagFilter(t *testing.T) {
+	tests := []struct {
+		name           string
+		filter         *v1.OCITagFilter
+		expectedErrMsg string
+	}{
+		{
+			name:   "filters tags by version constraint",
+			filter: &v1.OCITagFilter{VersionConstraint: ">= 0.1.0"},
+		},
+		{
+			name:   "filters tags by include and exclude expressions",
+			filter: &v1.OCITagFilter{Include: `^0\.`, Exclude: `^0\.0\.`},
+		},
+		{
+			name:           "returns an error if the version constraint is invalid",
+			filter:         &v1.OCITagFilter{VersionConstraint: "latest"},
+			expectedErrMsg: "invalid tag version constraint",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := spinRegistry(0, true, true, tt.name, t)
+			defer ts.Close()
+
+			// the tag 0.0.1 was indexed before the filter was set
+			indexFile := repo.NewIndexFile()
+			indexFile.Entries["testingchart"] = repo.ChartVersions{
+				&repo.ChartVersion{Metadata: &chart.Metadata{Name: "testingchart", Version: "0.0.1"}},
+			}
+
+			u := fmt.Sprintf("%s/testingchart", strings.Replace(ts.URL, "http", "oci", 1))
+			repoSpec := v1.RepoSpec{InsecurePlainHTTP: true, OCITagFilter: tt.filter}
+			ociClient, err := NewClient(u, repoSpec, nil)
+			assert.NoError(t, err)
+			i, err := GenerateIndex(ociClient, u, nil, repoSpec, v1.RepoStatus{}, indexFile)
+			if tt.expectedErrMsg != "" {
+				assert.ErrorContains(t, err, tt.expectedErrMsg)
+				return
+			}
+			assert.NoError(t, err)
+			if assert.Len(t, i.Entries["testingchart"], 1) {
+				assert.Equal(t, "0.1.0", i.Entries["testingchart"][0].Version)
+				assert.NotEmpty(t, i.Entries["testingchart"][0].Digest)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+/*
+Package verify verifies the signatures of Helm charts against the keys trusted by the verification policy of a ClusterRepo.
+
+Charts of HTTP Helm repositories are verified with their Helm provenance (.prov) file and a trusted PGP keyring.
+Charts of OCI Helm repositories are verified with their cosign signatures and a set of trusted cosign public keys.
+Only key based cosign signatures stored with the tag based scheme (sha256-<digest>.sig) are supported, transparency
+logs and certificate based (keyless) signatures are not checked.
+*/
+package verify
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"       //nolint:staticcheck // helm provenance files are verified with this package
+	"golang.org/x/crypto/openpgp/armor" //nolint:staticcheck // helm provenance files are verified with this package
+	"helm.sh/helm/v3/pkg/provenance"
+)
+
+const (
+	// Annotation is set on the entries of a Helm repository index with the verification status of the chart
+	// version, once it is known.
+	Annotation = "catalog.cattle.io/verification"
+
+	// KeyringSecretKey is the key of the provenance keyring secret holding the trusted PGP public keyring.
+	KeyringSecretKey = "keyring"
+
+	// CosignSignatureAnnotation is the annotation of a cosign signature layer that holds the base64 encoded signature
+	// of the layer payload.
+	CosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+	// CosignSimpleSigningMediaType is the media type of the layers of a cosign signature manifest.
+	CosignSimpleSigningMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+
+	ProvenanceMethod = "provenance"
+	CosignMethod     = "cosign"
+)
+
+// Status is the outcome of the verification of a chart.
+type Status string
+
+const (
+	// Verified is set when the chart was signed by one of the trusted keys.
+	Verified Status = "Verified"
+	// Failed is set when the chart is unsigned, or is not signed by one of the trusted keys.
+	Failed Status = "Failed"
+	// Unverified is set when the repository has no verification policy for the chart.
+	Unverified Status = "Unverified"
+)
+
+// Result describes the verification of a chart.
+type Result struct {
+	Status  Status `json:"status"`
+	Method  string `json:"method,omitempty"`
+	Signer  string `json:"signer,omitempty"`
+	Digest  string `json:"digest,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Err returns an error describing the result if the chart was not verified, or nil if it was.
+func (r *Result) Err() error {
+	if r == nil || r.Status == Verified {
+		return nil
+	}
+	if r.Message == "" {
+		return fmt.Errorf("chart verification status is %s", r.Status)
+	}
+	return fmt.Errorf("chart verification status is %s: %s", r.Status, r.Message)
+}
+
+func failed(method, format string, args ...interface{}) *Result {
+	return &Result{
+		Status:  Failed,
+		Method:  method,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// Provenance verifies the given chart archive with its Helm provenance file and the given PGP public keyring, which
+// may be armored or binary. The file name must be the name of the archive the provenance file was generated for.
+func Provenance(keyring []byte, fileName string, chart, prov []byte) *Result {
+	if len(prov) == 0 {
+		return failed(ProvenanceMethod, "chart has no provenance file")
+	}
+
+	ring, err := readKeyRing(keyring)
+	if err != nil {
+		return failed(ProvenanceMethod, "failed to read keyring: %v", err)
+	}
+
+	// the helm provenance package only verifies files
+	dir, err := os.MkdirTemp("", "chart-provenance")
+	if err != nil {
+		return failed(ProvenanceMethod, "failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	chartPath := filepath.Join(dir, filepath.Base(fileName))
+	provPath := chartPath + ".prov"
+	if err := os.WriteFile(chartPath, chart, 0600); err != nil {
+		return failed(ProvenanceMethod, "failed to write chart: %v", err)
+	}
+	if err := os.WriteFile(provPath, prov, 0600); err != nil {
+		return failed(ProvenanceMethod, "failed to write provenance file: %v", err)
+	}
+
+	signatory := &provenance.Signatory{KeyRing: ring}
+	verification, err := signatory.Verify(chartPath, provPath)
+	if err != nil {
+		return failed(ProvenanceMethod, "%v", err)
+	}
+
+	return &Result{
+		Status: Verified,
+		Method: ProvenanceMethod,
+		Signer: entityName(verification.SignedBy),
+		Digest: verification.FileHash,
+	}
+}
+
+func readKeyRing(keyring []byte) (openpgp.EntityList, error) {
+	if len(keyring) == 0 {
+		return nil, errors.New("keyring is empty")
+	}
+	if block, err := armor.Decode(bytes.NewReader(keyring)); err == nil {
+		return openpgp.ReadKeyRing(block.Body)
+	}
+	return openpgp.ReadKeyRing(bytes.NewReader(keyring))
+}
+
+func entityName(entity *openpgp.Entity) string {
+	if entity == nil {
+		return ""
+	}
+	var names []string
+	for name := range entity.Identities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fingerprint := ""
+	if entity.PrimaryKey != nil {
+		fingerprint = strings.ToUpper(hex.EncodeToString(entity.PrimaryKey.Fingerprint[:]))
+	}
+	if len(names) == 0 {
+		return fingerprint
+	}
+	return fmt.Sprintf("%s (%s)", names[0], fingerprint)
+}
+
+// CosignSignature is a signature layer of the cosign signature manifest of an OCI artifact.
+type CosignSignature struct {
+	// Payload is the simple signing payload that was signed.
+	Payload []byte
+	// Signature is the base64 encoded signature of the payload.
+	Signature string
+}
+
+// SignedChart is an OCI Helm chart along with its cosign signatures.
+type SignedChart struct {
+	// ManifestDigest is the digest of the manifest of the chart artifact.
+	ManifestDigest string
+	// ChartDigest is the digest of the chart layer referenced by the manifest.
+	ChartDigest string
+	Signatures  []CosignSignature
+}
+
+type simpleSigningPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+// Cosign verifies that the given chart archive is the chart layer of the signed manifest, and that the manifest was
+// signed with one of the given PEM encoded public keys.
+func Cosign(publicKeys [][]byte, chart []byte, signed *SignedChart) *Result {
+	keys, err := parsePublicKeys(publicKeys)
+	if err != nil {
+		return failed(CosignMethod, "%v", err)
+	}
+	if len(keys) == 0 {
+		return failed(CosignMethod, "no cosign public keys are trusted")
+	}
+	if signed == nil || len(signed.Signatures) == 0 {
+		return failed(CosignMethod, "chart has no cosign signatures")
+	}
+
+	chartDigest := fmt.Sprintf("sha256:%x", sha256.Sum256(chart))
+	if chartDigest != signed.ChartDigest {
+		return failed(CosignMethod, "chart digest %s does not match the digest %s of the signed manifest", chartDigest, signed.ChartDigest)
+	}
+
+	for _, signature := range signed.Signatures {
+		payload := simpleSigningPayload{}
+		if err := json.Unmarshal(signature.Payload, &payload); err != nil {
+			continue
+		}
+		if payload.Critical.Image.DockerManifestDigest != signed.ManifestDigest {
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(signature.Signature)
+		if err != nil {
+			continue
+		}
+		for _, key := range keys {
+			if verifySignature(key.key, signature.Payload, sig) {
+				return &Result{
+					Status: Verified,
+					Method: CosignMethod,
+					Signer: key.name,
+					Digest: signed.ManifestDigest,
+				}
+			}
+		}
+	}
+
+	return failed(CosignMethod, "no cosign signature of manifest %s was made by a trusted key", signed.ManifestDigest)
+}
+
+type publicKey struct {
+	name string
+	key  crypto.PublicKey
+}
+
+func parsePublicKeys(publicKeys [][]byte) ([]publicKey, error) {
+	var result []publicKey
+	for _, data := range publicKeys {
+		for {
+			var block *pem.Block
+			block, data = pem.Decode(data)
+			if block == nil {
+				break
+			}
+			if block.Type != "PUBLIC KEY" {
+				continue
+			}
+			key, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse cosign public key: %w", err)
+			}
+			sum := sha256.Sum256(block.Bytes)
+			result = append(result, publicKey{
+				name: "sha256:" + hex.EncodeToString(sum[:]),
+				key:  key,
+			})
+		}
+	}
+	return result, nil
+}
+
+func verifySignature(key crypto.PublicKey, payload, signature []byte) bool {
+	digest := sha256.Sum256(payload)
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(k, digest[:], signature)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) == nil ||
+			rsa.VerifyPSS(k, crypto.SHA256, digest[:], signature, nil) == nil
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, payload, signature)
+	}
+	return false
+}
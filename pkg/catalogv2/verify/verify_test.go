@@ -0,0 +1,118 @@
+package verify
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvenance(t *testing.T) {
+	keyring, err := os.ReadFile("testdata/helm-test-key.pub")
+	require.NoError(t, err)
+	chart, err := os.ReadFile("testdata/hashtest-1.2.3.tgz")
+	require.NoError(t, err)
+	prov, err := os.ReadFile("testdata/hashtest-1.2.3.tgz.prov")
+	require.NoError(t, err)
+
+	result := Provenance(keyring, "hashtest-1.2.3.tgz", chart, prov)
+	assert.Equal(t, Verified, result.Status, result.Message)
+	assert.Contains(t, result.Signer, "Helm Testing")
+	assert.NoError(t, result.Err())
+
+	tampered := append([]byte{}, chart...)
+	tampered[len(tampered)-1] ^= 0xff
+	result = Provenance(keyring, "hashtest-1.2.3.tgz", tampered, prov)
+	assert.Equal(t, Failed, result.Status)
+	assert.Error(t, result.Err())
+
+	result = Provenance(keyring, "hashtest-1.2.3.tgz", chart, nil)
+	assert.Equal(t, Failed, result.Status)
+	assert.Equal(t, "chart has no provenance file", result.Message)
+}
+
+func TestCosign(t *testing.T) {
+	trusted, trustedPEM := newKey(t)
+	untrusted, _ := newKey(t)
+
+	chart := []byte("chart")
+	chartDigest := fmt.Sprintf("sha256:%x", sha256.Sum256(chart))
+	manifestDigest := "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	sign := func(key *ecdsa.PrivateKey, digest string) CosignSignature {
+		payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"registry.example.com/charts/test"},"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`, digest))
+		sum := sha256.Sum256(payload)
+		sig, err := ecdsa.SignASN1(rand.Reader, key, sum[:])
+		require.NoError(t, err)
+		return CosignSignature{
+			Payload:   payload,
+			Signature: base64.StdEncoding.EncodeToString(sig),
+		}
+	}
+
+	tests := []struct {
+		name           string
+		chart          []byte
+		signatures     []CosignSignature
+		expectedStatus Status
+	}{
+		{
+			name:           "signed by a trusted key",
+			chart:          chart,
+			signatures:     []CosignSignature{sign(untrusted, manifestDigest), sign(trusted, manifestDigest)},
+			expectedStatus: Verified,
+		},
+		{
+			name:           "signed by an untrusted key",
+			chart:          chart,
+			signatures:     []CosignSignature{sign(untrusted, manifestDigest)},
+			expectedStatus: Failed,
+		},
+		{
+			name:           "signature of another manifest",
+			chart:          chart,
+			signatures:     []CosignSignature{sign(trusted, "sha256:fedcba")},
+			expectedStatus: Failed,
+		},
+		{
+			name:           "chart does not match the manifest",
+			chart:          []byte("other chart"),
+			signatures:     []CosignSignature{sign(trusted, manifestDigest)},
+			expectedStatus: Failed,
+		},
+		{
+			name:           "unsigned",
+			chart:          chart,
+			expectedStatus: Failed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Cosign([][]byte{trustedPEM}, tt.chart, &SignedChart{
+				ManifestDigest: manifestDigest,
+				ChartDigest:    chartDigest,
+				Signatures:     tt.signatures,
+			})
+			assert.Equal(t, tt.expectedStatus, result.Status, result.Message)
+			assert.Equal(t, CosignMethod, result.Method)
+		})
+	}
+}
+
+func newKey(t *testing.T) (*ecdsa.PrivateKey, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	return key, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
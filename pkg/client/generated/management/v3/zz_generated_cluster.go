@@ -52,6 +52,7 @@
 	ClusterFieldFleetWorkspaceName                                   = "fleetWorkspaceName"
 	ClusterFieldGKEConfig                                            = "gkeConfig"
 	ClusterFieldGKEStatus                                            = "gkeStatus"
+	ClusterFieldHealth                                               = "health"
 	ClusterFieldImportedConfig                                       = "importedConfig"
 	ClusterFieldInternal                                             = "internal"
 	ClusterFieldIstioEnabled                                         = "istioEnabled"
@@ -133,6 +134,7 @@ type Cluster struct {
 	FleetWorkspaceName                                   string                         `json:"fleetWorkspaceName,omitempty" yaml:"fleetWorkspaceName,omitempty"`
 	GKEConfig                                            *GKEClusterConfigSpec          `json:"gkeConfig,omitempty" yaml:"gkeConfig,omitempty"`
 	GKEStatus                                            *GKEStatus                     `json:"gkeStatus,omitempty" yaml:"gkeStatus,omitempty"`
+	Health                                               *ClusterHealth                 `json:"health,omitempty" yaml:"health,omitempty"`
 	ImportedConfig                                       *ImportedConfig                `json:"importedConfig,omitempty" yaml:"importedConfig,omitempty"`
 	Internal                                             bool                           `json:"internal,omitempty" yaml:"internal,omitempty"`
 	IstioEnabled                                         bool                           `json:"istioEnabled,omitempty" yaml:"istioEnabled,omitempty"`
@@ -0,0 +1,14 @@
+package client
+
+const (
+	ClusterHealthType        = "clusterHealth"
+	ClusterHealthFieldChecks = "checks"
+	ClusterHealthFieldScore  = "score"
+	ClusterHealthFieldState  = "state"
+)
+
+type ClusterHealth struct {
+	Checks []ClusterHealthCheck `json:"checks,omitempty" yaml:"checks,omitempty"`
+	Score  int64                `json:"score,omitempty" yaml:"score,omitempty"`
+	State  string               `json:"state,omitempty" yaml:"state,omitempty"`
+}
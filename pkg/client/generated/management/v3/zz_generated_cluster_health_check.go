@@ -0,0 +1,18 @@
+package client
+
+const (
+	ClusterHealthCheckType         = "clusterHealthCheck"
+	ClusterHealthCheckFieldMessage = "message"
+	ClusterHealthCheckFieldName    = "name"
+	ClusterHealthCheckFieldReason  = "reason"
+	ClusterHealthCheckFieldScore   = "score"
+	ClusterHealthCheckFieldWeight  = "weight"
+)
+
+type ClusterHealthCheck struct {
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+	Name    string `json:"name,omitempty" yaml:"name,omitempty"`
+	Reason  string `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Score   int64  `json:"score,omitempty" yaml:"score,omitempty"`
+	Weight  int64  `json:"weight,omitempty" yaml:"weight,omitempty"`
+}
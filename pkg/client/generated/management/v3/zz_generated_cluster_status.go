@@ -25,6 +25,7 @@
 	ClusterStatusFieldEKSStatus                                  = "eksStatus"
 	ClusterStatusFieldFailedSpec                                 = "failedSpec"
 	ClusterStatusFieldGKEStatus                                  = "gkeStatus"
+	ClusterStatusFieldHealth                                     = "health"
 	ClusterStatusFieldIstioEnabled                               = "istioEnabled"
 	ClusterStatusFieldLimits                                     = "limits"
 	ClusterStatusFieldLinuxWorkerCount                           = "linuxWorkerCount"
@@ -67,6 +68,7 @@ type ClusterStatus struct {
 	EKSStatus                                  *EKSStatus                    `json:"eksStatus,omitempty" yaml:"eksStatus,omitempty"`
 	FailedSpec                                 *ClusterSpec                  `json:"failedSpec,omitempty" yaml:"failedSpec,omitempty"`
 	GKEStatus                                  *GKEStatus                    `json:"gkeStatus,omitempty" yaml:"gkeStatus,omitempty"`
+	Health                                     *ClusterHealth                `json:"health,omitempty" yaml:"health,omitempty"`
 	IstioEnabled                               bool                          `json:"istioEnabled,omitempty" yaml:"istioEnabled,omitempty"`
 	Limits                                     map[string]string             `json:"limits,omitempty" yaml:"limits,omitempty"`
 	LinuxWorkerCount                           int64                         `json:"linuxWorkerCount,omitempty" yaml:"linuxWorkerCount,omitempty"`
@@ -13,6 +13,7 @@
 	FeatureFieldName                 = "name"
 	FeatureFieldOwnerReferences      = "ownerReferences"
 	FeatureFieldRemoved              = "removed"
+	FeatureFieldRules                = "rules"
 	FeatureFieldState                = "state"
 	FeatureFieldStatus               = "status"
 	FeatureFieldTransitioning        = "transitioning"
@@ -30,6 +31,7 @@ type Feature struct {
 	Name                 string            `json:"name,omitempty" yaml:"name,omitempty"`
 	OwnerReferences      []OwnerReference  `json:"ownerReferences,omitempty" yaml:"ownerReferences,omitempty"`
 	Removed              string            `json:"removed,omitempty" yaml:"removed,omitempty"`
+	Rules                []FeatureRule     `json:"rules,omitempty" yaml:"rules,omitempty"`
 	State                string            `json:"state,omitempty" yaml:"state,omitempty"`
 	Status               *FeatureStatus    `json:"status,omitempty" yaml:"status,omitempty"`
 	Transitioning        string            `json:"transitioning,omitempty" yaml:"transitioning,omitempty"`
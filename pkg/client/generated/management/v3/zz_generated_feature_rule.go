@@ -0,0 +1,14 @@
+package client
+
+const (
+	FeatureRuleType                 = "featureRule"
+	FeatureRuleFieldClusterSelector = "clusterSelector"
+	FeatureRuleFieldGroups          = "groups"
+	FeatureRuleFieldValue           = "value"
+)
+
+type FeatureRule struct {
+	ClusterSelector *LabelSelector `json:"clusterSelector,omitempty" yaml:"clusterSelector,omitempty"`
+	Groups          []string       `json:"groups,omitempty" yaml:"groups,omitempty"`
+	Value           bool           `json:"value,omitempty" yaml:"value,omitempty"`
+}
@@ -2,9 +2,11 @@
 
 const (
 	FeatureSpecType       = "featureSpec"
+	FeatureSpecFieldRules = "rules"
 	FeatureSpecFieldValue = "value"
 )
 
 type FeatureSpec struct {
-	Value *bool `json:"value,omitempty" yaml:"value,omitempty"`
+	Rules []FeatureRule `json:"rules,omitempty" yaml:"rules,omitempty"`
+	Value *bool         `json:"value,omitempty" yaml:"value,omitempty"`
 }
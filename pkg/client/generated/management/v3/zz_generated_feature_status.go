@@ -1,16 +1,20 @@
 package client
 
 const (
-	FeatureStatusType             = "featureStatus"
-	FeatureStatusFieldDefault     = "default"
-	FeatureStatusFieldDescription = "description"
-	FeatureStatusFieldDynamic     = "dynamic"
-	FeatureStatusFieldLockedValue = "lockedValue"
+	FeatureStatusType                 = "featureStatus"
+	FeatureStatusFieldDefault         = "default"
+	FeatureStatusFieldDescription     = "description"
+	FeatureStatusFieldDynamic         = "dynamic"
+	FeatureStatusFieldEnabledClusters = "enabledClusters"
+	FeatureStatusFieldEnabledGroups   = "enabledGroups"
+	FeatureStatusFieldLockedValue     = "lockedValue"
 )
 
 type FeatureStatus struct {
-	Default     bool   `json:"default,omitempty" yaml:"default,omitempty"`
-	Description string `json:"description,omitempty" yaml:"description,omitempty"`
-	Dynamic     bool   `json:"dynamic,omitempty" yaml:"dynamic,omitempty"`
-	LockedValue *bool  `json:"lockedValue,omitempty" yaml:"lockedValue,omitempty"`
+	Default         bool     `json:"default,omitempty" yaml:"default,omitempty"`
+	Description     string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Dynamic         bool     `json:"dynamic,omitempty" yaml:"dynamic,omitempty"`
+	EnabledClusters []string `json:"enabledClusters,omitempty" yaml:"enabledClusters,omitempty"`
+	EnabledGroups   []string `json:"enabledGroups,omitempty" yaml:"enabledGroups,omitempty"`
+	LockedValue     *bool    `json:"lockedValue,omitempty" yaml:"lockedValue,omitempty"`
 }
@@ -7,10 +7,12 @@
 const (
 	SettingType                 = "setting"
 	SettingFieldAnnotations     = "annotations"
+	SettingFieldConditions      = "conditions"
 	SettingFieldCreated         = "created"
 	SettingFieldCreatorID       = "creatorId"
 	SettingFieldCustomized      = "customized"
 	SettingFieldDefault         = "default"
+	SettingFieldHistory         = "history"
 	SettingFieldLabels          = "labels"
 	SettingFieldName            = "name"
 	SettingFieldOwnerReferences = "ownerReferences"
@@ -23,10 +25,12 @@
 type Setting struct {
 	types.Resource
 	Annotations     map[string]string `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	Conditions      []Condition       `json:"conditions,omitempty" yaml:"conditions,omitempty"`
 	Created         string            `json:"created,omitempty" yaml:"created,omitempty"`
 	CreatorID       string            `json:"creatorId,omitempty" yaml:"creatorId,omitempty"`
 	Customized      bool              `json:"customized,omitempty" yaml:"customized,omitempty"`
 	Default         string            `json:"default,omitempty" yaml:"default,omitempty"`
+	History         []SettingChange   `json:"history,omitempty" yaml:"history,omitempty"`
 	Labels          map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
 	Name            string            `json:"name,omitempty" yaml:"name,omitempty"`
 	OwnerReferences []OwnerReference  `json:"ownerReferences,omitempty" yaml:"ownerReferences,omitempty"`
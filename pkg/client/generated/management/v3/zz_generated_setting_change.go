@@ -0,0 +1,14 @@
+package client
+
+const (
+	SettingChangeType       = "settingChange"
+	SettingChangeFieldTime  = "time"
+	SettingChangeFieldUser  = "user"
+	SettingChangeFieldValue = "value"
+)
+
+type SettingChange struct {
+	Time  string `json:"time,omitempty" yaml:"time,omitempty"`
+	User  string `json:"user,omitempty" yaml:"user,omitempty"`
+	Value string `json:"value,omitempty" yaml:"value,omitempty"`
+}
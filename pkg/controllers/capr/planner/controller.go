@@ -10,7 +10,10 @@
 	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
 	"github.com/rancher/rancher/pkg/capr"
 	caprplanner "github.com/rancher/rancher/pkg/capr/planner"
+	"github.com/rancher/rancher/pkg/events"
+	provisioningcontrollers "github.com/rancher/rancher/pkg/generated/controllers/provisioning.cattle.io/v1"
 	v1 "github.com/rancher/rancher/pkg/generated/controllers/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/logging"
 	"github.com/rancher/rancher/pkg/wrangler"
 	"github.com/rancher/wrangler/v3/pkg/generic"
 	"github.com/rancher/wrangler/v3/pkg/relatedresource"
@@ -18,10 +21,13 @@
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	capi "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
 var (
+	logger = logging.For("capr/planner")
+
 	capiScalingUpCondition   = condition.Cond("ScalingUp")
 	capiScalingDownCondition = condition.Cond("ScalingDown")
 	capiRollingOutCondition  = condition.Cond("RollingOut")
@@ -30,12 +36,16 @@
 type handler struct {
 	planner       *caprplanner.Planner
 	controlPlanes v1.RKEControlPlaneController
+	clusters      provisioningcontrollers.ClusterCache
+	recorder      record.EventRecorder
 }
 
 func Register(ctx context.Context, clients *wrangler.Context, planner *caprplanner.Planner) {
 	h := handler{
 		planner:       planner,
 		controlPlanes: clients.RKE.RKEControlPlane(),
+		clusters:      clients.Provisioning.Cluster().Cache(),
+		recorder:      clients.EventRecorder,
 	}
 	v1.RegisterRKEControlPlaneStatusHandler(ctx, clients.RKE.RKEControlPlane(), "", "planner", h.OnChange)
 	relatedresource.Watch(ctx, "planner", func(namespace, name string, obj runtime.Object) ([]relatedresource.Key, error) {
@@ -43,7 +53,7 @@ func Register(ctx context.Context, clients *wrangler.Context, planner *caprplann
 			var relatedResources []relatedresource.Key
 			clusterName := secret.Labels[capr.ClusterNameLabel]
 			if clusterName != "" {
-				logrus.Tracef("[planner] rkecluster %s/%s enqueue triggered by secret %s/%s", secret.Namespace, clusterName, secret.Namespace, secret.Name)
+				logger.WithField(logging.FieldKey, secret.Namespace+"/"+clusterName).Tracef("enqueue triggered by secret %s/%s", secret.Namespace, secret.Name)
 				relatedResources = append(relatedResources, relatedresource.Key{
 					Namespace: secret.Namespace,
 					Name:      clusterName,
@@ -52,7 +62,7 @@ func Register(ctx context.Context, clients *wrangler.Context, planner *caprplann
 			authorizedObjects := secret.Annotations[capr.AuthorizedObjectAnnotation]
 			if authorizedObjects != "" {
 				for _, clusterName = range strings.Split(authorizedObjects, ",") {
-					logrus.Tracef("[planner] rkecluster %s/%s enqueue triggered by authorized secret %s/%s", secret.Namespace, clusterName, secret.Namespace, secret.Name)
+					logger.WithField(logging.FieldKey, secret.Namespace+"/"+clusterName).Tracef("enqueue triggered by authorized secret %s/%s", secret.Namespace, secret.Name)
 					relatedResources = append(relatedResources, relatedresource.Key{
 						Namespace: secret.Namespace,
 						Name:      clusterName,
@@ -63,7 +73,7 @@ func Register(ctx context.Context, clients *wrangler.Context, planner *caprplann
 		} else if machine, ok := obj.(*capi.Machine); ok {
 			clusterName := machine.Labels[capi.ClusterNameLabel]
 			if clusterName != "" {
-				logrus.Tracef("[planner] rkecluster %s/%s enqueue triggered by machine %s/%s", machine.Namespace, clusterName, machine.Namespace, machine.Name)
+				logger.WithField(logging.FieldKey, machine.Namespace+"/"+clusterName).Tracef("enqueue triggered by machine %s/%s", machine.Namespace, machine.Name)
 				return []relatedresource.Key{{
 					Namespace: machine.Namespace,
 					Name:      clusterName,
@@ -74,7 +84,7 @@ func Register(ctx context.Context, clients *wrangler.Context, planner *caprplann
 			authorizedObjects := configmap.Annotations[capr.AuthorizedObjectAnnotation]
 			if authorizedObjects != "" {
 				for _, clusterName := range strings.Split(authorizedObjects, ",") {
-					logrus.Tracef("[planner] rkecluster %s/%s enqueue triggered by authorized configmap %s/%s", configmap.Namespace, clusterName, configmap.Namespace, configmap.Name)
+					logger.WithField(logging.FieldKey, configmap.Namespace+"/"+clusterName).Tracef("enqueue triggered by authorized configmap %s/%s", configmap.Namespace, configmap.Name)
 					relatedResources = append(relatedResources, relatedresource.Key{
 						Namespace: configmap.Namespace,
 						Name:      clusterName,
@@ -88,7 +98,12 @@ func Register(ctx context.Context, clients *wrangler.Context, planner *caprplann
 }
 
 func (h *handler) OnChange(cp *rkev1.RKEControlPlane, status rkev1.RKEControlPlaneStatus) (rkev1.RKEControlPlaneStatus, error) {
-	logrus.Debugf("[planner] rkecluster %s/%s: handler OnChange called", cp.Namespace, cp.Name)
+	log := logger.WithFields(logrus.Fields{
+		logging.FieldController: "planner",
+		logging.FieldCluster:    cp.Spec.ManagementClusterName,
+		logging.FieldKey:        cp.Namespace + "/" + cp.Name,
+	})
+	log.Debug("handler OnChange called")
 	if !cp.DeletionTimestamp.IsZero() {
 		return status, nil
 	}
@@ -111,7 +126,7 @@ func (h *handler) OnChange(cp *rkev1.RKEControlPlane, status rkev1.RKEControlPla
 	}
 
 	if !scalingUpFound || !scalingDownFound || !rollingOutFound {
-		logrus.Debugf("[planner] rkecluster %s/%s: setting CAPI v1beta2 conditions", cp.Namespace, cp.Name)
+		log.Debug("setting CAPI v1beta2 conditions")
 		capiScalingUpCondition.False(&status)
 		capiScalingDownCondition.False(&status)
 		capiRollingOutCondition.False(&status)
@@ -120,7 +135,7 @@ func (h *handler) OnChange(cp *rkev1.RKEControlPlane, status rkev1.RKEControlPla
 
 	status.ObservedGeneration = cp.Generation
 
-	logrus.Debugf("[planner] rkecluster %s/%s: calling planner process", cp.Namespace, cp.Name)
+	log.Debug("calling planner process")
 	status, err := h.planner.Process(cp, status)
 	if err != nil {
 		// planner.Process can encounter 3 types of errors:
@@ -128,7 +143,10 @@ func (h *handler) OnChange(cp *rkev1.RKEControlPlane, status rkev1.RKEControlPla
 		// * generic.ErrSkip - These will cause the object to be re-enqueued after 5 seconds.
 		// * error - All other errors. This should be an actual error during planner processing.
 		if caprplanner.IsErrWaiting(err) {
-			logrus.Infof("[planner] rkecluster %s/%s: %v", cp.Namespace, cp.Name, err)
+			log.Info(err)
+			if capr.Ready.GetMessage(cp) != err.Error() {
+				h.recorder.Event(h.eventObject(cp), corev1.EventTypeNormal, events.ReasonPlanWaiting, err.Error())
+			}
 			capr.Ready.SetStatus(&status, "Unknown")
 			capr.Ready.Message(&status, err.Error())
 			capr.Ready.Reason(&status, "Waiting")
@@ -142,18 +160,24 @@ func (h *handler) OnChange(cp *rkev1.RKEControlPlane, status rkev1.RKEControlPla
 			return status, nil
 		}
 		if errors.Is(err, generic.ErrSkip) {
-			logrus.Debugf("[planner] rkecluster %s/%s: ErrSkip: %v", cp.Namespace, cp.Name, err)
+			log.Debugf("ErrSkip: %v", err)
 			h.controlPlanes.EnqueueAfter(cp.Namespace, cp.Name, 5*time.Second)
 			return status, err
 		}
 		// An actual error occurred, so set the Ready and Reconciled conditions to this error and return
-		logrus.Errorf("[planner] rkecluster %s/%s: error during plan processing: %v", cp.Namespace, cp.Name, err)
+		log.Errorf("error during plan processing: %v", err)
+		if capr.Ready.GetMessage(cp) != err.Error() {
+			h.recorder.Event(h.eventObject(cp), corev1.EventTypeWarning, events.ReasonPlanFailed, err.Error())
+		}
 		capr.Ready.SetError(&status, "", err)
 		capr.Reconciled.SetError(&status, "", err)
 		return status, err
 	}
 	// No error encountered during planner.Process
-	logrus.Debugf("[planner] rkecluster %s/%s: reconciliation complete", cp.Namespace, cp.Name)
+	log.Debug("reconciliation complete")
+	if !capr.Reconciled.IsTrue(cp) {
+		h.recorder.Event(h.eventObject(cp), corev1.EventTypeNormal, events.ReasonReconciled, "The cluster plan has been applied")
+	}
 	capr.Ready.True(&status)
 	capr.Ready.Message(&status, "")
 	capr.Ready.Reason(&status, "")
@@ -166,3 +190,12 @@ func (h *handler) OnChange(cp *rkev1.RKEControlPlane, status rkev1.RKEControlPla
 	capr.Reconciled.Reason(&status, "")
 	return status, nil
 }
+
+// eventObject returns the provisioning cluster of the control plane, which is the object users describe, or the control
+// plane itself if the cluster can't be found.
+func (h *handler) eventObject(cp *rkev1.RKEControlPlane) runtime.Object {
+	if cluster, err := h.clusters.Get(cp.Namespace, cp.Name); err == nil {
+		return cluster
+	}
+	return cp
+}
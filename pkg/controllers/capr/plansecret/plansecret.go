@@ -107,6 +107,14 @@ func (h *handler) OnChange(_ string, secret *corev1.Secret) (*corev1.Secret, err
 		secretChanged = true
 	}
 
+	timelineChanged, err := planner.UpdateTimeline(secret, node, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if timelineChanged {
+		secretChanged = true
+	}
+
 	if secretChanged {
 		// don't return the secret at this point, we want to attempt to update the machine status later on
 		secret, err = h.secrets.Update(secret)
@@ -0,0 +1,307 @@
+package helm
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rancher/lasso/pkg/client"
+	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/catalogv2/helm"
+	catalogv1 "github.com/rancher/rancher/pkg/generated/controllers/catalog.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/settings"
+	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// driftHandler periodically compares the live resources of installed helm 3 releases with their release
+// manifest, reports the drift on the App status and re-applies the manifest of enforced apps.
+type driftHandler struct {
+	ctx                 context.Context
+	apps                catalogv1.AppController
+	secretCache         corecontrollers.SecretCache
+	sharedClientFactory client.SharedClientFactory
+
+	// checked records when the apps were last checked, as the status is not updated when nothing but the time of the
+	// check changed.
+	lock    sync.Mutex
+	checked map[string]driftCheck
+}
+
+// driftCheck is the time an app was last checked at the given release version.
+type driftCheck struct {
+	version int
+	time    time.Time
+}
+
+func RegisterDrift(ctx context.Context,
+	shareClientFactory client.SharedClientFactory,
+	secrets corecontrollers.SecretCache,
+	apps catalogv1.AppController,
+) {
+	d := &driftHandler{
+		ctx:                 ctx,
+		apps:                apps,
+		secretCache:         secrets,
+		sharedClientFactory: shareClientFactory,
+		checked:             map[string]driftCheck{},
+	}
+	apps.OnChange(ctx, "helm-app-drift", d.OnChange)
+}
+
+func (d *driftHandler) OnChange(key string, app *v1.App) (*v1.App, error) {
+	if app == nil || app.DeletionTimestamp != nil {
+		d.lock.Lock()
+		delete(d.checked, key)
+		d.lock.Unlock()
+		return app, nil
+	}
+
+	interval := driftCheckInterval()
+	if interval == 0 || app.Spec.HelmMajorVersion != 3 || app.Spec.Info == nil || app.Spec.Info.Status != v1.StatusDeployed {
+		return app, nil
+	}
+
+	if last := d.lastChecked(key, app); !last.IsZero() {
+		if wait := interval - time.Since(last); wait > 0 {
+			d.apps.EnqueueAfter(app.Namespace, app.Name, wait)
+			return app, nil
+		}
+	}
+
+	drift := d.checkDrift(app)
+	if app.Status.Drift != nil {
+		drift.LastCorrected = app.Status.Drift.LastCorrected
+	}
+	if drift.Drifted && drift.Error == "" && enforced(app) {
+		if err := d.correctDrift(app, drift); err != nil {
+			drift.Error = err.Error()
+		} else {
+			logrus.Infof("[helm] re-applied %d drifted resources of app %s", len(drift.Resources), key)
+			corrected := drift.LastChecked
+			drift = d.checkDrift(app)
+			drift.LastCorrected = corrected
+		}
+	}
+
+	d.lock.Lock()
+	d.checked[key] = driftCheck{version: app.Spec.Version, time: drift.LastChecked.Time}
+	d.lock.Unlock()
+
+	if !sameDrift(app.Status.Drift, drift) {
+		app = app.DeepCopy()
+		app.Status.Drift = drift
+		var err error
+		if app, err = d.apps.UpdateStatus(app); err != nil {
+			return nil, err
+		}
+	}
+
+	d.apps.EnqueueAfter(app.Namespace, app.Name, interval)
+	return app, nil
+}
+
+// lastChecked returns when the release version of the given app was last checked, or the zero time if it never was.
+func (d *driftHandler) lastChecked(key string, app *v1.App) time.Time {
+	var last time.Time
+	if drift := app.Status.Drift; drift != nil && drift.ReleaseVersion == app.Spec.Version && drift.LastChecked != nil {
+		last = drift.LastChecked.Time
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if checked, ok := d.checked[key]; ok && checked.version == app.Spec.Version && checked.time.After(last) {
+		last = checked.time
+	}
+	return last
+}
+
+// sameDrift returns true if the given drift results only differ by the time of the check.
+func sameDrift(a, b *v1.ReleaseDrift) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	a, b = a.DeepCopy(), b.DeepCopy()
+	a.LastChecked, b.LastChecked = nil, nil
+	return equality.Semantic.DeepEqual(a, b)
+}
+
+// checkDrift compares the live resources of the release of the given app with its release manifest.
+func (d *driftHandler) checkDrift(app *v1.App) *v1.ReleaseDrift {
+	now := metav1.Now()
+	drift := &v1.ReleaseDrift{
+		ReleaseVersion: app.Spec.Version,
+		LastChecked:    &now,
+	}
+
+	desired, err := d.manifestObjects(app)
+	if err != nil {
+		drift.Error = err.Error()
+		return drift
+	}
+
+	for _, obj := range desired {
+		resource := v1.ResourceDrift{
+			ReleaseResource: v1.ReleaseResource{
+				Name:      obj.GetName(),
+				Namespace: obj.GetNamespace(),
+			},
+		}
+		resource.APIVersion, resource.Kind = obj.GroupVersionKind().ToAPIVersionAndKind()
+
+		live, err := d.get(obj)
+		if apierrors.IsNotFound(err) {
+			resource.Missing = true
+		} else if err != nil {
+			drift.Error = fmt.Sprintf("failed to get %s %s: %v", resource.Kind, resourceKey(obj), err)
+			return drift
+		} else if resource.Fields, err = helm.DriftedFields(obj, live); err != nil {
+			drift.Error = err.Error()
+			return drift
+		}
+
+		if resource.Missing || len(resource.Fields) > 0 {
+			drift.Resources = append(drift.Resources, resource)
+		}
+	}
+
+	drift.Drifted = len(drift.Resources) > 0
+	return drift
+}
+
+// correctDrift re-applies the drifted resources of the given app from its release manifest. Missing
+// resources are created and the fields of the other drifted resources are reset with a merge patch.
+func (d *driftHandler) correctDrift(app *v1.App, drift *v1.ReleaseDrift) error {
+	desired, err := d.manifestObjects(app)
+	if err != nil {
+		return err
+	}
+
+	drifted := map[v1.ReleaseResource]v1.ResourceDrift{}
+	for _, resource := range drift.Resources {
+		drifted[resource.ReleaseResource] = resource
+	}
+
+	for _, obj := range desired {
+		key := v1.ReleaseResource{
+			Name:      obj.GetName(),
+			Namespace: obj.GetNamespace(),
+		}
+		key.APIVersion, key.Kind = obj.GroupVersionKind().ToAPIVersionAndKind()
+		resource, ok := drifted[key]
+		if !ok {
+			continue
+		}
+
+		c, err := d.sharedClientFactory.ForKind(obj.GroupVersionKind())
+		if err != nil {
+			return err
+		}
+		if resource.Missing {
+			err = c.Create(d.ctx, obj.GetNamespace(), releaseOwned(app, obj), &unstructured.Unstructured{}, metav1.CreateOptions{})
+		} else {
+			var patch []byte
+			patch, err = helm.CorrectionPatch(obj)
+			if err != nil {
+				return err
+			}
+			err = c.Patch(d.ctx, obj.GetNamespace(), obj.GetName(), types.MergePatchType, patch, &unstructured.Unstructured{}, metav1.PatchOptions{})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to re-apply %s %s: %w", key.Kind, resourceKey(obj), err)
+		}
+	}
+
+	return nil
+}
+
+// manifestObjects returns the objects of the manifest of the release of the given app.
+func (d *driftHandler) manifestObjects(app *v1.App) ([]*unstructured.Unstructured, error) {
+	secrets, err := d.secretCache.List(app.Namespace, labels.SelectorFromSet(labels.Set{
+		"owner":   "helm",
+		"name":    app.Spec.Name,
+		"version": strconv.Itoa(app.Spec.Version),
+	}))
+	if err != nil {
+		return nil, err
+	}
+	if len(secrets) == 0 {
+		return nil, fmt.Errorf("failed to find the secret of version %d of release %s", app.Spec.Version, app.Spec.Name)
+	}
+
+	return helm.ManifestObjects(secrets[0], d.isNamespaced)
+}
+
+func (d *driftHandler) get(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	c, err := d.sharedClientFactory.ForKind(obj.GroupVersionKind())
+	if err != nil {
+		return nil, err
+	}
+	live := &unstructured.Unstructured{}
+	if err := c.Get(d.ctx, obj.GetNamespace(), obj.GetName(), live, metav1.GetOptions{}); err != nil {
+		return nil, err
+	}
+	return live, nil
+}
+
+func (d *driftHandler) isNamespaced(gvk schema.GroupVersionKind) bool {
+	_, nsed, err := d.sharedClientFactory.ResourceForGVK(gvk)
+	if err != nil {
+		return false
+	}
+	return nsed
+}
+
+// releaseOwned returns a copy of the given object with the labels and annotations helm sets on the resources of
+// a release, so that the re-created resource is adopted by the next upgrade of the release.
+func releaseOwned(app *v1.App, obj *unstructured.Unstructured) *unstructured.Unstructured {
+	obj = obj.DeepCopy()
+	objLabels := obj.GetLabels()
+	if objLabels == nil {
+		objLabels = map[string]string{}
+	}
+	objLabels["app.kubernetes.io/managed-by"] = "Helm"
+	obj.SetLabels(objLabels)
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations["meta.helm.sh/release-name"] = app.Spec.Name
+	annotations["meta.helm.sh/release-namespace"] = app.Namespace
+	obj.SetAnnotations(annotations)
+	return obj
+}
+
+// enforced returns true if drifted resources of the given app must be re-applied.
+func enforced(app *v1.App) bool {
+	return app.Annotations[v1.AppEnforceReleaseAnnotation] == "true"
+}
+
+func driftCheckInterval() time.Duration {
+	value := settings.AppDriftCheckInterval.Get()
+	if value == "" {
+		return 0
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		logrus.Errorf("[helm] invalid value %q for setting %s: %v", value, settings.AppDriftCheckInterval.Name, err)
+		return 0
+	}
+	return interval
+}
+
+func resourceKey(obj *unstructured.Unstructured) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetName()
+	}
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
@@ -0,0 +1,56 @@
+package helm
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSameDrift(t *testing.T) {
+	checked := metav1.NewTime(time.Now())
+	later := metav1.NewTime(checked.Add(time.Minute))
+	drift := &v1.ReleaseDrift{
+		ReleaseVersion: 2,
+		LastChecked:    &checked,
+		Drifted:        true,
+		Resources: []v1.ResourceDrift{{
+			ReleaseResource: v1.ReleaseResource{APIVersion: "v1", Kind: "ConfigMap", Namespace: "default", Name: "test"},
+			Fields:          []string{"data.key"},
+		}},
+	}
+
+	rechecked := drift.DeepCopy()
+	rechecked.LastChecked = &later
+	assert.True(t, sameDrift(drift, rechecked))
+	assert.Equal(t, &checked, drift.LastChecked)
+
+	corrected := rechecked.DeepCopy()
+	corrected.Drifted = false
+	corrected.Resources = nil
+	assert.False(t, sameDrift(drift, corrected))
+
+	assert.False(t, sameDrift(nil, drift))
+	assert.True(t, sameDrift(nil, nil))
+}
+
+func TestLastChecked(t *testing.T) {
+	checked := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	app := &v1.App{
+		Spec:   v1.ReleaseSpec{Version: 2},
+		Status: v1.ReleaseStatus{Drift: &v1.ReleaseDrift{ReleaseVersion: 2, LastChecked: &checked}},
+	}
+	d := &driftHandler{checked: map[string]driftCheck{}}
+	assert.True(t, checked.Time.Equal(d.lastChecked("default/test", app)))
+
+	// checks that were not written to the status are taken into account
+	now := time.Now()
+	d.checked["default/test"] = driftCheck{version: 2, time: now}
+	assert.True(t, now.Equal(d.lastChecked("default/test", app)))
+
+	// but not once the release was upgraded
+	app.Spec.Version = 3
+	assert.True(t, d.lastChecked("default/test", app).IsZero())
+}
@@ -6,9 +6,13 @@
 
 	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
 	catalogcontrollers "github.com/rancher/rancher/pkg/generated/controllers/catalog.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/tracing"
 	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
 	"github.com/rancher/wrangler/v3/pkg/kstatus"
 	"github.com/rancher/wrangler/v3/pkg/relatedresource"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -83,6 +87,10 @@ func (o *operationHandler) onOperationChange(operation *catalog.Operation, statu
 			kstatus.SetTransitioning(&status, "running operation")
 		} else if container.State.Terminated != nil {
 			status.PodCreated = true
+			if !kstatus.Reconciling.IsFalse(&status) {
+				// the operation is seen completed for the first time
+				recordPodSpan(o.ctx, operation, pod, container.State.Terminated)
+			}
 			if container.State.Terminated.ExitCode == 0 {
 				kstatus.SetActive(&status)
 			} else {
@@ -104,6 +112,27 @@ func (o *operationHandler) onOperationChange(operation *catalog.Operation, statu
 	return status, nil
 }
 
+// recordPodSpan records the run of the helm commands of the pod of an operation in a span, which continues the trace
+// of the request that created the operation.
+func recordPodSpan(ctx context.Context, operation *catalog.Operation, pod *corev1.Pod, terminated *corev1.ContainerStateTerminated) {
+	ctx = tracing.ContextFromAnnotations(ctx, operation.Annotations)
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return
+	}
+	_, span := tracing.Tracer().Start(ctx, "helm operation pod",
+		trace.WithTimestamp(terminated.StartedAt.Time),
+		trace.WithAttributes(
+			attribute.String("helm.action", operation.Status.Action),
+			attribute.String("k8s.namespace.name", pod.Namespace),
+			attribute.String("k8s.pod.name", pod.Name),
+			attribute.Int("process.exit.code", int(terminated.ExitCode)),
+		))
+	if terminated.ExitCode != 0 {
+		span.SetStatus(codes.Error, fmt.Sprintf("%s exit code: %d", terminated.Message, terminated.ExitCode))
+	}
+	span.End(trace.WithTimestamp(terminated.FinishedAt.Time))
+}
+
 func (o *operationHandler) cleanup(pod *corev1.Pod) error {
 	running := false
 	success := false
@@ -0,0 +1,64 @@
+package helm
+
+import (
+	"context"
+	"testing"
+
+	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/tracing"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestOnOperationChangeRecordsPodSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	ctx, request := tracing.Tracer().Start(context.Background(), "helm operation")
+	request.End()
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "cattle-system", Name: "helm-operation-abc"},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name: "helm",
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+				ExitCode:   1,
+				StartedAt:  metav1.Unix(100, 0),
+				FinishedAt: metav1.Unix(160, 0),
+			}},
+		}}},
+	}
+	pods := fake.NewMockCacheInterface[*corev1.Pod](gomock.NewController(t))
+	pods.EXPECT().Get(pod.Namespace, pod.Name).Return(pod, nil).Times(2)
+	o := &operationHandler{ctx: context.Background(), pods: pods}
+
+	operation := &catalog.Operation{
+		ObjectMeta: metav1.ObjectMeta{Annotations: tracing.Annotations(ctx)},
+		Status:     catalog.OperationStatus{Action: "install", PodNamespace: pod.Namespace, PodName: pod.Name},
+	}
+	status, err := o.onOperationChange(operation, operation.Status)
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	span := spans[1]
+	assert.Equal(t, "helm operation pod", span.Name())
+	assert.Equal(t, request.SpanContext().SpanID(), span.Parent().SpanID())
+	assert.Equal(t, codes.Error, span.Status().Code)
+	assert.Equal(t, int64(60), int64(span.EndTime().Sub(span.StartTime()).Seconds()))
+
+	// the span is only recorded once
+	_, err = o.onOperationChange(operation, status)
+	require.NoError(t, err)
+	assert.Len(t, recorder.Ended(), 2)
+}
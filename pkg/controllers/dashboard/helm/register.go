@@ -12,7 +12,8 @@ func Register(ctx context.Context, wrangler *wrangler.Context) {
 		wrangler.Core.Secret().Cache(),
 		wrangler.Catalog.ClusterRepo(),
 		wrangler.Core.ConfigMap(),
-		wrangler.Core.ConfigMap().Cache())
+		wrangler.Core.ConfigMap().Cache(),
+		wrangler.EventRecorder)
 	RegisterOCIRepo(ctx,
 		wrangler.Apply,
 		wrangler.Catalog.ClusterRepo(),
@@ -24,6 +25,17 @@ func Register(ctx context.Context, wrangler *wrangler.Context) {
 		wrangler.Core.ConfigMap(),
 		wrangler.Core.Secret(),
 		wrangler.Catalog.App())
+	RegisterDrift(ctx,
+		wrangler.ControllerFactory.SharedCacheFactory().SharedClientFactory(),
+		wrangler.Core.Secret().Cache(),
+		wrangler.Catalog.App())
+	RegisterUpgrades(ctx,
+		wrangler.ControllerFactory.SharedCacheFactory().SharedClientFactory(),
+		wrangler.Catalog.App(),
+		wrangler.Catalog.ClusterRepo(),
+		wrangler.HelmOperations,
+		wrangler.CatalogContentManager,
+		wrangler.EventRecorder)
 	RegisterOperations(ctx,
 		wrangler.K8s,
 		wrangler.Core.Pod(),
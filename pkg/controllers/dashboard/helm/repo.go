@@ -14,8 +14,10 @@
 
 	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
 	"github.com/rancher/rancher/pkg/catalogv2"
+	"github.com/rancher/rancher/pkg/catalogv2/bundle"
 	"github.com/rancher/rancher/pkg/catalogv2/git"
 	helmhttp "github.com/rancher/rancher/pkg/catalogv2/http"
+	"github.com/rancher/rancher/pkg/events"
 	catalogcontrollers "github.com/rancher/rancher/pkg/generated/controllers/catalog.cattle.io/v1"
 	namespaces "github.com/rancher/rancher/pkg/namespace"
 	"github.com/rancher/wrangler/v3/pkg/apply"
@@ -30,6 +32,8 @@
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/yaml"
 )
 
 const (
@@ -50,6 +54,7 @@ type repoHandler struct {
 	configMaps     corev1controllers.ConfigMapClient
 	configMapCache corev1controllers.ConfigMapCache
 	apply          apply.Apply
+	recorder       record.EventRecorder
 }
 
 func RegisterRepos(ctx context.Context,
@@ -57,13 +62,15 @@ func RegisterRepos(ctx context.Context,
 	secrets corev1controllers.SecretCache,
 	clusterRepos catalogcontrollers.ClusterRepoController,
 	configMap corev1controllers.ConfigMapController,
-	configMapCache corev1controllers.ConfigMapCache) {
+	configMapCache corev1controllers.ConfigMapCache,
+	recorder record.EventRecorder) {
 	h := &repoHandler{
 		secrets:        secrets,
 		clusterRepos:   clusterRepos,
 		configMaps:     configMap,
 		configMapCache: configMapCache,
 		apply:          apply.WithCacheTypes(configMap).WithStrictCaching().WithSetOwnerReference(false, false),
+		recorder:       recorder,
 	}
 
 	clusterRepos.OnChange(ctx, "helm-clusterrepo-download-on-change", h.ClusterRepoOnChange)
@@ -246,7 +253,12 @@ func (r *repoHandler) ensure(repoSpec *catalog.RepoSpec, status catalog.RepoStat
 		return status, err
 	}
 
-	return status, git.Ensure(secret, metadata.Namespace, metadata.Name, status.URL, status.Commit, repoSpec.InsecureSkipTLSverify, repoSpec.CABundle)
+	keys, err := r.gitCommitKeys(repoSpec, metadata)
+	if err != nil {
+		return status, err
+	}
+
+	return status, git.Ensure(secret, metadata.Namespace, metadata.Name, status.URL, status.Commit, repoSpec.InsecureSkipTLSverify, repoSpec.CABundle, keys)
 }
 
 func (r *repoHandler) download(repository *catalog.ClusterRepo, newStatus *catalog.RepoStatus, owner metav1.OwnerReference, interval time.Duration, retryPolicy retryPolicy) (*catalog.ClusterRepo, error) {
@@ -267,28 +279,40 @@ func (r *repoHandler) download(repository *catalog.ClusterRepo, newStatus *catal
 	downloadTime := metav1.Now()
 	backoff := calculateBackoff(repository, retryPolicy)
 	retriable := false
-	if repoSpec.GitRepo != "" && newStatus.IndexConfigMapName == "" {
-		commit, err = git.Head(secret, metadata.Namespace, metadata.Name, repoSpec.GitRepo, repoSpec.GitBranch, repoSpec.InsecureSkipTLSverify, repoSpec.CABundle)
+	if repoSpec.GitRepo != "" {
+		var keys *git.CommitKeys
+		keys, err = r.gitCommitKeys(&repoSpec, &metadata)
 		if err != nil {
-			retriable = true
+			return setErrorCondition(repository, err, newStatus, interval, repoCondition, r.clusterRepos)
+		}
+		if newStatus.IndexConfigMapName == "" {
+			commit, err = git.Head(secret, metadata.Namespace, metadata.Name, repoSpec.GitRepo, gitRevision(&repoSpec), repoSpec.InsecureSkipTLSverify, repoSpec.CABundle, keys)
 		} else {
-			newStatus.URL = repoSpec.GitRepo
-			newStatus.Branch = repoSpec.GitBranch
-			index, err = git.BuildOrGetIndex(metadata.Namespace, metadata.Name, repoSpec.GitRepo)
+			commit, err = git.Update(secret, metadata.Namespace, metadata.Name, repoSpec.GitRepo, gitRevision(&repoSpec), repoSpec.InsecureSkipTLSverify, repoSpec.CABundle, keys)
 		}
-	} else if repoSpec.GitRepo != "" {
-		commit, err = git.Update(secret, metadata.Namespace, metadata.Name, repoSpec.GitRepo, repoSpec.GitBranch, repoSpec.InsecureSkipTLSverify, repoSpec.CABundle)
 		if err != nil {
 			retriable = true
+			// A fresh clone may be left on a commit that failed the verification, so the repo is reset back to
+			// the last commit that was indexed.
+			if keys != nil && newStatus.Commit != "" {
+				if ensureErr := git.Ensure(secret, metadata.Namespace, metadata.Name, repoSpec.GitRepo, newStatus.Commit, repoSpec.InsecureSkipTLSverify, repoSpec.CABundle, keys); ensureErr != nil {
+					logrus.Errorf("[%s] failed to reset git repo to commit %s: %v", metadata.Name, newStatus.Commit, ensureErr)
+				}
+			}
 		} else {
 			newStatus.URL = repoSpec.GitRepo
 			newStatus.Branch = repoSpec.GitBranch
-			if newStatus.Commit == commit {
+			// The index is rebuilt if the spec changed, as the subdirectory or the trusted keys may have changed.
+			if newStatus.IndexConfigMapName != "" && newStatus.Commit == commit && newStatus.ObservedGeneration == repository.Generation {
 				newStatus.DownloadTime = downloadTime
 				return setErrorCondition(repository, err, newStatus, interval, repoCondition, r.clusterRepos)
 			}
-			index, err = git.BuildOrGetIndex(metadata.Namespace, metadata.Name, repoSpec.GitRepo)
+			index, err = git.BuildOrGetIndex(metadata.Namespace, metadata.Name, repoSpec.GitRepo, repoSpec.GitSubDirectory)
 		}
+	} else if repoSpec.Imported {
+		index, err = r.importedIndex(&metadata)
+		newStatus.URL = ""
+		newStatus.Branch = ""
 	} else if repoSpec.URL != "" {
 		index, err = helmhttp.DownloadIndex(secret, repoSpec.URL, repoSpec.CABundle, repoSpec.InsecureSkipTLSverify, repoSpec.DisableSameOriginCheck)
 		retriable = true
@@ -297,6 +321,9 @@ func (r *repoHandler) download(repository *catalog.ClusterRepo, newStatus *catal
 	} else {
 		return setErrorCondition(repository, err, newStatus, interval, repoCondition, r.clusterRepos)
 	}
+	if err != nil {
+		r.recorder.Event(repository, corev1.EventTypeWarning, events.ReasonRepoDownloadFailed, err.Error())
+	}
 	if retriable && err != nil {
 		newStatus.NumberOfRetries++
 		if newStatus.NumberOfRetries > retryPolicy.MaxRetry {
@@ -314,8 +341,10 @@ func (r *repoHandler) download(repository *catalog.ClusterRepo, newStatus *catal
 	index.SortEntries()
 	cm, err := createOrUpdateMap(metadata.Namespace, index, owner, r.apply)
 	if err != nil {
+		r.recorder.Event(repository, corev1.EventTypeWarning, events.ReasonRepoDownloadFailed, err.Error())
 		return setErrorCondition(repository, err, newStatus, interval, repoCondition, r.clusterRepos)
 	}
+	r.recorder.Eventf(repository, corev1.EventTypeNormal, events.ReasonRepoDownloaded, "Downloaded the index of %d charts", len(index.Entries))
 
 	newStatus.IndexConfigMapName = cm.Name
 	newStatus.IndexConfigMapNamespace = cm.Namespace
@@ -325,6 +354,59 @@ func (r *repoHandler) download(repository *catalog.ClusterRepo, newStatus *catal
 	return setErrorCondition(repository, nil, newStatus, interval, repoCondition, r.clusterRepos)
 }
 
+// importedIndex reads the index of a repository imported from a catalog bundle.
+func (r *repoHandler) importedIndex(metadata *metav1.ObjectMeta) (*repo.IndexFile, error) {
+	data, err := bundle.ReadFile(func(namespace, name string) (*corev1.ConfigMap, error) {
+		return r.configMaps.Get(namespace, name, metav1.GetOptions{})
+	}, namespaces.System, metadata.Name, metadata.Annotations[bundle.GenerationAnnotation], bundle.IndexFile)
+	if err != nil {
+		return nil, err
+	}
+
+	index := &repo.IndexFile{}
+	if err := yaml.Unmarshal(data, index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// gitRevision returns the git revision the helm repository is pinned to: the commit, the tag or the branch.
+func gitRevision(repoSpec *catalog.RepoSpec) string {
+	if repoSpec.GitCommit != "" {
+		return repoSpec.GitCommit
+	}
+	if repoSpec.GitTag != "" {
+		return repoSpec.GitTag
+	}
+	return repoSpec.GitBranch
+}
+
+// gitCommitKeys returns the keys trusted to sign the commits of the git repo by the commit verification policy of the
+// repository, or nil if it has none.
+func (r *repoHandler) gitCommitKeys(repoSpec *catalog.RepoSpec, metadata *metav1.ObjectMeta) (*git.CommitKeys, error) {
+	if repoSpec.GitCommitVerification == nil {
+		return nil, nil
+	}
+
+	ref := repoSpec.GitCommitVerification.PublicKeysSecret
+	if ref == nil {
+		return nil, errors.New("git commit verification requires a public keys secret")
+	}
+	ns := ref.Namespace
+	if metadata.Namespace != "" {
+		ns = metadata.Namespace
+	}
+	secret, err := r.secrets.Get(ns, ref.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &git.CommitKeys{
+		GPG: secret.Data["gpg"],
+		SSH: secret.Data["ssh"],
+	}, nil
+}
+
 func ensureIndexConfigMap(status *catalog.RepoStatus, configMap corev1controllers.ConfigMapClient) error {
 	// Charts from the clusterRepo will be unavailable if the IndexConfigMap recorded in the status does not exist.
 	// By resetting the value of IndexConfigMapName, IndexConfigMapNamespace, IndexConfigMapResourceVersion to "",
@@ -281,6 +281,12 @@ func getIndexfile(clusterRepoStatus catalog.RepoStatus,
 		if err != nil {
 			return indexFile, fmt.Errorf("failed to fetch the index configmap for clusterRepo %s", owner.Name)
 		}
+		// The index is only updated incrementally from the version recorded in the status, the index
+		// is generated from scratch if the configmap was changed since.
+		if clusterRepoStatus.IndexConfigMapResourceVersion != "" && configMap.ResourceVersion != clusterRepoStatus.IndexConfigMapResourceVersion {
+			logrus.Debugf("index configmap of clusterRepo %s changed since it was generated, generating it again", owner.Name)
+			return indexFile, nil
+		}
 	} else {
 		// otherwise if the configmap is already created, fetch it using the name of the configmap and the namespace.
 		configMapName := GenerateConfigMapName(owner.Name, 0, owner.UID)
@@ -201,6 +201,38 @@ func(ctrl *gomock.Controller) *fake.MockControllerInterface[*corev1.ConfigMap, *
 			indexFile,
 			"",
 		},
+		{
+			"empty indexFile is returned if the configmap changed since the status was updated",
+			&catalog.ClusterRepo{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "repoName",
+					UID:  "unique",
+				},
+				Spec: catalog.RepoSpec{
+					URL: "www.example.com",
+				},
+				Status: catalog.RepoStatus{
+					URL:                           "www.example.com",
+					IndexConfigMapName:            "repoName-0-unique",
+					IndexConfigMapNamespace:       "cattle-system",
+					IndexConfigMapResourceVersion: "1",
+				},
+			},
+			func(ctrl *gomock.Controller) *fake.MockControllerInterface[*corev1.ConfigMap, *corev1.ConfigMapList] {
+				configMapControllerFake := fake.NewMockControllerInterface[*corev1.ConfigMap, *corev1.ConfigMapList](ctrl)
+				// Set the configMapController Expectations
+
+				changedConfigMap := configMap.DeepCopy()
+				changedConfigMap.ResourceVersion = "2"
+				configMapControllerFake.EXPECT().Get("cattle-system", "repoName-0-unique", metav1.GetOptions{}).Return(changedConfigMap, nil)
+
+				return configMapControllerFake
+			},
+			false,
+			"",
+			repo.NewIndexFile(),
+			"",
+		},
 		{
 			"when spec URL and status URL differ, empty indexFile is returned",
 			&catalog.ClusterRepo{
@@ -0,0 +1,382 @@
+package helm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/rancher/lasso/pkg/client"
+	"github.com/rancher/rancher/pkg/api/steve/catalog/types"
+	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/catalogv2/helm"
+	"github.com/rancher/rancher/pkg/events"
+	catalogv1 "github.com/rancher/rancher/pkg/generated/controllers/catalog.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/notifier"
+	"github.com/rancher/wrangler/v3/pkg/summary"
+	"github.com/sirupsen/logrus"
+	"helm.sh/helm/v3/pkg/repo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/tools/record"
+)
+
+const upgradeHealthCheckInterval = 15 * time.Second
+
+// UpgradeOperations creates the helm operations of automatic upgrades.
+type UpgradeOperations interface {
+	Upgrade(ctx context.Context, user user.Info, namespace, name string, options io.Reader, imageOverride string) (*v1.Operation, error)
+	Rollback(ctx context.Context, user user.Info, namespace, name string, options io.Reader, imageOverride string) (*v1.Operation, error)
+}
+
+// UpgradeContent provides the index of the ClusterRepo of an App.
+type UpgradeContent interface {
+	Index(namespace, name, targetK8sVersion string, skipFilter bool) (*repo.IndexFile, error)
+}
+
+// upgradeHandler upgrades the Apps that have an upgrade policy when new versions of their chart are added to their
+// ClusterRepo, then checks the health of the upgraded release and rolls it back if the policy asks for it.
+type upgradeHandler struct {
+	ctx                 context.Context
+	apps                catalogv1.AppController
+	clusterRepos        catalogv1.ClusterRepoCache
+	operations          UpgradeOperations
+	content             UpgradeContent
+	sharedClientFactory client.SharedClientFactory
+	recorder            record.EventRecorder
+}
+
+func RegisterUpgrades(ctx context.Context,
+	shareClientFactory client.SharedClientFactory,
+	apps catalogv1.AppController,
+	clusterRepos catalogv1.ClusterRepoController,
+	operations UpgradeOperations,
+	content UpgradeContent,
+	recorder record.EventRecorder,
+) {
+	u := &upgradeHandler{
+		ctx:                 ctx,
+		apps:                apps,
+		clusterRepos:        clusterRepos.Cache(),
+		operations:          operations,
+		content:             content,
+		sharedClientFactory: shareClientFactory,
+		recorder:            recorder,
+	}
+	apps.OnChange(ctx, "helm-app-upgrade", u.OnChange)
+	clusterRepos.OnChange(ctx, "helm-app-upgrade-repo", u.OnRepoChange)
+}
+
+// OnRepoChange re-evaluates the upgrade policies of the Apps installed from a ClusterRepo whenever it changes, so
+// that new versions are found as soon as the index of the repository is refreshed.
+func (u *upgradeHandler) OnRepoChange(_ string, clusterRepo *v1.ClusterRepo) (*v1.ClusterRepo, error) {
+	if clusterRepo == nil || clusterRepo.DeletionTimestamp != nil {
+		return clusterRepo, nil
+	}
+
+	apps, err := u.apps.Cache().List("", labels.SelectorFromSet(labels.Set{
+		v1.ClusterRepoNameLabel: clusterRepo.Name,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	for _, app := range apps {
+		if _, ok := app.Annotations[v1.AppUpgradePolicyAnnotation]; ok {
+			u.apps.Enqueue(app.Namespace, app.Name)
+		}
+	}
+	return clusterRepo, nil
+}
+
+func (u *upgradeHandler) OnChange(key string, app *v1.App) (*v1.App, error) {
+	if app == nil || app.DeletionTimestamp != nil || app.Spec.HelmMajorVersion != 3 {
+		return app, nil
+	}
+
+	policy, err := helm.UpgradePolicy(app)
+	if policy == nil && err == nil {
+		if app.Status.Upgrade == nil {
+			return app, nil
+		}
+		// the policy was removed
+		return u.updateStatus(app, nil)
+	}
+
+	status := &v1.ReleaseUpgrade{}
+	if app.Status.Upgrade != nil {
+		status = app.Status.Upgrade.DeepCopy()
+	}
+	if err != nil {
+		status.Error = err.Error()
+		return u.updateStatus(app, status)
+	}
+
+	if status.State == v1.UpgradeStateUpgrading {
+		u.checkUpgrade(key, app, policy, status)
+	} else {
+		u.planUpgrade(key, app, policy, status)
+	}
+	return u.updateStatus(app, status)
+}
+
+// planUpgrade looks for a newer version of the chart of the given app allowed by its upgrade policy, and starts the
+// upgrade if the upgrade window is open.
+func (u *upgradeHandler) planUpgrade(key string, app *v1.App, policy *v1.AppUpgradePolicy, status *v1.ReleaseUpgrade) {
+	repoName := app.Labels[v1.ClusterRepoNameLabel]
+	if repoName == "" || app.Spec.Chart == nil || app.Spec.Chart.Metadata == nil {
+		status.Error = "the app was not installed from a ClusterRepo"
+		return
+	}
+	chartName, currentVersion := app.Spec.Chart.Metadata.Name, app.Spec.Chart.Metadata.Version
+
+	index, err := u.content.Index("", repoName, "", false)
+	if err != nil {
+		status.Error = fmt.Sprintf("failed to get the index of repository %s: %v", repoName, err)
+		return
+	}
+	candidate, err := helm.UpgradeCandidate(policy, index, chartName, currentVersion)
+	if err != nil {
+		status.Error = err.Error()
+		return
+	}
+	status.Error = ""
+	status.NextWindow = nil
+
+	if candidate == nil || (status.State == v1.UpgradeStateFailed || status.State == v1.UpgradeStateRolledBack) && candidate.Version == status.ToVersion {
+		// a version that failed its upgrade is not retried, a newer one has to be released
+		status.PendingVersion = ""
+		if status.State == "" || status.State == v1.UpgradeStatePending {
+			status.State = v1.UpgradeStateUpToDate
+		}
+		return
+	}
+
+	if status.PendingVersion != candidate.Version {
+		logrus.Infof("[helm] version %s of chart %s is pending for the upgrade of app %s from version %s", candidate.Version, chartName, key, currentVersion)
+		u.recorder.Eventf(app, corev1.EventTypeNormal, events.ReasonAppUpgradePending, "Version %s is pending for the upgrade from version %s", candidate.Version, currentVersion)
+		notifyUpgrade(notifier.EventAppUpgradePending, app,
+			fmt.Sprintf("Upgrade of app %s pending", key),
+			fmt.Sprintf("Version %s of chart %s is pending for the upgrade of app %s from version %s.", candidate.Version, chartName, key, currentVersion))
+	}
+	status.State = v1.UpgradeStatePending
+	status.PendingVersion = candidate.Version
+
+	if app.Spec.Info == nil || app.Spec.Info.Status != v1.StatusDeployed {
+		// the app is re-evaluated when its release changes
+		return
+	}
+	open, next, err := helm.UpgradeWindowOpen(policy.Window, time.Now())
+	if err != nil {
+		status.Error = err.Error()
+		return
+	}
+	if !open {
+		status.NextWindow = &metav1.Time{Time: next}
+		u.apps.EnqueueAfter(app.Namespace, app.Name, time.Until(next))
+		return
+	}
+
+	op, err := u.upgrade(app, repoName, candidate.Version, policy)
+	if err != nil {
+		status.Error = fmt.Sprintf("failed to upgrade to version %s: %v", candidate.Version, err)
+		u.recorder.Event(app, corev1.EventTypeWarning, events.ReasonAppUpgradeFailed, status.Error)
+		notifyUpgrade(notifier.EventAppUpgradeFailed, app, fmt.Sprintf("Upgrade of app %s failed", key), status.Error)
+		return
+	}
+
+	logrus.Infof("[helm] upgrading app %s from version %s to %s", key, currentVersion, candidate.Version)
+	u.recorder.Eventf(app, corev1.EventTypeNormal, events.ReasonAppUpgrading, "Upgrading from version %s to %s", currentVersion, candidate.Version)
+	now := metav1.Now()
+	status.State = v1.UpgradeStateUpgrading
+	status.PendingVersion = ""
+	status.FromVersion = currentVersion
+	status.FromRevision = app.Spec.Version
+	status.ToVersion = candidate.Version
+	status.Operation = op.Namespace + "/" + op.Name
+	status.Started = &now
+	status.Completed = nil
+	u.apps.EnqueueAfter(app.Namespace, app.Name, upgradeHealthCheckInterval)
+}
+
+// upgradeUser returns the user automatic upgrades and rollbacks of the apps of the given ClusterRepo run as: its
+// service account. The upgrade policy of an app is set by whoever can edit the app, so the identity is only taken from
+// the ClusterRepo, which only administrators can edit, and upgrades are refused if it has no service account.
+func (u *upgradeHandler) upgradeUser(repoName string) (user.Info, error) {
+	clusterRepo, err := u.clusterRepos.Get(repoName)
+	if err != nil {
+		return nil, err
+	}
+	if sa, ns := clusterRepo.Spec.ServiceAccount, clusterRepo.Spec.ServiceAccountNamespace; sa != "" && ns != "" && !strings.Contains(ns, ":") {
+		return serviceAccountUser(ns, sa), nil
+	}
+	return nil, fmt.Errorf("automatic upgrades require a service account on ClusterRepo %s", repoName)
+}
+
+// notifyUpgrade sends a notification of an automatic upgrade of the given app.
+func notifyUpgrade(eventType string, app *v1.App, title, message string) {
+	notifier.Notify(notifier.Event{
+		Type:    eventType,
+		Subject: app.Namespace + "/" + app.Name,
+		Title:   title,
+		Message: message,
+	})
+}
+
+func serviceAccountUser(namespace, name string) user.Info {
+	return &user.DefaultInfo{
+		Name: fmt.Sprintf("system:serviceaccount:%s:%s", namespace, name),
+		Groups: []string{
+			"system:serviceaccounts",
+			"system:serviceaccounts:" + namespace,
+		},
+	}
+}
+
+// upgrade creates the operation upgrading the release of the given app to the given version, carrying forward the
+// values selected by its upgrade policy.
+func (u *upgradeHandler) upgrade(app *v1.App, repoName, version string, policy *v1.AppUpgradePolicy) (*v1.Operation, error) {
+	upgradeUser, err := u.upgradeUser(repoName)
+	if err != nil {
+		return nil, err
+	}
+	upgrade, err := json.Marshal(types.ChartUpgradeAction{
+		Timeout:    &metav1.Duration{Duration: helm.UpgradeHealthTimeout(policy)},
+		Wait:       true,
+		MaxHistory: 5,
+		Namespace:  app.Namespace,
+		Charts: []types.ChartUpgrade{
+			{
+				ChartName:   app.Spec.Chart.Metadata.Name,
+				Version:     version,
+				ReleaseName: app.Spec.Name,
+				Values:      helm.CarriedValues(app.Spec.Values, policy.CarryValues),
+				ResetValues: true,
+				Description: fmt.Sprintf("Automatic upgrade from version %s", app.Spec.Chart.Metadata.Version),
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u.operations.Upgrade(u.ctx, upgradeUser, "", repoName, bytes.NewBuffer(upgrade), "")
+}
+
+// checkUpgrade is the health gate of an upgrade: the upgrade succeeds once the new revision of the release is
+// deployed and all its resources are ready, and fails if that does not happen before the health timeout.
+func (u *upgradeHandler) checkUpgrade(key string, app *v1.App, policy *v1.AppUpgradePolicy, status *v1.ReleaseUpgrade) {
+	upgraded := app.Spec.Version > status.FromRevision && app.Spec.Chart != nil && app.Spec.Chart.Metadata != nil &&
+		app.Spec.Chart.Metadata.Version == status.ToVersion && app.Spec.Info != nil
+
+	reason := ""
+	if upgraded && app.Spec.Info.Status == v1.StatusFailed {
+		reason = "the release failed"
+	} else if upgraded && app.Spec.Info.Status == v1.StatusDeployed {
+		healthy, err := u.healthy(app)
+		if err != nil {
+			reason = err.Error()
+		} else if healthy {
+			logrus.Infof("[helm] upgraded app %s from version %s to %s", key, status.FromVersion, status.ToVersion)
+			u.recorder.Eventf(app, corev1.EventTypeNormal, events.ReasonAppUpgraded, "Upgraded from version %s to %s", status.FromVersion, status.ToVersion)
+			notifyUpgrade(notifier.EventAppUpgraded, app,
+				fmt.Sprintf("App %s upgraded", key),
+				fmt.Sprintf("App %s was upgraded from version %s to %s.", key, status.FromVersion, status.ToVersion))
+			now := metav1.Now()
+			status.State = v1.UpgradeStateSucceeded
+			status.Completed = &now
+			status.Error = ""
+			return
+		}
+	}
+
+	if reason == "" {
+		if status.Started != nil && time.Since(status.Started.Time) < helm.UpgradeHealthTimeout(policy) {
+			u.apps.EnqueueAfter(app.Namespace, app.Name, upgradeHealthCheckInterval)
+			return
+		}
+		reason = "the resources of the release did not become ready in time"
+	}
+
+	now := metav1.Now()
+	status.Completed = &now
+	status.State = v1.UpgradeStateFailed
+	status.Error = fmt.Sprintf("upgrade to version %s failed: %s", status.ToVersion, reason)
+	logrus.Errorf("[helm] failed to upgrade app %s: %s", key, status.Error)
+	u.recorder.Event(app, corev1.EventTypeWarning, events.ReasonAppUpgradeFailed, status.Error)
+	notifyUpgrade(notifier.EventAppUpgradeFailed, app, fmt.Sprintf("Upgrade of app %s failed", key), status.Error)
+
+	if !policy.RollbackOnFailure || status.FromRevision == 0 {
+		return
+	}
+	if err := u.rollback(app, status.FromRevision, policy); err != nil {
+		status.Error = fmt.Sprintf("%s, rollback to revision %d failed: %v", status.Error, status.FromRevision, err)
+		return
+	}
+	logrus.Infof("[helm] rolling back app %s to revision %d", key, status.FromRevision)
+	u.recorder.Eventf(app, corev1.EventTypeNormal, events.ReasonAppRollingBack, "Rolling back to revision %d", status.FromRevision)
+	status.State = v1.UpgradeStateRolledBack
+}
+
+// rollback creates the operation rolling the release of the given app back to the given revision.
+func (u *upgradeHandler) rollback(app *v1.App, revision int, policy *v1.AppUpgradePolicy) error {
+	upgradeUser, err := u.upgradeUser(app.Labels[v1.ClusterRepoNameLabel])
+	if err != nil {
+		return err
+	}
+	rollback, err := json.Marshal(types.ChartRollbackAction{
+		Revision:   revision,
+		Timeout:    &metav1.Duration{Duration: helm.UpgradeHealthTimeout(policy)},
+		Wait:       true,
+		MaxHistory: 5,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = u.operations.Rollback(u.ctx, upgradeUser, app.Namespace, app.Spec.Name, bytes.NewBuffer(rollback), "")
+	return err
+}
+
+// healthy returns whether all the resources of the release of the given app are ready. An error is returned if a
+// resource is in an error state.
+func (u *upgradeHandler) healthy(app *v1.App) (bool, error) {
+	for _, resource := range app.Spec.Resources {
+		gvk := schema.FromAPIVersionAndKind(resource.APIVersion, resource.Kind)
+		c, err := u.sharedClientFactory.ForKind(gvk)
+		if err != nil {
+			return false, err
+		}
+		obj := &unstructured.Unstructured{}
+		err = c.Get(u.ctx, resource.Namespace, resource.Name, obj, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+
+		s := summary.Summarize(obj)
+		if s.Error {
+			return false, fmt.Errorf("%s %s/%s is in error state %s", resource.Kind, resource.Namespace, resource.Name, s.State)
+		}
+		if s.Transitioning {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (u *upgradeHandler) updateStatus(app *v1.App, status *v1.ReleaseUpgrade) (*v1.App, error) {
+	if equality.Semantic.DeepEqual(app.Status.Upgrade, status) {
+		return app, nil
+	}
+	app = app.DeepCopy()
+	app.Status.Upgrade = status
+	return u.apps.UpdateStatus(app)
+}
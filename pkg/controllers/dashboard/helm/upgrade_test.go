@@ -0,0 +1,181 @@
+package helm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/tools/record"
+)
+
+type testUpgradeOperations struct {
+	upgrades  []user.Info
+	rollbacks []user.Info
+}
+
+func (o *testUpgradeOperations) Upgrade(_ context.Context, user user.Info, _, _ string, _ io.Reader, _ string) (*v1.Operation, error) {
+	o.upgrades = append(o.upgrades, user)
+	return &v1.Operation{ObjectMeta: metav1.ObjectMeta{Namespace: "cattle-system", Name: "helm-operation-upgrade"}}, nil
+}
+
+func (o *testUpgradeOperations) Rollback(_ context.Context, user user.Info, _, _ string, _ io.Reader, _ string) (*v1.Operation, error) {
+	o.rollbacks = append(o.rollbacks, user)
+	return &v1.Operation{ObjectMeta: metav1.ObjectMeta{Namespace: "cattle-system", Name: "helm-operation-rollback"}}, nil
+}
+
+type testUpgradeContent struct {
+	index *repo.IndexFile
+}
+
+func (c *testUpgradeContent) Index(_, _, _ string, _ bool) (*repo.IndexFile, error) {
+	return c.index, nil
+}
+
+func upgradeTestApp(t *testing.T, policy v1.AppUpgradePolicy) *v1.App {
+	data, err := json.Marshal(policy)
+	require.NoError(t, err)
+	return &v1.App{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "test-ns",
+			Name:        "test",
+			Labels:      map[string]string{v1.ClusterRepoNameLabel: "test-repo"},
+			Annotations: map[string]string{v1.AppUpgradePolicyAnnotation: string(data)},
+		},
+		Spec: v1.ReleaseSpec{
+			Name:             "test",
+			Version:          1,
+			HelmMajorVersion: 3,
+			Chart:            &v1.Chart{Metadata: &v1.Metadata{Name: "test", Version: "1.0.0"}},
+			Info:             &v1.Info{Status: v1.StatusDeployed},
+		},
+	}
+}
+
+func newTestUpgradeHandler(t *testing.T, clusterRepo *v1.ClusterRepo) (*upgradeHandler, *testUpgradeOperations) {
+	ctrl := gomock.NewController(t)
+
+	index := repo.NewIndexFile()
+	require.NoError(t, index.MustAdd(&chart.Metadata{APIVersion: chart.APIVersionV2, Name: "test", Version: "1.0.1"}, "test-1.0.1.tgz", "https://charts.example.com", ""))
+
+	apps := fake.NewMockControllerInterface[*v1.App, *v1.AppList](ctrl)
+	apps.EXPECT().EnqueueAfter(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	apps.EXPECT().UpdateStatus(gomock.Any()).DoAndReturn(func(app *v1.App) (*v1.App, error) {
+		return app, nil
+	}).AnyTimes()
+	clusterRepos := fake.NewMockNonNamespacedCacheInterface[*v1.ClusterRepo](ctrl)
+	clusterRepos.EXPECT().Get("test-repo").Return(clusterRepo, nil).AnyTimes()
+
+	operations := &testUpgradeOperations{}
+	return &upgradeHandler{
+		ctx:          context.Background(),
+		apps:         apps,
+		clusterRepos: clusterRepos,
+		operations:   operations,
+		content:      &testUpgradeContent{index: index},
+		recorder:     record.NewFakeRecorder(10),
+	}, operations
+}
+
+func TestUpgradeUser(t *testing.T) {
+	tests := []struct {
+		name        string
+		repoSpec    v1.RepoSpec
+		policy      v1.AppUpgradePolicy
+		expectUser  string
+		expectError string
+	}{
+		{
+			name:       "service account of the repository",
+			repoSpec:   v1.RepoSpec{ServiceAccount: "charts", ServiceAccountNamespace: "cattle-system"},
+			expectUser: "system:serviceaccount:cattle-system:charts",
+		},
+		{
+			name:        "no service account",
+			expectError: "automatic upgrades require a service account",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, operations := newTestUpgradeHandler(t, &v1.ClusterRepo{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-repo"},
+				Spec:       tt.repoSpec,
+			})
+
+			app, err := u.OnChange("test-ns/test", upgradeTestApp(t, tt.policy))
+			require.NoError(t, err)
+			status := app.Status.Upgrade
+			require.NotNil(t, status)
+
+			if tt.expectError != "" {
+				assert.Empty(t, operations.upgrades)
+				assert.Equal(t, v1.UpgradeStatePending, status.State)
+				assert.Contains(t, status.Error, tt.expectError)
+				return
+			}
+			require.Len(t, operations.upgrades, 1)
+			assert.Equal(t, tt.expectUser, operations.upgrades[0].GetName())
+			assert.NotContains(t, operations.upgrades[0].GetGroups(), "system:masters")
+			assert.Equal(t, v1.UpgradeStateUpgrading, status.State)
+			assert.Equal(t, "1.0.1", status.ToVersion)
+			assert.Equal(t, "cattle-system/helm-operation-upgrade", status.Operation)
+		})
+	}
+}
+
+func TestUpgradeRollback(t *testing.T) {
+	u, operations := newTestUpgradeHandler(t, &v1.ClusterRepo{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-repo"},
+		Spec:       v1.RepoSpec{ServiceAccount: "charts", ServiceAccountNamespace: "cattle-system"},
+	})
+
+	app := upgradeTestApp(t, v1.AppUpgradePolicy{RollbackOnFailure: true})
+	app.Spec.Version = 2
+	app.Spec.Chart.Metadata.Version = "1.0.1"
+	app.Spec.Info.Status = v1.StatusFailed
+	started := metav1.Now()
+	app.Status.Upgrade = &v1.ReleaseUpgrade{
+		State:        v1.UpgradeStateUpgrading,
+		FromVersion:  "1.0.0",
+		FromRevision: 1,
+		ToVersion:    "1.0.1",
+		Started:      &started,
+	}
+
+	app, err := u.OnChange("test-ns/test", app)
+	require.NoError(t, err)
+	assert.Equal(t, v1.UpgradeStateRolledBack, app.Status.Upgrade.State)
+	assert.Contains(t, app.Status.Upgrade.Error, "the release failed")
+	require.Len(t, operations.rollbacks, 1)
+	assert.Equal(t, "system:serviceaccount:cattle-system:charts", operations.rollbacks[0].GetName())
+}
+
+func TestUpgradeRollbackRefused(t *testing.T) {
+	u, operations := newTestUpgradeHandler(t, &v1.ClusterRepo{ObjectMeta: metav1.ObjectMeta{Name: "test-repo"}})
+
+	app := upgradeTestApp(t, v1.AppUpgradePolicy{RollbackOnFailure: true})
+	app.Spec.Version = 2
+	app.Spec.Chart.Metadata.Version = "1.0.1"
+	app.Spec.Info.Status = v1.StatusFailed
+	app.Status.Upgrade = &v1.ReleaseUpgrade{
+		State:        v1.UpgradeStateUpgrading,
+		FromRevision: 1,
+		ToVersion:    "1.0.1",
+	}
+
+	app, err := u.OnChange("test-ns/test", app)
+	require.NoError(t, err)
+	assert.Equal(t, v1.UpgradeStateFailed, app.Status.Upgrade.State)
+	assert.Contains(t, app.Status.Upgrade.Error, "automatic upgrades require a service account")
+	assert.Empty(t, operations.rollbacks)
+}
@@ -15,7 +15,9 @@
 	"github.com/rancher/rancher/pkg/namespace"
 	"github.com/rancher/rancher/pkg/settings"
 	"github.com/rancher/rancher/pkg/wrangler"
+	"github.com/rancher/wrangler/v3/pkg/relatedresource"
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime"
 
 	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
 	plugincontroller "github.com/rancher/rancher/pkg/generated/controllers/catalog.cattle.io/v1"
@@ -37,14 +39,18 @@ func Register(
 		systemNamespace: namespace.UIPluginNamespace,
 		plugin:          wContext.Catalog.UIPlugin(),
 		pluginCache:     wContext.Catalog.UIPlugin().Cache(),
+		appCache:        wContext.Catalog.App().Cache(),
 	}
 	wContext.Catalog.UIPlugin().OnChange(ctx, "on-ui-plugin-change", h.OnPluginChange)
+	// re-evaluate all the plugins when the allowed origins or publishers change
+	relatedresource.Watch(ctx, "ui-plugin-allowlist", h.resolveSetting, wContext.Catalog.UIPlugin(), wContext.Mgmt.Setting())
 }
 
 type handler struct {
 	systemNamespace string
 	plugin          plugincontroller.UIPluginController
 	pluginCache     plugincontroller.UIPluginCache
+	appCache        plugincontroller.AppCache
 }
 
 func (h *handler) OnPluginChange(key string, plugin *v1.UIPlugin) (*v1.UIPlugin, error) {
@@ -55,6 +61,7 @@ func (h *handler) OnPluginChange(key string, plugin *v1.UIPlugin) (*v1.UIPlugin,
 	if err != nil {
 		return plugin, fmt.Errorf("failed to list plugins from cache: %w", err)
 	}
+	cachedPlugins = h.allowedPlugins(cachedPlugins)
 	err = Index.Generate(cachedPlugins)
 	if err != nil {
 		return plugin, fmt.Errorf("failed to generate index with cached plugins: %w", err)
@@ -105,13 +112,31 @@ func (h *handler) OnPluginChange(key string, plugin *v1.UIPlugin) (*v1.UIPlugin,
 
 	plugin.Status.ObservedGeneration = plugin.Generation
 
+	if err := verifyAllowed(&plugin.Spec.Plugin, h.publisher(plugin)); err != nil {
+		return h.reject(plugin, err)
+	}
+	if Pinned(&plugin.Spec.Plugin) && plugin.Spec.Plugin.NoCache {
+		return h.reject(plugin, fmt.Errorf("%w: plugins with expected digests must be cached", errIntegrity))
+	}
+
 	defer Index.Ready(plugin)
 	defer Index.CacheState(plugin)
 	defer AnonymousIndex.Ready(plugin)
 	defer AnonymousIndex.CacheState(plugin)
 
 	err = FsCache.SyncWithControllersCache(plugin, forceUpdate)
-	if errors.Is(err, errMaxFileSizeError) {
+	if errors.Is(err, errIntegrity) {
+		logrus.Errorf("failed to verify plugin [%s]: %s", plugin.Spec.Plugin.Name, err.Error())
+		if err2 := FsCache.Delete(plugin.Spec.Plugin.Name, plugin.Spec.Plugin.Version); err2 != nil {
+			logrus.Error(err2)
+		}
+		plugin.Status.Ready = false
+		plugin.Status.Error = err.Error()
+		return h.retry(plugin, err)
+	} else if errors.Is(err, errMaxFileSizeError) && Pinned(&plugin.Spec.Plugin) {
+		// the cache of plugins with expected digests cannot be disabled, as their content would not be verified
+		return h.reject(plugin, fmt.Errorf("failed to cache plugin due to max file size limit: %w", err))
+	} else if errors.Is(err, errMaxFileSizeError) {
 		logrus.Errorf("one of the files is more than the defaultUIPluginFileByteSize limit %s", strconv.FormatInt(maxFileSize, 10))
 		// update CRD to remove cache
 		plugin.Spec.Plugin.NoCache = true
@@ -152,6 +177,67 @@ func (h *handler) OnPluginChange(key string, plugin *v1.UIPlugin) (*v1.UIPlugin,
 	return plugin, nil
 }
 
+// reject marks the given plugin as not ready without retrying, as it cannot become ready until it or the settings
+// change, and removes it from the filesystem cache.
+func (h *handler) reject(plugin *v1.UIPlugin, err error) (*v1.UIPlugin, error) {
+	logrus.Errorf("rejected plugin [%s]: %s", plugin.Spec.Plugin.Name, err.Error())
+	if err := FsCache.Delete(plugin.Spec.Plugin.Name, plugin.Spec.Plugin.Version); err != nil {
+		logrus.Error(err)
+	}
+	plugin.Status.Ready = false
+	plugin.Status.Error = err.Error()
+	plugin.Status.CacheState = Disabled
+	plugin.Status.RetryNumber = 0
+	plugin.Status.RetryAt = metav1.Time{}
+	return plugin, nil
+}
+
+// allowedPlugins returns the plugins allowed by the ui-plugin-allowed-origins and ui-plugin-allowed-publishers
+// settings. Plugins that are not allowed are left out of the indexes, so that they are never served.
+func (h *handler) allowedPlugins(plugins []*v1.UIPlugin) []*v1.UIPlugin {
+	var result []*v1.UIPlugin
+	for _, plugin := range plugins {
+		if err := verifyAllowed(&plugin.Spec.Plugin, h.publisher(plugin)); err != nil {
+			logrus.Debugf("leaving plugin [%s] out of the index: %s", plugin.Spec.Plugin.Name, err.Error())
+			continue
+		}
+		result = append(result, plugin)
+	}
+	return result
+}
+
+// publisher returns the name of the ClusterRepo the Helm release that created the given plugin was installed from, as
+// labeled by Rancher when installing it, or an empty string if the plugin was not installed from a ClusterRepo. The
+// metadata of the chart is not trusted, as it is set by the author of the chart.
+func (h *handler) publisher(plugin *v1.UIPlugin) string {
+	releaseName := plugin.Annotations["meta.helm.sh/release-name"]
+	if releaseName == "" {
+		return ""
+	}
+	app, err := h.appCache.Get(plugin.Annotations["meta.helm.sh/release-namespace"], releaseName)
+	if err != nil {
+		return ""
+	}
+	return app.Labels[v1.ClusterRepoNameLabel]
+}
+
+// resolveSetting enqueues all the plugins when the ui-plugin-allowed-origins or ui-plugin-allowed-publishers
+// settings change.
+func (h *handler) resolveSetting(_, name string, _ runtime.Object) ([]relatedresource.Key, error) {
+	if name != settings.UIPluginAllowedOrigins.Name && name != settings.UIPluginAllowedPublishers.Name {
+		return nil, nil
+	}
+	plugins, err := h.pluginCache.List(h.systemNamespace, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]relatedresource.Key, 0, len(plugins))
+	for _, plugin := range plugins {
+		keys = append(keys, relatedresource.Key{Namespace: plugin.Namespace, Name: plugin.Name})
+	}
+	return keys, nil
+}
+
 func (h *handler) retry(plugin *v1.UIPlugin, err error) (*v1.UIPlugin, error) {
 	logrus.WithError(err).Error("failed to sync filesystem cache with controller cache")
 	backoff := calculateBackoff(plugin.Status.RetryNumber).Round(time.Second)
@@ -4,7 +4,11 @@
 	"testing"
 	"time"
 
+	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func Test_calculateBackoff(t *testing.T) {
@@ -42,3 +46,54 @@ func Test_calculateBackoff(t *testing.T) {
 		})
 	}
 }
+
+func Test_publisher(t *testing.T) {
+	tests := []struct {
+		name     string
+		app      *v1.App
+		expected string
+	}{
+		{
+			name: "installed from a cluster repo",
+			app: &v1.App{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.ClusterRepoNameLabel: "rancher-charts"}},
+			},
+			expected: "rancher-charts",
+		},
+		{
+			name: "chart metadata is ignored",
+			app: &v1.App{
+				Spec: v1.ReleaseSpec{
+					Chart: &v1.Chart{
+						Metadata: &v1.Metadata{
+							Annotations: map[string]string{
+								"catalog.cattle.io/ui-source-repo-type": "cluster",
+								"catalog.cattle.io/ui-source-repo":      "rancher-charts",
+							},
+						},
+					},
+				},
+			},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			appCache := fake.NewMockCacheInterface[*v1.App](ctrl)
+			appCache.EXPECT().Get("cattle-ui-plugin-system", "elemental").Return(tt.app, nil)
+			h := &handler{appCache: appCache}
+
+			plugin := &v1.UIPlugin{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						"meta.helm.sh/release-name":      "elemental",
+						"meta.helm.sh/release-namespace": "cattle-ui-plugin-system",
+					},
+				},
+			}
+			assert.Equal(t, tt.expected, h.publisher(plugin))
+		})
+	}
+}
@@ -2,6 +2,7 @@
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
 	"encoding/json"
 	"errors"
@@ -71,7 +72,23 @@ func (c FSCache) SyncWithControllersCache(p *v1.UIPlugin, forceUpdate bool) erro
 		}
 	}
 
-	if plugin.CompressedEndpoint != "" {
+	if plugin.CompressedEndpoint != "" && Pinned(&plugin) {
+		data, err := fetchFile(plugin.CompressedEndpoint)
+		if err != nil {
+			return err
+		}
+		if err := verifyDigest(plugin.CompressedEndpoint, data, plugin.CompressedDigest); err != nil {
+			return err
+		}
+		p, _ := filepathsecure.SecureJoin(FSCacheRootDir, plugin.Name)
+		if err := os.MkdirAll(p, os.ModePerm); err != nil {
+			return fmt.Errorf("failed to create cache directory with path [%s]. Error: %w", p, err)
+		}
+		err = Untar(p, bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("failed to untar file: %w", err)
+		}
+	} else if plugin.CompressedEndpoint != "" {
 		resp, err := http.Get(plugin.CompressedEndpoint)
 		if err != nil {
 			return fmt.Errorf("get request failed for URL [%s]. Error: %w", plugin.CompressedEndpoint, err)
@@ -104,6 +121,9 @@ func (c FSCache) SyncWithControllersCache(p *v1.UIPlugin, forceUpdate bool) erro
 		if err != nil {
 			return fmt.Errorf("failed to get files.txt file. Error: %w", err)
 		}
+		// all the files are fetched and verified before any of them is cached, so that
+		// a plugin is never partially cached with files that do not match their digest
+		contents := make(map[string][]byte, len(files))
 		for _, file := range files {
 			if file == "" {
 				continue
@@ -112,6 +132,14 @@ func (c FSCache) SyncWithControllersCache(p *v1.UIPlugin, forceUpdate bool) erro
 			if err != nil {
 				return fmt.Errorf("failed to fetch file [%s] .Error: %w", file, err)
 			}
+			if Pinned(&plugin) {
+				if err := verifyDigest(file, data, plugin.Digests[file]); err != nil {
+					return err
+				}
+			}
+			contents[file] = data
+		}
+		for file, data := range contents {
 			path, err := filepathsecure.SecureJoin(FSCacheRootDir, filepath.Join(plugin.Name, plugin.Version, file))
 			if err != nil {
 				return fmt.Errorf("failed to build file [%s] path for caching. Error: %w", file, err)
@@ -0,0 +1,97 @@
+package plugin
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/settings"
+)
+
+const digestPrefix = "sha256:"
+
+var (
+	errIntegrity  = errors.New("plugin content does not match its expected digests")
+	errNotAllowed = errors.New("plugin is not allowed")
+)
+
+// Pinned returns true if the content of the given plugin is pinned to expected digests. Pinned plugins are only
+// served from the filesystem cache, once their content has been verified.
+func Pinned(plugin *v1.UIPluginEntry) bool {
+	return len(plugin.Digests) > 0 || plugin.CompressedDigest != ""
+}
+
+// verifyDigest checks that the given data matches the expected digest, in the "sha256:<hex>" format.
+func verifyDigest(name string, data []byte, expected string) error {
+	if expected == "" {
+		return fmt.Errorf("%w: file [%s] has no expected digest", errIntegrity, name)
+	}
+	expectedSum, err := hex.DecodeString(strings.TrimPrefix(expected, digestPrefix))
+	if err != nil || !strings.HasPrefix(expected, digestPrefix) || len(expectedSum) != sha256.Size {
+		return fmt.Errorf("%w: digest [%s] of file [%s] is not a sha256 digest", errIntegrity, expected, name)
+	}
+	sum := sha256.Sum256(data)
+	if subtle.ConstantTimeCompare(sum[:], expectedSum) != 1 {
+		return fmt.Errorf("%w: file [%s] has digest [%s%x], expected [%s]", errIntegrity, name, digestPrefix, sum, expected)
+	}
+	return nil
+}
+
+// verifyAllowed checks that the endpoints of the given plugin and the ClusterRepo it was published from are allowed by
+// the ui-plugin-allowed-origins and ui-plugin-allowed-publishers settings.
+func verifyAllowed(plugin *v1.UIPluginEntry, publisher string) error {
+	if origins := splitSetting(settings.UIPluginAllowedOrigins.Get()); len(origins) > 0 {
+		for _, endpoint := range []string{plugin.Endpoint, plugin.CompressedEndpoint} {
+			if endpoint != "" && !originAllowed(endpoint, origins) {
+				return fmt.Errorf("%w: origin [%s] is not in the allowed origins", errNotAllowed, endpoint)
+			}
+		}
+	}
+	if publishers := splitSetting(settings.UIPluginAllowedPublishers.Get()); len(publishers) > 0 {
+		if publisher == "" {
+			return fmt.Errorf("%w: it was not installed from an allowed publisher", errNotAllowed)
+		}
+		for _, allowed := range publishers {
+			if allowed == publisher {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: publisher [%s] is not in the allowed publishers", errNotAllowed, publisher)
+	}
+	return nil
+}
+
+func splitSetting(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// originAllowed returns true if the given URL has the scheme and host of one of the allowed origins, and is below
+// its path if it has one.
+func originAllowed(endpoint string, origins []string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+	for _, origin := range origins {
+		allowed, err := url.Parse(origin)
+		if err != nil || !strings.EqualFold(allowed.Scheme, u.Scheme) || !strings.EqualFold(allowed.Host, u.Host) {
+			continue
+		}
+		prefix := strings.TrimSuffix(allowed.Path, "/")
+		if prefix == "" || u.Path == prefix || strings.HasPrefix(u.Path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
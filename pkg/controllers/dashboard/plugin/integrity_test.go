@@ -0,0 +1,86 @@
+package plugin
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func digest(data string) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(data)))
+}
+
+func Test_verifyDigest(t *testing.T) {
+	assert.NoError(t, verifyDigest("index.js", []byte("content"), digest("content")))
+	assert.ErrorIs(t, verifyDigest("index.js", []byte("injected"), digest("content")), errIntegrity)
+	assert.ErrorIs(t, verifyDigest("index.js", []byte("content"), ""), errIntegrity)
+	assert.ErrorIs(t, verifyDigest("index.js", []byte("content"), "md5:9a0364b9e99bb480dd25e1f0284c8555"), errIntegrity)
+}
+
+func Test_verifyAllowed(t *testing.T) {
+	defer settings.UIPluginAllowedOrigins.Set("")
+	defer settings.UIPluginAllowedPublishers.Set("")
+
+	plugin := &v1.UIPluginEntry{Name: "test", Endpoint: "https://charts.example.com/extensions/test"}
+	assert.NoError(t, verifyAllowed(plugin, ""))
+
+	settings.UIPluginAllowedOrigins.Set("https://other.example.com, https://charts.example.com/extensions")
+	assert.NoError(t, verifyAllowed(plugin, ""))
+	plugin.Endpoint = "https://charts.example.com/extensions-evil/test"
+	assert.ErrorIs(t, verifyAllowed(plugin, ""), errNotAllowed)
+	plugin.Endpoint = "https://charts.example.com.evil.io/extensions/test"
+	assert.ErrorIs(t, verifyAllowed(plugin, ""), errNotAllowed)
+	plugin.Endpoint = "https://charts.example.com/extensions/test"
+
+	settings.UIPluginAllowedPublishers.Set("rancher-ui-plugins")
+	assert.NoError(t, verifyAllowed(plugin, "rancher-ui-plugins"))
+	assert.ErrorIs(t, verifyAllowed(plugin, "untrusted"), errNotAllowed)
+	assert.ErrorIs(t, verifyAllowed(plugin, ""), errNotAllowed)
+}
+
+func TestSyncWithControllersCache_digests(t *testing.T) {
+	rootDir := FSCacheRootDir
+	FSCacheRootDir = t.TempDir()
+	defer func() { FSCacheRootDir = rootDir }()
+
+	files := map[string]string{
+		"plugin/package.json": `{"version":"0.1.0"}`,
+		"plugin/index.js":     "console.log('test')",
+		FilesTxtFilename:      "plugin/package.json\nplugin/index.js\n",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(files[r.URL.Path[1:]]))
+	}))
+	defer server.Close()
+
+	plugin := &v1.UIPlugin{
+		Spec: v1.UIPluginSpec{Plugin: v1.UIPluginEntry{
+			Name:     "test",
+			Version:  "0.1.0",
+			Endpoint: server.URL,
+			Digests: map[string]string{
+				"plugin/package.json": digest(files["plugin/package.json"]),
+				"plugin/index.js":     digest("console.log('expected')"),
+			},
+		}},
+	}
+	err := FsCache.SyncWithControllersCache(plugin, true)
+	assert.ErrorIs(t, err, errIntegrity)
+	_, err = os.Stat(filepath.Join(FSCacheRootDir, "test"))
+	assert.ErrorIs(t, err, os.ErrNotExist, "no file should be cached when a digest does not match")
+
+	plugin.Spec.Plugin.Digests["plugin/index.js"] = digest(files["plugin/index.js"])
+	require.NoError(t, FsCache.SyncWithControllersCache(plugin, true))
+	data, err := os.ReadFile(filepath.Join(FSCacheRootDir, "test", "0.1.0", "plugin", "index.js"))
+	require.NoError(t, err)
+	assert.Equal(t, files["plugin/index.js"], string(data))
+}
@@ -54,6 +54,12 @@ func ReconcileFeatures(obj *v3.Feature, newVal bool) error {
 		return nil
 	}
 
+	if obj.Status.LockedValue != nil {
+		feature.SetRules(nil)
+	} else {
+		feature.SetRules(obj.Spec.Rules)
+	}
+
 	if newVal == feature.Enabled() {
 		return nil
 	}
@@ -58,17 +58,24 @@ func (s *settingsProvider) Set(name, value string) error {
 	if envValue != "" {
 		return fmt.Errorf("setting %s can not be set because it is from environment variable", name)
 	}
+	if err := settings.Validate(name, value); err != nil {
+		return err
+	}
 	obj, err := s.settings.Get(name, metav1.GetOptions{})
 	if err != nil {
 		return err
 	}
 
+	settings.RecordChange(obj, value, settings.SystemUser)
 	obj.Value = value
 	_, err = s.settings.Update(obj)
 	return err
 }
 
 func (s *settingsProvider) SetIfUnset(name, value string) error {
+	if err := settings.Validate(name, value); err != nil {
+		return err
+	}
 	obj, err := s.settings.Get(name, metav1.GetOptions{})
 	if err != nil {
 		return err
@@ -78,6 +85,7 @@ func (s *settingsProvider) SetIfUnset(name, value string) error {
 		return nil
 	}
 
+	settings.RecordChange(obj, value, settings.SystemUser)
 	obj.Value = value
 	_, err = s.settings.Update(obj)
 	return err
@@ -97,6 +105,11 @@ func (s *settingsProvider) SetAll(settingsMap map[string]settings.Setting) error
 	for name, setting := range settingsMap {
 		key := settings.GetEnvKey(name)
 		envValue, envOk := os.LookupEnv(key)
+		if envOk {
+			if err := setting.Validate(envValue); err != nil {
+				return fmt.Errorf("invalid value of environment variable %s: %w", key, err)
+			}
+		}
 
 		obj, err := s.settings.Get(setting.Name, metav1.GetOptions{})
 		if apierrors.IsNotFound(err) {
@@ -145,6 +158,7 @@ func (s *settingsProvider) SetAll(settingsMap map[string]settings.Setting) error
 				update = true
 			}
 			if envOk && obj.Value != envValue {
+				settings.RecordChange(obj, envValue, settings.SystemUser)
 				obj.Value = envValue
 				update = true
 			}
@@ -458,3 +458,48 @@ func storeOperations(store map[string]v3.Setting) (get, set, list) {
 type get func(name string, opts metav1.GetOptions) (*v3.Setting, error)
 type set func(setting *v3.Setting) (*v3.Setting, error)
 type list func(opts metav1.ListOptions) (*v3.SettingList, error)
+
+func TestSet(t *testing.T) {
+	client := fake.NewMockNonNamespacedControllerInterface[*v3.Setting, *v3.SettingList](gomock.NewController(t))
+	provider := settingsProvider{
+		settings: client,
+	}
+
+	stored := &v3.Setting{
+		ObjectMeta: metav1.ObjectMeta{Name: settings.PasswordMinLength.Name},
+		Default:    settings.PasswordMinLength.Default,
+	}
+	client.EXPECT().Get(settings.PasswordMinLength.Name, gomock.Any()).DoAndReturn(func(string, metav1.GetOptions) (*v3.Setting, error) {
+		return stored.DeepCopy(), nil
+	}).AnyTimes()
+	client.EXPECT().Update(gomock.Any()).DoAndReturn(func(setting *v3.Setting) (*v3.Setting, error) {
+		stored = setting
+		return setting, nil
+	}).Times(2)
+
+	assert.Error(t, provider.Set(settings.PasswordMinLength.Name, "twelve"), "values must have the type of the setting")
+	assert.Error(t, provider.Set(settings.PasswordMinLength.Name, "1"), "values must pass the validator of the setting")
+	assert.Error(t, provider.SetIfUnset(settings.PasswordMinLength.Name, "1"))
+
+	require.NoError(t, provider.Set(settings.PasswordMinLength.Name, "16"))
+	require.NoError(t, provider.Set(settings.PasswordMinLength.Name, ""))
+
+	assert.Equal(t, "", stored.Value)
+	require.Len(t, stored.History, 2)
+	assert.Equal(t, "16", stored.History[0].Value)
+	assert.Equal(t, settings.SystemUser, stored.History[0].User)
+	assert.Equal(t, "", stored.History[1].Value)
+}
+
+func TestSetAllInvalidEnvValue(t *testing.T) {
+	client := fake.NewMockNonNamespacedControllerInterface[*v3.Setting, *v3.SettingList](gomock.NewController(t))
+	client.EXPECT().List(gomock.Any()).Return(&v3.SettingList{}, nil).AnyTimes()
+	provider := settingsProvider{
+		settings: client,
+	}
+
+	t.Setenv(settings.GetEnvKey(settings.PasswordMinLength.Name), "twelve")
+
+	err := provider.SetAll(map[string]settings.Setting{settings.PasswordMinLength.Name: settings.PasswordMinLength})
+	assert.ErrorContains(t, err, settings.GetEnvKey(settings.PasswordMinLength.Name))
+}
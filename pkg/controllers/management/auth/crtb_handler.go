@@ -18,6 +18,7 @@
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 )
 
@@ -89,6 +90,7 @@ type crtbLifecycle struct {
 	crtbClient    controllersv3.ClusterRoleTemplateBindingController
 	crtbCache     controllersv3.ClusterRoleTemplateBindingCache
 	s             *status.Status
+	recorder      record.EventRecorder
 }
 
 func (c *crtbLifecycle) Create(obj *v3.ClusterRoleTemplateBinding) (runtime.Object, error) {
@@ -392,6 +394,7 @@ func (c *crtbLifecycle) updateStatus(crtb *v3.ClusterRoleTemplateBinding, localC
 			}
 		}
 
+		oldConditions := crtbFromCluster.Status.LocalConditions
 		crtbFromCluster.Status.LastUpdateTime = timeNow().Format(time.RFC3339)
 		crtbFromCluster.Status.ObservedGenerationLocal = crtb.ObjectMeta.Generation
 		crtbFromCluster.Status.LocalConditions = localConditions
@@ -399,6 +402,7 @@ func (c *crtbLifecycle) updateStatus(crtb *v3.ClusterRoleTemplateBinding, localC
 		if err != nil {
 			return err
 		}
+		status.RecordEvents(c.recorder, crtb, oldConditions, localConditions)
 
 		return nil
 	})
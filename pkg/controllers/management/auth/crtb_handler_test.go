@@ -18,6 +18,7 @@
 	"go.uber.org/mock/gomock"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/record"
 )
 
 var (
@@ -680,6 +681,7 @@ func TestUpdateStatus(t *testing.T) {
 			c := crtbLifecycle{
 				crtbClient: test.crtbClient(test.crtb),
 				crtbCache:  crtbCache,
+				recorder:   &record.FakeRecorder{},
 			}
 			err := c.updateStatus(test.crtb, test.localConditions)
 			assert.Equal(t, test.wantErr, err)
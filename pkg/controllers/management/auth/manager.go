@@ -93,6 +93,7 @@ func newRTBLifecycles(management *config.ManagementContext) (*prtbLifecycle, *cr
 		crtbClient:    management.Wrangler.Mgmt.ClusterRoleTemplateBinding(),
 		crtbCache:     management.Wrangler.Mgmt.ClusterRoleTemplateBinding().Cache(),
 		s:             status.NewStatus(),
+		recorder:      management.Wrangler.EventRecorder,
 	}
 	return prtb, crtb
 }
@@ -16,6 +16,7 @@
 	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 )
 
@@ -29,6 +30,7 @@ type crtbHandler struct {
 	crbController  wrbacv1.ClusterRoleBindingController
 	crtbCache      mgmtv3.ClusterRoleTemplateBindingCache
 	crtbClient     mgmtv3.ClusterRoleTemplateBindingController
+	recorder       record.EventRecorder
 }
 
 func newCRTBHandler(management *config.ManagementContext) *crtbHandler {
@@ -42,6 +44,7 @@ func newCRTBHandler(management *config.ManagementContext) *crtbHandler {
 		crbController:  management.Wrangler.RBAC.ClusterRoleBinding(),
 		crtbCache:      management.Wrangler.Mgmt.ClusterRoleTemplateBinding().Cache(),
 		crtbClient:     management.Wrangler.Mgmt.ClusterRoleTemplateBinding(),
+		recorder:       management.Wrangler.EventRecorder,
 	}
 }
 
@@ -271,6 +274,7 @@ func (c *crtbHandler) updateStatus(crtb *v3.ClusterRoleTemplateBinding, localCon
 			}
 		}
 
+		oldConditions := crtbFromCluster.Status.LocalConditions
 		crtbFromCluster.Status.LastUpdateTime = timeNow().Format(time.RFC3339)
 		crtbFromCluster.Status.ObservedGenerationLocal = crtb.ObjectMeta.Generation
 		crtbFromCluster.Status.LocalConditions = localConditions
@@ -278,6 +282,7 @@ func (c *crtbHandler) updateStatus(crtb *v3.ClusterRoleTemplateBinding, localCon
 		if err != nil {
 			return err
 		}
+		status.RecordEvents(c.recorder, crtb, oldConditions, localConditions)
 
 		return nil
 	})
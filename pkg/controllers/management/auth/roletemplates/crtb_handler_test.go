@@ -15,6 +15,7 @@
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 )
 
 type reducedCondition struct {
@@ -633,6 +634,7 @@ func TestUpdateStatus(t *testing.T) {
 			c := crtbHandler{
 				crtbClient: test.crtbClient(test.crtb),
 				crtbCache:  crtbCache,
+				recorder:   &record.FakeRecorder{},
 			}
 			err := c.updateStatus(test.crtb, test.localConditions)
 			assert.Equal(t, test.wantErr, err)
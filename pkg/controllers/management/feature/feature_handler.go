@@ -4,6 +4,7 @@
 	"context"
 	"fmt"
 	"reflect"
+	"slices"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -24,7 +25,9 @@
 
 type handler struct {
 	featuresClient       managementv3.FeatureClient
+	featuresCache        managementv3.FeatureCache
 	featureEnqueue       func(string, time.Duration)
+	clusterCache         managementv3.ClusterCache
 	tokensLister         managementv3.TokenCache
 	tokenEnqueue         func(string, time.Duration)
 	nodeDriverController normanv3.NodeDriverInterface
@@ -34,13 +37,16 @@ type handler struct {
 func Register(ctx context.Context, management *config.ManagementContext, wContext *wrangler.Context) {
 	h := handler{
 		featuresClient:       wContext.Mgmt.Feature(),
+		featuresCache:        wContext.Mgmt.Feature().Cache(),
 		featureEnqueue:       wContext.Mgmt.Feature().EnqueueAfter,
+		clusterCache:         wContext.Mgmt.Cluster().Cache(),
 		tokensLister:         wContext.Mgmt.Token().Cache(),
 		tokenEnqueue:         wContext.Mgmt.Token().EnqueueAfter,
 		nodeDriverController: management.Management.NodeDrivers(""),
 		managementContext:    management,
 	}
 	wContext.Mgmt.Feature().OnChange(ctx, "feature-handler", h.sync)
+	wContext.Mgmt.Cluster().OnChange(ctx, "feature-rules-cluster-handler", h.enqueueFeaturesWithRules)
 }
 
 func (h *handler) sync(_ string, obj *v3.Feature) (*v3.Feature, error) {
@@ -53,6 +59,11 @@ func (h *handler) sync(_ string, obj *v3.Feature) (*v3.Feature, error) {
 		return obj, err
 	}
 
+	obj, err = h.setEffectiveStatus(obj)
+	if err != nil {
+		return obj, err
+	}
+
 	if obj.Name == features.TokenHashing.Name() {
 		return obj, h.refreshTokens()
 	}
@@ -174,6 +185,52 @@ func (h *handler) refreshTokens() error {
 	return nil
 }
 
+// setEffectiveStatus records the downstream clusters and groups a feature with rules is effectively enabled for.
+func (h *handler) setEffectiveStatus(obj *v3.Feature) (*v3.Feature, error) {
+	var enabledClusters, enabledGroups []string
+	if hasEffectiveStatus(obj) {
+		clusters, err := h.clusterCache.List(labels.Everything())
+		if err != nil {
+			return obj, err
+		}
+		enabledClusters, enabledGroups = features.EffectiveStatus(obj.Name, obj.Spec.Rules, features.IsEnabled(obj), clusters)
+	}
+
+	if reflect.DeepEqual(enabledClusters, obj.Status.EnabledClusters) && reflect.DeepEqual(enabledGroups, obj.Status.EnabledGroups) {
+		return obj, nil
+	}
+
+	featureCopy := obj.DeepCopy()
+	featureCopy.Status.EnabledClusters = enabledClusters
+	featureCopy.Status.EnabledGroups = enabledGroups
+	return h.featuresClient.Update(featureCopy)
+}
+
+// enqueueFeaturesWithRules enqueues the features with rules whose effective status no longer reflects whether they are
+// enabled for the given cluster, which changed or was removed.
+func (h *handler) enqueueFeaturesWithRules(key string, cluster *v3.Cluster) (*v3.Cluster, error) {
+	featureList, err := h.featuresCache.List(labels.Everything())
+	if err != nil {
+		return cluster, err
+	}
+	for _, feature := range featureList {
+		if !hasEffectiveStatus(feature) {
+			continue
+		}
+		enabled := cluster != nil && cluster.DeletionTimestamp == nil && features.ClusterValue(feature.Name, feature.Spec.Rules, features.IsEnabled(feature), cluster)
+		if enabled != slices.Contains(feature.Status.EnabledClusters, key) {
+			h.featureEnqueue(feature.Name, 0)
+		}
+	}
+	return cluster, nil
+}
+
+// hasEffectiveStatus returns whether the clusters and groups the given feature is enabled for are recorded in its status, which is
+// the case of features with rules whose value is not locked.
+func hasEffectiveStatus(obj *v3.Feature) bool {
+	return len(obj.Spec.Rules) > 0 && obj.Status.LockedValue == nil
+}
+
 // setLockedValue evaluates whether a value should be written to the lockedValue
 // field on status and records the value if so.
 func (h *handler) setLockedValue(obj *v3.Feature) (*v3.Feature, error) {
@@ -0,0 +1,89 @@
+package feature
+
+import (
+	"testing"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestEnqueueFeaturesWithRules(t *testing.T) {
+	canary := []v3.FeatureRule{{
+		Value:           true,
+		ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"rollout": "canary"}},
+	}}
+	locked := true
+	featureList := []*v3.Feature{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "canary"},
+			Spec:       v3.FeatureSpec{Rules: canary},
+			Status:     v3.FeatureStatus{EnabledClusters: []string{"c-canary"}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "locked"},
+			Spec:       v3.FeatureSpec{Rules: canary},
+			Status:     v3.FeatureStatus{LockedValue: &locked},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "without-rules"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		cluster  *v3.Cluster
+		expected []string
+	}{
+		{
+			name:    "cluster still selected",
+			key:     "c-canary",
+			cluster: &v3.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c-canary", Labels: map[string]string{"rollout": "canary"}}},
+		},
+		{
+			name:    "cluster still not selected",
+			key:     "c-prod",
+			cluster: &v3.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c-prod"}},
+		},
+		{
+			name:     "cluster newly selected",
+			key:      "c-prod",
+			cluster:  &v3.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c-prod", Labels: map[string]string{"rollout": "canary"}}},
+			expected: []string{"canary"},
+		},
+		{
+			name:     "cluster no longer selected",
+			key:      "c-canary",
+			cluster:  &v3.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c-canary"}},
+			expected: []string{"canary"},
+		},
+		{
+			name:     "cluster removed",
+			key:      "c-canary",
+			expected: []string{"canary"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			featureCache := fake.NewMockNonNamespacedCacheInterface[*v3.Feature](gomock.NewController(t))
+			featureCache.EXPECT().List(labels.Everything()).Return(featureList, nil)
+
+			var enqueued []string
+			h := handler{
+				featuresCache: featureCache,
+				featureEnqueue: func(name string, _ time.Duration) {
+					enqueued = append(enqueued, name)
+				},
+			}
+			_, err := h.enqueueFeaturesWithRules(tt.key, tt.cluster)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, enqueued)
+		})
+	}
+}
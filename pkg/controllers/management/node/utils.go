@@ -19,6 +19,7 @@
 	"github.com/rancher/rancher/pkg/auth/tokens"
 	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/jailer"
+	"github.com/rancher/rancher/pkg/logging"
 	"github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
@@ -145,7 +146,7 @@ func logOptMapToSlice(m map[string]string) []string {
 
 func buildCommand(nodeDir string, node *v3.Node, cmdArgs []string) (*exec.Cmd, error) {
 	// only in trace because machine has sensitive details and we can't control who debugs what in there easily
-	if logrus.GetLevel() >= logrus.TraceLevel {
+	if logging.Level() >= logrus.TraceLevel {
 		// prepend --debug to pass directly to machine
 		cmdArgs = append([]string{"--debug"}, cmdArgs...)
 	}
@@ -0,0 +1,191 @@
+// Package notifier watches the management objects whose state changes are notified to NotificationChannels.
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/controllers/management/clusterconnected"
+	"github.com/rancher/rancher/pkg/features"
+	mgmtcontrollers "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	provisioningcontrollers "github.com/rancher/rancher/pkg/generated/controllers/provisioning.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/notifier"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/rancher/pkg/wrangler"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	snapshotFailed = "failed"
+	// sentAnnotation records when the events of a cluster were last notified, so that they aren't notified again
+	// within the repeat interval when Rancher restarts or another replica takes over the cluster.
+	sentAnnotation = "notifier.cattle.io/sent"
+)
+
+type handler struct {
+	clusters             mgmtcontrollers.ClusterController
+	provisioningClusters provisioningcontrollers.ClusterCache
+	now                  func() time.Time
+}
+
+func Register(ctx context.Context, wContext *wrangler.Context) {
+	h := &handler{
+		clusters: wContext.Mgmt.Cluster(),
+		now:      time.Now,
+	}
+	wContext.Mgmt.Cluster().OnChange(ctx, "notifier-cluster-events", h.onClusterChange)
+
+	if features.ProvisioningV2.Enabled() && features.RKE2.Enabled() {
+		h.provisioningClusters = wContext.Provisioning.Cluster().Cache()
+		wContext.RKE.ETCDSnapshot().OnChange(ctx, "notifier-etcd-snapshot-events", h.onETCDSnapshotChange)
+	}
+}
+
+// onClusterChange notifies that the cluster is disconnected, stuck provisioning, or has certificates expiring. The
+// time of each notified event is recorded in the sentAnnotation of the cluster.
+func (h *handler) onClusterChange(_ string, cluster *v3.Cluster) (*v3.Cluster, error) {
+	if cluster == nil || cluster.DeletionTimestamp != nil {
+		return cluster, nil
+	}
+	name := cluster.Spec.DisplayName
+	if name == "" {
+		name = cluster.Name
+	}
+
+	sent := sentTimes(cluster)
+	interval := notifier.RepeatInterval()
+	changed := false
+	for key, t := range sent {
+		if h.now().Sub(t) >= interval {
+			delete(sent, key)
+			changed = true
+		}
+	}
+	notify := func(event notifier.Event) {
+		key := event.Type + "/" + event.Subject
+		if _, ok := sent[key]; ok {
+			return
+		}
+		notifier.Notify(event)
+		sent[key] = h.now()
+		changed = true
+	}
+
+	if clusterconnected.Connected.IsFalse(cluster) {
+		notify(notifier.Event{
+			Type:    notifier.EventClusterDisconnected,
+			Cluster: cluster.Name,
+			Subject: cluster.Name,
+			Title:   fmt.Sprintf("Cluster %s is disconnected", name),
+			Message: fmt.Sprintf("The agent of cluster %s isn't connected to Rancher.", name),
+		})
+	}
+
+	if !v3.ClusterConditionProvisioned.IsTrue(cluster) {
+		timeout, err := time.ParseDuration(settings.ProvisioningStuckTimeout.Get())
+		if err != nil {
+			logrus.Errorf("[notifier] invalid value for setting %s: %v", settings.ProvisioningStuckTimeout.Name, err)
+		} else if elapsed := h.now().Sub(cluster.CreationTimestamp.Time); elapsed < timeout {
+			h.clusters.EnqueueAfter(cluster.Name, timeout-elapsed)
+		} else {
+			message := fmt.Sprintf("Cluster %s has been provisioning for %s.", name, elapsed.Round(time.Minute))
+			if reason := v3.ClusterConditionProvisioned.GetMessage(cluster); reason != "" {
+				message += " " + reason
+			}
+			notify(notifier.Event{
+				Type:    notifier.EventProvisioningStuck,
+				Cluster: cluster.Name,
+				Subject: cluster.Name,
+				Title:   fmt.Sprintf("Cluster %s is stuck provisioning", name),
+				Message: message,
+			})
+		}
+	}
+
+	var expiring []string
+	for certName, certExp := range cluster.Status.CertificatesExpiration {
+		date, err := time.Parse(time.RFC3339, certExp.ExpirationDate)
+		if err != nil {
+			continue
+		}
+		// within a month, like the certsexpiration controllers warn
+		if h.now().AddDate(0, 1, 0).After(date) {
+			expiring = append(expiring, certName)
+		}
+	}
+	sort.Strings(expiring)
+	for _, certName := range expiring {
+		notify(notifier.Event{
+			Type:    notifier.EventCertificateExpiring,
+			Cluster: cluster.Name,
+			Subject: certName,
+			Title:   fmt.Sprintf("Certificate %s of cluster %s is expiring", certName, name),
+			Message: fmt.Sprintf("Certificate %s of cluster %s expires on %s.", certName, name, cluster.Status.CertificatesExpiration[certName].ExpirationDate),
+		})
+	}
+
+	if !changed {
+		return cluster, nil
+	}
+	cluster = cluster.DeepCopy()
+	if len(sent) == 0 {
+		delete(cluster.Annotations, sentAnnotation)
+	} else {
+		data, err := json.Marshal(sent)
+		if err != nil {
+			return cluster, err
+		}
+		if cluster.Annotations == nil {
+			cluster.Annotations = map[string]string{}
+		}
+		cluster.Annotations[sentAnnotation] = string(data)
+	}
+	return h.clusters.Update(cluster)
+}
+
+// sentTimes returns the times the events of the cluster were last notified, by event type and subject.
+func sentTimes(cluster *v3.Cluster) map[string]time.Time {
+	sent := map[string]time.Time{}
+	if data, ok := cluster.Annotations[sentAnnotation]; ok {
+		if err := json.Unmarshal([]byte(data), &sent); err != nil {
+			logrus.Errorf("[notifier] invalid annotation %s on cluster %s: %v", sentAnnotation, cluster.Name, err)
+		}
+	}
+	return sent
+}
+
+// onETCDSnapshotChange notifies the recent snapshots that failed.
+func (h *handler) onETCDSnapshotChange(_ string, snapshot *rkev1.ETCDSnapshot) (*rkev1.ETCDSnapshot, error) {
+	if snapshot == nil || snapshot.DeletionTimestamp != nil || snapshot.SnapshotFile.Status != snapshotFailed {
+		return snapshot, nil
+	}
+	created := snapshot.CreationTimestamp.Time
+	if snapshot.SnapshotFile.CreatedAt != nil {
+		created = snapshot.SnapshotFile.CreatedAt.Time
+	}
+	if !notifier.Recent(created) {
+		return snapshot, nil
+	}
+
+	var clusterName string
+	cluster, err := h.provisioningClusters.Get(snapshot.Namespace, snapshot.Spec.ClusterName)
+	if err == nil {
+		clusterName = cluster.Status.ClusterName
+	} else if !apierrors.IsNotFound(err) {
+		return snapshot, err
+	}
+	notifier.Notify(notifier.Event{
+		Type:    notifier.EventEtcdSnapshotFailed,
+		Cluster: clusterName,
+		Subject: snapshot.Namespace + "/" + snapshot.Name,
+		Title:   fmt.Sprintf("Etcd snapshot of cluster %s failed", snapshot.Spec.ClusterName),
+		Message: fmt.Sprintf("Etcd snapshot %s of node %s failed: %s", snapshot.SnapshotFile.Name, snapshot.SnapshotFile.NodeName, snapshot.SnapshotFile.Message),
+	})
+	return snapshot, nil
+}
@@ -0,0 +1,50 @@
+package notifier
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/controllers/management/clusterconnected"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestOnClusterChangeRecordsSentEvents(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	clusters := fake.NewMockNonNamespacedControllerInterface[*v3.Cluster, *v3.ClusterList](gomock.NewController(t))
+	h := &handler{clusters: clusters, now: func() time.Time { return now }}
+
+	cluster := &v3.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c-1"}}
+	v3.ClusterConditionProvisioned.True(cluster)
+	clusterconnected.Connected.False(cluster)
+
+	var updated *v3.Cluster
+	clusters.EXPECT().Update(gomock.Any()).DoAndReturn(func(c *v3.Cluster) (*v3.Cluster, error) {
+		updated = c
+		return c, nil
+	}).Times(2)
+
+	_, err := h.onClusterChange("", cluster)
+	require.NoError(t, err)
+	require.NotNil(t, updated)
+	var sent map[string]time.Time
+	require.NoError(t, json.Unmarshal([]byte(updated.Annotations[sentAnnotation]), &sent))
+	assert.Equal(t, map[string]time.Time{"ClusterDisconnected/c-1": now}, sent)
+
+	// a restarted Rancher doesn't notify the event again within the repeat interval
+	now = now.Add(time.Hour)
+	_, err = h.onClusterChange("", updated)
+	require.NoError(t, err)
+
+	// once the cluster is connected, the record expires with the repeat interval
+	clusterconnected.Connected.True(updated)
+	now = now.Add(24 * time.Hour)
+	_, err = h.onClusterChange("", updated)
+	require.NoError(t, err)
+	assert.NotContains(t, updated.Annotations, sentAnnotation)
+}
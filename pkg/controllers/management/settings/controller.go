@@ -7,14 +7,24 @@
 	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/settings"
 	"github.com/rancher/rancher/pkg/types/config"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+const (
+	// validCondition is the condition of the settings whose stored value is invalid, it is removed once fixed.
+	validCondition     = "Valid"
+	invalidValueReason = "InvalidValue"
+)
+
 var toCopy = map[string]bool{}
 
 type handler struct {
-	cluster v3.ClusterController
+	cluster  v3.ClusterController
+	settings v3.SettingInterface
 }
 
 func init() {
@@ -27,10 +37,12 @@ func init() {
 
 func Register(ctx context.Context, management *config.ManagementContext) {
 	h := &handler{
-		cluster: management.Management.Clusters("").Controller(),
+		cluster:  management.Management.Clusters("").Controller(),
+		settings: management.Management.Settings(""),
 	}
 
 	management.Management.Settings("").AddHandler(ctx, "copy-settings", h.onChange)
+	management.Management.Settings("").AddHandler(ctx, "validate-settings", h.validate)
 }
 
 func (h *handler) onChange(key string, obj *apis.Setting) (runtime.Object, error) {
@@ -50,3 +62,36 @@ func (h *handler) onChange(key string, obj *apis.Setting) (runtime.Object, error
 
 	return obj, nil
 }
+
+// validate reports, through the Valid condition of the setting, whether its stored value is valid. Invalid values, such
+// as values written directly to the cluster rather than through the API, or values stored before the setting was
+// validated, are kept for an administrator to fix rather than being overwritten.
+func (h *handler) validate(key string, obj *apis.Setting) (runtime.Object, error) {
+	if obj == nil || obj.DeletionTimestamp != nil {
+		return obj, nil
+	}
+
+	existing := meta.FindStatusCondition(obj.Conditions, validCondition)
+	err := settings.Validate(obj.Name, obj.Value)
+	if err == nil {
+		if existing == nil {
+			return obj, nil
+		}
+		obj = obj.DeepCopy()
+		meta.RemoveStatusCondition(&obj.Conditions, validCondition)
+		return h.settings.Update(obj)
+	}
+
+	if existing != nil && existing.Message == err.Error() {
+		return obj, nil
+	}
+	logrus.Errorf("[settings] %v", err)
+	obj = obj.DeepCopy()
+	meta.SetStatusCondition(&obj.Conditions, metav1.Condition{
+		Type:    validCondition,
+		Status:  metav1.ConditionFalse,
+		Reason:  invalidValueReason,
+		Message: err.Error(),
+	})
+	return h.settings.Update(obj)
+}
@@ -0,0 +1,90 @@
+package settings
+
+import (
+	"testing"
+
+	apis "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3/fakes"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidate(t *testing.T) {
+	invalid := metav1.Condition{
+		Type:    validCondition,
+		Status:  metav1.ConditionFalse,
+		Reason:  invalidValueReason,
+		Message: "invalid value for setting password-min-length: 1 is not between 2 and 256",
+	}
+
+	tests := []struct {
+		name          string
+		value         string
+		conditions    []metav1.Condition
+		wantWrite     bool
+		wantCondition *metav1.Condition
+	}{
+		{
+			name:  "valid value",
+			value: "16",
+		},
+		{
+			name:          "invalid value",
+			value:         "1",
+			wantWrite:     true,
+			wantCondition: &invalid,
+		},
+		{
+			name:       "invalid value already reported",
+			value:      "1",
+			conditions: []metav1.Condition{invalid},
+		},
+		{
+			name:       "fixed value",
+			value:      "16",
+			conditions: []metav1.Condition{invalid},
+			wantWrite:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated *apis.Setting
+			h := &handler{
+				settings: &fakes.SettingInterfaceMock{
+					UpdateFunc: func(setting *apis.Setting) (*apis.Setting, error) {
+						updated = setting
+						return setting, nil
+					},
+				},
+			}
+			setting := &apis.Setting{
+				ObjectMeta: metav1.ObjectMeta{Name: settings.PasswordMinLength.Name},
+				Value:      tt.value,
+				Conditions: tt.conditions,
+			}
+
+			_, err := h.validate(setting.Name, setting)
+			require.NoError(t, err)
+			if !tt.wantWrite {
+				assert.Nil(t, updated)
+				return
+			}
+			require.NotNil(t, updated)
+			assert.Equal(t, tt.value, updated.Value, "the stored value must not be overwritten")
+			condition := meta.FindStatusCondition(updated.Conditions, validCondition)
+			if tt.wantCondition == nil {
+				assert.Nil(t, condition)
+			} else {
+				require.NotNil(t, condition)
+				assert.Equal(t, tt.wantCondition.Status, condition.Status)
+				assert.Equal(t, tt.wantCondition.Reason, condition.Reason)
+				assert.Equal(t, tt.wantCondition.Message, condition.Message)
+			}
+			assert.Equal(t, tt.conditions, setting.Conditions, "the cached setting must not be modified")
+		})
+	}
+}
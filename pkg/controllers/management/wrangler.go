@@ -10,6 +10,7 @@
 	"github.com/rancher/rancher/pkg/controllers/management/feature"
 	"github.com/rancher/rancher/pkg/controllers/management/gke"
 	"github.com/rancher/rancher/pkg/controllers/management/k3sbasedupgrade"
+	"github.com/rancher/rancher/pkg/controllers/management/notifier"
 	"github.com/rancher/rancher/pkg/controllers/management/oidcprovider"
 	"github.com/rancher/rancher/pkg/features"
 	"github.com/rancher/rancher/pkg/types/config"
@@ -24,6 +25,7 @@ func RegisterWrangler(ctx context.Context, wranglerContext *wrangler.Context, ma
 	clusterupstreamrefresher.Register(ctx, wranglerContext)
 
 	feature.Register(ctx, management, wranglerContext)
+	notifier.Register(ctx, wranglerContext)
 
 	if features.ProvisioningV2.Enabled() {
 		if err := authprovisioningv2.Register(ctx, wranglerContext, management); err != nil {
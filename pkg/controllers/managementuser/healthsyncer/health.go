@@ -0,0 +1,405 @@
+package healthsyncer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	appsv1 "github.com/rancher/rancher/pkg/generated/norman/apps/v1"
+	corev1 "github.com/rancher/rancher/pkg/generated/norman/core/v1"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	k8sappsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	HealthStateHealthy   = "Healthy"
+	HealthStateDegraded  = "Degraded"
+	HealthStateUnhealthy = "Unhealthy"
+
+	healthyScore  = 90
+	degradedScore = 60
+
+	checkTimeout = 10 * time.Second
+	// maxListed is the maximum number of resources listed in the message of a check
+	maxListed = 5
+)
+
+// Checker checks an aspect of the health of a downstream cluster. The results of the checkers are combined into the
+// scored health of the cluster.
+type Checker interface {
+	// Name of the check in the health of the cluster.
+	Name() string
+	// Weight of the check in the score of the cluster.
+	Weight() int
+	// Check returns the score of the check from 0 to 100, and the reason and message for a lower score. It returns a
+	// negative score if the check doesn't apply to the cluster.
+	Check(ctx context.Context, cluster *v3.Cluster) (score int, reason, message string)
+}
+
+// DefaultCheckers returns the checkers of the health of the downstream cluster of the given client, whose nodes and
+// workloads are listed from the given caches.
+func DefaultCheckers(k8s kubernetes.Interface, nodes corev1.NodeLister, deployments appsv1.DeploymentLister, daemonSets appsv1.DaemonSetLister) []Checker {
+	readyz := readyzGetter(k8s.Discovery().RESTClient())
+	return []Checker{
+		&apiServerChecker{readyz: readyz},
+		&nodeChecker{nodes: nodes},
+		&workloadChecker{deployments: deployments, daemonSets: daemonSets, workloads: systemWorkloads},
+		&etcdChecker{readyz: readyz, nodes: nodes},
+		&certificateChecker{now: time.Now},
+	}
+}
+
+// scoreHealth runs the checkers against the cluster and returns its health, or nil if no checker applies to it.
+func scoreHealth(ctx context.Context, cluster *v3.Cluster, checkers []Checker) *v32.ClusterHealth {
+	health := &v32.ClusterHealth{}
+	var total, weights int
+	for _, checker := range checkers {
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		score, reason, message := checker.Check(checkCtx, cluster)
+		cancel()
+		if score < 0 {
+			continue
+		}
+		if score > 100 {
+			score = 100
+		}
+		health.Checks = append(health.Checks, v32.ClusterHealthCheck{
+			Name:    checker.Name(),
+			Score:   score,
+			Weight:  checker.Weight(),
+			Reason:  reason,
+			Message: message,
+		})
+		total += score * checker.Weight()
+		weights += checker.Weight()
+	}
+	if weights == 0 {
+		return nil
+	}
+	health.Score = total / weights
+	switch {
+	case health.Score >= healthyScore:
+		health.State = HealthStateHealthy
+	case health.Score >= degradedScore:
+		health.State = HealthStateDegraded
+	default:
+		health.State = HealthStateUnhealthy
+	}
+	return health
+}
+
+// unreachableHealth returns the health of a cluster whose API server can't be reached, which fails every check.
+func unreachableHealth(err error) *v32.ClusterHealth {
+	return &v32.ClusterHealth{
+		State: HealthStateUnhealthy,
+		Checks: []v32.ClusterHealthCheck{{
+			Name:    (&apiServerChecker{}).Name(),
+			Weight:  (&apiServerChecker{}).Weight(),
+			Reason:  "APIServerUnreachable",
+			Message: err.Error(),
+		}},
+	}
+}
+
+// readyzGetter returns a function getting the verbose output of the given readiness endpoint of the API server.
+func readyzGetter(client rest.Interface) func(ctx context.Context, path string) ([]byte, error) {
+	return func(ctx context.Context, path string) ([]byte, error) {
+		return client.Get().AbsPath(path).Param("verbose", "true").DoRaw(ctx)
+	}
+}
+
+// failedChecks returns the names of the failed checks in the verbose output of a readiness endpoint of the API server.
+func failedChecks(output []byte) []string {
+	var failed []string
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "[-]") {
+			continue
+		}
+		name, _, _ := strings.Cut(strings.TrimPrefix(line, "[-]"), " ")
+		failed = append(failed, name)
+	}
+	return failed
+}
+
+// apiServerChecker checks the readiness endpoint of the API server, ignoring the checks of etcd.
+type apiServerChecker struct {
+	readyz func(ctx context.Context, path string) ([]byte, error)
+}
+
+func (c *apiServerChecker) Name() string { return "apiserver" }
+
+func (c *apiServerChecker) Weight() int { return 30 }
+
+func (c *apiServerChecker) Check(ctx context.Context, _ *v3.Cluster) (int, string, string) {
+	output, err := c.readyz(ctx, "/readyz")
+	if err == nil {
+		return 100, "", ""
+	}
+	all := failedChecks(output)
+	var failed []string
+	for _, name := range all {
+		if !strings.HasPrefix(name, "etcd") {
+			failed = append(failed, name)
+		}
+	}
+	if len(all) > 0 && len(failed) == 0 {
+		// only etcd is failing, which the etcd checker scores
+		return 100, "", ""
+	}
+	if len(failed) > 0 {
+		return 0, "APIServerNotReady", "failed readiness checks: " + strings.Join(failed, ", ")
+	}
+	return 0, "APIServerNotReady", err.Error()
+}
+
+// nodeChecker scores the ratio of the ready nodes of the cluster.
+type nodeChecker struct {
+	nodes corev1.NodeLister
+}
+
+func (c *nodeChecker) Name() string { return "nodes" }
+
+func (c *nodeChecker) Weight() int { return 25 }
+
+func (c *nodeChecker) Check(_ context.Context, _ *v3.Cluster) (int, string, string) {
+	nodes, err := c.nodes.List("", labels.Everything())
+	if err != nil {
+		return 0, "NodesUnknown", err.Error()
+	}
+	if len(nodes) == 0 {
+		return 0, "NoNodes", "the cluster has no nodes"
+	}
+	var notReady []string
+	for _, node := range nodes {
+		if !nodeReady(node) {
+			notReady = append(notReady, node.Name)
+		}
+	}
+	if len(notReady) == 0 {
+		return 100, "", ""
+	}
+	sort.Strings(notReady)
+	score := 100 * (len(nodes) - len(notReady)) / len(nodes)
+	return score, "NodesNotReady", fmt.Sprintf("%d of %d nodes are not ready: %s", len(notReady), len(nodes), list(notReady))
+}
+
+func nodeReady(node *v1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == v1.NodeReady {
+			return cond.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+type workloadKind string
+
+const (
+	deployment workloadKind = "Deployment"
+	daemonSet  workloadKind = "DaemonSet"
+)
+
+// systemWorkload is a workload that is critical for the cluster to be healthy.
+type systemWorkload struct {
+	name      string
+	kind      workloadKind
+	namespace string
+	selector  string
+	// required workloads fail the check if they are missing
+	required bool
+}
+
+// systemWorkloads are the critical system workloads of clusters. Workloads of hosted and imported clusters vary, so
+// only CoreDNS is required.
+var systemWorkloads = []systemWorkload{
+	{name: "CoreDNS", kind: deployment, namespace: "kube-system", selector: "k8s-app=kube-dns", required: true},
+	{name: "CNI", kind: daemonSet, selector: "k8s-app in (aws-node,calico-node,canal,cilium)"},
+	{name: "CNI", kind: daemonSet, selector: "app=flannel"},
+	{name: "cattle-cluster-agent", kind: deployment, namespace: "cattle-system", selector: "app=cattle-cluster-agent"},
+}
+
+// workloadChecker scores the availability of the critical system workloads of the cluster, by the least available.
+type workloadChecker struct {
+	deployments appsv1.DeploymentLister
+	daemonSets  appsv1.DaemonSetLister
+	workloads   []systemWorkload
+}
+
+func (c *workloadChecker) Name() string { return "systemWorkloads" }
+
+func (c *workloadChecker) Weight() int { return 20 }
+
+func (c *workloadChecker) Check(_ context.Context, _ *v3.Cluster) (int, string, string) {
+	score := 100
+	var reason string
+	var messages []string
+	for _, workload := range c.workloads {
+		available, desired, found, err := c.availability(workload)
+		if err != nil {
+			return 0, "WorkloadsUnknown", err.Error()
+		}
+		if !found {
+			if workload.required {
+				score, reason = 0, "WorkloadMissing"
+				messages = append(messages, workload.name+" is missing")
+			}
+			continue
+		}
+		if desired == 0 || available >= desired {
+			continue
+		}
+		if s := 100 * available / desired; s < score {
+			score = s
+		}
+		if reason == "" {
+			reason = "WorkloadUnavailable"
+		}
+		messages = append(messages, fmt.Sprintf("%s has %d of %d replicas available", workload.name, available, desired))
+	}
+	return score, reason, strings.Join(messages, "; ")
+}
+
+// availability returns the available and desired replicas of all the workloads matching the given system workload,
+// and whether any does.
+func (c *workloadChecker) availability(workload systemWorkload) (available, desired int, found bool, err error) {
+	selector, err := labels.Parse(workload.selector)
+	if err != nil {
+		return
+	}
+	switch workload.kind {
+	case deployment:
+		var deployments []*k8sappsv1.Deployment
+		deployments, err = c.deployments.List(workload.namespace, selector)
+		if err != nil {
+			return
+		}
+		for _, d := range deployments {
+			found = true
+			available += int(d.Status.AvailableReplicas)
+			if d.Spec.Replicas != nil {
+				desired += int(*d.Spec.Replicas)
+			} else {
+				desired++
+			}
+		}
+	case daemonSet:
+		var daemonSets []*k8sappsv1.DaemonSet
+		daemonSets, err = c.daemonSets.List(workload.namespace, selector)
+		if err != nil {
+			return
+		}
+		for _, ds := range daemonSets {
+			found = true
+			available += int(ds.Status.NumberAvailable)
+			desired += int(ds.Status.DesiredNumberScheduled)
+		}
+	}
+	return
+}
+
+// etcdChecker checks the etcd readiness endpoint of the API server, and the etcd conditions that RKE2 and K3s set on
+// etcd nodes, which report members that are unhealthy or out of quorum.
+type etcdChecker struct {
+	readyz func(ctx context.Context, path string) ([]byte, error)
+	nodes  corev1.NodeLister
+}
+
+// etcdNodes selects the etcd nodes of RKE2 and K3s clusters.
+var etcdNodes = labels.SelectorFromSet(labels.Set{"node-role.kubernetes.io/etcd": "true"})
+
+func (c *etcdChecker) Name() string { return "etcd" }
+
+func (c *etcdChecker) Weight() int { return 15 }
+
+func (c *etcdChecker) Check(ctx context.Context, _ *v3.Cluster) (int, string, string) {
+	if output, err := c.readyz(ctx, "/readyz/etcd"); err != nil {
+		if failed := failedChecks(output); len(failed) > 0 {
+			return 0, "EtcdNotReady", "failed readiness checks: " + strings.Join(failed, ", ")
+		}
+		return 0, "EtcdNotReady", err.Error()
+	}
+
+	nodes, err := c.nodes.List("", etcdNodes)
+	if err != nil {
+		return 0, "EtcdUnknown", err.Error()
+	}
+	var alarms []string
+	for _, node := range nodes {
+		for _, cond := range node.Status.Conditions {
+			if strings.HasPrefix(string(cond.Type), "Etcd") && cond.Status == v1.ConditionFalse {
+				alarms = append(alarms, fmt.Sprintf("%s: %s", node.Name, conditionMessage(cond)))
+			}
+		}
+	}
+	if len(alarms) > 0 {
+		return 50, "EtcdAlarm", list(alarms)
+	}
+	return 100, "", ""
+}
+
+func conditionMessage(cond v1.NodeCondition) string {
+	if cond.Message != "" {
+		return cond.Message
+	}
+	if cond.Reason != "" {
+		return cond.Reason
+	}
+	return string(cond.Type) + " is false"
+}
+
+// certificateChecker scores the expiration of the certificates of the cluster, set in its status by the
+// certsexpiration controller.
+type certificateChecker struct {
+	now func() time.Time
+}
+
+func (c *certificateChecker) Name() string { return "certificates" }
+
+func (c *certificateChecker) Weight() int { return 10 }
+
+func (c *certificateChecker) Check(_ context.Context, cluster *v3.Cluster) (int, string, string) {
+	if len(cluster.Status.CertificatesExpiration) == 0 {
+		return -1, "", ""
+	}
+	now := c.now().UTC()
+	var expired, expiring []string
+	for name, certExp := range cluster.Status.CertificatesExpiration {
+		date, err := time.Parse(time.RFC3339, certExp.ExpirationDate)
+		if err != nil {
+			continue
+		}
+		if now.After(date) {
+			expired = append(expired, name)
+		} else if now.AddDate(0, 1, 0).After(date) { // within a month, like the certsexpiration controller warns
+			expiring = append(expiring, name)
+		}
+	}
+	if len(expired) > 0 {
+		sort.Strings(expired)
+		return 0, "CertificatesExpired", "expired certificates: " + list(expired)
+	}
+	if len(expiring) > 0 {
+		sort.Strings(expiring)
+		return 50, "CertificatesExpiring", "certificates expiring within a month: " + list(expiring)
+	}
+	return 100, "", ""
+}
+
+// list joins the given names, up to maxListed of them.
+func list(names []string) string {
+	if len(names) > maxListed {
+		return strings.Join(names[:maxListed], ", ") + fmt.Sprintf(" and %d more", len(names)-maxListed)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,379 @@
+package healthsyncer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	appsfakes "github.com/rancher/rancher/pkg/generated/norman/apps/v1/fakes"
+	corev1 "github.com/rancher/rancher/pkg/generated/norman/core/v1"
+	"github.com/rancher/rancher/pkg/generated/norman/core/v1/fakes"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/ptr"
+)
+
+type fakeChecker struct {
+	name   string
+	weight int
+	score  int
+}
+
+func (f *fakeChecker) Name() string { return f.name }
+
+func (f *fakeChecker) Weight() int { return f.weight }
+
+func (f *fakeChecker) Check(context.Context, *v3.Cluster) (int, string, string) {
+	if f.score < 100 {
+		return f.score, "Failing", "failing"
+	}
+	return f.score, "", ""
+}
+
+func TestScoreHealth(t *testing.T) {
+	tests := []struct {
+		name           string
+		checkers       []Checker
+		expectedScore  int
+		expectedState  string
+		expectedChecks int
+		expectedNil    bool
+	}{
+		{
+			name:        "no checkers",
+			expectedNil: true,
+		},
+		{
+			name:        "only skipped checkers",
+			checkers:    []Checker{&fakeChecker{name: "a", weight: 10, score: -1}},
+			expectedNil: true,
+		},
+		{
+			name: "healthy",
+			checkers: []Checker{
+				&fakeChecker{name: "a", weight: 30, score: 100},
+				&fakeChecker{name: "b", weight: 10, score: 100},
+			},
+			expectedScore:  100,
+			expectedState:  HealthStateHealthy,
+			expectedChecks: 2,
+		},
+		{
+			name: "degraded",
+			checkers: []Checker{
+				&fakeChecker{name: "a", weight: 30, score: 100},
+				&fakeChecker{name: "b", weight: 10, score: 0},
+			},
+			expectedScore:  75,
+			expectedState:  HealthStateDegraded,
+			expectedChecks: 2,
+		},
+		{
+			name: "unhealthy",
+			checkers: []Checker{
+				&fakeChecker{name: "a", weight: 30, score: 0},
+				&fakeChecker{name: "b", weight: 10, score: 100},
+			},
+			expectedScore:  25,
+			expectedState:  HealthStateUnhealthy,
+			expectedChecks: 2,
+		},
+		{
+			name: "skipped checkers don't count",
+			checkers: []Checker{
+				&fakeChecker{name: "a", weight: 30, score: 100},
+				&fakeChecker{name: "b", weight: 10, score: -1},
+			},
+			expectedScore:  100,
+			expectedState:  HealthStateHealthy,
+			expectedChecks: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health := scoreHealth(context.Background(), &v3.Cluster{}, tt.checkers)
+			if tt.expectedNil {
+				assert.Nil(t, health)
+				return
+			}
+			assert.Equal(t, tt.expectedScore, health.Score)
+			assert.Equal(t, tt.expectedState, health.State)
+			assert.Len(t, health.Checks, tt.expectedChecks)
+		})
+	}
+}
+
+func TestAPIServerChecker(t *testing.T) {
+	tests := []struct {
+		name           string
+		output         string
+		err            error
+		expectedScore  int
+		expectedReason string
+	}{
+		{
+			name:          "ready",
+			output:        "[+]ping ok\n[+]etcd ok\nreadyz check passed",
+			expectedScore: 100,
+		},
+		{
+			name:          "only etcd failing",
+			output:        "[+]ping ok\n[-]etcd failed: reason withheld\nreadyz check failed",
+			err:           errors.New("the server is currently unable to handle the request"),
+			expectedScore: 100,
+		},
+		{
+			name:           "informer sync failing",
+			output:         "[+]ping ok\n[-]informer-sync failed: reason withheld\nreadyz check failed",
+			err:            errors.New("the server is currently unable to handle the request"),
+			expectedScore:  0,
+			expectedReason: "APIServerNotReady",
+		},
+		{
+			name:           "unreachable",
+			err:            errors.New("connection refused"),
+			expectedScore:  0,
+			expectedReason: "APIServerNotReady",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &apiServerChecker{readyz: func(context.Context, string) ([]byte, error) {
+				return []byte(tt.output), tt.err
+			}}
+			score, reason, _ := c.Check(context.Background(), &v3.Cluster{})
+			assert.Equal(t, tt.expectedScore, score)
+			assert.Equal(t, tt.expectedReason, reason)
+		})
+	}
+}
+
+func TestNodeChecker(t *testing.T) {
+	tests := []struct {
+		name           string
+		nodes          []*v1.Node
+		expectedScore  int
+		expectedReason string
+	}{
+		{
+			name:           "no nodes",
+			expectedScore:  0,
+			expectedReason: "NoNodes",
+		},
+		{
+			name:          "all ready",
+			nodes:         []*v1.Node{node("a", true, nil), node("b", true, nil)},
+			expectedScore: 100,
+		},
+		{
+			name:           "one of four not ready",
+			nodes:          []*v1.Node{node("a", true, nil), node("b", true, nil), node("c", true, nil), node("d", false, nil)},
+			expectedScore:  75,
+			expectedReason: "NodesNotReady",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &nodeChecker{nodes: nodeLister(tt.nodes...)}
+			score, reason, _ := c.Check(context.Background(), &v3.Cluster{})
+			assert.Equal(t, tt.expectedScore, score)
+			assert.Equal(t, tt.expectedReason, reason)
+		})
+	}
+}
+
+func TestWorkloadChecker(t *testing.T) {
+	coreDNS := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system", Labels: map[string]string{"k8s-app": "kube-dns"}},
+		Spec:       appsv1.DeploymentSpec{Replicas: ptr.To[int32](2)},
+		Status:     appsv1.DeploymentStatus{AvailableReplicas: 2},
+	}
+	degradedCoreDNS := coreDNS.DeepCopy()
+	degradedCoreDNS.Status.AvailableReplicas = 1
+	canal := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "canal", Namespace: "kube-system", Labels: map[string]string{"k8s-app": "canal"}},
+		Status:     appsv1.DaemonSetStatus{DesiredNumberScheduled: 4, NumberAvailable: 1},
+	}
+
+	tests := []struct {
+		name            string
+		deployments     []*appsv1.Deployment
+		daemonSets      []*appsv1.DaemonSet
+		expectedScore   int
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name:            "required workload missing",
+			expectedScore:   0,
+			expectedReason:  "WorkloadMissing",
+			expectedMessage: "CoreDNS is missing",
+		},
+		{
+			name:          "available",
+			deployments:   []*appsv1.Deployment{coreDNS},
+			expectedScore: 100,
+		},
+		{
+			name:            "least available workload scores",
+			deployments:     []*appsv1.Deployment{degradedCoreDNS},
+			daemonSets:      []*appsv1.DaemonSet{canal},
+			expectedScore:   25,
+			expectedReason:  "WorkloadUnavailable",
+			expectedMessage: "CoreDNS has 1 of 2 replicas available; CNI has 1 of 4 replicas available",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &workloadChecker{
+				deployments: &appsfakes.DeploymentListerMock{
+					ListFunc: func(namespace string, selector labels.Selector) ([]*appsv1.Deployment, error) {
+						var result []*appsv1.Deployment
+						for _, d := range tt.deployments {
+							if (namespace == "" || d.Namespace == namespace) && selector.Matches(labels.Set(d.Labels)) {
+								result = append(result, d)
+							}
+						}
+						return result, nil
+					},
+				},
+				daemonSets: &appsfakes.DaemonSetListerMock{
+					ListFunc: func(namespace string, selector labels.Selector) ([]*appsv1.DaemonSet, error) {
+						var result []*appsv1.DaemonSet
+						for _, ds := range tt.daemonSets {
+							if (namespace == "" || ds.Namespace == namespace) && selector.Matches(labels.Set(ds.Labels)) {
+								result = append(result, ds)
+							}
+						}
+						return result, nil
+					},
+				},
+				workloads: systemWorkloads,
+			}
+			score, reason, message := c.Check(context.Background(), &v3.Cluster{})
+			assert.Equal(t, tt.expectedScore, score)
+			assert.Equal(t, tt.expectedReason, reason)
+			assert.Equal(t, tt.expectedMessage, message)
+		})
+	}
+}
+
+func TestEtcdChecker(t *testing.T) {
+	voter := v1.NodeCondition{Type: "EtcdIsVoter", Status: v1.ConditionFalse, Message: "node is a learner"}
+	tests := []struct {
+		name           string
+		readyzErr      error
+		nodes          []*v1.Node
+		expectedScore  int
+		expectedReason string
+	}{
+		{
+			name:          "healthy",
+			nodes:         []*v1.Node{node("a", true, nil)},
+			expectedScore: 100,
+		},
+		{
+			name:           "not ready",
+			readyzErr:      errors.New("etcd failed"),
+			expectedScore:  0,
+			expectedReason: "EtcdNotReady",
+		},
+		{
+			name:           "member condition false",
+			nodes:          []*v1.Node{node("a", true, &voter)},
+			expectedScore:  50,
+			expectedReason: "EtcdAlarm",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &etcdChecker{
+				readyz: func(context.Context, string) ([]byte, error) { return nil, tt.readyzErr },
+				nodes:  nodeLister(tt.nodes...),
+			}
+			score, reason, _ := c.Check(context.Background(), &v3.Cluster{})
+			assert.Equal(t, tt.expectedScore, score)
+			assert.Equal(t, tt.expectedReason, reason)
+		})
+	}
+}
+
+func TestCertificateChecker(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name           string
+		expirations    map[string]v32.CertExpiration
+		expectedScore  int
+		expectedReason string
+	}{
+		{
+			name:          "no certificates",
+			expectedScore: -1,
+		},
+		{
+			name:          "valid",
+			expirations:   map[string]v32.CertExpiration{"kube-apiserver": {ExpirationDate: "2025-06-01T00:00:00Z"}},
+			expectedScore: 100,
+		},
+		{
+			name:           "expiring",
+			expirations:    map[string]v32.CertExpiration{"kube-apiserver": {ExpirationDate: "2024-06-15T00:00:00Z"}},
+			expectedScore:  50,
+			expectedReason: "CertificatesExpiring",
+		},
+		{
+			name: "expired",
+			expirations: map[string]v32.CertExpiration{
+				"kube-apiserver": {ExpirationDate: "2024-06-15T00:00:00Z"},
+				"kube-proxy":     {ExpirationDate: "2024-05-01T00:00:00Z"},
+			},
+			expectedScore:  0,
+			expectedReason: "CertificatesExpired",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &certificateChecker{now: func() time.Time { return now }}
+			cluster := &v3.Cluster{Status: v32.ClusterStatus{CertificatesExpiration: tt.expirations}}
+			score, reason, _ := c.Check(context.Background(), cluster)
+			assert.Equal(t, tt.expectedScore, score)
+			assert.Equal(t, tt.expectedReason, reason)
+		})
+	}
+}
+
+func node(name string, ready bool, cond *v1.NodeCondition) *v1.Node {
+	status := v1.ConditionFalse
+	if ready {
+		status = v1.ConditionTrue
+	}
+	n := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"node-role.kubernetes.io/etcd": "true"}},
+		Status:     v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: status}}},
+	}
+	if cond != nil {
+		n.Status.Conditions = append(n.Status.Conditions, *cond)
+	}
+	return n
+}
+
+// nodeLister returns a lister of the given nodes.
+func nodeLister(nodes ...*v1.Node) corev1.NodeLister {
+	return &fakes.NodeListerMock{
+		ListFunc: func(_ string, selector labels.Selector) ([]*v1.Node, error) {
+			var result []*v1.Node
+			for _, node := range nodes {
+				if selector.Matches(labels.Set(node.Labels)) {
+					result = append(result, node)
+				}
+			}
+			return result, nil
+		},
+	}
+}
@@ -55,9 +55,14 @@ type HealthSyncer struct {
 	componentStatuses corev1.ComponentStatusInterface
 	namespaces        corev1.NamespaceInterface
 	k8s               kubernetes.Interface
+	checkers          []Checker
 }
 
 func Register(ctx context.Context, workload *config.UserContext) {
+	checkers := DefaultCheckers(workload.K8sClient,
+		workload.Core.Nodes("").Controller().Lister(),
+		workload.Apps.Deployments("").Controller().Lister(),
+		workload.Apps.DaemonSets("").Controller().Lister())
 	h := &HealthSyncer{
 		ctx:               ctx,
 		clusterName:       workload.ClusterName,
@@ -66,6 +71,7 @@ func Register(ctx context.Context, workload *config.UserContext) {
 		componentStatuses: workload.Core.ComponentStatuses(""),
 		namespaces:        workload.Core.Namespaces(""),
 		k8s:               workload.K8sClient,
+		checkers:          checkers,
 	}
 
 	go h.syncHealth(ctx, syncInterval)
@@ -175,6 +181,9 @@ func (h *HealthSyncer) updateClusterHealth() error {
 	if err == nil {
 		v32.ClusterConditionWaiting.True(newObj)
 		v32.ClusterConditionWaiting.Message(newObj, "")
+		newObj.(*v3.Cluster).Status.Health = scoreHealth(h.ctx, newObj.(*v3.Cluster), h.checkers)
+	} else {
+		newObj.(*v3.Cluster).Status.Health = unreachableHealth(err)
 	}
 
 	if !reflect.DeepEqual(oldCluster, newObj) {
@@ -0,0 +1,37 @@
+// Package notifier watches the downstream objects whose state changes are notified to NotificationChannels.
+package notifier
+
+import (
+	"context"
+	"fmt"
+
+	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/notifier"
+	"github.com/rancher/rancher/pkg/types/config"
+	"github.com/rancher/wrangler/v3/pkg/kstatus"
+)
+
+type handler struct {
+	clusterName string
+}
+
+func Register(ctx context.Context, cluster *config.UserContext) {
+	h := &handler{clusterName: cluster.ClusterName}
+	cluster.Catalog.V1().Operation().OnChange(ctx, "notifier-operation-events", h.onOperationChange)
+}
+
+// onOperationChange notifies the recent catalog operations that failed.
+func (h *handler) onOperationChange(_ string, op *catalog.Operation) (*catalog.Operation, error) {
+	if op == nil || op.DeletionTimestamp != nil || !kstatus.Stalled.IsTrue(op) || !notifier.Recent(op.CreationTimestamp.Time) {
+		return op, nil
+	}
+	notifier.Notify(notifier.Event{
+		Type:    notifier.EventCatalogOperationFailed,
+		Cluster: h.clusterName,
+		Subject: op.Namespace + "/" + op.Name,
+		Title:   fmt.Sprintf("Catalog %s of %s failed", op.Status.Action, op.Status.Release),
+		Message: fmt.Sprintf("The %s operation of release %s/%s, chart %s %s, failed: %s",
+			op.Status.Action, op.Status.Namespace, op.Status.Release, op.Status.Chart, op.Status.Version, kstatus.Stalled.GetMessage(op)),
+	})
+	return op, nil
+}
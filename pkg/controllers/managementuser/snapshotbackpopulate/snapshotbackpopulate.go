@@ -22,6 +22,7 @@
 	provisioningcontrollers "github.com/rancher/rancher/pkg/generated/controllers/provisioning.cattle.io/v1"
 	rkev1controllers "github.com/rancher/rancher/pkg/generated/controllers/rke.cattle.io/v1"
 	"github.com/rancher/rancher/pkg/types/config"
+	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
 	"github.com/rancher/wrangler/v3/pkg/name"
 	"github.com/sirupsen/logrus"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -37,6 +38,9 @@
 
 const (
 	StorageAnnotationKey = "etcdsnapshot.rke.io/storage"
+	// EncryptedSnapshotSourceAnnotation is set on the snapshot objects of encrypted S3 snapshots, and refers to the
+	// downstream snapshot file of the local snapshot that was encrypted and uploaded.
+	EncryptedSnapshotSourceAnnotation = "etcdsnapshot.rke.io/encrypted-source-snapshot-name"
 )
 
 type Storage string
@@ -56,6 +60,7 @@ type handler struct {
 	capiClusterCache           capicontrollers.ClusterCache
 	etcdSnapshotFileController k3scontrollers.ETCDSnapshotFileController
 	etcdSnapshotFileCache      k3scontrollers.ETCDSnapshotFileCache
+	secretCache                corecontrollers.SecretCache
 }
 
 // Register sets up the v2provisioning snapshot backpopulate controller. This controller is responsible for monitoring
@@ -71,6 +76,7 @@ func Register(ctx context.Context, userContext *config.UserContext) {
 		capiClusterCache:           userContext.Management.Wrangler.CAPI.Cluster().Cache(),
 		etcdSnapshotFileController: userContext.K3s.V1().ETCDSnapshotFile(),
 		etcdSnapshotFileCache:      userContext.K3s.V1().ETCDSnapshotFile().Cache(),
+		secretCache:                userContext.Management.Wrangler.Core.Secret().Cache(),
 	}
 
 	userContext.Management.Wrangler.RKE.ETCDSnapshot().OnChange(ctx, "snapshotcleanup", h.OnUpstreamChange)
@@ -177,6 +183,10 @@ func (h *handler) OnDownstreamChange(_ string, downstream *k3s.ETCDSnapshotFile)
 
 	logrus.Infof("%s processing snapshot %s", logPrefix, downstream.Name)
 
+	if err := h.reconcileEncryptedSnapshot(cluster, controlPlane, downstream); err != nil {
+		return downstream, err
+	}
+
 	// get upstream snapshot object
 	// if upstream snapshot object does not exist, create it
 	upstreamSnapshots, err := h.getSnapshotsFromSnapshotFile(cluster, downstream)
@@ -192,8 +202,10 @@ func (h *handler) OnDownstreamChange(_ string, downstream *k3s.ETCDSnapshotFile)
 		}
 		logrus.Debugf("%s creating snapshot %s", logPrefix, upstream.Name)
 
-		_, err = h.etcdSnapshotController.Create(upstream)
-		return downstream, err
+		if _, err = h.etcdSnapshotController.Create(upstream); err != nil {
+			return downstream, err
+		}
+		return downstream, h.pruneTarget(cluster, controlPlane, upstream)
 	} else if len(upstreamSnapshots) > 1 {
 		logrus.Warnf("%s multiple snapshots objects found for snapshot %s", logPrefix, downstream.Name)
 
@@ -280,6 +292,19 @@ func (h *handler) populateUpstreamSnapshotFromDownstream(upstream *rkev1.ETCDSna
 	}
 	upstream.Annotations[StorageAnnotationKey] = string(storage)
 	upstream.Annotations[capr.SnapshotNameAnnotation] = downstream.Name
+	if err := h.setClusterObjectsHash(upstream, cluster, downstream); err != nil {
+		return nil, err
+	}
+
+	var target string
+	if storage == S3 {
+		target = capr.ETCDSnapshotTargetFromSnapshotName(controlPlane.Spec.ETCD, downstream.Spec.SnapshotName)
+	}
+	if target != "" {
+		upstream.Labels[capr.ETCDSnapshotTargetLabel] = target
+	} else {
+		delete(upstream.Labels, capr.ETCDSnapshotTargetLabel)
+	}
 
 	upstream.Spec.ClusterName = cluster.Name
 	upstream.SnapshotFile = rkev1.ETCDSnapshotFile{
@@ -287,6 +312,7 @@ func (h *handler) populateUpstreamSnapshotFromDownstream(upstream *rkev1.ETCDSna
 		Location:  downstream.Spec.Location,
 		NodeName:  downstream.Spec.NodeName,
 		CreatedAt: downstream.Status.CreationTime,
+		Target:    target,
 	}
 
 	b, err := json.Marshal(&downstream.Spec.Metadata)
@@ -345,6 +371,167 @@ func (h *handler) populateUpstreamSnapshotFromDownstream(upstream *rkev1.ETCDSna
 	return upstream, nil
 }
 
+// reconcileEncryptedSnapshot creates the snapshot object representing the encrypted S3 copy of the given local
+// snapshot, if snapshots of the cluster are encrypted before being uploaded, once the node confirmed that the snapshot
+// was uploaded. The object records the key the snapshot was encrypted with. The encrypted copy is deleted from S3 by
+// the node when the distribution prunes the local snapshot, so the object refers to the local snapshot file and is
+// deleted along with it.
+func (h *handler) reconcileEncryptedSnapshot(cluster *provv1.Cluster, controlPlane *rkev1.RKEControlPlane, downstream *k3s.ETCDSnapshotFile) error {
+	if downstream.Spec.S3 != nil || controlPlane.Spec.ETCD == nil || !capr.ETCDSnapshotS3Encrypted(controlPlane.Spec.ETCD.S3) {
+		return nil
+	}
+	if downstream.Status.ReadyToUse == nil || !*downstream.Status.ReadyToUse {
+		return nil
+	}
+
+	snapshotName := name.SafeConcatName(cluster.Name, strings.ToLower(InvalidKeyChars.ReplaceAllString(downstream.Spec.SnapshotName, "-")), string(S3))
+	if _, err := h.etcdSnapshotCache.Get(cluster.Namespace, snapshotName); err == nil || !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	keyID, err := h.getEncryptedSnapshotKeyID(cluster, downstream)
+	if err != nil {
+		return err
+	}
+	if keyID == "" {
+		logrus.Debugf("%s waiting for encrypted snapshot %s to be uploaded", getLogPrefix(cluster), downstream.Spec.SnapshotName)
+		h.etcdSnapshotFileController.EnqueueAfter(downstream.Name, time.Minute)
+		return nil
+	}
+
+	capiCluster, err := capr.GetCAPIClusterFromLabel(controlPlane, h.capiClusterCache)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(&downstream.Spec.Metadata)
+	if err != nil {
+		return err
+	}
+
+	snapshot := &rkev1.ETCDSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cluster.Namespace,
+			Name:      snapshotName,
+			Labels: map[string]string{
+				capr.ClusterNameLabel: cluster.Name,
+			},
+			Annotations: map[string]string{
+				StorageAnnotationKey:              string(S3),
+				EncryptedSnapshotSourceAnnotation: downstream.Name,
+				capr.SnapshotNameAnnotation:       downstream.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{capr.ToOwnerReference(capiCluster.TypeMeta, capiCluster.ObjectMeta)},
+		},
+		Spec: rkev1.ETCDSnapshotSpec{
+			ClusterName: cluster.Name,
+		},
+		SnapshotFile: rkev1.ETCDSnapshotFile{
+			Name:            downstream.Spec.SnapshotName,
+			NodeName:        downstream.Spec.NodeName,
+			CreatedAt:       downstream.Status.CreationTime,
+			Metadata:        base64.StdEncoding.EncodeToString(b),
+			S3:              controlPlane.Spec.ETCD.S3.DeepCopy(),
+			Status:          "successful",
+			EncryptionKeyID: keyID,
+		},
+	}
+	if downstream.Status.Size != nil {
+		snapshot.SnapshotFile.Size, _ = downstream.Status.Size.AsInt64()
+	}
+	if err := h.setClusterObjectsHash(snapshot, cluster, downstream); err != nil {
+		return err
+	}
+
+	logrus.Debugf("%s creating encrypted snapshot %s", getLogPrefix(cluster), snapshot.Name)
+	_, err = h.etcdSnapshotController.Create(snapshot)
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+// setClusterObjectsHash refers the given snapshot to the Rancher objects of the cluster that were captured when the
+// downstream snapshot was taken, unless it already refers to them.
+func (h *handler) setClusterObjectsHash(snapshot *rkev1.ETCDSnapshot, cluster *provv1.Cluster, downstream *k3s.ETCDSnapshotFile) error {
+	if snapshot.Annotations[capr.ClusterObjectsHashAnnotation] != "" || downstream.Status.CreationTime == nil {
+		return nil
+	}
+	hash, err := capr.GetClusterObjectsHashAt(h.secretCache, cluster.Namespace, cluster.Name, downstream.Status.CreationTime.Time)
+	if err != nil || hash == "" {
+		return err
+	}
+	snapshot.Annotations[capr.ClusterObjectsHashAnnotation] = hash
+	return nil
+}
+
+// getEncryptedSnapshotKeyID returns the ID of the key the given local snapshot was encrypted with, as reported in the
+// plan secret of its node by the upload instruction, or an empty string if the node has not uploaded it yet.
+func (h *handler) getEncryptedSnapshotKeyID(cluster *provv1.Cluster, downstream *k3s.ETCDSnapshotFile) (string, error) {
+	machine, err := h.getMachineFromNode(downstream.Spec.NodeName, cluster.Name, cluster.Namespace)
+	if err != nil {
+		return "", err
+	}
+	if machine.Spec.Bootstrap.ConfigRef == nil {
+		return "", fmt.Errorf("machine %s/%s does not have a bootstrap config", machine.Namespace, machine.Name)
+	}
+	secret, err := h.secretCache.Get(machine.Namespace, capr.PlanSecretFromBootstrapName(machine.Spec.Bootstrap.ConfigRef.Name))
+	if err != nil {
+		return "", err
+	}
+	uploads, err := capr.ETCDSnapshotEncryptedUploads(secret)
+	if err != nil {
+		return "", err
+	}
+	return uploads[downstream.Spec.SnapshotName], nil
+}
+
+// pruneTarget deletes the downstream snapshots stored on the snapshot target of the given snapshot, which was just
+// created, that are not retained by the retention policy of the target. The created snapshot is added to the snapshots
+// listed from the cache, as the cache may not hold it yet. The distribution removes the snapshot from storage once its
+// snapshot file object is deleted, which in turn causes the local snapshot representation to be deleted.
+func (h *handler) pruneTarget(cluster *provv1.Cluster, controlPlane *rkev1.RKEControlPlane, created *rkev1.ETCDSnapshot) error {
+	target := capr.GetETCDSnapshotTarget(controlPlane.Spec.ETCD, created.SnapshotFile.Target)
+	if target == nil || target.Retention == nil {
+		return nil
+	}
+
+	cached, err := h.etcdSnapshotCache.List(cluster.Namespace, labels.SelectorFromSet(labels.Set{
+		capr.ClusterNameLabel:        cluster.Name,
+		capr.ETCDSnapshotTargetLabel: target.Name,
+	}))
+	if err != nil {
+		return err
+	}
+	snapshots := []*rkev1.ETCDSnapshot{created}
+	for _, snapshot := range cached {
+		if snapshot.Name != created.Name {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+
+	// failed snapshots do not count towards the retention policy, so they can't displace a usable snapshot
+	var successful []*rkev1.ETCDSnapshot
+	for _, snapshot := range snapshots {
+		if snapshot.SnapshotFile.Status == "successful" {
+			successful = append(successful, snapshot)
+		}
+	}
+
+	var errs []error
+	for _, snapshot := range capr.ETCDSnapshotsToPrune(target.Retention, successful) {
+		snapshotFileName := snapshot.Annotations[capr.SnapshotNameAnnotation]
+		if snapshotFileName == "" {
+			continue
+		}
+		logrus.Infof("%s pruning snapshot %s from target %s", getLogPrefix(cluster), snapshot.SnapshotFile.Name, target.Name)
+		if err := h.etcdSnapshotFileController.Delete(snapshotFileName, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // getCluster returns the provisioning cluster associated with the current userContext.
 func (h *handler) getCluster() (*provv1.Cluster, error) {
 	clusters, err := h.clusterCache.GetByIndex(cluster2.ByCluster, h.clusterName)
@@ -364,7 +551,14 @@ func (h *handler) getSnapshotsFromSnapshotFile(cluster *provv1.Cluster, snapshot
 	if err != nil {
 		return nil, err
 	}
-	return snapshots, nil
+	// the encrypted S3 copy of a local snapshot refers to the same snapshot file, but is reconciled separately
+	result := snapshots[:0:0]
+	for _, snapshot := range snapshots {
+		if snapshot.Annotations[EncryptedSnapshotSourceAnnotation] == "" {
+			result = append(result, snapshot)
+		}
+	}
+	return result, nil
 }
 
 // getMachineFromNode attempts to find the corresponding machine for an etcd snapshot that is found in the configmap. If the machine list is successful, it will return true on the boolean, otherwise, it can be assumed that a false, nil, and defined error indicate the machine does not exist.
@@ -749,6 +749,19 @@ func TestGetSnapshotsFromSnapshotFile(t *testing.T) {
 			expectedSnapshots: []*rkev1.ETCDSnapshot{{}, {}},
 			expectErr:         false,
 		},
+		{
+			name:         "encrypted copy excluded",
+			snapshotFile: k3s.NewETCDSnapshotFile("", "test-snapshot", k3s.ETCDSnapshotFile{}),
+			cluster:      provv1.NewCluster("test-namespace", "test-cluster", provv1.Cluster{}),
+			cacheFunc: func(cache *fake.MockCacheInterface[*rkev1.ETCDSnapshot]) {
+				cache.EXPECT().GetByIndex(cluster2.ByETCDSnapshotName, "test-namespace/test-cluster/test-snapshot").Return([]*rkev1.ETCDSnapshot{
+					{ObjectMeta: metav1.ObjectMeta{Name: "local"}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "encrypted", Annotations: map[string]string{EncryptedSnapshotSourceAnnotation: "test-snapshot"}}},
+				}, nil)
+			},
+			expectedSnapshots: []*rkev1.ETCDSnapshot{{ObjectMeta: metav1.ObjectMeta{Name: "local"}}},
+			expectErr:         false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -986,3 +999,50 @@ func TestGetMachineByID(t *testing.T) {
 func TestGetLogPrefix(t *testing.T) {
 	assert.Equal(t, "[snapshotbackpopulate] rkecluster test-namespace/test-cluster:", getLogPrefix(&provv1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "test-namespace", Name: "test-cluster"}}))
 }
+
+func TestPruneTargetCountsCreatedSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	cluster := &provv1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "test"}}
+	controlPlane := &rkev1.RKEControlPlane{
+		Spec: rkev1.RKEControlPlaneSpec{
+			RKEClusterSpecCommon: rkev1.RKEClusterSpecCommon{
+				ETCD: &rkev1.ETCD{
+					Targets: []rkev1.ETCDSnapshotTarget{
+						{Name: "backup", Retention: &rkev1.ETCDSnapshotRetention{Count: 1}},
+					},
+				},
+			},
+		},
+	}
+	snapshot := func(name string, created time.Time) *rkev1.ETCDSnapshot {
+		return &rkev1.ETCDSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   cluster.Namespace,
+				Name:        name,
+				Annotations: map[string]string{capr.SnapshotNameAnnotation: name + "-file"},
+			},
+			SnapshotFile: rkev1.ETCDSnapshotFile{
+				Name:      name,
+				Target:    "backup",
+				Status:    "successful",
+				CreatedAt: &metav1.Time{Time: created},
+			},
+		}
+	}
+	now := time.Now()
+	older := snapshot("older", now.Add(-time.Hour))
+	created := snapshot("created", now)
+
+	// the cache does not hold the snapshot that was just created yet
+	etcdSnapshotCache := fake.NewMockCacheInterface[*rkev1.ETCDSnapshot](ctrl)
+	etcdSnapshotCache.EXPECT().List(cluster.Namespace, gomock.Any()).Return([]*rkev1.ETCDSnapshot{older}, nil)
+	etcdSnapshotFileController := fake.NewMockNonNamespacedControllerInterface[*k3s.ETCDSnapshotFile, *k3s.ETCDSnapshotFileList](ctrl)
+	etcdSnapshotFileController.EXPECT().Delete("older-file", gomock.Any()).Return(nil)
+
+	h := handler{
+		etcdSnapshotCache:          etcdSnapshotCache,
+		etcdSnapshotFileController: etcdSnapshotFileController,
+	}
+	assert.NoError(t, h.pruneTarget(cluster, controlPlane, created))
+}
@@ -0,0 +1,264 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rancher/lasso/pkg/controller"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ManagementCluster is the cluster label of the controllers of the management contexts, which run against the cluster
+// Rancher is installed in. The user controllers of that cluster are labeled "local", like its cluster object.
+const ManagementCluster = "management"
+
+var (
+	prometheusMetrics = false
+
+	reconcileTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "rancher_controller",
+			Name:      "reconcile_total",
+			Help:      "Total number of reconciles run by a controller handler for a cluster",
+		},
+		[]string{"controller", "handler", "cluster"},
+	)
+
+	reconcileErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "rancher_controller",
+			Name:      "reconcile_errors_total",
+			Help:      "Total number of reconciles of a controller handler for a cluster that returned an error",
+		},
+		[]string{"controller", "handler", "cluster"},
+	)
+
+	reconcileRetries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "rancher_controller",
+			Name:      "reconcile_retries_total",
+			Help:      "Total number of reconciles of a controller handler for a cluster that retried a key whose previous reconcile failed",
+		},
+		[]string{"controller", "handler", "cluster"},
+	)
+
+	reconcileDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: "rancher_controller",
+			Name:      "reconcile_duration_seconds",
+			Help:      "Duration of the reconciles of a controller handler for a cluster",
+			Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		},
+		[]string{"controller", "handler", "cluster"},
+	)
+
+	queueDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: "rancher_controller",
+			Name:      "queue_depth",
+			Help:      "Approximate number of keys waiting to be reconciled by a controller for a cluster",
+		},
+		[]string{"controller", "cluster"},
+	)
+)
+
+// MetricsCollectors returns the controller metrics, which are all labeled by cluster.
+func MetricsCollectors() []prometheus.Collector {
+	return []prometheus.Collector{reconcileTotal, reconcileErrors, reconcileRetries, reconcileDuration, queueDepth}
+}
+
+// RegisterMetrics registers the controller metrics and starts updating them.
+func RegisterMetrics() {
+	prometheusMetrics = true
+	prometheus.MustRegister(MetricsCollectors()...)
+}
+
+// InstrumentFactory wraps a SharedControllerFactory of the given context type so that the handlers registered on its
+// controllers record reconcile metrics labeled by controller, handler and the given cluster.
+func InstrumentFactory(factory controller.SharedControllerFactory, contextType controllerContextType, cluster string) controller.SharedControllerFactory {
+	return &instrumentedFactory{
+		SharedControllerFactory: factory,
+		cluster:                 cluster,
+		syncOnlyChangedObjects:  syncOnlyChangedObjects(contextType),
+		controllers:             map[schema.GroupVersionResource]*instrumentedController{},
+	}
+}
+
+type instrumentedFactory struct {
+	controller.SharedControllerFactory
+
+	cluster                string
+	syncOnlyChangedObjects bool
+
+	lock        sync.Mutex
+	controllers map[schema.GroupVersionResource]*instrumentedController
+}
+
+func (f *instrumentedFactory) ForObject(obj runtime.Object) (controller.SharedController, error) {
+	gvk, err := f.SharedCacheFactory().SharedClientFactory().GVKForObject(obj)
+	if err != nil {
+		return nil, err
+	}
+	return f.ForKind(gvk)
+}
+
+func (f *instrumentedFactory) ForKind(gvk schema.GroupVersionKind) (controller.SharedController, error) {
+	gvr, namespaced, err := f.SharedCacheFactory().SharedClientFactory().ResourceForGVK(gvk)
+	if err != nil {
+		return nil, err
+	}
+	return f.ForResourceKind(gvr, gvk.Kind, namespaced), nil
+}
+
+func (f *instrumentedFactory) ForResource(gvr schema.GroupVersionResource, namespaced bool) controller.SharedController {
+	return f.ForResourceKind(gvr, "", namespaced)
+}
+
+func (f *instrumentedFactory) ForResourceKind(gvr schema.GroupVersionResource, kind string, namespaced bool) controller.SharedController {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if c, ok := f.controllers[gvr]; ok {
+		return c
+	}
+	c := &instrumentedController{
+		SharedController:       f.SharedControllerFactory.ForResourceKind(gvr, kind, namespaced),
+		name:                   gvr.String(),
+		cluster:                f.cluster,
+		syncOnlyChangedObjects: f.syncOnlyChangedObjects,
+		pending:                map[string]bool{},
+		failing:                map[string]bool{},
+	}
+	f.controllers[gvr] = c
+	return c
+}
+
+// instrumentedController tracks the keys waiting in the workqueue of a controller, which it can't access, from the
+// events of its informer, the keys explicitly enqueued and the keys requeued after a failed reconcile.
+type instrumentedController struct {
+	controller.SharedController
+
+	name                   string
+	cluster                string
+	syncOnlyChangedObjects bool
+	watchOnce              sync.Once
+
+	lock    sync.Mutex
+	pending map[string]bool
+	failing map[string]bool
+}
+
+func (c *instrumentedController) RegisterHandler(ctx context.Context, name string, handler controller.SharedControllerHandler) {
+	c.SharedController.RegisterHandler(ctx, name, controller.SharedControllerHandlerFunc(func(key string, obj runtime.Object) (runtime.Object, error) {
+		if !prometheusMetrics {
+			return handler.OnChange(key, obj)
+		}
+		return c.reconcile(name, key, obj, handler)
+	}))
+	// the controller is initialized by RegisterHandler, so its informer can be watched without starting it early
+	c.watchOnce.Do(func() {
+		c.SharedController.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: c.enqueueObject,
+			UpdateFunc: func(old, new interface{}) {
+				if !c.syncOnlyChangedObjects || old.(controller.ResourceVersionGetter).GetResourceVersion() != new.(controller.ResourceVersionGetter).GetResourceVersion() {
+					c.enqueueObject(new)
+				}
+			},
+			DeleteFunc: c.enqueueObject,
+		})
+	})
+}
+
+func (c *instrumentedController) reconcile(handlerName, key string, obj runtime.Object, handler controller.SharedControllerHandler) (runtime.Object, error) {
+	c.lock.Lock()
+	retry := c.failing[handlerName+"/"+key]
+	c.setPending(key, false)
+	c.lock.Unlock()
+
+	labels := prometheus.Labels{"controller": c.name, "handler": handlerName, "cluster": c.cluster}
+	start := time.Now()
+	newObj, err := handler.OnChange(key, obj)
+	reconcileDuration.With(labels).Observe(time.Since(start).Seconds())
+	reconcileTotal.With(labels).Inc()
+	if retry {
+		reconcileRetries.With(labels).Inc()
+	}
+
+	failed := err != nil && !errors.Is(err, controller.ErrIgnore)
+	if failed {
+		reconcileErrors.With(labels).Inc()
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if failed {
+		c.failing[handlerName+"/"+key] = true
+		c.setPending(key, true)
+	} else {
+		delete(c.failing, handlerName+"/"+key)
+	}
+	return newObj, err
+}
+
+func (c *instrumentedController) Enqueue(namespace, name string) {
+	c.enqueue(keyFunc(namespace, name))
+	c.SharedController.Enqueue(namespace, name)
+}
+
+// EnqueueAfter counts the key as waiting only once it is due, as the workqueue holds it back until then.
+func (c *instrumentedController) EnqueueAfter(namespace, name string, delay time.Duration) {
+	key := keyFunc(namespace, name)
+	time.AfterFunc(delay, func() {
+		c.enqueue(key)
+	})
+	c.SharedController.EnqueueAfter(namespace, name, delay)
+}
+
+func (c *instrumentedController) EnqueueKey(key string) {
+	c.enqueue(key)
+	c.SharedController.EnqueueKey(key)
+}
+
+func (c *instrumentedController) enqueueObject(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	c.enqueue(key)
+}
+
+func (c *instrumentedController) enqueue(key string) {
+	if !prometheusMetrics {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.setPending(key, true)
+}
+
+// setPending must be called with the lock held.
+func (c *instrumentedController) setPending(key string, pending bool) {
+	if pending {
+		c.pending[key] = true
+	} else {
+		delete(c.pending, key)
+	}
+	if prometheusMetrics {
+		queueDepth.WithLabelValues(c.name, c.cluster).Set(float64(len(c.pending)))
+	}
+}
+
+func keyFunc(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rancher/lasso/pkg/controller"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+type fakeSharedController struct {
+	controller.SharedController
+
+	informer cache.SharedIndexInformer
+	handlers map[string]controller.SharedControllerHandler
+}
+
+func (f *fakeSharedController) RegisterHandler(_ context.Context, name string, handler controller.SharedControllerHandler) {
+	f.handlers[name] = handler
+}
+
+func (f *fakeSharedController) Informer() cache.SharedIndexInformer {
+	return f.informer
+}
+
+func (f *fakeSharedController) EnqueueKey(string) {}
+
+func (f *fakeSharedController) EnqueueAfter(string, string, time.Duration) {}
+
+func TestInstrumentedController(t *testing.T) {
+	prometheusMetrics = true
+	defer func() {
+		prometheusMetrics = false
+		reconcileTotal.Reset()
+		reconcileErrors.Reset()
+		reconcileRetries.Reset()
+		reconcileDuration.Reset()
+		queueDepth.Reset()
+	}()
+
+	fake := &fakeSharedController{
+		informer: cache.NewSharedIndexInformer(nil, nil, 0, cache.Indexers{}),
+		handlers: map[string]controller.SharedControllerHandler{},
+	}
+	c := &instrumentedController{
+		SharedController: fake,
+		name:             "management.cattle.io/v3, Resource=clusters",
+		cluster:          "c-abc",
+		pending:          map[string]bool{},
+		failing:          map[string]bool{},
+	}
+
+	var fail bool
+	c.RegisterHandler(context.Background(), "cluster-handler", controller.SharedControllerHandlerFunc(func(key string, obj runtime.Object) (runtime.Object, error) {
+		if fail {
+			return nil, errors.New("failed")
+		}
+		return obj, nil
+	}))
+	handler := fake.handlers["cluster-handler"]
+
+	c.EnqueueKey("c-abc")
+	c.EnqueueKey("c-def")
+	assert.Equal(t, float64(2), testutil.ToFloat64(queueDepth.WithLabelValues(c.name, "c-abc")))
+
+	fail = true
+	_, err := handler.OnChange("c-abc", nil)
+	assert.Error(t, err)
+	assert.Equal(t, float64(2), testutil.ToFloat64(queueDepth.WithLabelValues(c.name, "c-abc")), "failed keys are requeued")
+
+	fail = false
+	_, err = handler.OnChange("c-abc", nil)
+	assert.NoError(t, err)
+	_, err = handler.OnChange("c-abc", nil)
+	assert.NoError(t, err)
+
+	labels := []string{c.name, "cluster-handler", "c-abc"}
+	assert.Equal(t, float64(3), testutil.ToFloat64(reconcileTotal.WithLabelValues(labels...)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(reconcileErrors.WithLabelValues(labels...)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(reconcileRetries.WithLabelValues(labels...)))
+	assert.Equal(t, 1, testutil.CollectAndCount(reconcileDuration))
+	assert.Equal(t, float64(1), testutil.ToFloat64(queueDepth.WithLabelValues(c.name, "c-abc")))
+
+	c.EnqueueAfter("", "c-ghi", 50*time.Millisecond)
+	assert.Equal(t, float64(1), testutil.ToFloat64(queueDepth.WithLabelValues(c.name, "c-abc")), "delayed keys are not waiting yet")
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(queueDepth.WithLabelValues(c.name, "c-abc")) == 2
+	}, 5*time.Second, 10*time.Millisecond, "delayed keys are waiting once due")
+}
@@ -12,6 +12,7 @@
 	v1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
 	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
 	"github.com/rancher/rancher/pkg/capr"
+	"github.com/rancher/rancher/pkg/events"
 	"github.com/rancher/rancher/pkg/features"
 	fleetconst "github.com/rancher/rancher/pkg/fleet"
 	capicontrollers "github.com/rancher/rancher/pkg/generated/controllers/cluster.x-k8s.io/v1beta1"
@@ -39,6 +40,7 @@
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 )
 
 const (
@@ -74,6 +76,7 @@ type handler struct {
 	secretCache           corecontrollers.SecretCache
 	kubeconfigManager     *kubeconfig.Manager
 	apply                 apply.Apply
+	recorder              record.EventRecorder
 
 	capiClustersCache capicontrollers.ClusterCache
 	capiClusters      capicontrollers.ClusterClient
@@ -99,6 +102,7 @@ func Register(
 		capiClusters:          clients.CAPI.Cluster(),
 		capiMachinesCache:     clients.CAPI.Machine().Cache(),
 		kubeconfigManager:     kubeconfigManager,
+		recorder:              clients.EventRecorder,
 		apply: clients.Apply.WithCacheTypes(
 			clients.Provisioning.Cluster(),
 			clients.Mgmt.Cluster()),
@@ -608,6 +612,13 @@ func (h *handler) updateStatus(objs []runtime.Object, cluster *v1.Cluster, statu
 		status.AgentDeployed = v3.ClusterConditionAgentDeployed.IsTrue(existing)
 	}
 
+	if cluster.Status.ClusterName == "" {
+		h.recorder.Eventf(cluster, corev1.EventTypeNormal, events.ReasonClusterCreated, "Created management cluster %s", rCluster.Name)
+	}
+	if ready && !cluster.Status.Ready {
+		h.recorder.Event(cluster, corev1.EventTypeNormal, events.ReasonClusterReady, "The cluster is ready")
+	}
+
 	// Never set ready back to false because we will end up deleting the secret
 	status.Ready = status.Ready || ready
 	status.ObservedGeneration = cluster.Generation
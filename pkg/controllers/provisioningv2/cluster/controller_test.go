@@ -12,6 +12,7 @@
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 )
 
 func TestRegexp(t *testing.T) {
@@ -63,6 +64,7 @@ func TestController_updateImportedCluster(t *testing.T) {
 
 			h := handler{
 				mgmtClusterCache: clusterCache,
+				recorder:         &record.FakeRecorder{},
 			}
 
 			obj, _, err := h.updateImportedCluster(tt.cluster, tt.cluster.Status, tt.mgmtCluster)
@@ -224,6 +226,7 @@ func TestController_createNewCluster(t *testing.T) {
 			h := handler{
 				mgmtClusterCache: clusterCache,
 				featureCache:     featureCache,
+				recorder:         &record.FakeRecorder{},
 			}
 
 			obj, _, err := h.createNewCluster(tt.cluster, tt.cluster.Status, tt.clusterSpec)
@@ -7,7 +7,9 @@
 	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	v1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
 	"github.com/rancher/rancher/pkg/capr"
+	"github.com/rancher/rancher/pkg/events"
 	"github.com/rancher/wrangler/v3/pkg/generic"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -43,6 +45,7 @@ func (h *handler) OnMgmtClusterRemove(_ string, cluster *v3.Cluster) (*v3.Cluste
 
 func (h *handler) OnClusterRemove(_ string, cluster *v1.Cluster) (*v1.Cluster, error) {
 	oldStatus := cluster.Status
+	oldMessage := capr.Removed.GetMessage(cluster)
 	cluster = cluster.DeepCopy()
 
 	err := capr.DoRemoveAndUpdateStatus(cluster, h.doClusterRemove(cluster), h.clusters.EnqueueAfter)
@@ -51,6 +54,14 @@ func (h *handler) OnClusterRemove(_ string, cluster *v1.Cluster) (*v1.Cluster, e
 		return cluster, err
 	}
 
+	if message := capr.Removed.GetMessage(cluster); message != "" && message != oldMessage {
+		eventType := corev1.EventTypeNormal
+		if capr.Removed.IsFalse(cluster) {
+			eventType = corev1.EventTypeWarning
+		}
+		h.recorder.Event(cluster, eventType, events.ReasonClusterRemoving, message)
+	}
+
 	cluster, updateErr := h.clusters.UpdateStatus(cluster)
 	if updateErr != nil {
 		return cluster, updateErr
@@ -1,6 +1,7 @@
 package cluster
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -13,7 +14,7 @@
 
 // updateV1SchedulingCustomization looks for the provisioning.cattle.io/enable-scheduling-customization annotation on the v1.Cluster
 // and if found populates the spec.ClusterAgentDeploymentCustomization.SchedulingCustomization field with the default values set in the
-// global settings. If the cluster-agent-scheduling-customization feature is disabled, the cluster will be returned unchanged.
+// global settings. If the cluster-agent-scheduling-customization feature is disabled for the cluster, the cluster will be returned unchanged.
 // The provisioning.cattle.io/enable-scheduling-customization annotation can be set to 'true' or 'false', which will add or remove
 // the scheduling customization field as needed.
 func (h *handler) updateV1SchedulingCustomization(_ string, cluster *v1.Cluster) (*v1.Cluster, error) {
@@ -21,7 +22,7 @@ func (h *handler) updateV1SchedulingCustomization(_ string, cluster *v1.Cluster)
 		return nil, nil
 	}
 
-	if !features.ClusterAgentSchedulingCustomization.Enabled() {
+	if !features.ClusterAgentSchedulingCustomization.EnabledFor(features.WithCluster(context.Background(), cluster)) {
 		return cluster, nil
 	}
 
@@ -71,7 +72,7 @@ func (h *handler) updateV1SchedulingCustomization(_ string, cluster *v1.Cluster)
 
 // updateV1SchedulingCustomization looks for the provisioning.cattle.io/enable-scheduling-customization annotation on the v3.Cluster
 // and if found populates the spec.ClusterAgentDeploymentCustomization.SchedulingCustomization field with the default values set in the
-// global settings. If the cluster-agent-scheduling-customization feature is disabled, the cluster will be returned unchanged.
+// global settings. If the cluster-agent-scheduling-customization feature is disabled for the cluster, the cluster will be returned unchanged.
 // The provisioning.cattle.io/enable-scheduling-customization annotation can be set to 'true' or 'false', which will add or remove
 // the scheduling customization field as needed. updateV3SchedulingCustomization is intended to handle KEv2 and legacy clusters specifically.
 func (h *handler) updateV3SchedulingCustomization(_ string, cluster *v3.Cluster) (*v3.Cluster, error) {
@@ -83,7 +84,7 @@ func (h *handler) updateV3SchedulingCustomization(_ string, cluster *v3.Cluster)
 		return nil, nil
 	}
 
-	if !features.ClusterAgentSchedulingCustomization.Enabled() {
+	if !features.ClusterAgentSchedulingCustomization.EnabledFor(features.WithCluster(context.Background(), cluster)) {
 		return cluster, nil
 	}
 
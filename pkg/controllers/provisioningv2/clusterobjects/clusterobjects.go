@@ -0,0 +1,583 @@
+package clusterobjects
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	provv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/capr"
+	clustercontroller "github.com/rancher/rancher/pkg/controllers/provisioningv2/cluster"
+	"github.com/rancher/rancher/pkg/features"
+	mgmtcontrollers "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	provcontrollers "github.com/rancher/rancher/pkg/generated/controllers/provisioning.cattle.io/v1"
+	rkecontrollers "github.com/rancher/rancher/pkg/generated/controllers/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/wrangler"
+	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/v3/pkg/name"
+	"github.com/rancher/wrangler/v3/pkg/relatedresource"
+	"github.com/rancher/wrangler/v3/pkg/yaml"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// clusterObjectsRetention is how long the captured objects of a cluster are retained once superseded, if no etcd
+// snapshot refers to them. It leaves time for the snapshots taken meanwhile to be recorded.
+const clusterObjectsRetention = 24 * time.Hour
+
+type handler struct {
+	clusterCache      provcontrollers.ClusterCache
+	clusters          provcontrollers.ClusterClient
+	controlPlaneCache rkecontrollers.RKEControlPlaneCache
+	etcdSnapshotCache rkecontrollers.ETCDSnapshotCache
+	secretCache       corecontrollers.SecretCache
+	secrets           corecontrollers.SecretClient
+	projectCache      mgmtcontrollers.ProjectCache
+	projects          mgmtcontrollers.ProjectClient
+	crtbCache         mgmtcontrollers.ClusterRoleTemplateBindingCache
+	crtbs             mgmtcontrollers.ClusterRoleTemplateBindingClient
+	prtbCache         mgmtcontrollers.ProjectRoleTemplateBindingCache
+	prtbs             mgmtcontrollers.ProjectRoleTemplateBindingClient
+	// mccCache and mccs are nil when fleet is disabled, as managed charts are not available in that case.
+	mccCache mgmtcontrollers.ManagedChartCache
+	mccs     mgmtcontrollers.ManagedChartClient
+}
+
+// Register registers the clusterobjects controller, which captures the Rancher objects that belong to a provisioning
+// cluster into secrets named after their hash, which the etcd snapshots of the cluster refer to, and reconciles them
+// back to the snapshot state once a restore that requested it has finished.
+func Register(ctx context.Context, clients *wrangler.Context) {
+	h := &handler{
+		clusterCache:      clients.Provisioning.Cluster().Cache(),
+		clusters:          clients.Provisioning.Cluster(),
+		controlPlaneCache: clients.RKE.RKEControlPlane().Cache(),
+		etcdSnapshotCache: clients.RKE.ETCDSnapshot().Cache(),
+		secretCache:       clients.Core.Secret().Cache(),
+		secrets:           clients.Core.Secret(),
+		projectCache:      clients.Mgmt.Project().Cache(),
+		projects:          clients.Mgmt.Project(),
+		crtbCache:         clients.Mgmt.ClusterRoleTemplateBinding().Cache(),
+		crtbs:             clients.Mgmt.ClusterRoleTemplateBinding(),
+		prtbCache:         clients.Mgmt.ProjectRoleTemplateBinding().Cache(),
+		prtbs:             clients.Mgmt.ProjectRoleTemplateBinding(),
+	}
+
+	watched := []relatedresource.ControllerWrapper{
+		clients.RKE.RKEControlPlane(),
+		clients.RKE.ETCDSnapshot(),
+		clients.Mgmt.Project(),
+		clients.Mgmt.ClusterRoleTemplateBinding(),
+		clients.Mgmt.ProjectRoleTemplateBinding(),
+	}
+	if features.Fleet.Enabled() {
+		h.mccCache = clients.Mgmt.ManagedChart().Cache()
+		h.mccs = clients.Mgmt.ManagedChart()
+		watched = append(watched, clients.Mgmt.ManagedChart())
+	}
+
+	clients.Provisioning.Cluster().OnChange(ctx, "cluster-objects", h.OnChange)
+	relatedresource.Watch(ctx, "cluster-objects-trigger", h.resolve, clients.Provisioning.Cluster(), watched...)
+}
+
+// resolve maps changes to the captured objects to the provisioning cluster they belong to.
+func (h *handler) resolve(namespace, name string, obj runtime.Object) ([]relatedresource.Key, error) {
+	switch o := obj.(type) {
+	case *rkev1.RKEControlPlane:
+		return []relatedresource.Key{{Namespace: o.Namespace, Name: o.Name}}, nil
+	case *rkev1.ETCDSnapshot:
+		// the captured objects that snapshots no longer refer to can be deleted
+		if o.Spec.ClusterName == "" {
+			return nil, nil
+		}
+		return []relatedresource.Key{{Namespace: o.Namespace, Name: o.Spec.ClusterName}}, nil
+	case *v3.Project:
+		return h.keysForManagementCluster(o.Namespace)
+	case *v3.ClusterRoleTemplateBinding:
+		return h.keysForManagementCluster(o.Namespace)
+	case *v3.ProjectRoleTemplateBinding:
+		clusterName, _, _ := strings.Cut(o.ProjectName, ":")
+		return h.keysForManagementCluster(clusterName)
+	case *v3.ManagedChart:
+		var result []relatedresource.Key
+		for _, target := range o.Spec.Targets {
+			if target.ClusterName != "" {
+				result = append(result, relatedresource.Key{Namespace: o.Namespace, Name: target.ClusterName})
+			}
+		}
+		return result, nil
+	}
+	return nil, nil
+}
+
+func (h *handler) keysForManagementCluster(mgmtClusterName string) ([]relatedresource.Key, error) {
+	if mgmtClusterName == "" {
+		return nil, nil
+	}
+	clusters, err := h.clusterCache.GetByIndex(clustercontroller.ByCluster, mgmtClusterName)
+	if err != nil || len(clusters) == 0 {
+		// ignore
+		return nil, nil
+	}
+	return []relatedresource.Key{{Namespace: clusters[0].Namespace, Name: clusters[0].Name}}, nil
+}
+
+func (h *handler) OnChange(_ string, cluster *provv1.Cluster) (*provv1.Cluster, error) {
+	if cluster == nil || !cluster.DeletionTimestamp.IsZero() || cluster.Spec.RKEConfig == nil || cluster.Status.ClusterName == "" {
+		return cluster, nil
+	}
+
+	controlPlane, err := h.controlPlaneCache.Get(cluster.Namespace, cluster.Name)
+	if apierrors.IsNotFound(err) {
+		return cluster, nil
+	} else if err != nil {
+		return cluster, err
+	}
+
+	cluster, err = h.restore(cluster, controlPlane)
+	if err != nil {
+		return cluster, err
+	}
+
+	return cluster, h.capture(cluster)
+}
+
+// capture stores the current Rancher objects of the cluster in a secret named after their hash, unless they did not
+// change, and deletes the secrets that are no longer needed. The objects are not part of the plans of the nodes, so
+// changing them does not affect the nodes: instead, each etcd snapshot refers to the secret that was current when it
+// was taken.
+func (h *handler) capture(cluster *provv1.Cluster) error {
+	objects, err := h.clusterObjects(cluster)
+	if err != nil {
+		return err
+	}
+
+	value, err := capr.CompressInterface(objects)
+	if err != nil {
+		return err
+	}
+	hash := capr.ClusterObjectsHash(value)
+
+	secrets, err := capr.ListClusterObjectsSecrets(h.secretCache, cluster.Namespace, cluster.Name)
+	if err != nil {
+		return err
+	}
+	sort.Slice(secrets, func(i, j int) bool {
+		return capr.ClusterObjectsCapturedAt(secrets[i]).Before(capr.ClusterObjectsCapturedAt(secrets[j]))
+	})
+
+	if len(secrets) == 0 || secrets[len(secrets)-1].Annotations[capr.ClusterObjectsHashAnnotation] != hash {
+		if err := h.store(cluster, hash, value); err != nil {
+			return err
+		}
+	}
+	return h.prune(cluster, secrets, hash)
+}
+
+// store records that the given objects are the current objects of the cluster from now on.
+func (h *handler) store(cluster *provv1.Cluster, hash, value string) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	secret, err := h.secretCache.Get(cluster.Namespace, capr.ClusterObjectsSecretName(cluster.Name, hash))
+	if apierrors.IsNotFound(err) {
+		_, err = h.secrets.Create(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: cluster.Namespace,
+				Name:      capr.ClusterObjectsSecretName(cluster.Name, hash),
+				Labels: map[string]string{
+					capr.ClusterNameLabel:    cluster.Name,
+					capr.ClusterObjectsLabel: "true",
+				},
+				Annotations: map[string]string{
+					capr.ClusterObjectsHashAnnotation:       hash,
+					capr.ClusterObjectsCapturedAtAnnotation: now,
+				},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: provv1.SchemeGroupVersion.String(),
+					Kind:       "Cluster",
+					Name:       cluster.Name,
+					UID:        cluster.UID,
+				}},
+			},
+			Data: map[string][]byte{
+				capr.ClusterObjectsSecretKey: []byte(value),
+			},
+		})
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	// the objects went back to a state that was captured before
+	secret = secret.DeepCopy()
+	secret.Annotations[capr.ClusterObjectsCapturedAtAnnotation] = now
+	_, err = h.secrets.Update(secret)
+	return err
+}
+
+// prune deletes the given secrets, sorted by capture time, that no etcd snapshot of the cluster refers to once they
+// have been superseded for longer than the retention.
+func (h *handler) prune(cluster *provv1.Cluster, secrets []*corev1.Secret, current string) error {
+	snapshots, err := h.etcdSnapshotCache.List(cluster.Namespace, labels.SelectorFromSet(labels.Set{
+		capr.ClusterNameLabel: cluster.Name,
+	}))
+	if err != nil {
+		return err
+	}
+	referenced := map[string]bool{current: true}
+	for _, snapshot := range snapshots {
+		referenced[snapshot.Annotations[capr.ClusterObjectsHashAnnotation]] = true
+	}
+
+	var errs []error
+	for i, secret := range secrets {
+		if referenced[secret.Annotations[capr.ClusterObjectsHashAnnotation]] || i == len(secrets)-1 ||
+			time.Since(capr.ClusterObjectsCapturedAt(secrets[i+1])) < clusterObjectsRetention {
+			continue
+		}
+		if err := h.secrets.Delete(secret.Namespace, secret.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// clusterObjects returns the manifest of the Rancher objects that currently belong to the given cluster, sorted by name
+// so the result is stable across calls.
+func (h *handler) clusterObjects(cluster *provv1.Cluster) (*capr.ClusterObjects, error) {
+	mgmtClusterName := cluster.Status.ClusterName
+	objects := &capr.ClusterObjects{
+		Labels: yaml.CleanAnnotationsForExport(cluster.Labels),
+	}
+
+	projects, err := h.projectCache.List(mgmtClusterName, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Name < projects[j].Name })
+	for _, project := range projects {
+		objects.Projects = append(objects.Projects, v3.Project{
+			ObjectMeta: exportMeta(project.ObjectMeta),
+			Spec:       project.Spec,
+			Status:     v3.ProjectStatus{BackingNamespace: project.Status.BackingNamespace},
+		})
+
+		prtbs, err := h.prtbCache.List(project.GetProjectBackingNamespace(), labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(prtbs, func(i, j int) bool { return prtbs[i].Name < prtbs[j].Name })
+		for _, prtb := range prtbs {
+			prtb = prtb.DeepCopy()
+			prtb.ObjectMeta = exportMeta(prtb.ObjectMeta)
+			objects.ProjectRoleTemplateBindings = append(objects.ProjectRoleTemplateBindings, *prtb)
+		}
+	}
+
+	crtbs, err := h.crtbCache.List(mgmtClusterName, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(crtbs, func(i, j int) bool { return crtbs[i].Name < crtbs[j].Name })
+	for _, crtb := range crtbs {
+		crtb = crtb.DeepCopy()
+		crtb.ObjectMeta = exportMeta(crtb.ObjectMeta)
+		crtb.Status = v3.ClusterRoleTemplateBindingStatus{}
+		objects.ClusterRoleTemplateBindings = append(objects.ClusterRoleTemplateBindings, *crtb)
+	}
+
+	if h.mccCache != nil {
+		mccs, err := h.mccCache.List(cluster.Namespace, labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(mccs, func(i, j int) bool { return mccs[i].Name < mccs[j].Name })
+		for _, mcc := range mccs {
+			if !targetsCluster(mcc, cluster.Name) {
+				continue
+			}
+			objects.ManagedCharts = append(objects.ManagedCharts, v3.ManagedChart{
+				ObjectMeta: exportMeta(mcc.ObjectMeta),
+				Spec:       *mcc.Spec.DeepCopy(),
+			})
+		}
+	}
+
+	return objects, nil
+}
+
+// restore reconciles the Rancher objects of the cluster back to the state captured in the snapshot being restored, once
+// the etcd restore has finished. It is only done once per restore generation.
+func (h *handler) restore(cluster *provv1.Cluster, controlPlane *rkev1.RKEControlPlane) (*provv1.Cluster, error) {
+	restore := cluster.Spec.RKEConfig.ETCDSnapshotRestore
+	if restore == nil || !restore.RestoreRancherObjects || restore.Name == "" ||
+		controlPlane.Status.ETCDSnapshotRestorePhase != rkev1.ETCDSnapshotPhaseFinished ||
+		!equality.Semantic.DeepEqual(controlPlane.Status.ETCDSnapshotRestore, restore) {
+		return cluster, nil
+	}
+
+	restoreKey := fmt.Sprintf("%s/%d", restore.Name, restore.Generation)
+	if cluster.Annotations[capr.ClusterObjectsRestoredAnnotation] == restoreKey {
+		return cluster, nil
+	}
+
+	snapshot, err := h.etcdSnapshotCache.Get(cluster.Namespace, restore.Name)
+	if err != nil {
+		return cluster, fmt.Errorf("failed to get etcd snapshot %s/%s to restore Rancher objects from: %w", cluster.Namespace, restore.Name, err)
+	}
+
+	objects, err := capr.GetSnapshotClusterObjects(h.secretCache, snapshot)
+	if err != nil {
+		return cluster, err
+	}
+
+	logrus.Infof("[clusterobjects] rkecluster %s/%s: restoring Rancher objects from etcd snapshot %s", cluster.Namespace, cluster.Name, restore.Name)
+	if err := h.reconcile(cluster, restore.Name, objects); err != nil {
+		return cluster, err
+	}
+
+	cluster = cluster.DeepCopy()
+	for k := range yaml.CleanAnnotationsForExport(cluster.Labels) {
+		if _, ok := objects.Labels[k]; !ok {
+			delete(cluster.Labels, k)
+		}
+	}
+	for k, v := range objects.Labels {
+		if cluster.Labels == nil {
+			cluster.Labels = map[string]string{}
+		}
+		cluster.Labels[k] = v
+	}
+	if cluster.Annotations == nil {
+		cluster.Annotations = map[string]string{}
+	}
+	cluster.Annotations[capr.ClusterObjectsRestoredAnnotation] = restoreKey
+	return h.clusters.Update(cluster)
+}
+
+// reconcile creates and updates the objects of the given manifest, and removes the role template bindings created by
+// an earlier restore that did not exist when it was captured. Projects and managed charts that were created afterward
+// are left in place, as deleting them would remove the workloads and resources they hold.
+func (h *handler) reconcile(cluster *provv1.Cluster, restoreName string, objects *capr.ClusterObjects) error {
+	var errs []error
+	mgmtClusterName := cluster.Status.ClusterName
+
+	backingNamespaces := map[string]string{}
+	for _, desired := range objects.Projects {
+		desired.Namespace = mgmtClusterName
+		desired.Spec.ClusterName = mgmtClusterName
+		capturedNamespace := desired.GetProjectBackingNamespace()
+		project, err := h.reconcileProject(&desired)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if project.Status.BackingNamespace == "" && !v3.NamespaceBackedResource.IsTrue(project) {
+			// the bindings of a recreated project can only be restored once its backing namespace exists
+			errs = append(errs, fmt.Errorf("waiting for backing namespace of project %s/%s", project.Namespace, project.Name))
+			continue
+		}
+		backingNamespaces[capturedNamespace] = project.GetProjectBackingNamespace()
+	}
+
+	errs = append(errs, h.reconcileCRTBs(mgmtClusterName, restoreName, objects.ClusterRoleTemplateBindings))
+	errs = append(errs, h.reconcilePRTBs(backingNamespaces, restoreName, objects.ProjectRoleTemplateBindings))
+
+	if h.mccs != nil {
+		for _, desired := range objects.ManagedCharts {
+			desired.Namespace = cluster.Namespace
+			errs = append(errs, h.reconcileManagedChart(&desired))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (h *handler) reconcileProject(desired *v3.Project) (*v3.Project, error) {
+	existing, err := h.projectCache.Get(desired.Namespace, desired.Name)
+	if apierrors.IsNotFound(err) {
+		desired.Status = v3.ProjectStatus{}
+		return h.projects.Create(desired)
+	} else if err != nil {
+		return nil, err
+	}
+	if equality.Semantic.DeepEqual(existing.Spec, desired.Spec) {
+		return existing, nil
+	}
+	existing = existing.DeepCopy()
+	existing.Spec = desired.Spec
+	return h.projects.Update(existing)
+}
+
+func (h *handler) reconcileManagedChart(desired *v3.ManagedChart) error {
+	existing, err := h.mccCache.Get(desired.Namespace, desired.Name)
+	if apierrors.IsNotFound(err) {
+		_, err = h.mccs.Create(desired)
+		return err
+	} else if err != nil {
+		return err
+	}
+	if equality.Semantic.DeepEqual(existing.Spec, desired.Spec) {
+		return nil
+	}
+	existing = existing.DeepCopy()
+	existing.Spec = desired.Spec
+	_, err = h.mccs.Update(existing)
+	return err
+}
+
+// reconcileCRTBs restores the desired cluster role template bindings of the cluster. The bindings it creates carry the
+// restore label, and only the bindings carrying it are removed when they are not desired: bindings created by users
+// after the snapshot was taken and the ones managed by Rancher are left in place. The subject and role of a binding are
+// immutable, so restored bindings that differ are recreated.
+func (h *handler) reconcileCRTBs(mgmtClusterName, restoreName string, desired []v3.ClusterRoleTemplateBinding) error {
+	existing, err := h.crtbCache.List(mgmtClusterName, labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	desiredByName := map[string]*v3.ClusterRoleTemplateBinding{}
+	for i := range desired {
+		crtb := &desired[i]
+		crtb.Namespace = mgmtClusterName
+		crtb.ClusterName = mgmtClusterName
+		crtb.Labels = restoreLabels(crtb.Labels, restoreName)
+		desiredByName[crtb.Name] = crtb
+	}
+
+	for _, crtb := range existing {
+		want, ok := desiredByName[crtb.Name]
+		if ok && crtbEqual(crtb, want) {
+			delete(desiredByName, crtb.Name)
+			continue
+		}
+		if crtb.Labels[capr.ClusterObjectsRestoreLabel] == "" {
+			if ok {
+				logrus.Warnf("[clusterobjects] not restoring cluster role template binding %s/%s: a different binding with the same name exists", crtb.Namespace, crtb.Name)
+				delete(desiredByName, crtb.Name)
+			}
+			continue
+		}
+		if err := h.crtbs.Delete(crtb.Namespace, crtb.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, err)
+			delete(desiredByName, crtb.Name)
+		}
+	}
+
+	for _, crtb := range desiredByName {
+		if _, err := h.crtbs.Create(crtb); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// reconcilePRTBs restores the desired project role template bindings of the restored projects, removing only the
+// bindings created by an earlier restore like reconcileCRTBs. Bindings are moved to the current backing namespace of
+// their project, in case the project was recreated.
+func (h *handler) reconcilePRTBs(backingNamespaces map[string]string, restoreName string, desired []v3.ProjectRoleTemplateBinding) error {
+	var errs []error
+	desiredByKey := map[string]*v3.ProjectRoleTemplateBinding{}
+	for i := range desired {
+		prtb := &desired[i]
+		namespace, ok := backingNamespaces[prtb.Namespace]
+		if !ok {
+			// the project could not be restored
+			continue
+		}
+		prtb.Namespace = namespace
+		prtb.Labels = restoreLabels(prtb.Labels, restoreName)
+		desiredByKey[prtb.Namespace+"/"+prtb.Name] = prtb
+	}
+
+	for _, namespace := range backingNamespaces {
+		existing, err := h.prtbCache.List(namespace, labels.Everything())
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, prtb := range existing {
+			key := prtb.Namespace + "/" + prtb.Name
+			want, ok := desiredByKey[key]
+			if ok && prtbEqual(prtb, want) {
+				delete(desiredByKey, key)
+				continue
+			}
+			if prtb.Labels[capr.ClusterObjectsRestoreLabel] == "" {
+				if ok {
+					logrus.Warnf("[clusterobjects] not restoring project role template binding %s: a different binding with the same name exists", key)
+					delete(desiredByKey, key)
+				}
+				continue
+			}
+			if err := h.prtbs.Delete(prtb.Namespace, prtb.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				errs = append(errs, err)
+				delete(desiredByKey, key)
+			}
+		}
+	}
+
+	for _, prtb := range desiredByKey {
+		if _, err := h.prtbs.Create(prtb); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// restoreLabels returns a copy of the given labels with the restore label set to the given restore.
+func restoreLabels(objectLabels map[string]string, restoreName string) map[string]string {
+	result := map[string]string{}
+	for k, v := range objectLabels {
+		result[k] = v
+	}
+	result[capr.ClusterObjectsRestoreLabel] = name.SafeConcatName(restoreName)
+	return result
+}
+
+func crtbEqual(a, b *v3.ClusterRoleTemplateBinding) bool {
+	return a.RoleTemplateName == b.RoleTemplateName &&
+		a.UserName == b.UserName &&
+		a.UserPrincipalName == b.UserPrincipalName &&
+		a.GroupName == b.GroupName &&
+		a.GroupPrincipalName == b.GroupPrincipalName &&
+		a.ClusterName == b.ClusterName
+}
+
+func prtbEqual(a, b *v3.ProjectRoleTemplateBinding) bool {
+	return a.RoleTemplateName == b.RoleTemplateName &&
+		a.UserName == b.UserName &&
+		a.UserPrincipalName == b.UserPrincipalName &&
+		a.GroupName == b.GroupName &&
+		a.GroupPrincipalName == b.GroupPrincipalName &&
+		a.ProjectName == b.ProjectName &&
+		a.ServiceAccount == b.ServiceAccount
+}
+
+func targetsCluster(mcc *v3.ManagedChart, clusterName string) bool {
+	for _, target := range mcc.Spec.Targets {
+		if target.ClusterName == clusterName {
+			return true
+		}
+	}
+	return false
+}
+
+// exportMeta strips the server populated fields from the given object metadata so the object can be recreated from it.
+func exportMeta(meta metav1.ObjectMeta) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:        meta.Name,
+		Namespace:   meta.Namespace,
+		Labels:      meta.Labels,
+		Annotations: yaml.CleanAnnotationsForExport(meta.Annotations),
+	}
+}
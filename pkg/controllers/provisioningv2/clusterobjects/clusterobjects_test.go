@@ -0,0 +1,208 @@
+package clusterobjects
+
+import (
+	"testing"
+	"time"
+
+	fleet "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	provv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/capr"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func crtb(name, user, role string) *v3.ClusterRoleTemplateBinding {
+	return &v3.ClusterRoleTemplateBinding{
+		ObjectMeta:       metav1.ObjectMeta{Namespace: "c-m-abc", Name: name},
+		ClusterName:      "c-m-abc",
+		UserName:         user,
+		RoleTemplateName: role,
+	}
+}
+
+func TestClusterObjects(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	cluster := &provv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "fleet-default",
+			Name:      "test",
+			Labels: map[string]string{
+				"env":                      "prod",
+				"provisioning.cattle.io/x": "internal",
+			},
+		},
+		Status: provv1.ClusterStatus{ClusterName: "c-m-abc"},
+	}
+
+	project := &v3.Project{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "c-m-abc",
+			Name:            "p-xyz",
+			ResourceVersion: "42",
+			UID:             "uid",
+		},
+		Spec:   v3.ProjectSpec{DisplayName: "Default", ClusterName: "c-m-abc"},
+		Status: v3.ProjectStatus{BackingNamespace: "c-m-abc-p-xyz"},
+	}
+	prtb := &v3.ProjectRoleTemplateBinding{
+		ObjectMeta:       metav1.ObjectMeta{Namespace: "c-m-abc-p-xyz", Name: "prtb-1", ResourceVersion: "7"},
+		ProjectName:      "c-m-abc:p-xyz",
+		UserName:         "u-1",
+		RoleTemplateName: "project-member",
+	}
+
+	projectCache := fake.NewMockCacheInterface[*v3.Project](ctrl)
+	projectCache.EXPECT().List("c-m-abc", labels.Everything()).Return([]*v3.Project{project}, nil)
+	prtbCache := fake.NewMockCacheInterface[*v3.ProjectRoleTemplateBinding](ctrl)
+	prtbCache.EXPECT().List("c-m-abc-p-xyz", labels.Everything()).Return([]*v3.ProjectRoleTemplateBinding{prtb}, nil)
+	crtbCache := fake.NewMockCacheInterface[*v3.ClusterRoleTemplateBinding](ctrl)
+	crtbCache.EXPECT().List("c-m-abc", labels.Everything()).Return([]*v3.ClusterRoleTemplateBinding{
+		crtb("crtb-b", "u-2", "cluster-member"),
+		crtb("crtb-a", "u-1", "cluster-owner"),
+	}, nil)
+	mccCache := fake.NewMockCacheInterface[*v3.ManagedChart](ctrl)
+	mccCache.EXPECT().List("fleet-default", labels.Everything()).Return([]*v3.ManagedChart{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "mine"},
+			Spec:       v3.ManagedChartSpec{Chart: "a", Targets: []fleet.BundleTarget{{ClusterName: "test"}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "other"},
+			Spec:       v3.ManagedChartSpec{Chart: "b", Targets: []fleet.BundleTarget{{ClusterName: "other"}}},
+		},
+	}, nil)
+
+	h := &handler{
+		projectCache: projectCache,
+		prtbCache:    prtbCache,
+		crtbCache:    crtbCache,
+		mccCache:     mccCache,
+	}
+
+	objects, err := h.clusterObjects(cluster)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"env": "prod"}, objects.Labels)
+	require.Len(t, objects.Projects, 1)
+	assert.Equal(t, metav1.ObjectMeta{Namespace: "c-m-abc", Name: "p-xyz", Annotations: map[string]string{}}, objects.Projects[0].ObjectMeta)
+	assert.Equal(t, "c-m-abc-p-xyz", objects.Projects[0].Status.BackingNamespace)
+	require.Len(t, objects.ProjectRoleTemplateBindings, 1)
+	assert.Empty(t, objects.ProjectRoleTemplateBindings[0].ResourceVersion)
+	require.Len(t, objects.ClusterRoleTemplateBindings, 2)
+	assert.Equal(t, "crtb-a", objects.ClusterRoleTemplateBindings[0].Name)
+	require.Len(t, objects.ManagedCharts, 1)
+	assert.Equal(t, "mine", objects.ManagedCharts[0].Name)
+}
+
+func restored(crtb *v3.ClusterRoleTemplateBinding) *v3.ClusterRoleTemplateBinding {
+	crtb.Labels = map[string]string{capr.ClusterObjectsRestoreLabel: "old-snapshot"}
+	return crtb
+}
+
+func TestReconcileCRTBs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	crtbCache := fake.NewMockCacheInterface[*v3.ClusterRoleTemplateBinding](ctrl)
+	crtbCache.EXPECT().List("c-m-abc", labels.Everything()).Return([]*v3.ClusterRoleTemplateBinding{
+		crtb("unchanged", "u-1", "cluster-owner"),
+		restored(crtb("drifted", "u-2", "cluster-owner")),
+		restored(crtb("extra", "u-3", "cluster-member")),
+		crtb("created-later", "u-5", "cluster-member"),
+		crtb("conflict", "u-6", "cluster-owner"),
+	}, nil)
+
+	crtbs := fake.NewMockControllerInterface[*v3.ClusterRoleTemplateBinding, *v3.ClusterRoleTemplateBindingList](ctrl)
+	crtbs.EXPECT().Delete("c-m-abc", "drifted", gomock.Any()).Return(nil)
+	crtbs.EXPECT().Delete("c-m-abc", "extra", gomock.Any()).Return(nil)
+	var created []string
+	crtbs.EXPECT().Create(gomock.Any()).DoAndReturn(func(obj *v3.ClusterRoleTemplateBinding) (*v3.ClusterRoleTemplateBinding, error) {
+		assert.Equal(t, "snapshot", obj.Labels[capr.ClusterObjectsRestoreLabel])
+		created = append(created, obj.Name+"="+obj.RoleTemplateName)
+		return obj, nil
+	}).Times(2)
+
+	h := &handler{
+		crtbCache: crtbCache,
+		crtbs:     crtbs,
+	}
+
+	err := h.reconcileCRTBs("c-m-abc", "snapshot", []v3.ClusterRoleTemplateBinding{
+		*crtb("unchanged", "u-1", "cluster-owner"),
+		*crtb("drifted", "u-2", "cluster-member"),
+		*crtb("missing", "u-4", "cluster-member"),
+		*crtb("conflict", "u-6", "cluster-member"),
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"drifted=cluster-member", "missing=cluster-member"}, created)
+}
+
+func TestCapture(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	cluster := &provv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "test", UID: "uid"},
+		Status:     provv1.ClusterStatus{ClusterName: "c-m-abc"},
+	}
+	captured := func(hash string, capturedAt time.Time) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "fleet-default",
+				Name:      capr.ClusterObjectsSecretName("test", hash),
+				Annotations: map[string]string{
+					capr.ClusterObjectsHashAnnotation:       hash,
+					capr.ClusterObjectsCapturedAtAnnotation: capturedAt.UTC().Format(time.RFC3339),
+				},
+			},
+		}
+	}
+	now := time.Now()
+
+	projectCache := fake.NewMockCacheInterface[*v3.Project](ctrl)
+	projectCache.EXPECT().List("c-m-abc", labels.Everything()).Return(nil, nil)
+	crtbCache := fake.NewMockCacheInterface[*v3.ClusterRoleTemplateBinding](ctrl)
+	crtbCache.EXPECT().List("c-m-abc", labels.Everything()).Return([]*v3.ClusterRoleTemplateBinding{crtb("crtb-a", "u-1", "cluster-owner")}, nil)
+
+	secretCache := fake.NewMockCacheInterface[*corev1.Secret](ctrl)
+	secretCache.EXPECT().List("fleet-default", gomock.Any()).Return([]*corev1.Secret{
+		captured("referenced", now.Add(-72*time.Hour)),
+		captured("unreferenced", now.Add(-71*time.Hour)),
+		captured("superseding", now.Add(-48*time.Hour)),
+	}, nil)
+	secretCache.EXPECT().Get("fleet-default", gomock.Any()).Return(nil, apierrors.NewNotFound(schema.GroupResource{}, ""))
+
+	etcdSnapshotCache := fake.NewMockCacheInterface[*rkev1.ETCDSnapshot](ctrl)
+	etcdSnapshotCache.EXPECT().List("fleet-default", gomock.Any()).Return([]*rkev1.ETCDSnapshot{
+		{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{capr.ClusterObjectsHashAnnotation: "referenced"}}},
+	}, nil)
+
+	secrets := fake.NewMockControllerInterface[*corev1.Secret, *corev1.SecretList](ctrl)
+	secrets.EXPECT().Create(gomock.Any()).DoAndReturn(func(secret *corev1.Secret) (*corev1.Secret, error) {
+		assert.Equal(t, "true", secret.Labels[capr.ClusterObjectsLabel])
+		assert.Equal(t, capr.ClusterObjectsSecretName("test", secret.Annotations[capr.ClusterObjectsHashAnnotation]), secret.Name)
+		objects := &capr.ClusterObjects{}
+		require.NoError(t, capr.DecompressInterface(string(secret.Data[capr.ClusterObjectsSecretKey]), objects))
+		require.Len(t, objects.ClusterRoleTemplateBindings, 1)
+		return secret, nil
+	})
+	// superseded for longer than the retention, and not referenced by any snapshot
+	secrets.EXPECT().Delete("fleet-default", capr.ClusterObjectsSecretName("test", "unreferenced"), gomock.Any()).Return(nil)
+
+	h := &handler{
+		projectCache:      projectCache,
+		crtbCache:         crtbCache,
+		secretCache:       secretCache,
+		secrets:           secrets,
+		etcdSnapshotCache: etcdSnapshotCache,
+	}
+	require.NoError(t, h.capture(cluster))
+}
@@ -4,6 +4,7 @@
 	"context"
 
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/cluster"
+	"github.com/rancher/rancher/pkg/controllers/provisioningv2/clusterobjects"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/fleetcluster"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/fleetworkspace"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/harvestercleanup"
@@ -21,6 +22,7 @@ func Register(ctx context.Context, clients *wrangler.Context, kubeconfigManager
 	cluster.Register(ctx, clients, kubeconfigManager)
 	if features.MCM.Enabled() {
 		secret.Register(ctx, clients)
+		clusterobjects.Register(ctx, clients)
 	}
 	provisioningcluster.Register(ctx, clients)
 	provisioninglog.Register(ctx, clients)
@@ -517,6 +517,25 @@ func rkeCluster(cluster *rancherv1.Cluster) *rkev1.RKECluster {
 	}
 }
 
+// machinePoolOSMaintenance returns the OS maintenance configuration of the machine pools of the cluster, selecting the
+// machines of each pool. They are appended after the cluster wide configuration so they take precedence over it.
+func machinePoolOSMaintenance(cluster *rancherv1.Cluster) []rkev1.OSMaintenance {
+	var result []rkev1.OSMaintenance
+	for _, machinePool := range cluster.Spec.RKEConfig.MachinePools {
+		if machinePool.OSMaintenance == nil {
+			continue
+		}
+		maintenance := *machinePool.OSMaintenance.DeepCopy()
+		maintenance.MachineLabelSelector = &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				capr.RKEMachinePoolNameLabel: machinePool.Name,
+			},
+		}
+		result = append(result, maintenance)
+	}
+	return result
+}
+
 // rkeControlPlane generates the rkecontrolplane object for a provided cluster object
 func rkeControlPlane(cluster *rancherv1.Cluster) (*rkev1.RKEControlPlane, error) {
 	// We need to base64/gzip encode the spec of our rancherv1.Cluster object so that we can reference it from the
@@ -533,6 +552,7 @@ func rkeControlPlane(cluster *rancherv1.Cluster) (*rkev1.RKEControlPlane, error)
 		return nil, err
 	}
 	rkeConfig := cluster.Spec.RKEConfig.DeepCopy()
+	rkeConfig.OSMaintenance = append(rkeConfig.OSMaintenance, machinePoolOSMaintenance(cluster)...)
 	return &rkev1.RKEControlPlane{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      cluster.Name,
@@ -3,7 +3,11 @@
 import (
 	"time"
 
+	"github.com/rancher/rancher/pkg/events"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 )
 
 const (
@@ -87,3 +91,36 @@ func KeepLastTransitionTimeIfConditionHasNotChanged(conditions []metav1.Conditio
 		}
 	}
 }
+
+// RecordEvents records a warning event for each condition that started failing or failed differently between
+// oldConditions and newConditions, and a normal event when all the conditions are met again.
+func RecordEvents(recorder record.EventRecorder, obj runtime.Object, oldConditions []metav1.Condition, newConditions []metav1.Condition) {
+	met := true
+	for _, c := range newConditions {
+		if c.Status == metav1.ConditionTrue {
+			continue
+		}
+		met = false
+		if !hasCondition(oldConditions, c) {
+			recorder.Event(obj, corev1.EventTypeWarning, c.Reason, c.Message)
+		}
+	}
+	if !met {
+		return
+	}
+	for _, c := range oldConditions {
+		if c.Status != metav1.ConditionTrue {
+			recorder.Event(obj, corev1.EventTypeNormal, events.ReasonReconciled, "All conditions are met")
+			return
+		}
+	}
+}
+
+func hasCondition(conditions []metav1.Condition, condition metav1.Condition) bool {
+	for _, c := range conditions {
+		if c.Type == condition.Type && c.Status == condition.Status && c.Reason == condition.Reason && c.Message == condition.Message {
+			return true
+		}
+	}
+	return false
+}
@@ -6,8 +6,10 @@
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 )
 
 const (
@@ -427,3 +429,53 @@ func TestKeepLastTransitionTimeIfConditionIsPresent(t *testing.T) {
 	}
 
 }
+
+func TestRecordEvents(t *testing.T) {
+	rolesExist := v1.Condition{Type: clusterRolesExists, Status: v1.ConditionTrue, Reason: clusterRolesExists}
+	rolesFailed := v1.Condition{Type: clusterRolesExists, Status: v1.ConditionFalse, Reason: failedToCreateRoles, Message: "mock error"}
+	bindingsExist := v1.Condition{Type: clusterRoleBindingsExists, Status: v1.ConditionTrue, Reason: clusterRoleBindingsExists}
+
+	tests := map[string]struct {
+		oldConditions []v1.Condition
+		newConditions []v1.Condition
+		wantEvents    []string
+	}{
+		"no events when all conditions stay met": {
+			oldConditions: []v1.Condition{rolesExist},
+			newConditions: []v1.Condition{rolesExist, bindingsExist},
+		},
+		"warning when a condition starts failing": {
+			oldConditions: []v1.Condition{rolesExist, bindingsExist},
+			newConditions: []v1.Condition{rolesFailed, bindingsExist},
+			wantEvents:    []string{"Warning FailedToCreateRoles mock error"},
+		},
+		"no events when a condition keeps failing the same way": {
+			oldConditions: []v1.Condition{rolesFailed},
+			newConditions: []v1.Condition{rolesFailed, bindingsExist},
+		},
+		"warning when a condition fails differently": {
+			oldConditions: []v1.Condition{rolesFailed},
+			newConditions: []v1.Condition{{Type: clusterRolesExists, Status: v1.ConditionFalse, Reason: failedToCreateRoles, Message: "other error"}},
+			wantEvents:    []string{"Warning FailedToCreateRoles other error"},
+		},
+		"normal event when all conditions are met again": {
+			oldConditions: []v1.Condition{rolesFailed, bindingsExist},
+			newConditions: []v1.Condition{rolesExist, bindingsExist},
+			wantEvents:    []string{"Normal Reconciled All conditions are met"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			RecordEvents(recorder, &corev1.ConfigMap{}, test.oldConditions, test.newConditions)
+			close(recorder.Events)
+
+			var events []string
+			for event := range recorder.Events {
+				events = append(events, event)
+			}
+			assert.Equal(t, test.wantEvents, events)
+		})
+	}
+}
@@ -120,6 +120,17 @@ func List(cfg *rest.Config) (_ []crd.CRD, err error) {
 		}),
 	}
 
+	if features.MCM.Enabled() {
+		result = append(result, newCRD(&v3.NotificationChannel{}, func(c crd.CRD) crd.CRD {
+			c.NonNamespace = true
+			return c.
+				WithStatus().
+				WithColumn("Display Name", ".spec.displayName").
+				WithColumn("Last Sent", ".status.lastSentTime").
+				WithColumn("Last Error", ".status.lastError")
+		}))
+	}
+
 	if features.Fleet.Enabled() {
 		result = append(result, crd.CRD{
 			SchemaObject: v3.FleetWorkspace{},
@@ -14,6 +14,8 @@
 	"github.com/rancher/norman/types/slice"
 	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/tracing"
+	"github.com/rancher/rancher/pkg/tunnelserver"
 	"github.com/rancher/rancher/pkg/types/config"
 	"github.com/rancher/rancher/pkg/types/config/dialer"
 	"github.com/rancher/rancher/pkg/wrangler"
@@ -235,7 +237,7 @@ func (f *Factory) clusterDialer(clusterName, address string, retryOnError bool)
 
 	if f.TunnelServer.HasSession(cluster.Name) {
 		logrus.Tracef("dialerFactory: tunnel session found for cluster [%s]", cluster.Name)
-		cd := f.TunnelServer.Dialer(cluster.Name)
+		cd := tracing.Dialer(cluster.Name, tunnelserver.InstrumentDialer(cluster.Name, f.TunnelServer.Dialer(cluster.Name)))
 		return func(ctx context.Context, network, address string) (net.Conn, error) {
 			if cluster.Status.Driver == v32.ClusterDriverRKE {
 				address = f.translateClusterAddress(cluster, hostPort, address)
@@ -286,7 +288,7 @@ func (f *Factory) clusterDialer(clusterName, address string, retryOnError bool)
 	for i := 0; i < 4; i++ {
 		if f.TunnelServer.HasSession(cluster.Name) {
 			logrus.Debugf("Cluster [%s] has reconnected, resuming", cluster.Name)
-			cd := f.TunnelServer.Dialer(cluster.Name)
+			cd := tracing.Dialer(cluster.Name, tunnelserver.InstrumentDialer(cluster.Name, f.TunnelServer.Dialer(cluster.Name)))
 			return func(ctx context.Context, network, address string) (net.Conn, error) {
 				if cluster.Status.Driver == v32.ClusterDriverRKE {
 					address = f.translateClusterAddress(cluster, hostPort, address)
@@ -325,7 +327,7 @@ func (f *Factory) DockerDialer(clusterName, machineName string) (dialer.Dialer,
 		if machine.Status.InternalNodeStatus.NodeInfo.OperatingSystem == "windows" {
 			network, address = "npipe", "//./pipe/docker_engine"
 		}
-		d := f.TunnelServer.Dialer(sessionKey)
+		d := tunnelserver.InstrumentDialer(sessionKey, f.TunnelServer.Dialer(sessionKey))
 		return func(ctx context.Context, _ string, _ string) (net.Conn, error) {
 			return d(ctx, network, address)
 		}, nil
@@ -352,7 +354,7 @@ func (f *Factory) nodeDialer(clusterName, machineName string) (dialer.Dialer, er
 
 	sessionKey := machineSessionKey(machine)
 	if f.TunnelServer.HasSession(sessionKey) {
-		d := f.TunnelServer.Dialer(sessionKey)
+		d := tunnelserver.InstrumentDialer(sessionKey, f.TunnelServer.Dialer(sessionKey))
 		return dialer.Dialer(d), nil
 	}
 
@@ -0,0 +1,53 @@
+// Package events records Kubernetes Events for the significant transitions and errors of Rancher's controllers, so
+// that they are shown by kubectl describe next to the conditions of the objects.
+package events
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// Component is the source component of the events recorded by Rancher.
+const Component = "rancher"
+
+// Reasons of the events recorded by Rancher's controllers.
+const (
+	// ReasonReconciled is recorded once an object is reconciled after it failed or was waiting.
+	ReasonReconciled = "Reconciled"
+
+	// capr planner
+	ReasonPlanWaiting = "Waiting"
+	ReasonPlanFailed  = "PlanFailed"
+
+	// cluster provisioning
+	ReasonClusterCreated  = "ManagementClusterCreated"
+	ReasonClusterReady    = "ClusterReady"
+	ReasonClusterRemoving = "Removing"
+
+	// catalog
+	ReasonRepoDownloaded     = "Downloaded"
+	ReasonRepoDownloadFailed = "DownloadFailed"
+	ReasonAppUpgradePending  = "UpgradePending"
+	ReasonAppUpgrading       = "Upgrading"
+	ReasonAppUpgraded        = "Upgraded"
+	ReasonAppUpgradeFailed   = "UpgradeFailed"
+	ReasonAppRollingBack     = "RollingBack"
+)
+
+// NewRecorder returns an event recorder writing the events of the objects of the given scheme to the cluster of the
+// given client, until the context is done. The recorded events are aggregated and rate limited like the ones of the
+// Kubernetes controllers.
+func NewRecorder(ctx context.Context, k8s kubernetes.Interface, scheme *runtime.Scheme) record.EventRecorder {
+	broadcaster := record.NewBroadcaster(record.WithContext(ctx))
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: k8s.CoreV1().Events("")})
+	broadcaster.StartLogging(func(format string, args ...interface{}) {
+		logrus.Debugf("[events] "+format, args...)
+	})
+	return broadcaster.NewRecorder(scheme, corev1.EventSource{Component: Component})
+}
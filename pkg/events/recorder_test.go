@@ -0,0 +1,44 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNewRecorder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, catalog.AddToScheme(scheme))
+	k8s := fake.NewSimpleClientset()
+	recorder := NewRecorder(ctx, k8s, scheme)
+
+	repo := &catalog.ClusterRepo{ObjectMeta: metav1.ObjectMeta{Name: "rancher-charts", UID: "1234"}}
+	recorder.Event(repo, corev1.EventTypeWarning, ReasonRepoDownloadFailed, "connection refused")
+
+	var events *corev1.EventList
+	require.Eventually(t, func() bool {
+		var err error
+		events, err = k8s.CoreV1().Events(metav1.NamespaceDefault).List(ctx, metav1.ListOptions{})
+		return err == nil && len(events.Items) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	event := events.Items[0]
+	assert.Equal(t, corev1.EventTypeWarning, event.Type)
+	assert.Equal(t, ReasonRepoDownloadFailed, event.Reason)
+	assert.Equal(t, "connection refused", event.Message)
+	assert.Equal(t, Component, event.Source.Component)
+	assert.Equal(t, "ClusterRepo", event.InvolvedObject.Kind)
+	assert.Equal(t, "catalog.cattle.io/v1", event.InvolvedObject.APIVersion)
+	assert.Equal(t, "rancher-charts", event.InvolvedObject.Name)
+}
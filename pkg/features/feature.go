@@ -4,6 +4,7 @@
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 
 	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	managementv3 "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
@@ -195,6 +196,9 @@ type Feature struct {
 	install bool
 	// If a feature is locked on install, it can't be modified after install. A new Rancher instance is required to change the value.
 	lockedOnInstall bool
+	// rules override the value of the feature for the downstream clusters and groups they target, see EnabledFor
+	lock  sync.RWMutex
+	rules []v3.FeatureRule
 }
 
 // InitializeFeatures updates feature default if given valid --features flag and creates/updates necessary features in k8s
@@ -273,6 +277,8 @@ func InitializeFeatures(featuresClient managementv3.FeatureClient, featureArgs s
 				continue
 			}
 
+			f.SetRules(featureState.Spec.Rules)
+
 			if featureState.Spec.Value == nil {
 				continue
 			}
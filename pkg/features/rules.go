@@ -0,0 +1,109 @@
+package features
+
+import (
+	"context"
+	"slices"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+type clusterKey struct{}
+
+// WithCluster returns a context in which features are evaluated for the given downstream cluster.
+func WithCluster(ctx context.Context, cluster metav1.Object) context.Context {
+	return context.WithValue(ctx, clusterKey{}, labels.Set(cluster.GetLabels()))
+}
+
+// EnabledFor returns whether the feature is enabled for the downstream cluster of the given context, set by
+// WithCluster, and for its user, set by the API server. The first rule of the feature that targets the cluster or a
+// group of the user applies, the feature has its global value if none does.
+func (f *Feature) EnabledFor(ctx context.Context) bool {
+	f.lock.RLock()
+	rules := f.rules
+	f.lock.RUnlock()
+
+	clusterLabels, hasCluster := ctx.Value(clusterKey{}).(labels.Set)
+	var groups []string
+	if u, ok := request.UserFrom(ctx); ok {
+		groups = u.GetGroups()
+	}
+	for _, rule := range rules {
+		if (hasCluster && selectsCluster(f.name, rule, clusterLabels)) || targetsGroup(rule, groups) {
+			return rule.Value
+		}
+	}
+	return f.Enabled()
+}
+
+// SetRules sets the rules that override the value of the feature for the clusters and groups they target.
+func (f *Feature) SetRules(rules []v3.FeatureRule) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.rules = rules
+}
+
+// ClusterValue returns the value of the feature with the given name, rules and global value for the given downstream
+// cluster.
+func ClusterValue(name string, rules []v3.FeatureRule, enabled bool, c *v3.Cluster) bool {
+	for _, rule := range rules {
+		if selectsCluster(name, rule, c.Labels) {
+			return rule.Value
+		}
+	}
+	return enabled
+}
+
+// GroupValue returns the value of the feature with the given rules and global value for the members of the given group.
+func GroupValue(rules []v3.FeatureRule, enabled bool, group string) bool {
+	for _, rule := range rules {
+		if targetsGroup(rule, []string{group}) {
+			return rule.Value
+		}
+	}
+	return enabled
+}
+
+// EffectiveStatus returns the downstream clusters, and the groups targeted by the given rules, that a feature with the
+// given rules and global value is enabled for.
+func EffectiveStatus(name string, rules []v3.FeatureRule, enabled bool, clusters []*v3.Cluster) (enabledClusters, enabledGroups []string) {
+	for _, c := range clusters {
+		if ClusterValue(name, rules, enabled, c) {
+			enabledClusters = append(enabledClusters, c.Name)
+		}
+	}
+	for _, rule := range rules {
+		for _, group := range rule.Groups {
+			if GroupValue(rules, enabled, group) && !slices.Contains(enabledGroups, group) {
+				enabledGroups = append(enabledGroups, group)
+			}
+		}
+	}
+	slices.Sort(enabledClusters)
+	slices.Sort(enabledGroups)
+	return
+}
+
+func selectsCluster(name string, rule v3.FeatureRule, clusterLabels labels.Set) bool {
+	if rule.ClusterSelector == nil {
+		return false
+	}
+	selector, err := metav1.LabelSelectorAsSelector(rule.ClusterSelector)
+	if err != nil {
+		logrus.Errorf("[features] invalid cluster selector in rules of feature %s: %v", name, err)
+		return false
+	}
+	return selector.Matches(clusterLabels)
+}
+
+func targetsGroup(rule v3.FeatureRule, groups []string) bool {
+	for _, group := range groups {
+		if slices.Contains(rule.Groups, group) {
+			return true
+		}
+	}
+	return false
+}
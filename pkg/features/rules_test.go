@@ -0,0 +1,74 @@
+package features
+
+import (
+	"context"
+	"testing"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+var (
+	canaryRule = v3.FeatureRule{
+		Value: true,
+		ClusterSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"rollout": "canary"},
+		},
+	}
+	betaRule = v3.FeatureRule{
+		Value:  true,
+		Groups: []string{"okta_group://beta-testers"},
+	}
+	optOutRule = v3.FeatureRule{
+		Value: false,
+		ClusterSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"rollout-opt-out": "true"},
+		},
+	}
+)
+
+func newCluster(name string, labels map[string]string) *v3.Cluster {
+	return &v3.Cluster{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func TestEnabledFor(t *testing.T) {
+	f := &Feature{name: "rollout-test"}
+	f.SetRules([]v3.FeatureRule{optOutRule, canaryRule, betaRule})
+
+	ctx := context.Background()
+	assert.False(t, f.EnabledFor(ctx), "without a target the feature has its global value")
+
+	assert.True(t, f.EnabledFor(WithCluster(ctx, newCluster("c-canary", map[string]string{"rollout": "canary"}))))
+	assert.False(t, f.EnabledFor(WithCluster(ctx, newCluster("c-prod", map[string]string{"rollout": "stable"}))), "without a matching rule the feature has its global value")
+
+	assert.True(t, f.EnabledFor(request.WithUser(ctx, &user.DefaultInfo{Name: "u-abc", Groups: []string{"okta_group://beta-testers"}})))
+	assert.False(t, f.EnabledFor(request.WithUser(ctx, &user.DefaultInfo{Name: "u-abc"})))
+	assert.True(t, f.EnabledFor(request.WithUser(WithCluster(ctx, newCluster("c-prod", nil)), &user.DefaultInfo{Name: "u-abc", Groups: []string{"okta_group://beta-testers"}})), "rules target the cluster or the groups of the user")
+
+	f.Set(true)
+	assert.False(t, f.EnabledFor(WithCluster(ctx, newCluster("c-canary", map[string]string{"rollout": "canary", "rollout-opt-out": "true"}))), "the first matching rule applies")
+	assert.True(t, f.EnabledFor(WithCluster(ctx, newCluster("c-prod", nil))))
+
+	f.SetRules(nil)
+	f.Set(false)
+	assert.False(t, f.EnabledFor(WithCluster(ctx, newCluster("c-canary", map[string]string{"rollout": "canary"}))))
+}
+
+func TestEffectiveStatus(t *testing.T) {
+	clusters := []*v3.Cluster{
+		newCluster("c-canary", map[string]string{"rollout": "canary"}),
+		newCluster("c-prod", map[string]string{"rollout": "stable"}),
+		newCluster("local", nil),
+	}
+
+	enabledClusters, enabledGroups := EffectiveStatus("rollout-test", []v3.FeatureRule{optOutRule, canaryRule, betaRule}, false, clusters)
+	assert.Equal(t, []string{"c-canary"}, enabledClusters)
+	assert.Equal(t, []string{"okta_group://beta-testers"}, enabledGroups)
+
+	enabledClusters, enabledGroups = EffectiveStatus("rollout-test", []v3.FeatureRule{{Value: false, ClusterSelector: canaryRule.ClusterSelector, Groups: betaRule.Groups}}, true, clusters)
+	assert.Equal(t, []string{"c-prod", "local"}, enabledClusters)
+	assert.Empty(t, enabledGroups)
+}
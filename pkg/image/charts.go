@@ -2,6 +2,7 @@
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
 	"fmt"
 	"io"
@@ -206,6 +207,22 @@ func pickImagesFromValuesMap(imagesSet map[string]map[string]struct{}, values ma
 	return nil
 }
 
+// ChartImages returns the images of the given OS type that are referenced by the values files of the given chart archive.
+func ChartImages(chartData []byte, chartNameAndVersion string, osType OSType) ([]string, error) {
+	valuesSlice, err := decodeValuesFiles(bytes.NewReader(chartData))
+	if err != nil {
+		return nil, err
+	}
+	imagesSet := map[string]map[string]struct{}{}
+	for _, values := range valuesSlice {
+		if err := pickImagesFromValuesMap(imagesSet, values, chartNameAndVersion, osType, ""); err != nil {
+			return nil, err
+		}
+	}
+	images, _ := generateImageAndSourceLists(imagesSet)
+	return images, nil
+}
+
 // decodeValueFilesInTgz reads tarball in tgzPath and returns a slice of values corresponding to values.yaml files found inside of it.
 func decodeValuesFilesInTgz(tgzPath string) ([]map[interface{}]interface{}, error) {
 	tgz, err := os.Open(tgzPath)
@@ -213,6 +230,11 @@ func decodeValuesFilesInTgz(tgzPath string) ([]map[interface{}]interface{}, erro
 		return nil, err
 	}
 	defer tgz.Close()
+	return decodeValuesFiles(tgz)
+}
+
+// decodeValuesFiles reads the given tarball and returns a slice of values corresponding to values.yaml files found inside of it.
+func decodeValuesFiles(tgz io.Reader) ([]map[interface{}]interface{}, error) {
 	gzr, err := gzip.NewReader(tgz)
 	if err != nil {
 		return nil, err
@@ -20,6 +20,7 @@
 	"github.com/rancher/rancher/pkg/kontainer-engine/drivers/options"
 	"github.com/rancher/rancher/pkg/kontainer-engine/drivers/util"
 	"github.com/rancher/rancher/pkg/kontainer-engine/types"
+	"github.com/rancher/rancher/pkg/logging"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/ssh"
 	"gopkg.in/yaml.v2"
@@ -1438,7 +1439,7 @@ func (d *Driver) RemoveLegacyServiceAccount(ctx context.Context, info *types.Clu
 }
 
 func logClusterConfig(config containerservice.ManagedCluster) {
-	if logrus.GetLevel() == logrus.DebugLevel {
+	if logging.Level() == logrus.DebugLevel {
 		out, err := json.Marshal(config)
 		if err != nil {
 			logrus.Error("Error marshalling config for logging")
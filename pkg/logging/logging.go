@@ -0,0 +1,176 @@
+// Package logging configures the logs of Rancher: their format, their consistent structured fields, and the log levels
+// of its subsystems, which can be raised at runtime without flooding the logs of the other subsystems.
+package logging
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ehazlett/simplelog"
+	"github.com/sirupsen/logrus"
+)
+
+// Fields of the structured logs of Rancher.
+const (
+	FieldSubsystem  = "subsystem"
+	FieldController = "controller"
+	FieldCluster    = "cluster"
+	FieldKey        = "key"
+	FieldProvider   = "provider"
+	FieldRequestID  = "requestID"
+)
+
+var (
+	// configLock serializes the changes of the configuration of the loggers, while lock guards the levels and the
+	// loggers of the subsystems.
+	configLock sync.Mutex
+	lock       sync.RWMutex
+	baseLevel  = logrus.InfoLevel
+	subsystems = map[string]logrus.Level{}
+	loggers    = map[string]*logrus.Logger{}
+)
+
+// For returns the logger of the given subsystem, such as "capr/planner" or "auth/providers/ldap". Its entries are
+// logged at the level of the subsystem, if one is set, or else at the level of Rancher.
+func For(subsystem string) *logrus.Entry {
+	subsystem = strings.Trim(subsystem, "/")
+	lock.Lock()
+	defer lock.Unlock()
+	logger, ok := loggers[subsystem]
+	if !ok {
+		logger = newLogger(subsystemLevel(subsystem))
+		loggers[subsystem] = logger
+	}
+	return logger.WithField(FieldSubsystem, subsystem)
+}
+
+// newLogger returns a logger with the given level that writes its entries like the standard logger, through its
+// formatter, output and hooks, so that the level of a subsystem can differ from the one of Rancher without raising the
+// level of the standard logger, which every other log entry of the process is checked against.
+func newLogger(level logrus.Level) *logrus.Logger {
+	std := logrus.StandardLogger()
+	return &logrus.Logger{
+		Out:          standardOutput{},
+		Hooks:        std.Hooks,
+		Formatter:    standardFormatter{},
+		ReportCaller: std.ReportCaller,
+		Level:        level,
+		ExitFunc:     std.ExitFunc,
+	}
+}
+
+// standardOutput writes to the output of the standard logger.
+type standardOutput struct{}
+
+func (standardOutput) Write(p []byte) (int, error) {
+	return logrus.StandardLogger().Out.Write(p)
+}
+
+// standardFormatter formats entries with the formatter of the standard logger.
+type standardFormatter struct{}
+
+func (standardFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	return logrus.StandardLogger().Formatter.Format(entry)
+}
+
+// SetFormat sets the format of the logs, one of "simple", "text" or "json".
+func SetFormat(format string) {
+	configLock.Lock()
+	defer configLock.Unlock()
+	switch format {
+	case "simple":
+		logrus.SetFormatter(&simplelog.StandardFormatter{})
+	case "text":
+		logrus.SetFormatter(&logrus.TextFormatter{})
+	case "json":
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	}
+}
+
+// Level returns the log level of Rancher.
+func Level() logrus.Level {
+	lock.RLock()
+	defer lock.RUnlock()
+	return baseLevel
+}
+
+// SetLevel sets the log level of Rancher, which is the one of the subsystems with no level of their own.
+func SetLevel(level logrus.Level) {
+	configLock.Lock()
+	defer configLock.Unlock()
+	lock.Lock()
+	baseLevel = level
+	lock.Unlock()
+	apply()
+}
+
+// SubsystemLevel returns the log level of the given subsystem, which is the one set for the subsystem or its closest
+// parent, or else the level of Rancher.
+func SubsystemLevel(subsystem string) logrus.Level {
+	lock.RLock()
+	defer lock.RUnlock()
+	return subsystemLevel(subsystem)
+}
+
+// SetSubsystemLevel sets the log level of the given subsystem and its children. The level of "auth/providers" applies
+// to "auth/providers/ldap", unless the latter has its own.
+func SetSubsystemLevel(subsystem string, level logrus.Level) {
+	configLock.Lock()
+	defer configLock.Unlock()
+	lock.Lock()
+	subsystems[strings.Trim(subsystem, "/")] = level
+	lock.Unlock()
+	apply()
+}
+
+// ResetSubsystemLevel removes the log level of the given subsystem, which falls back to the one of its parents.
+func ResetSubsystemLevel(subsystem string) {
+	configLock.Lock()
+	defer configLock.Unlock()
+	lock.Lock()
+	delete(subsystems, strings.Trim(subsystem, "/"))
+	lock.Unlock()
+	apply()
+}
+
+// SubsystemLevels returns the subsystems that have a log level of their own, sorted by name.
+func SubsystemLevels() []string {
+	lock.RLock()
+	defer lock.RUnlock()
+	names := make([]string, 0, len(subsystems))
+	for subsystem := range subsystems {
+		names = append(names, subsystem)
+	}
+	sort.Strings(names)
+	result := make([]string, 0, len(names))
+	for _, subsystem := range names {
+		result = append(result, subsystem+"="+subsystems[subsystem].String())
+	}
+	return result
+}
+
+func subsystemLevel(subsystem string) logrus.Level {
+	for subsystem != "" {
+		if level, ok := subsystems[subsystem]; ok {
+			return level
+		}
+		i := strings.LastIndex(subsystem, "/")
+		if i < 0 {
+			break
+		}
+		subsystem = subsystem[:i]
+	}
+	return baseLevel
+}
+
+// apply sets the level of the standard logger to the level of Rancher, and the level of the logger of each subsystem to
+// its own.
+func apply() {
+	lock.RLock()
+	defer lock.RUnlock()
+	for subsystem, logger := range loggers {
+		logger.SetLevel(subsystemLevel(subsystem))
+	}
+	logrus.SetLevel(baseLevel)
+}
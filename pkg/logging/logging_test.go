@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setup captures the JSON logs in the returned buffer, restoring the logger and the levels once the test is done.
+func setup(t *testing.T) *bytes.Buffer {
+	logger := logrus.StandardLogger()
+	out, formatter, level := logger.Out, logger.Formatter, logger.Level
+	t.Cleanup(func() {
+		logger.SetOutput(out)
+		logger.SetFormatter(formatter)
+		logger.SetLevel(level)
+		lock.Lock()
+		baseLevel = logrus.InfoLevel
+		subsystems = map[string]logrus.Level{}
+		loggers = map[string]*logrus.Logger{}
+		lock.Unlock()
+	})
+
+	buf := &bytes.Buffer{}
+	logger.SetOutput(buf)
+	SetFormat("json")
+	SetLevel(logrus.InfoLevel)
+	return buf
+}
+
+func entries(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var result []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		entry := map[string]any{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		result = append(result, entry)
+	}
+	return result
+}
+
+func TestSubsystemLevel(t *testing.T) {
+	setup(t)
+
+	SetSubsystemLevel("auth/providers", logrus.DebugLevel)
+	SetSubsystemLevel("/auth/providers/ldap/", logrus.TraceLevel)
+
+	assert.Equal(t, logrus.TraceLevel, SubsystemLevel("auth/providers/ldap"))
+	assert.Equal(t, logrus.TraceLevel, SubsystemLevel("auth/providers/ldap/client"))
+	assert.Equal(t, logrus.DebugLevel, SubsystemLevel("auth/providers/github"))
+	assert.Equal(t, logrus.InfoLevel, SubsystemLevel("auth"))
+	assert.Equal(t, logrus.InfoLevel, SubsystemLevel("capr/planner"))
+	assert.Equal(t, []string{"auth/providers=debug", "auth/providers/ldap=trace"}, SubsystemLevels())
+	assert.Equal(t, logrus.TraceLevel, For("auth/providers/ldap").Logger.GetLevel())
+	assert.Equal(t, logrus.InfoLevel, logrus.GetLevel(), "the level of the standard logger is not raised")
+
+	ResetSubsystemLevel("auth/providers/ldap")
+	assert.Equal(t, logrus.DebugLevel, SubsystemLevel("auth/providers/ldap"))
+	assert.Equal(t, logrus.DebugLevel, For("auth/providers/ldap").Logger.GetLevel())
+	assert.Equal(t, logrus.InfoLevel, logrus.GetLevel())
+
+	ResetSubsystemLevel("auth/providers")
+	assert.Equal(t, logrus.InfoLevel, SubsystemLevel("auth/providers/ldap"))
+	assert.Empty(t, SubsystemLevels())
+	assert.Equal(t, logrus.InfoLevel, For("auth/providers/ldap").Logger.GetLevel())
+}
+
+func TestFilter(t *testing.T) {
+	buf := setup(t)
+
+	SetSubsystemLevel("capr/planner", logrus.DebugLevel)
+
+	For("capr/planner").WithField(FieldKey, "fleet-default/test").Debug("planner debug")
+	For("capr/planner").Trace("planner trace")
+	For("auth/providers/ldap").Debug("ldap debug")
+	For("auth/providers/ldap").Info("ldap info")
+	logrus.Debug("rancher debug")
+	logrus.Warn("rancher warn")
+
+	result := entries(t, buf)
+	require.Len(t, result, 3)
+	assert.Equal(t, "planner debug", result[0]["msg"])
+	assert.Equal(t, "capr/planner", result[0][FieldSubsystem])
+	assert.Equal(t, "fleet-default/test", result[0][FieldKey])
+	assert.Equal(t, "ldap info", result[1]["msg"])
+	assert.Equal(t, "rancher warn", result[2]["msg"])
+
+	buf.Reset()
+	SetLevel(logrus.DebugLevel)
+	SetSubsystemLevel("auth/providers/ldap", logrus.WarnLevel)
+
+	For("auth/providers/ldap").Info("ldap info")
+	logrus.Debug("rancher debug")
+
+	result = entries(t, buf)
+	require.Len(t, result, 1)
+	assert.Equal(t, "rancher debug", result[0]["msg"])
+}
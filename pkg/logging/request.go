@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"github.com/pborman/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// RequestIDHeader is the header carrying the ID of an API request, which is set by the caller or else generated.
+const RequestIDHeader = "X-Request-Id"
+
+// validRequestID matches the IDs accepted from callers, so that they can't inject arbitrary or overly long content in the logs.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type entryKey struct{}
+
+// Middleware gives an ID to each API request, returned in the RequestIDHeader of the response, and adds a logger
+// with the ID to the context of the request.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		id := req.Header.Get(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewRandom().String()
+		}
+		rw.Header().Set(RequestIDHeader, id)
+		entry := logrus.WithField(FieldRequestID, id)
+		next.ServeHTTP(rw, req.WithContext(WithLogger(req.Context(), entry)))
+	})
+}
+
+// Requests logs the API requests served by next at debug level for the given subsystem, with the ID of each request.
+func Requests(subsystem string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		FromContext(req.Context(), subsystem).Debugf("%s %s", req.Method, req.URL.Path)
+		next.ServeHTTP(rw, req)
+	})
+}
+
+// WithLogger returns a copy of the context with the given logger.
+func WithLogger(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, entryKey{}, entry)
+}
+
+// FromContext returns the logger of the context, with the ID of the API request being served if any, logging the
+// entries of the given subsystem.
+func FromContext(ctx context.Context, subsystem string) *logrus.Entry {
+	entry, ok := ctx.Value(entryKey{}).(*logrus.Entry)
+	if !ok {
+		return For(subsystem)
+	}
+	return entry.WithField(FieldSubsystem, subsystem)
+}
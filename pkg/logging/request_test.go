@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		wantCaller bool
+	}{
+		{
+			name:   "generated ID",
+			header: "",
+		},
+		{
+			name:       "ID of the caller",
+			header:     "1234",
+			wantCaller: true,
+		},
+		{
+			name:   "invalid ID of the caller",
+			header: "1234\nlevel=error",
+		},
+		{
+			name:   "overly long ID of the caller",
+			header: strings.Repeat("a", 129),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requestID any
+			var subsystem any
+			handler := Middleware(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				entry := FromContext(req.Context(), "auth/providers/ldap")
+				requestID = entry.Data[FieldRequestID]
+				subsystem = entry.Data[FieldSubsystem]
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/v3/users", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(RequestIDHeader)
+			assert.NotEmpty(t, id)
+			if tt.wantCaller {
+				assert.Equal(t, tt.header, id)
+			} else {
+				assert.NotEqual(t, tt.header, id)
+			}
+			assert.Equal(t, id, requestID)
+			assert.Equal(t, "auth/providers/ldap", subsystem)
+		})
+	}
+}
+
+func TestFromContextWithoutRequest(t *testing.T) {
+	entry := FromContext(context.Background(), "capr/planner")
+	assert.Equal(t, "capr/planner", entry.Data[FieldSubsystem])
+	assert.NotContains(t, entry.Data, FieldRequestID)
+}
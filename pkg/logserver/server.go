@@ -6,6 +6,7 @@
 	"net/http"
 	"os"
 
+	"github.com/rancher/rancher/pkg/logging"
 	"github.com/sirupsen/logrus"
 )
 
@@ -13,6 +14,9 @@
 	DefaultSocketLocation = "/tmp/log.sock"
 )
 
+// defaultLevel is the level resetting a subsystem to the level of Rancher.
+const defaultLevel = "default"
+
 // Server structure is used to the store backend information
 type Server struct {
 	SocketLocation string
@@ -48,23 +52,43 @@ func (s *Server) ListenAndServe() error {
 
 func (s *Server) loglevel(rw http.ResponseWriter, req *http.Request) {
 	// curl -X POST -d "level=debug" localhost:12345/v1/loglevel
+	// curl -X POST -d "level=debug" -d "subsystem=capr/planner" localhost:12345/v1/loglevel
+	// curl -X POST -d "level=default" -d "subsystem=capr/planner" localhost:12345/v1/loglevel
 	logrus.Debugf("Received loglevel request")
+	if err := req.ParseForm(); err != nil {
+		rw.WriteHeader(http.StatusInternalServerError)
+		rw.Write([]byte(fmt.Sprintf("Failed to parse form: %v\n", err)))
+		return
+	}
+	subsystem := req.Form.Get("subsystem")
+
 	if req.Method == http.MethodGet {
-		level := logrus.GetLevel().String()
-		rw.Write([]byte(fmt.Sprintf("%s\n", level)))
+		if subsystem != "" {
+			rw.Write([]byte(fmt.Sprintf("%s\n", logging.SubsystemLevel(subsystem))))
+			return
+		}
+		rw.Write([]byte(fmt.Sprintf("%s\n", logging.Level())))
+		for _, level := range logging.SubsystemLevels() {
+			rw.Write([]byte(fmt.Sprintf("%s\n", level)))
+		}
 	}
 
 	if req.Method == http.MethodPost {
-		if err := req.ParseForm(); err != nil {
-			rw.WriteHeader(http.StatusInternalServerError)
-			rw.Write([]byte(fmt.Sprintf("Failed to parse form: %v\n", err)))
+		if subsystem != "" && req.Form.Get("level") == defaultLevel {
+			logging.ResetSubsystemLevel(subsystem)
+			rw.Write([]byte("OK\n"))
+			return
 		}
 		level, err := logrus.ParseLevel(req.Form.Get("level"))
 		if err != nil {
 			rw.WriteHeader(http.StatusInternalServerError)
 			rw.Write([]byte(fmt.Sprintf("Failed to parse loglevel: %v\n", err)))
 		} else {
-			logrus.SetLevel(level)
+			if subsystem != "" {
+				logging.SetSubsystemLevel(subsystem, level)
+			} else {
+				logging.SetLevel(level)
+			}
 			rw.Write([]byte("OK\n"))
 		}
 	}
@@ -6,9 +6,11 @@
 
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
+	"github.com/rancher/rancher/pkg/controllers"
 	v1 "github.com/rancher/rancher/pkg/generated/norman/core/v1"
 	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/rancher/pkg/tunnelserver"
 	rm "github.com/rancher/remotedialer/metrics"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/labels"
@@ -85,6 +87,9 @@ func (gc *metricGarbageCollector) metricGarbageCollection() {
 	buildObservedLabelMaps(targetMetricsByNameForClientKey, "clientkey", observedLabelsMap)
 	buildObservedLabelMaps(targetMetricsByIPForPeer, "peer", observedLabelsMap)
 	buildObservedLabelMaps([]interface{}{clusterOwner}, "cluster", observedLabelsMap)
+	buildObservedLabelMaps(toInterfaces(tunnelserver.ClusterCollectors()), "cluster", observedLabelsMap)
+	buildObservedLabelMaps(toInterfaces(tunnelserver.PeerCollectors()), "peer", observedLabelsMap)
+	buildObservedLabelMaps(toInterfaces(controllers.MetricsCollectors()), "cluster", observedLabelsMap)
 
 	removedCount := removeMetricsForDeletedResource(observedLabelsMap, observedResourceNames)
 
@@ -152,6 +157,12 @@ func removeMetricsForDeletedResource(observedMetrics map[string]map[interface{}]
 					} else {
 						logrus.Errorf("[metrics-garbage-collector] failed to delete %T metrics related to %s: %v", v, m, label)
 					}
+				case *prometheus.HistogramVec:
+					if v.Delete(label) {
+						removedCount++
+					} else {
+						logrus.Errorf("[metrics-garbage-collector] failed to delete %T metrics related to %s: %v", v, m, label)
+					}
 				default:
 					logrus.Errorf("[metrics-garbage-collector] saw unknown Metric definition %T", v)
 				}
@@ -162,6 +173,14 @@ func removeMetricsForDeletedResource(observedMetrics map[string]map[interface{}]
 	return removedCount
 }
 
+func toInterfaces(collectors []prometheus.Collector) []interface{} {
+	result := make([]interface{}, 0, len(collectors))
+	for _, collector := range collectors {
+		result = append(result, collector)
+	}
+	return result
+}
+
 func appendIfLabelIsNotInList(targetLabel map[string]string, labelList []map[string]string) []map[string]string {
 	found := false
 	for _, label := range labelList {
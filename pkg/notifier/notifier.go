@@ -0,0 +1,299 @@
+// Package notifier sends notifications of Rancher events to the NotificationChannels whose rules match them.
+package notifier
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	mgmtcontrollers "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/settings"
+	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Types of the events notifications are sent for.
+const (
+	EventClusterDisconnected    = "ClusterDisconnected"
+	EventCertificateExpiring    = "CertificateExpiring"
+	EventEtcdSnapshotFailed     = "EtcdSnapshotFailed"
+	EventProvisioningStuck      = "ProvisioningStuck"
+	EventUserDisabled           = "UserDisabled"
+	EventCatalogOperationFailed = "CatalogOperationFailed"
+	EventAppUpgradePending      = "AppUpgradePending"
+	EventAppUpgraded            = "AppUpgraded"
+	EventAppUpgradeFailed       = "AppUpgradeFailed"
+)
+
+const (
+	queueSize = 1000
+	// maxAttempts is the number of times a notification is sent to a channel before giving up.
+	maxAttempts = 5
+	// retryBackoff is the delay before the first retry of a failed notification, which doubles with each attempt.
+	retryBackoff = 10 * time.Second
+)
+
+// Event is a Rancher event that notifications are sent for.
+type Event struct {
+	// Type is one of the Event* constants.
+	Type string `json:"type"`
+	// Cluster is the name of the management cluster the event is about, empty for events that aren't about a cluster.
+	Cluster string `json:"cluster,omitempty"`
+	// Subject identifies the object the event is about. Notifications of events of the same type, cluster and subject
+	// are sent once per notification-repeat-interval.
+	Subject string `json:"subject"`
+	// Title is a short summary of the event.
+	Title string `json:"title"`
+	// Message describes the event.
+	Message string `json:"message"`
+	// Time the event occurred at.
+	Time time.Time `json:"time"`
+
+	// attempt and channels are set on the retries of a notification, to the channels sending it failed for.
+	attempt  int
+	channels []string
+}
+
+func (e Event) key() string {
+	return e.Type + "/" + e.Cluster + "/" + e.Subject
+}
+
+var current atomic.Pointer[Notifier]
+
+// Notify sends notifications of the event to the channels whose rules match it. Events are dropped if no notifier is
+// running, which is the case before the controllers start. A notifier runs on each replica of Rancher, which notifies
+// the events of the downstream clusters it owns, and on the leader those of the management objects.
+func Notify(event Event) {
+	if n := current.Load(); n != nil {
+		n.Notify(event)
+	}
+}
+
+// Notifier sends notifications of events to the NotificationChannels whose rules match them.
+type Notifier struct {
+	channels      mgmtcontrollers.NotificationChannelCache
+	channelClient mgmtcontrollers.NotificationChannelClient
+	clusters      mgmtcontrollers.ClusterCache
+	secrets       corecontrollers.SecretCache
+	client        *http.Client
+	events        chan Event
+	now           func() time.Time
+	retryBackoff  time.Duration
+
+	lock     sync.Mutex
+	sent     map[string]time.Time
+	retrying map[string]bool
+}
+
+// New returns a notifier reading the channels, and the clusters and secrets they refer to, from the given caches.
+func New(channels mgmtcontrollers.NotificationChannelController, clusters mgmtcontrollers.ClusterCache, secrets corecontrollers.SecretCache) *Notifier {
+	return &Notifier{
+		channels:      channels.Cache(),
+		channelClient: channels,
+		clusters:      clusters,
+		secrets:       secrets,
+		client:        &http.Client{Timeout: sendTimeout},
+		events:        make(chan Event, queueSize),
+		now:           time.Now,
+		retryBackoff:  retryBackoff,
+		sent:          map[string]time.Time{},
+		retrying:      map[string]bool{},
+	}
+}
+
+// Start sends the notifications of the events passed to Notify until the context is done.
+func (n *Notifier) Start(ctx context.Context) {
+	current.Store(n)
+	go func() {
+		defer current.CompareAndSwap(n, nil)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-n.events:
+				n.dispatch(ctx, event)
+			}
+		}
+	}()
+}
+
+// Notify queues the event to be sent to the channels whose rules match it.
+func (n *Notifier) Notify(event Event) {
+	if event.Time.IsZero() {
+		event.Time = n.now()
+	}
+	select {
+	case n.events <- event:
+	default:
+		logrus.Warnf("[notifier] dropping %s event of %s: the queue is full", event.Type, event.Subject)
+	}
+}
+
+// dispatch sends the event to the channels whose rules match it unless it was recently sent, or to the channels of a
+// retry. The event is recorded as sent once a channel received it, and retried for the channels that failed.
+func (n *Notifier) dispatch(ctx context.Context, event Event) {
+	if event.attempt == 0 && n.recentlySent(event) {
+		return
+	}
+	n.setRetrying(event, false)
+	retry := len(event.channels) > 0
+	channels, err := n.channels.List(labels.Everything())
+	if err != nil {
+		logrus.Errorf("[notifier] failed to list notification channels: %v", err)
+		n.retry(ctx, event, event.channels)
+		return
+	}
+	var clusterLabels labels.Set
+	if event.Cluster != "" && !retry {
+		if cluster, err := n.clusters.Get(event.Cluster); err == nil {
+			clusterLabels = cluster.Labels
+		} else if !apierrors.IsNotFound(err) {
+			logrus.Errorf("[notifier] failed to get cluster %s: %v", event.Cluster, err)
+		}
+	}
+
+	var (
+		sent   bool
+		failed []string
+	)
+	for _, channel := range channels {
+		if retry && !slices.Contains(event.channels, channel.Name) || !retry && !matches(channel, event, clusterLabels) {
+			continue
+		}
+		err := n.send(ctx, channel, event)
+		if err != nil {
+			logrus.Errorf("[notifier] failed to send %s event of %s to notification channel %s: %v", event.Type, event.Subject, channel.Name, err)
+			failed = append(failed, channel.Name)
+		} else {
+			sent = true
+		}
+		n.updateStatus(channel, err)
+	}
+	if sent {
+		n.recordSent(event)
+	}
+	if len(failed) > 0 {
+		n.retry(ctx, event, failed)
+	}
+}
+
+// retry queues the event again for the given channels after a backoff that doubles with each attempt, until
+// maxAttempts. Without channels, the retry is sent to the channels whose rules match the event.
+func (n *Notifier) retry(ctx context.Context, event Event, channels []string) {
+	if event.attempt+1 >= maxAttempts {
+		logrus.Errorf("[notifier] giving up sending %s event of %s after %d attempts", event.Type, event.Subject, maxAttempts)
+		return
+	}
+	n.setRetrying(event, true)
+	delay := n.retryBackoff << event.attempt
+	event.attempt++
+	event.channels = channels
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+			select {
+			case n.events <- event:
+			default:
+				n.setRetrying(event, false)
+				logrus.Warnf("[notifier] dropping retry of %s event of %s: the queue is full", event.Type, event.Subject)
+			}
+		}
+	}()
+}
+
+// recentlySent returns whether a notification of the event was sent within the repeat interval, or is being retried.
+func (n *Notifier) recentlySent(event Event) bool {
+	interval := RepeatInterval()
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	now := n.now()
+	for key, sent := range n.sent {
+		if now.Sub(sent) >= interval {
+			delete(n.sent, key)
+		}
+	}
+	_, ok := n.sent[event.key()]
+	return ok || n.retrying[event.key()]
+}
+
+// setRetrying sets whether a notification of the event is waiting to be retried.
+func (n *Notifier) setRetrying(event Event, retrying bool) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if retrying {
+		n.retrying[event.key()] = true
+	} else {
+		delete(n.retrying, event.key())
+	}
+}
+
+// recordSent records that a notification of the event was sent. The time of the event is recorded rather than that of
+// the notification, so that watchers repeating an event once per interval aren't deduplicated by a late send.
+func (n *Notifier) recordSent(event Event) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.sent[event.key()] = event.Time
+}
+
+// RepeatInterval returns the notification-repeat-interval setting.
+func RepeatInterval() time.Duration {
+	interval, err := time.ParseDuration(settings.NotificationRepeatInterval.Get())
+	if err != nil {
+		logrus.Errorf("[notifier] invalid value for setting %s: %v", settings.NotificationRepeatInterval.Name, err)
+		return 0
+	}
+	return interval
+}
+
+// Recent returns whether the given time is within the repeat interval. Watchers of objects that stay in a failed
+// state only notify their recent failures, so that they aren't notified again each time Rancher restarts.
+func Recent(t time.Time) bool {
+	return time.Since(t) < RepeatInterval()
+}
+
+// matches returns whether any of the rules of the channel select the event. A channel without rules receives all
+// events.
+func matches(channel *v3.NotificationChannel, event Event, clusterLabels labels.Set) bool {
+	if len(channel.Spec.Rules) == 0 {
+		return true
+	}
+	for _, rule := range channel.Spec.Rules {
+		if len(rule.EventTypes) > 0 && !slices.Contains(rule.EventTypes, event.Type) {
+			continue
+		}
+		if rule.ClusterSelector == nil || event.Cluster == "" {
+			return true
+		}
+		selector, err := metav1.LabelSelectorAsSelector(rule.ClusterSelector)
+		if err != nil {
+			logrus.Errorf("[notifier] invalid cluster selector in rules of notification channel %s: %v", channel.Name, err)
+			continue
+		}
+		if selector.Matches(clusterLabels) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *Notifier) updateStatus(channel *v3.NotificationChannel, sendErr error) {
+	channel = channel.DeepCopy()
+	if sendErr != nil {
+		channel.Status.LastError = sendErr.Error()
+	} else {
+		channel.Status.LastError = ""
+		channel.Status.LastSentTime = n.now().UTC().Format(time.RFC3339)
+	}
+	if _, err := n.channelClient.UpdateStatus(channel); err != nil && !apierrors.IsConflict(err) {
+		logrus.Errorf("[notifier] failed to update status of notification channel %s: %v", channel.Name, err)
+	}
+}
@@ -0,0 +1,218 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestMatches(t *testing.T) {
+	prod := &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}
+	tests := []struct {
+		name          string
+		rules         []v3.NotificationRule
+		event         Event
+		clusterLabels labels.Set
+		expected      bool
+	}{
+		{
+			name:     "no rules",
+			event:    Event{Type: EventUserDisabled},
+			expected: true,
+		},
+		{
+			name:     "event type matches",
+			rules:    []v3.NotificationRule{{EventTypes: []string{EventClusterDisconnected, EventUserDisabled}}},
+			event:    Event{Type: EventUserDisabled},
+			expected: true,
+		},
+		{
+			name:  "event type doesn't match",
+			rules: []v3.NotificationRule{{EventTypes: []string{EventClusterDisconnected}}},
+			event: Event{Type: EventUserDisabled},
+		},
+		{
+			name:          "cluster selector matches",
+			rules:         []v3.NotificationRule{{EventTypes: []string{EventClusterDisconnected}, ClusterSelector: prod}},
+			event:         Event{Type: EventClusterDisconnected, Cluster: "c-1"},
+			clusterLabels: labels.Set{"env": "prod"},
+			expected:      true,
+		},
+		{
+			name:          "cluster selector doesn't match",
+			rules:         []v3.NotificationRule{{ClusterSelector: prod}},
+			event:         Event{Type: EventClusterDisconnected, Cluster: "c-1"},
+			clusterLabels: labels.Set{"env": "dev"},
+		},
+		{
+			name:     "cluster selector ignored for events without cluster",
+			rules:    []v3.NotificationRule{{ClusterSelector: prod}},
+			event:    Event{Type: EventUserDisabled},
+			expected: true,
+		},
+		{
+			name: "any rule matches",
+			rules: []v3.NotificationRule{
+				{EventTypes: []string{EventClusterDisconnected}},
+				{EventTypes: []string{EventCatalogOperationFailed}, ClusterSelector: prod},
+			},
+			event:         Event{Type: EventCatalogOperationFailed, Cluster: "c-1"},
+			clusterLabels: labels.Set{"env": "prod"},
+			expected:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channel := &v3.NotificationChannel{Spec: v3.NotificationChannelSpec{Rules: tt.rules}}
+			assert.Equal(t, tt.expected, matches(channel, tt.event, tt.clusterLabels))
+		})
+	}
+}
+
+func TestRecentlySent(t *testing.T) {
+	require.NoError(t, settings.NotificationRepeatInterval.Set("1h"))
+	defer settings.NotificationRepeatInterval.Set(settings.NotificationRepeatInterval.Default)
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	n := &Notifier{now: func() time.Time { return now }, sent: map[string]time.Time{}, retrying: map[string]bool{}}
+	event := Event{Type: EventClusterDisconnected, Cluster: "c-1", Subject: "c-1", Time: now.Add(-time.Minute)}
+
+	assert.False(t, n.recentlySent(event))
+	n.recordSent(event)
+	assert.True(t, n.recentlySent(event))
+	assert.False(t, n.recentlySent(Event{Type: EventClusterDisconnected, Cluster: "c-2", Subject: "c-2"}))
+
+	now = now.Add(time.Hour - time.Minute)
+	assert.False(t, n.recentlySent(event), "the repeat interval starts at the time of the event")
+
+	n.setRetrying(event, true)
+	assert.True(t, n.recentlySent(event), "events being retried are not sent again")
+}
+
+func TestDispatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	var received []webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload webhookPayload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		received = append(received, payload)
+	}))
+	defer server.Close()
+
+	prodWebhook := &v3.NotificationChannel{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+		Spec: v3.NotificationChannelSpec{
+			Webhook: &v3.WebhookNotificationConfig{URL: server.URL},
+			Rules: []v3.NotificationRule{{
+				EventTypes:      []string{EventClusterDisconnected},
+				ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+			}},
+		},
+	}
+	users := &v3.NotificationChannel{
+		ObjectMeta: metav1.ObjectMeta{Name: "users"},
+		Spec: v3.NotificationChannelSpec{
+			Webhook: &v3.WebhookNotificationConfig{URL: server.URL},
+			Rules:   []v3.NotificationRule{{EventTypes: []string{EventUserDisabled}}},
+		},
+	}
+
+	channels := fake.NewMockNonNamespacedCacheInterface[*v3.NotificationChannel](ctrl)
+	channels.EXPECT().List(gomock.Any()).Return([]*v3.NotificationChannel{prodWebhook, users}, nil)
+	clusters := fake.NewMockNonNamespacedCacheInterface[*v3.Cluster](ctrl)
+	clusters.EXPECT().Get("c-1").Return(&v3.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c-1", Labels: map[string]string{"env": "prod"}}}, nil)
+	channelClient := fake.NewMockNonNamespacedClientInterface[*v3.NotificationChannel, *v3.NotificationChannelList](ctrl)
+	var status v3.NotificationChannelStatus
+	channelClient.EXPECT().UpdateStatus(gomock.Any()).DoAndReturn(func(channel *v3.NotificationChannel) (*v3.NotificationChannel, error) {
+		assert.Equal(t, "prod", channel.Name)
+		status = channel.Status
+		return channel, nil
+	})
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	n := &Notifier{
+		channels:      channels,
+		channelClient: channelClient,
+		clusters:      clusters,
+		client:        server.Client(),
+		now:           func() time.Time { return now },
+		sent:          map[string]time.Time{},
+		retrying:      map[string]bool{},
+	}
+	n.dispatch(context.Background(), Event{Type: EventClusterDisconnected, Cluster: "c-1", Subject: "c-1", Title: "Cluster c-1 is disconnected", Time: now})
+
+	require.Len(t, received, 1)
+	assert.Equal(t, EventClusterDisconnected, received[0].Type)
+	assert.Equal(t, "c-1", received[0].Cluster)
+	assert.Equal(t, "Cluster c-1 is disconnected", received[0].Title)
+	assert.Equal(t, "2024-06-01T00:00:00Z", status.LastSentTime)
+	assert.Empty(t, status.LastError)
+}
+
+func TestDispatchRetry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	failures := 2
+	var received int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received++
+	}))
+	defer server.Close()
+
+	webhook := &v3.NotificationChannel{
+		ObjectMeta: metav1.ObjectMeta{Name: "webhook"},
+		Spec:       v3.NotificationChannelSpec{Webhook: &v3.WebhookNotificationConfig{URL: server.URL}},
+	}
+	channels := fake.NewMockNonNamespacedCacheInterface[*v3.NotificationChannel](ctrl)
+	channels.EXPECT().List(gomock.Any()).Return([]*v3.NotificationChannel{webhook}, nil).AnyTimes()
+	channelClient := fake.NewMockNonNamespacedClientInterface[*v3.NotificationChannel, *v3.NotificationChannelList](ctrl)
+	channelClient.EXPECT().UpdateStatus(gomock.Any()).Return(webhook, nil).AnyTimes()
+
+	n := &Notifier{
+		channels:      channels,
+		channelClient: channelClient,
+		client:        server.Client(),
+		events:        make(chan Event, queueSize),
+		now:           time.Now,
+		retryBackoff:  time.Millisecond,
+		sent:          map[string]time.Time{},
+		retrying:      map[string]bool{},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	event := Event{Type: EventUserDisabled, Subject: "u-abc", Title: "User u-abc is disabled"}
+	n.dispatch(ctx, event)
+	assert.NotContains(t, n.sent, event.key(), "failed events are not recorded as sent")
+	assert.True(t, n.recentlySent(event), "failed events are not sent again while they are retried")
+
+	for received == 0 {
+		select {
+		case retry := <-n.events:
+			n.dispatch(ctx, retry)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the event was not retried")
+		}
+	}
+	assert.Equal(t, 1, received)
+	assert.Contains(t, n.sent, event.key())
+	assert.NotContains(t, n.retrying, event.key())
+}
@@ -0,0 +1,185 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/namespace"
+	"github.com/rancher/rancher/pkg/settings"
+)
+
+const (
+	sendTimeout = 30 * time.Second
+	titlePrefix = "[Rancher] "
+)
+
+// webhookPayload is the body posted to webhook channels.
+type webhookPayload struct {
+	Event
+	ServerURL string `json:"serverURL,omitempty"`
+}
+
+// slackPayload is the body posted to Slack and Microsoft Teams compatible incoming webhooks.
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+func (n *Notifier) send(ctx context.Context, channel *v3.NotificationChannel, event Event) error {
+	var secret map[string][]byte
+	if channel.Spec.SecretName != "" {
+		s, err := n.secrets.Get(namespace.GlobalNamespace, channel.Spec.SecretName)
+		if err != nil {
+			return fmt.Errorf("failed to get secret %s/%s: %w", namespace.GlobalNamespace, channel.Spec.SecretName, err)
+		}
+		secret = s.Data
+	}
+
+	switch {
+	case channel.Spec.SMTP != nil:
+		return sendEmail(ctx, channel.Spec.SMTP, string(secret["password"]), event)
+	case channel.Spec.Webhook != nil:
+		return n.post(ctx, webhookURL(channel.Spec.Webhook.URL, secret), webhookPayload{
+			Event:     event,
+			ServerURL: settings.ServerURL.Get(),
+		})
+	case channel.Spec.Slack != nil:
+		return n.post(ctx, webhookURL(channel.Spec.Slack.URL, secret), slackPayload{
+			Text: fmt.Sprintf("*%s%s*\n%s", titlePrefix, event.Title, body(event)),
+		})
+	}
+	return fmt.Errorf("the channel has no SMTP, webhook or Slack configuration")
+}
+
+// webhookURL returns the URL of the secret of a channel, if any, or else the one of its spec.
+func webhookURL(specURL string, secret map[string][]byte) string {
+	if url := string(secret["url"]); url != "" {
+		return url
+	}
+	return specURL
+}
+
+func (n *Notifier) post(ctx context.Context, url string, payload interface{}) error {
+	if url == "" {
+		return fmt.Errorf("the channel has no URL")
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook responded with %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// sendEmail sends the event by email through the SMTP server of the config, upgrading the connection with STARTTLS if
+// the config doesn't use TLS and the server supports it.
+func sendEmail(ctx context.Context, config *v3.SMTPNotificationConfig, password string, event Event) error {
+	if len(config.To) == 0 {
+		return fmt.Errorf("the channel has no recipients")
+	}
+	addr := net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
+	tlsConfig := &tls.Config{ServerName: config.Host}
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	var conn net.Conn
+	var err error
+	if config.TLS {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok && !config.TLS {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if config.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", config.Username, password, config.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(config.From); err != nil {
+		return err
+	}
+	for _, to := range config.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(emailMessage(config, event)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func emailMessage(config *v3.SMTPNotificationConfig, event Event) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", config.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(config.To, ", "))
+	// the title contains user-provided names, encoded so that they can't add headers
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", titlePrefix+event.Title))
+	fmt.Fprintf(&buf, "Date: %s\r\n", event.Time.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	buf.WriteString(strings.ReplaceAll(body(event), "\n", "\r\n"))
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
+
+// body returns the text of the notification of the event.
+func body(event Event) string {
+	text := event.Message
+	if event.Cluster != "" {
+		text += "\nCluster: " + event.Cluster
+	}
+	if serverURL := settings.ServerURL.Get(); serverURL != "" {
+		text += "\nRancher: " + serverURL
+	}
+	return text
+}
@@ -0,0 +1,172 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// smtpStub is a local SMTP server recording the messages it receives.
+type smtpStub struct {
+	listener net.Listener
+	auth     []string
+	from     string
+	to       []string
+	data     string
+}
+
+func newSMTPStub(t *testing.T) *smtpStub {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &smtpStub{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *smtpStub) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpStub) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 stub")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch cmd {
+		case "EHLO":
+			reply("250-stub")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			s.auth = append(s.auth, line)
+			reply("235 ok")
+		case "MAIL":
+			s.from = line
+			reply("250 ok")
+		case "RCPT":
+			s.to = append(s.to, line)
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil || l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.data = data.String()
+			reply("250 ok")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 unsupported")
+		}
+	}
+}
+
+func TestSendEmail(t *testing.T) {
+	stub := newSMTPStub(t)
+	config := &v3.SMTPNotificationConfig{
+		Host:     "127.0.0.1",
+		Port:     stub.port(),
+		Username: "rancher",
+		From:     "rancher@example.com",
+		To:       []string{"ops@example.com", "oncall@example.com"},
+	}
+	event := Event{
+		Type:    EventClusterDisconnected,
+		Cluster: "c-1",
+		Title:   "Cluster c-1 is disconnected",
+		Message: "The agent of cluster c-1 isn't connected to Rancher.",
+		Time:    time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	require.NoError(t, sendEmail(context.Background(), config, "password", event))
+
+	assert.Len(t, stub.auth, 1)
+	assert.Equal(t, "MAIL FROM:<rancher@example.com>", stub.from)
+	assert.Equal(t, []string{"RCPT TO:<ops@example.com>", "RCPT TO:<oncall@example.com>"}, stub.to)
+	assert.Contains(t, stub.data, "Subject: [Rancher] Cluster c-1 is disconnected\r\n")
+	assert.Contains(t, stub.data, "To: ops@example.com, oncall@example.com\r\n")
+	assert.Contains(t, stub.data, "The agent of cluster c-1 isn't connected to Rancher.\r\nCluster: c-1")
+}
+
+func TestEmailMessageSubject(t *testing.T) {
+	config := &v3.SMTPNotificationConfig{From: "rancher@example.com", To: []string{"ops@example.com"}}
+	event := Event{Title: "Cluster prod\r\nBcc: attacker@example.com is disconnected"}
+
+	message := string(emailMessage(config, event))
+
+	assert.NotContains(t, message, "\r\nBcc:")
+	assert.Contains(t, message, "Subject: =?UTF-8?q?")
+}
+
+func TestSendEmailNoRecipients(t *testing.T) {
+	err := sendEmail(context.Background(), &v3.SMTPNotificationConfig{Host: "127.0.0.1", Port: 25}, "", Event{})
+	assert.Error(t, err)
+}
+
+func TestSendSlack(t *testing.T) {
+	var payload slackPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+	}))
+	defer server.Close()
+
+	n := &Notifier{client: server.Client()}
+	channel := &v3.NotificationChannel{
+		ObjectMeta: metav1.ObjectMeta{Name: "slack"},
+		Spec:       v3.NotificationChannelSpec{Slack: &v3.SlackNotificationConfig{URL: server.URL}},
+	}
+	err := n.send(context.Background(), channel, Event{Type: EventUserDisabled, Title: "User alice was disabled", Message: "User alice was disabled for inactivity."})
+	require.NoError(t, err)
+	assert.Equal(t, "*[Rancher] User alice was disabled*\nUser alice was disabled for inactivity.", payload.Text)
+}
+
+func TestSendWebhookError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	n := &Notifier{client: server.Client()}
+	channel := &v3.NotificationChannel{Spec: v3.NotificationChannelSpec{Webhook: &v3.WebhookNotificationConfig{URL: server.URL}}}
+	err := n.send(context.Background(), channel, Event{Type: EventUserDisabled})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), strconv.Itoa(http.StatusUnauthorized))
+	assert.Contains(t, err.Error(), "invalid token")
+}
+
+func TestSendNoConfiguration(t *testing.T) {
+	n := &Notifier{}
+	err := n.send(context.Background(), &v3.NotificationChannel{}, Event{})
+	assert.Error(t, err)
+}
@@ -34,11 +34,14 @@
 	"github.com/rancher/rancher/pkg/features"
 	mgmntv3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/kontainerdrivermetadata"
+	"github.com/rancher/rancher/pkg/logging"
 	"github.com/rancher/rancher/pkg/multiclustermanager"
 	"github.com/rancher/rancher/pkg/namespace"
+	"github.com/rancher/rancher/pkg/notifier"
 	"github.com/rancher/rancher/pkg/serviceaccounttoken"
 	"github.com/rancher/rancher/pkg/settings"
 	"github.com/rancher/rancher/pkg/tls"
+	"github.com/rancher/rancher/pkg/tracing"
 	"github.com/rancher/rancher/pkg/ui"
 	"github.com/rancher/rancher/pkg/websocket"
 	"github.com/rancher/rancher/pkg/wrangler"
@@ -106,6 +109,8 @@ func New(ctx context.Context, clientConfg clientcmd.ClientConfig, opts *Options)
 		opts = &Options{}
 	}
 
+	tls.RegisterSettingValidators()
+
 	restConfig, err := clientConfg.ClientConfig()
 	if err != nil {
 		return nil, err
@@ -245,6 +250,12 @@ func New(ctx context.Context, clientConfg clientcmd.ClientConfig, opts *Options)
 	aggregationMiddleware := aggregation.NewMiddleware(ctx, wranglerContext.Mgmt.APIService(), wranglerContext.TunnelServer)
 
 	wranglerContext.OnLeader(func(ctx context.Context) error {
+		// The cleaned secrets are those of the local cluster, so the feature gating the cleaner is evaluated for it.
+		if localCluster, err := wranglerContext.Mgmt.Cluster().Get("local", metav1.GetOptions{}); err == nil {
+			ctx = features.WithCluster(ctx, localCluster)
+		} else if !k8serror.IsNotFound(err) {
+			logrus.Warnf("failed to get the local cluster to evaluate feature %s for: %v", features.CleanStaleSecrets.Name(), err)
+		}
 		serviceaccounttoken.StartServiceAccountSecretCleaner(
 			ctx,
 			wranglerContext.Core.Secret().Cache(),
@@ -254,8 +265,8 @@ func New(ctx context.Context, clientConfg clientcmd.ClientConfig, opts *Options)
 	})
 
 	return &Rancher{
-		Auth: authServer.Authenticator.Chain(
-			auditFilter),
+		Auth: tracing.WrapMiddleware("authenticate", authServer.Authenticator.Chain(
+			auditFilter)),
 		Handler: responsewriter.Chain{
 			auth.SetXAPICattleAuthHeader,
 			responsewriter.ContentTypeOptions,
@@ -269,7 +280,7 @@ func New(ctx context.Context, clientConfg clientcmd.ClientConfig, opts *Options)
 			authServer.Management,
 			additionalAPI,
 			requests.NewRequireAuthenticatedFilter("/v1/", "/v1/management.cattle.io.setting"),
-		}.Handler(steve),
+		}.Handler(tracing.Handler("steve", logging.Requests("steve", steve))),
 		Wrangler:   wranglerContext,
 		Steve:      steve,
 		auditLog:   auditLogWriter,
@@ -283,6 +294,10 @@ func (r *Rancher) Start(ctx context.Context) error {
 		return err
 	}
 
+	if err := tracing.Setup(ctx); err != nil {
+		return fmt.Errorf("setting up tracing: %w", err)
+	}
+
 	if err := steveapi.Setup(ctx, r.Steve, r.Wrangler); err != nil {
 		return err
 	}
@@ -295,6 +310,9 @@ func (r *Rancher) Start(ctx context.Context) error {
 		// Registers handlers for all rancher replicas running in the local cluster, but not downstream agents
 		nodedriver.Register(ctx, r.Wrangler)
 		kontainerdrivermetadata.Register(ctx, r.Wrangler)
+		// notifications are sent from every replica, as the downstream clusters whose events are notified are spread
+		// across them
+		notifier.New(r.Wrangler.Mgmt.NotificationChannel(), r.Wrangler.Mgmt.Cluster().Cache(), r.Wrangler.Core.Secret().Cache()).Start(ctx)
 		if err := r.Wrangler.MultiClusterManager.Start(ctx); err != nil {
 			return err
 		}
@@ -333,7 +351,7 @@ func (r *Rancher) ListenAndServe(ctx context.Context) error {
 	r.startAggregation(ctx)
 	go r.Steve.StartAggregation(ctx)
 	if err := tls.ListenAndServe(ctx, r.Wrangler.RESTConfig,
-		r.Auth(r.Handler),
+		tracing.Middleware(logging.Middleware(r.Auth(r.Handler))),
 		r.opts.BindHost,
 		r.opts.HTTPSListenPort,
 		r.opts.HTTPListenPort,
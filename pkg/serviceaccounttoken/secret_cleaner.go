@@ -49,9 +49,11 @@ type serviceAccountsCache interface {
 // StartServiceAccountSecretCleaner starts a background process to cleanup old
 // service accounts secrets.
 //
-// This should only be started in the leader pod.
+// This should only be started in the leader pod. It is not started if the
+// clean-stale-secrets feature is disabled for the cluster of the context, see
+// features.WithCluster.
 func StartServiceAccountSecretCleaner(ctx context.Context, secrets secretsCache, serviceAccounts serviceAccountsCache, client clientcorev1.CoreV1Interface) error {
-	if !features.CleanStaleSecrets.Enabled() {
+	if !features.CleanStaleSecrets.EnabledFor(ctx) {
 		logrus.Info("ServiceAccountSecretCleaner disabled - not starting")
 		return nil
 	}
@@ -56,34 +56,34 @@
 	}
 
 	AgentImage          = NewSetting("agent-image", "rancher/rancher-agent:head")
-	AgentRolloutTimeout = NewSetting("agent-rollout-timeout", "300s")
-	AgentRolloutWait    = NewSetting("agent-rollout-wait", "true")
+	AgentRolloutTimeout = NewSetting("agent-rollout-timeout", "300s").WithType(TypeDuration)
+	AgentRolloutWait    = NewSetting("agent-rollout-wait", "true").WithType(TypeBool)
 	// AgentTLSMode is translated to the environment variable STRICT_VERIFY when rendering the cluster/node agent manifests and should not be specified as a default agent setting as it has no direct effect on the agent itself.
-	AgentTLSMode                        = NewSetting("agent-tls-mode", AgentTLSModeStrict).WithDefaultOnUpgrade(AgentTLSModeSystemStore)
+	AgentTLSMode                        = NewSetting("agent-tls-mode", AgentTLSModeStrict).WithDefaultOnUpgrade(AgentTLSModeSystemStore).WithValidator(OneOf(AgentTLSModeStrict, AgentTLSModeSystemStore))
 	AuthImage                           = NewSetting("auth-image", v32.ToolsSystemImages.AuthSystemImages.KubeAPIAuth)
-	AuthorizationCacheTTLSeconds        = NewSetting("authorization-cache-ttl-seconds", "10")
-	AuthorizationDenyCacheTTLSeconds    = NewSetting("authorization-deny-cache-ttl-seconds", "10")
-	AzureGroupCacheSize                 = NewSetting("azure-group-cache-size", "10000")
+	AuthorizationCacheTTLSeconds        = NewSetting("authorization-cache-ttl-seconds", "10").WithType(TypeInt).WithValidator(MinInt(0))
+	AuthorizationDenyCacheTTLSeconds    = NewSetting("authorization-deny-cache-ttl-seconds", "10").WithType(TypeInt).WithValidator(MinInt(0))
+	AzureGroupCacheSize                 = NewSetting("azure-group-cache-size", "10000").WithType(TypeInt).WithValidator(MinInt(1))
 	CACerts                             = NewSetting("cacerts", "")
 	CLIURLDarwin                        = NewSetting("cli-url-darwin", "https://releases.rancher.com/cli/v1.0.0-alpha8/rancher-darwin-amd64-v1.0.0-alpha8.tar.gz")
 	CLIURLLinux                         = NewSetting("cli-url-linux", "https://releases.rancher.com/cli/v1.0.0-alpha8/rancher-linux-amd64-v1.0.0-alpha8.tar.gz")
 	CLIURLWindows                       = NewSetting("cli-url-windows", "https://releases.rancher.com/cli/v1.0.0-alpha8/rancher-windows-386-v1.0.0-alpha8.zip")
-	ClusterControllerStartCount         = NewSetting("cluster-controller-start-count", "50")
+	ClusterControllerStartCount         = NewSetting("cluster-controller-start-count", "50").WithType(TypeInt).WithValidator(MinInt(1))
 	EngineInstallURL                    = NewSetting("engine-install-url", "https://releases.rancher.com/install-docker/27.5.sh")
 	EngineISOURL                        = NewSetting("engine-iso-url", "https://releases.rancher.com/os/latest/rancheros-vmware.iso")
 	EngineNewestVersion                 = NewSetting("engine-newest-version", "v17.12.0")
 	EngineSupportedRange                = NewSetting("engine-supported-range", "~v1.11.2 || ~v1.12.0 || ~v1.13.0 || ~v17.03.0 || ~v17.06.0 || ~v17.09.0 || ~v18.06.0 || ~v18.09.0 || ~v19.03.0 || ~v20.10.0 || ~v23.0.0 || ~v24.0.0 || ~v25.0.0 || ~v26.0.0 || ~v26.1.0|| ~v27.0.0|| ~v27.1.0|| ~v27.2.0|| ~v27.3.0|| ~v27.4.0|| ~v27.5.0")
-	FirstLogin                          = NewSetting("first-login", "true")
-	GlobalRegistryEnabled               = NewSetting("global-registry-enabled", "false")
+	FirstLogin                          = NewSetting("first-login", "true").WithType(TypeBool)
+	GlobalRegistryEnabled               = NewSetting("global-registry-enabled", "false").WithType(TypeBool)
 	GithubProxyAPIURL                   = NewSetting("github-proxy-api-url", "https://api.github.com")
 	HelmVersion                         = NewSetting("helm-version", "dev")
-	HelmMaxHistory                      = NewSetting("helm-max-history", "10")
+	HelmMaxHistory                      = NewSetting("helm-max-history", "10").WithType(TypeInt).WithValidator(MinInt(0))
 	IngressIPDomain                     = NewSetting("ingress-ip-domain", "sslip.io")
 	InstallUUID                         = NewSetting("install-uuid", "")
 	InternalServerURL                   = NewSetting("internal-server-url", "")
 	InternalCACerts                     = NewSetting("internal-cacerts", "")
 	IsRKE                               = NewSetting("is-rke", "")
-	JailerTimeout                       = NewSetting("jailer-timeout", "60")
+	JailerTimeout                       = NewSetting("jailer-timeout", "60").WithType(TypeInt).WithValidator(MinInt(1))
 	KubernetesVersion                   = NewSetting("k8s-version", "")
 	KubernetesVersionToServiceOptions   = NewSetting("k8s-version-to-service-options", "")
 	KubernetesVersionToSystemImages     = NewSetting("k8s-version-to-images", "")
@@ -92,7 +92,7 @@
 	KDMBranch                           = NewSetting("kdm-branch", "dev-v2.11")
 	MachineVersion                      = NewSetting("machine-version", "dev")
 	Namespace                           = NewSetting("namespace", os.Getenv("CATTLE_NAMESPACE"))
-	PasswordMinLength                   = NewSetting("password-min-length", "12")
+	PasswordMinLength                   = NewSetting("password-min-length", "12").WithType(TypeInt).WithValidator(IntRange(2, 256))
 	PeerServices                        = NewSetting("peer-service", os.Getenv("CATTLE_PEER_SERVICE"))
 	RkeVersion                          = NewSetting("rke-version", "")
 	RkeMetadataConfig                   = NewSetting("rke-metadata-config", getMetadataConfig())
@@ -115,13 +115,13 @@
 	WhitelistDomain                     = NewSetting("whitelist-domain", "forums.rancher.com")
 	WhitelistEnvironmentVars            = NewSetting("whitelist-envvars", "HTTP_PROXY,HTTPS_PROXY,NO_PROXY")
 	AuthUserInfoResyncCron              = NewSetting("auth-user-info-resync-cron", "0 0 * * *")
-	APIUIVersion                        = NewSetting("api-ui-version", "1.1.11")              // Please update the CATTLE_API_UI_VERSION in package/Dockerfile when updating the version here.
-	RotateCertsIfExpiringInDays         = NewSetting("rotate-certs-if-expiring-in-days", "7") // 7 days
-	ClusterTemplateEnforcement          = NewSetting("cluster-template-enforcement", "false")
+	APIUIVersion                        = NewSetting("api-ui-version", "1.1.11")                                                         // Please update the CATTLE_API_UI_VERSION in package/Dockerfile when updating the version here.
+	RotateCertsIfExpiringInDays         = NewSetting("rotate-certs-if-expiring-in-days", "7").WithType(TypeInt).WithValidator(MinInt(0)) // 7 days
+	ClusterTemplateEnforcement          = NewSetting("cluster-template-enforcement", "false").WithType(TypeBool)
 	InitialDockerRootDir                = NewSetting("initial-docker-root-dir", "/var/lib/docker")
 	SystemCatalog                       = NewSetting("system-catalog", "external") // Options are 'external' or 'bundled'
 	ChartDefaultBranch                  = NewSetting("chart-default-branch", "dev-v2.12")
-	SystemManagedChartsOperationTimeout = NewSetting("system-managed-charts-operation-timeout", "300s")
+	SystemManagedChartsOperationTimeout = NewSetting("system-managed-charts-operation-timeout", "300s").WithType(TypeDuration)
 	FleetDefaultWorkspaceName           = NewSetting("fleet-default-workspace-name", fleetconst.ClustersDefaultNamespace) // fleetWorkspaceName to assign to clusters with none
 	ShellImage                          = NewSetting("shell-image", buildconfig.DefaultShellVersion)
 	IgnoreNodeName                      = NewSetting("ignore-node-name", "") // nodes to ignore when syncing v1.node to v3.node
@@ -130,12 +130,17 @@
 	EKSUpstreamRefreshCron              = NewSetting("eks-refresh-cron", "*/5 * * * *") // EKSUpstreamRefreshCron is deprecated and will be replaced by EKSUpstreamRefresh
 	EKSUpstreamRefresh                  = NewSetting("eks-refresh", "300")
 	GKEUpstreamRefresh                  = NewSetting("gke-refresh", "300")
-	HideLocalCluster                    = NewSetting("hide-local-cluster", "false")
+	HideLocalCluster                    = NewSetting("hide-local-cluster", "false").WithType(TypeBool)
 	MachineProvisionImage               = NewSetting("machine-provision-image", "rancher/machine:v0.15.0-rancher125")
-	SystemFeatureChartRefreshSeconds    = NewSetting("system-feature-chart-refresh-seconds", "21600")
+	SystemFeatureChartRefreshSeconds    = NewSetting("system-feature-chart-refresh-seconds", "21600").WithType(TypeInt).WithValidator(MinInt(1))
 	ClusterAgentDefaultAffinity         = NewSetting("cluster-agent-default-affinity", ClusterAgentAffinity)
 	FleetAgentDefaultAffinity           = NewSetting("fleet-agent-default-affinity", FleetAgentAffinity)
-	MaxUIPluginFileByteSize             = NewSetting("max-ui-plugin-file-byte-size", strconv.Itoa(DefaultMaxUIPluginFileSizeInBytes)) // Max file size in bytes for ui plugins
+	MaxUIPluginFileByteSize             = NewSetting("max-ui-plugin-file-byte-size", strconv.Itoa(DefaultMaxUIPluginFileSizeInBytes)).WithType(TypeInt).WithValidator(MinInt(1)) // Max file size in bytes for ui plugins
+
+	// UIPluginAllowedOrigins is a comma separated list of the URLs ui plugins can be fetched from or below, such as https://charts.example.com/extensions. All origins are allowed if it is empty.
+	UIPluginAllowedOrigins = NewSetting("ui-plugin-allowed-origins", "")
+	// UIPluginAllowedPublishers is a comma separated list of the ClusterRepos the charts of ui plugins can be installed from. All publishers are allowed if it is empty.
+	UIPluginAllowedPublishers = NewSetting("ui-plugin-allowed-publishers", "")
 
 	ClusterAgentDefaultPriorityClass       = NewSetting("cluster-agent-default-priority-class", ClusterAgentPriorityClass)
 	ClusterAgentDefaultPodDisruptionBudget = NewSetting("cluster-agent-default-pod-disruption-budget", ClusterAgentPodDisruptionBudget)
@@ -144,19 +149,19 @@
 	K3sDefaultVersion  = NewSetting("k3s-default-version", "")
 
 	// AuthTokenMaxTTLMinutes is the max allowable time to live for tokens. Excluding those created for UI sessions which is controlled by AuthUserSessionTTLMinutes.
-	AuthTokenMaxTTLMinutes = NewSetting("auth-token-max-ttl-minutes", "129600") // 90 days
+	AuthTokenMaxTTLMinutes = NewSetting("auth-token-max-ttl-minutes", "129600").WithType(TypeInt).WithValidator(MinInt(0)) // 90 days
 
 	// AuthUserInfoMaxAgeSeconds represents the maximum age of a users auth tokens before an auth provider group membership sync will be performed.
-	AuthUserInfoMaxAgeSeconds = NewSetting("auth-user-info-max-age-seconds", "3600") // 1 hour
+	AuthUserInfoMaxAgeSeconds = NewSetting("auth-user-info-max-age-seconds", "3600").WithType(TypeInt).WithValidator(MinInt(0)) // 1 hour
 
 	// AuthUserSessionTTLMinutes represents the time to live for tokens used for login sessions in minutes.
-	AuthUserSessionTTLMinutes = NewSetting("auth-user-session-ttl-minutes", "960") // 16 hours
+	AuthUserSessionTTLMinutes = NewSetting("auth-user-session-ttl-minutes", "960").WithType(TypeInt).WithValidator(MinInt(1)) // 16 hours
 
 	// AuthUserSessionIdleTTLMinutes represents the time to live without user activity for tokens controlling a login session, in minutes.
 	// By default, the value for auth-user-session-idle-ttl-minutes should be set
 	// to the same value as auth-user-session-ttl-minutes (for backward compatibility reasons),
 	// and it must never be greater than this value.
-	AuthUserSessionIdleTTLMinutes = NewSetting("auth-user-session-idle-ttl-minutes", "960") // 16 hours
+	AuthUserSessionIdleTTLMinutes = NewSetting("auth-user-session-idle-ttl-minutes", "960").WithType(TypeInt).WithValidator(MinInt(1)) // 16 hours
 
 	// ChartDefaultURL represents the default URL for the system charts repo. It should only be set for test or
 	// debug purposes.
@@ -165,17 +170,44 @@
 	// The value should be expressed in valid time.Duration units and truncated to a second e.g. "168h". See https://pkg.go.dev/time#ParseDuration
 	// DisableInactiveUserAfter should be greater than AuthUserSessionTTLMinutes.
 	// An empty string or a zero value means the feature is disabled.
-	DisableInactiveUserAfter = NewSetting("disable-inactive-user-after", "")
+	DisableInactiveUserAfter = NewSetting("disable-inactive-user-after", "").WithType(TypeDuration)
 
 	// DeleteInactiveUserAfter is the duration a user can be inactive after which it's deleted by the user retention process.
 	// The value should be expressed in valid time.Duration units and truncated to a second e.g. "168h". See https://pkg.go.dev/time#ParseDuration
 	// DeleteInactiveUserAfter should be greater than AuthUserSessionTTLMinutes.
 	// An empty string or a zero value means the feature is disabled.
-	DeleteInactiveUserAfter = NewSetting("delete-inactive-user-after", "")
+	DeleteInactiveUserAfter = NewSetting("delete-inactive-user-after", "").WithType(TypeDuration)
+
+	// AppDriftCheckInterval is how often the live resources of installed apps are compared with their release manifest.
+	// The value should be expressed in valid time.Duration units e.g. "15m". See https://pkg.go.dev/time#ParseDuration
+	// An empty string or a zero value means drift detection is disabled.
+	AppDriftCheckInterval = NewSetting("app-drift-check-interval", "").WithType(TypeDuration)
+
+	// NotificationRepeatInterval is how long a notification of an ongoing event, such as a disconnected cluster, is not
+	// sent again to notification channels. The value should be expressed in valid time.Duration units e.g. "24h".
+	NotificationRepeatInterval = NewSetting("notification-repeat-interval", "24h").WithType(TypeDuration)
+
+	// ProvisioningStuckTimeout is how long a cluster can be provisioning before a ProvisioningStuck notification is
+	// sent. The value should be expressed in valid time.Duration units e.g. "1h".
+	ProvisioningStuckTimeout = NewSetting("provisioning-stuck-timeout", "1h").WithType(TypeDuration)
+
+	// TracingOTLPEndpoint is the host:port, or URL, of the OTLP gRPC collector traces of API requests are exported to.
+	// An empty string means tracing is disabled. Changes take effect when Rancher restarts.
+	TracingOTLPEndpoint = NewSetting("tracing-otlp-endpoint", "")
+
+	// TracingOTLPInsecure disables TLS for the connection to the OTLP collector. Valid values are "true" and "false".
+	TracingOTLPInsecure = NewSetting("tracing-otlp-insecure", "false").WithType(TypeBool)
+
+	// TracingSampleRatio is the ratio, between 0 and 1, of the traces started by Rancher that are sampled. Traces
+	// continued from a caller are sampled if the caller sampled them.
+	TracingSampleRatio = NewSetting("tracing-sample-ratio", "1").WithType(TypeFloat).WithValidator(FloatRange(0, 1))
+
+	// SettingHistoryLimit is the number of changes of the value of each setting that are kept in its history.
+	SettingHistoryLimit = NewSetting("setting-history-limit", "10").WithType(TypeInt).WithValidator(IntRange(0, 100))
 
 	// UserRetentionDryRun determines if the user retention process should actually disable and delete users.
 	// Valid values are "true" and "false". An empty string means "false".
-	UserRetentionDryRun = NewSetting("user-retention-dry-run", "false")
+	UserRetentionDryRun = NewSetting("user-retention-dry-run", "false").WithType(TypeBool)
 
 	// UserLastLoginDefault is used if UserAttribute.LastLogin is not set.
 	// The value should be a date and time truncated to a second and formatted according to RFC3339 e.g. "2023-03-01T00:00:00Z".
@@ -212,11 +244,11 @@
 
 	// KubeconfigDefaultTokenTTLMinutes is the default time to live applied to kubeconfigs created for users.
 	// This setting will take effect regardless of the kubeconfig-generate-token status.
-	KubeconfigDefaultTokenTTLMinutes = NewSetting("kubeconfig-default-token-ttl-minutes", "43200") // 30 days
+	KubeconfigDefaultTokenTTLMinutes = NewSetting("kubeconfig-default-token-ttl-minutes", "43200").WithType(TypeInt).WithValidator(MinInt(0)) // 30 days
 
 	// KubeconfigGenerateToken determines whether the UI will return a generate token with kubeconfigs.
 	// If set to false the kubeconfig will contain a command to login to Rancher.
-	KubeconfigGenerateToken = NewSetting("kubeconfig-generate-token", "true")
+	KubeconfigGenerateToken = NewSetting("kubeconfig-generate-token", "true").WithType(TypeBool)
 
 	// PartnerChartDefaultBranch represents the default branch for the partner charts repo.
 	PartnerChartDefaultBranch = NewSetting("partner-chart-default-branch", "main")
@@ -243,7 +275,7 @@
 
 	// S3BucketCheckTimeout is the timeout for checking if an s3 bucket for etcd backups exists,
 	// in the go duration string format.
-	S3BucketCheckTimeout = NewSetting("s3-bucket-check-timeout", "30s")
+	S3BucketCheckTimeout = NewSetting("s3-bucket-check-timeout", "30s").WithType(TypeDuration)
 
 	// SystemDefaultRegistry is the default container registry used for images.
 	// The environmental variable "CATTLE_BASE_REGISTRY" controls the default value of this setting.
@@ -262,7 +294,7 @@
 
 	// UICommunityLinks displays community links in the UI.
 	// Deprecated in favour of UICustomLinks.
-	UICommunityLinks = NewSetting("ui-community-links", "true")
+	UICommunityLinks = NewSetting("ui-community-links", "true").WithType(TypeBool)
 
 	// UICustomLinks Key(display text), value(url) for user customisable links to display in homepage and support pages.
 	UICustomLinks = NewSetting("ui-custom-links", "")
@@ -356,7 +388,7 @@
 
 	// UnprivilegedJailUser controls whether jailed commands execute under a separate (unprivileged/non-root) user
 	// account. Setting it to false is only recommended for testing and development environments.
-	UnprivilegedJailUser = NewSetting("unprivileged-jail-user", "true")
+	UnprivilegedJailUser = NewSetting("unprivileged-jail-user", "true").WithType(TypeBool)
 
 	// ImportedClusterVersionManagement enables the version management feature on imported RKE2/K3s cluster,
 	// and the local cluster if it is an RKE2/K3s cluster.
@@ -365,7 +397,7 @@
 	// changing this flag will trigger a redeployment of the cluster agent during the next reconciliation
 	// (by default every 5 minutes, or as soon as the cluster is edited, whichever comes first).
 	// Valid values: ture, false
-	ImportedClusterVersionManagement = NewSetting("imported-cluster-version-management", "true")
+	ImportedClusterVersionManagement = NewSetting("imported-cluster-version-management", "true").WithType(TypeBool)
 )
 
 // FullShellImage returns the full private registry name of the rancher shell image.
@@ -433,6 +465,11 @@ type Setting struct {
 	// on upgraded setups but use a new value for fresh installations for backward compatibility.
 	DefaultOnUpgrade string
 	ReadOnly         bool
+	// Type is the type of the values of the setting, settings without a type accept any value.
+	Type Type
+	// Validator, if set, is called with the values of the setting, of its type, that are written through the settings
+	// provider or the API to enforce its constraints.
+	Validator func(value string) error
 }
 
 // SetIfUnset will store the given value of the setting if it was not already stored.
@@ -467,19 +504,23 @@ func (s Setting) Get() string {
 }
 
 // GetInt will return the currently stored value of the setting as an integer.
-// If the stored value is not an integer then the default value will be returned as an integer.
-// If the default value is not an integer then the function will return 0
+// If the stored value is not an integer, or is rejected by the validator of the setting, then the error is logged and
+// the default value will be returned as an integer. The settings controller reports such values in the conditions of
+// the setting. If the default value is not an integer then the function will return 0
 func (s Setting) GetInt() int {
 	v := s.Get()
-	i, err := strconv.Atoi(v)
+	err := Validate(s.Name, v)
 	if err == nil {
-		return i
+		var i int
+		if i, err = strconv.Atoi(v); err == nil {
+			return i
+		}
 	}
-	logrus.Errorf("failed to parse setting %s=%s as int: %v", s.Name, v, err)
-	i, err = strconv.Atoi(s.Default)
-	if err != nil {
-		return 0
+	i, defaultErr := strconv.Atoi(s.Default)
+	if defaultErr != nil {
+		i = 0
 	}
+	logrus.Errorf("failed to get setting %s=%s as int, using %d instead: %v", s.Name, v, i, err)
 	return i
 }
 
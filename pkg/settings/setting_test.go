@@ -168,6 +168,11 @@ func TestGetInt(t *testing.T) {
 	err = fakeStringSetting.Set("two")
 	assert.NoError(t, err)
 	assert.Equal(t, 0, fakeStringSetting.GetInt())
+
+	fakeMinIntSetting := NewSetting("min-int", "5").WithType(TypeInt).WithValidator(MinInt(1))
+	err = fakeMinIntSetting.Set("0")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, fakeMinIntSetting.GetInt(), "values rejected by the validator are not used")
 }
 
 func TestGetRancherVersion(t *testing.T) {
@@ -0,0 +1,170 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Type is the type of the values of a setting.
+type Type string
+
+const (
+	// TypeString settings accept any value, this is the type of settings that don't declare one.
+	TypeString Type = "string"
+	// TypeInt settings accept integers.
+	TypeInt Type = "int"
+	// TypeFloat settings accept floating-point numbers.
+	TypeFloat Type = "float"
+	// TypeBool settings accept "true" and "false".
+	TypeBool Type = "bool"
+	// TypeDuration settings accept durations in time.Duration units, such as "300s". See https://pkg.go.dev/time#ParseDuration
+	TypeDuration Type = "duration"
+	// TypeJSON settings accept JSON documents.
+	TypeJSON Type = "json"
+)
+
+// SystemUser is the user recorded in the history of settings changed by Rancher itself.
+const SystemUser = "system"
+
+func (t Type) validate(value string) error {
+	switch t {
+	case TypeInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("%s is not an integer", value)
+		}
+	case TypeFloat:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("%s is not a number", value)
+		}
+	case TypeBool:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("%s is not one of: true, false", value)
+		}
+	case TypeDuration:
+		if _, err := time.ParseDuration(value); err != nil {
+			return err
+		}
+	case TypeJSON:
+		if !json.Valid([]byte(value)) {
+			return fmt.Errorf("%s is not valid JSON", value)
+		}
+	}
+	return nil
+}
+
+// WithType takes a setting and returns a new setting that only accepts values of the given type.
+func (s Setting) WithType(t Type) Setting {
+	s.Type = t
+	settings[s.Name] = s
+	return s
+}
+
+// WithValidator takes a setting and returns a new setting whose values, of its type, must also pass the given
+// validation function.
+func (s Setting) WithValidator(validator func(value string) error) Setting {
+	s.Validator = validator
+	settings[s.Name] = s
+	return s
+}
+
+// Validate returns an error if the given value is not of the type of the setting or is rejected by its validation
+// function. An empty value is always valid as it resets the setting to its default.
+func (s Setting) Validate(value string) error {
+	if value == "" {
+		return nil
+	}
+	if err := s.Type.validate(value); err != nil {
+		return fmt.Errorf("invalid value for setting %s: %w", s.Name, err)
+	}
+	if s.Validator != nil {
+		if err := s.Validator(value); err != nil {
+			return fmt.Errorf("invalid value for setting %s: %w", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// Validate validates the given value of the setting with the given name. Unknown settings accept any value.
+func Validate(name, value string) error {
+	s, ok := settings[name]
+	if !ok {
+		return nil
+	}
+	return s.Validate(value)
+}
+
+// FloatRange returns a validation function of TypeFloat settings that accepts numbers between min and max, inclusive.
+func FloatRange(min, max float64) func(string) error {
+	return func(value string) error {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		if f < min || f > max {
+			return fmt.Errorf("%s is not between %s and %s", value, strconv.FormatFloat(min, 'f', -1, 64), strconv.FormatFloat(max, 'f', -1, 64))
+		}
+		return nil
+	}
+}
+
+// IntRange returns a validation function of TypeInt settings that accepts integers between min and max, inclusive.
+func IntRange(min, max int) func(string) error {
+	return func(value string) error {
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		if i < min || i > max {
+			return fmt.Errorf("%d is not between %d and %d", i, min, max)
+		}
+		return nil
+	}
+}
+
+// MinInt returns a validation function of TypeInt settings that accepts integers greater than or equal to min.
+func MinInt(min int) func(string) error {
+	return func(value string) error {
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		if i < min {
+			return fmt.Errorf("%d is less than %d", i, min)
+		}
+		return nil
+	}
+}
+
+// OneOf returns a validation function that only accepts the given values.
+func OneOf(values ...string) func(string) error {
+	return func(value string) error {
+		for _, v := range values {
+			if value == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s is not one of: %s", value, strings.Join(values, ", "))
+	}
+}
+
+// RecordChange appends the change of the value of the given setting by the given user to its history, keeping the
+// number of changes of the setting-history-limit setting. It must be called before the setting is updated.
+func RecordChange(setting *v32.Setting, value, user string) {
+	if value == setting.Value {
+		return
+	}
+	setting.History = append(setting.History, v32.SettingChange{
+		Value: value,
+		User:  user,
+		Time:  metav1.Now(),
+	})
+	if limit := SettingHistoryLimit.GetInt(); len(setting.History) > limit {
+		setting.History = setting.History[len(setting.History)-limit:]
+	}
+}
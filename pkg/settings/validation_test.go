@@ -0,0 +1,77 @@
+package settings
+
+import (
+	"testing"
+
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultsAreValid(t *testing.T) {
+	for name, setting := range settings {
+		assert.NoError(t, setting.Validate(setting.Default), "default of setting %s", name)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		setting string
+		value   string
+		wantErr bool
+	}{
+		{name: "empty values reset the default", setting: PasswordMinLength.Name, value: ""},
+		{name: "int", setting: PasswordMinLength.Name, value: "16"},
+		{name: "not an int", setting: PasswordMinLength.Name, value: "16 characters", wantErr: true},
+		{name: "int out of range", setting: PasswordMinLength.Name, value: "1", wantErr: true},
+		{name: "negative int", setting: AuthTokenMaxTTLMinutes.Name, value: "-1", wantErr: true},
+		{name: "bool", setting: FirstLogin.Name, value: "false"},
+		{name: "not a bool", setting: FirstLogin.Name, value: "no", wantErr: true},
+		{name: "duration", setting: AppDriftCheckInterval.Name, value: "1h30m"},
+		{name: "not a duration", setting: AppDriftCheckInterval.Name, value: "90", wantErr: true},
+		{name: "one of", setting: AgentTLSMode.Name, value: AgentTLSModeSystemStore},
+		{name: "not one of", setting: AgentTLSMode.Name, value: "insecure", wantErr: true},
+		{name: "ratio", setting: TracingSampleRatio.Name, value: "0.25"},
+		{name: "ratio out of range", setting: TracingSampleRatio.Name, value: "25", wantErr: true},
+		{name: "not a ratio", setting: TracingSampleRatio.Name, value: "all", wantErr: true},
+		{name: "untyped", setting: ServerURL.Name, value: "https://rancher.example.com"},
+		{name: "unknown", setting: "unknown-setting", value: "anything"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.setting, tt.value)
+			if tt.wantErr {
+				assert.ErrorContains(t, err, tt.setting)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestTypeJSON(t *testing.T) {
+	setting := Setting{Name: "json-setting", Type: TypeJSON}
+	assert.NoError(t, setting.Validate(`{"url": "https://example.com"}`))
+	assert.Error(t, setting.Validate(`{"url": `))
+}
+
+func TestRecordChange(t *testing.T) {
+	original := SettingHistoryLimit.Get()
+	require.NoError(t, SettingHistoryLimit.Set("2"))
+	defer SettingHistoryLimit.Set(original)
+
+	setting := &v32.Setting{Value: "12"}
+	RecordChange(setting, "12", "admin")
+	assert.Empty(t, setting.History, "unchanged values are not recorded")
+
+	for _, value := range []string{"14", "16", "18"} {
+		RecordChange(setting, value, "admin")
+		setting.Value = value
+	}
+	require.Len(t, setting.History, 2, "only the last changes are kept")
+	assert.Equal(t, "16", setting.History[0].Value)
+	assert.Equal(t, "18", setting.History[1].Value)
+	assert.Equal(t, "admin", setting.History[1].User)
+	assert.False(t, setting.History[1].Time.IsZero())
+}
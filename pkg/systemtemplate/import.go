@@ -2,6 +2,7 @@
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
@@ -247,6 +248,7 @@ func GetDesiredFeatures(cluster *apimgmtv3.Cluster) map[string]bool {
 		// the case of imported rke2/k3s cluster
 		enableMSUC = importedclusterversionmanagement.Enabled(cluster)
 	}
+	ctx := features.WithCluster(context.Background(), cluster)
 
 	return map[string]bool{
 		features.MCM.Name():                            false,
@@ -255,9 +257,9 @@ func GetDesiredFeatures(cluster *apimgmtv3.Cluster) map[string]bool {
 		features.RKE2.Name():                           false,
 		features.ProvisioningV2.Name():                 false,
 		features.EmbeddedClusterAPI.Name():             false,
-		features.UISQLCache.Name():                     features.UISQLCache.Enabled(),
+		features.UISQLCache.Name():                     features.UISQLCache.EnabledFor(ctx),
 		features.ProvisioningPreBootstrap.Name():       capr.PreBootstrap(cluster),
-		features.ManagedSystemUpgradeController.Name(): features.ManagedSystemUpgradeController.Enabled() && enableMSUC,
+		features.ManagedSystemUpgradeController.Name(): features.ManagedSystemUpgradeController.EnabledFor(ctx) && enableMSUC,
 	}
 }
 
@@ -5,6 +5,8 @@
 	"fmt"
 	"sort"
 	"strings"
+
+	"github.com/rancher/rancher/pkg/settings"
 )
 
 var (
@@ -50,6 +52,19 @@
 	}
 )
 
+// RegisterSettingValidators makes the TLS settings only accept the versions and ciphers supported by Rancher. The
+// settings package can't import this one, so it must be called before the settings are set or validated.
+func RegisterSettingValidators() {
+	settings.TLSMinVersion = settings.TLSMinVersion.WithValidator(func(value string) error {
+		_, err := validatedMinVersion(value)
+		return err
+	})
+	settings.TLSCiphers = settings.TLSCiphers.WithValidator(func(value string) error {
+		_, err := validatedCiphers(value, tls.VersionTLS12)
+		return err
+	})
+}
+
 func baseTLSConfig(minVersion, ciphers string) (*tls.Config, error) {
 	version, err := validatedMinVersion(minVersion)
 	if err != nil {
@@ -4,6 +4,8 @@
 	"crypto/tls"
 	"reflect"
 	"testing"
+
+	"github.com/rancher/rancher/pkg/settings"
 )
 
 func TestBaseTLSConfig(t *testing.T) {
@@ -94,3 +96,20 @@ func TestBaseTLSConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestSettingValidators(t *testing.T) {
+	RegisterSettingValidators()
+
+	if err := settings.Validate(settings.TLSCiphers.Name, "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_AES_128_GCM_SHA256"); err != nil {
+		t.Errorf("expected supported ciphers to be valid, got %v", err)
+	}
+	if err := settings.Validate(settings.TLSCiphers.Name, "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_128_GCM"); err == nil {
+		t.Error("expected a typo in the ciphers to be rejected")
+	}
+	if err := settings.Validate(settings.TLSMinVersion.Name, "1.3"); err != nil {
+		t.Errorf("expected TLS 1.3 to be valid, got %v", err)
+	}
+	if err := settings.Validate(settings.TLSMinVersion.Name, "1.4"); err == nil {
+		t.Error("expected TLS 1.4 to be rejected")
+	}
+}
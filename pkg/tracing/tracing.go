@@ -0,0 +1,192 @@
+/*
+Package tracing configures OpenTelemetry distributed tracing for Rancher. Spans of API requests are exported with OTLP
+to the collector configured by the tracing-otlp-endpoint setting and their context is propagated to downstream
+clusters and helm operations.
+*/
+package tracing
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/rancher/rancher"
+
+// Setup exports the spans of Rancher to the OTLP collector of the tracing-otlp-endpoint setting, if it is set, until
+// the given context is done. Tracing is disabled if the setting is empty.
+func Setup(ctx context.Context) error {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	endpoint := settings.TracingOTLPEndpoint.Get()
+	if endpoint == "" {
+		return nil
+	}
+
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(endpoint)}
+	if strings.Contains(endpoint, "://") {
+		opts = []otlptracegrpc.Option{otlptracegrpc.WithEndpointURL(endpoint)}
+	}
+	if settings.TracingOTLPInsecure.Get() == "true" {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return err
+	}
+
+	ratio, err := strconv.ParseFloat(settings.TracingSampleRatio.Get(), 64)
+	if err != nil {
+		logrus.Warnf("[tracing] invalid sample ratio [%s], sampling all traces: %v", settings.TracingSampleRatio.Get(), err)
+		ratio = 1
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			semconv.ServiceName("rancher"),
+			semconv.ServiceVersion(settings.ServerVersion.Get()),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	logrus.Infof("[tracing] exporting traces to [%s]", endpoint)
+
+	go func() {
+		<-ctx.Done()
+		if err := provider.Shutdown(context.Background()); err != nil {
+			logrus.Errorf("[tracing] failed to flush traces: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Tracer returns the tracer of Rancher spans.
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// Middleware starts a span for each API request, continuing the trace of the caller if the request has one.
+func Middleware(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "rancher", otelhttp.WithSpanNameFormatter(func(_ string, req *http.Request) string {
+		return req.Method + " " + route(req.URL.Path)
+	}))
+}
+
+// WrapMiddleware records the time spent in the given middleware, until it calls the next handler, in a span.
+func WrapMiddleware(name string, middleware func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	type spans struct {
+		parent, middleware trace.Span
+	}
+	type spansKey struct{}
+
+	return func(next http.Handler) http.Handler {
+		wrapped := middleware(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			if s, ok := req.Context().Value(spansKey{}).(spans); ok {
+				s.middleware.End()
+				// the next handlers are not part of the middleware, keep the values it added to the context but
+				// restore the span of the request
+				req = req.WithContext(trace.ContextWithSpan(req.Context(), s.parent))
+			}
+			next.ServeHTTP(rw, req)
+		}))
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			parent := trace.SpanFromContext(req.Context())
+			ctx, span := Tracer().Start(req.Context(), name)
+			defer span.End()
+			ctx = context.WithValue(ctx, spansKey{}, spans{parent: parent, middleware: span})
+			wrapped.ServeHTTP(rw, req.WithContext(ctx))
+		})
+	}
+}
+
+// Handler records the requests served by the given handler in a span.
+func Handler(name string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		ctx, span := Tracer().Start(req.Context(), name)
+		defer span.End()
+		next.ServeHTTP(rw, req.WithContext(ctx))
+	})
+}
+
+// Transport propagates the trace of the requests sent through the given transport and records them in spans.
+func Transport(rt http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(rt)
+}
+
+// Dialer records the connections dialed through the tunnel of the given cluster in spans.
+func Dialer(cluster string, dialer func(ctx context.Context, network, address string) (net.Conn, error)) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		ctx, span := Tracer().Start(ctx, "remotedialer dial", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+			attribute.String("rancher.cluster", cluster),
+			attribute.String("network.transport", network),
+			attribute.String("server.address", address),
+		))
+		defer span.End()
+		conn, err := dialer(ctx, network, address)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return conn, err
+	}
+}
+
+// traceAnnotationPrefix prefixes the annotations propagating a trace to an object.
+const traceAnnotationPrefix = "tracing.cattle.io/"
+
+// Annotations returns the annotations propagating the trace of the given context to an object, so that the spans
+// recorded when the object is reconciled later are part of the same trace.
+func Annotations(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	result := map[string]string{}
+	for key, value := range carrier {
+		result[traceAnnotationPrefix+key] = value
+	}
+	return result
+}
+
+// ContextFromAnnotations returns a copy of the given context continuing the trace propagated by the given annotations
+// of an object, if any.
+func ContextFromAnnotations(ctx context.Context, annotations map[string]string) context.Context {
+	carrier := propagation.MapCarrier{}
+	for _, key := range (propagation.TraceContext{}).Fields() {
+		if value, ok := annotations[traceAnnotationPrefix+key]; ok {
+			carrier[key] = value
+		}
+	}
+	return propagation.TraceContext{}.Extract(ctx, carrier)
+}
+
+// route returns the path of an API request without the names of the objects it targets, so that span names have a
+// low cardinality.
+func route(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(parts) >= 3 && parts[0] == "k8s" && parts[1] == "clusters":
+		return "/k8s/clusters/{cluster}"
+	case len(parts) >= 2 && (parts[0] == "v1" || parts[0] == "v3" || parts[0] == "v3-public"):
+		return "/" + parts[0] + "/" + parts[1]
+	default:
+		return "/" + parts[0]
+	}
+}
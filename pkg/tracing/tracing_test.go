@@ -0,0 +1,98 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func spanNames(spans []sdktrace.ReadOnlySpan) map[string]sdktrace.ReadOnlySpan {
+	result := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range spans {
+		result[span.Name()] = span
+	}
+	return result
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder := recordSpans(t)
+
+	type userKey struct{}
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(rw, req.WithContext(context.WithValue(req.Context(), userKey{}, "admin")))
+		})
+	}
+	var user interface{}
+	handler := Middleware(WrapMiddleware("authenticate", auth)(Handler("steve", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		user = req.Context().Value(userKey{})
+	}))))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/management.cattle.io.clusters/c-abc", nil))
+
+	assert.Equal(t, "admin", user)
+	spans := spanNames(recorder.Ended())
+	require.Len(t, spans, 3)
+	request := spans["GET /v1/management.cattle.io.clusters"]
+	require.NotNil(t, request)
+	assert.Equal(t, request.SpanContext().SpanID(), spans["authenticate"].Parent().SpanID())
+	assert.Equal(t, request.SpanContext().SpanID(), spans["steve"].Parent().SpanID(), "handlers after a middleware are not its children")
+}
+
+func TestDialer(t *testing.T) {
+	recorder := recordSpans(t)
+
+	dialer := Dialer("c-abc", func(ctx context.Context, network, address string) (net.Conn, error) {
+		return nil, errors.New("failed to find Session for client c-abc")
+	})
+	_, err := dialer(context.Background(), "tcp", "127.0.0.1:6080")
+	assert.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "remotedialer dial", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+}
+
+func TestAnnotations(t *testing.T) {
+	recordSpans(t)
+
+	assert.Empty(t, Annotations(context.Background()))
+
+	ctx, span := Tracer().Start(context.Background(), "helm operation")
+	defer span.End()
+	annotations := Annotations(ctx)
+	require.Len(t, annotations, 1)
+	assert.Contains(t, annotations["tracing.cattle.io/traceparent"], trace.SpanContextFromContext(ctx).TraceID().String())
+
+	restored := trace.SpanContextFromContext(ContextFromAnnotations(context.Background(), annotations))
+	assert.Equal(t, trace.SpanContextFromContext(ctx).TraceID(), restored.TraceID())
+	assert.Equal(t, trace.SpanContextFromContext(ctx).SpanID(), restored.SpanID())
+	assert.False(t, trace.SpanContextFromContext(ContextFromAnnotations(context.Background(), nil)).IsValid())
+}
+
+func TestRoute(t *testing.T) {
+	assert.Equal(t, "/k8s/clusters/{cluster}", route("/k8s/clusters/c-abc/api/v1/pods"))
+	assert.Equal(t, "/v1/catalog.cattle.io.clusterrepos", route("/v1/catalog.cattle.io.clusterrepos/rancher-charts"))
+	assert.Equal(t, "/v3/clusters", route("/v3/clusters/c-abc"))
+	assert.Equal(t, "/dashboard", route("/dashboard/c/local/explorer"))
+	assert.Equal(t, "/", route("/"))
+}
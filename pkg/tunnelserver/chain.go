@@ -36,6 +36,7 @@ func (a *Authorizers) Authorize(req *http.Request) (clientKey string, authed boo
 			}
 			continue
 		}
+		recordClientKey(req, key)
 		return key, authed, err
 	}
 
@@ -0,0 +1,262 @@
+package tunnelserver
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rancher/remotedialer"
+)
+
+var (
+	prometheusMetrics = false
+
+	tunnelSessions = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: "cluster_manager",
+			Name:      "tunnel_sessions",
+			Help:      "Number of agent tunnel sessions connected to this Rancher server for a cluster",
+		},
+		[]string{"cluster"},
+	)
+
+	tunnelConnects = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "cluster_manager",
+			Name:      "tunnel_connects_total",
+			Help:      "Total number of agent tunnel sessions established for a cluster",
+		},
+		[]string{"cluster"},
+	)
+
+	tunnelDisconnects = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "cluster_manager",
+			Name:      "tunnel_disconnects_total",
+			Help:      "Total number of agent tunnel sessions closed for a cluster",
+		},
+		[]string{"cluster"},
+	)
+
+	tunnelLastConnect = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: "cluster_manager",
+			Name:      "tunnel_last_connect_timestamp_seconds",
+			Help:      "Unix time at which the last agent tunnel session of a cluster was established",
+		},
+		[]string{"cluster"},
+	)
+
+	tunnelSessionDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: "cluster_manager",
+			Name:      "tunnel_session_duration_seconds",
+			Help:      "Age of agent tunnel sessions of a cluster when they are closed",
+			Buckets:   []float64{10, 60, 300, 1800, 3600, 6 * 3600, 24 * 3600, 7 * 24 * 3600},
+		},
+		[]string{"cluster"},
+	)
+
+	tunnelBytes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "cluster_manager",
+			Name:      "tunnel_proxied_bytes_total",
+			Help:      "Total number of bytes proxied through the agent tunnel of a cluster, by direction (sent to or received from the cluster)",
+		},
+		[]string{"cluster", "direction"},
+	)
+
+	tunnelDialDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: "cluster_manager",
+			Name:      "tunnel_dial_duration_seconds",
+			Help:      "Latency of connections dialed through the agent tunnel of a cluster",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"cluster"},
+	)
+
+	tunnelDialErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "cluster_manager",
+			Name:      "tunnel_dial_errors_total",
+			Help:      "Total number of connections that failed to be dialed through the agent tunnel of a cluster",
+		},
+		[]string{"cluster"},
+	)
+
+	tunnelPeers = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: "cluster_manager",
+			Name:      "tunnel_peer",
+			Help:      "Set to 1 while a Rancher server is a tunnel peer of this Rancher server, 0 once it is removed",
+		},
+		[]string{"peer"},
+	)
+
+	tunnelPeerChanges = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "cluster_manager",
+			Name:      "tunnel_peer_changes_total",
+			Help:      "Total number of times a Rancher server was added or removed as a tunnel peer of this Rancher server",
+		},
+		[]string{"peer", "event"},
+	)
+)
+
+// ClusterCollectors returns the tunnel metrics labeled by cluster.
+func ClusterCollectors() []prometheus.Collector {
+	return []prometheus.Collector{tunnelSessions, tunnelConnects, tunnelDisconnects, tunnelLastConnect,
+		tunnelSessionDuration, tunnelBytes, tunnelDialDuration, tunnelDialErrors}
+}
+
+// PeerCollectors returns the tunnel metrics labeled by peer.
+func PeerCollectors() []prometheus.Collector {
+	return []prometheus.Collector{tunnelPeers, tunnelPeerChanges}
+}
+
+// RegisterMetrics registers the tunnel metrics and starts updating them.
+func RegisterMetrics() {
+	prometheusMetrics = true
+	prometheus.MustRegister(ClusterCollectors()...)
+	prometheus.MustRegister(PeerCollectors()...)
+}
+
+type clientKeyContextKey struct{}
+
+// sessionInfo holds the client key the Authorizers authorized an agent connecting to the tunnel server with.
+type sessionInfo struct {
+	clientKey string
+}
+
+// InstrumentHandler wraps the tunnel server handler to record the sessions of the agents connecting to it. Peer
+// sessions are not authorized by the Authorizers and are recorded by the peer manager instead.
+func InstrumentHandler(server http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if !prometheusMetrics {
+			server.ServeHTTP(rw, req)
+			return
+		}
+
+		info := &sessionInfo{}
+		hw := &hijackWriter{ResponseWriter: rw, info: info}
+		server.ServeHTTP(hw, req.WithContext(context.WithValue(req.Context(), clientKeyContextKey{}, info)))
+		if !hw.hijacked || info.clientKey == "" {
+			return
+		}
+
+		cluster := clusterLabel(info.clientKey)
+		tunnelSessions.WithLabelValues(cluster).Dec()
+		tunnelDisconnects.WithLabelValues(cluster).Inc()
+		tunnelSessionDuration.WithLabelValues(cluster).Observe(time.Since(hw.start).Seconds())
+	})
+}
+
+// recordClientKey stores the client key an agent was authorized with so that its session can be recorded once the
+// connection is upgraded.
+func recordClientKey(req *http.Request, clientKey string) {
+	if info, ok := req.Context().Value(clientKeyContextKey{}).(*sessionInfo); ok {
+		info.clientKey = clientKey
+	}
+}
+
+// hijackWriter records the start of a session when the tunnel server upgrades the connection to a websocket.
+type hijackWriter struct {
+	http.ResponseWriter
+	info     *sessionInfo
+	hijacked bool
+	start    time.Time
+}
+
+func (h *hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := h.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	h.hijacked = true
+	h.start = time.Now()
+	if h.info.clientKey != "" {
+		sessionConnected(h.info.clientKey)
+	}
+	return conn, rw, nil
+}
+
+// InstrumentDialer wraps a dialer of the tunnel session with the given client key to record the latency, errors and
+// proxied bytes of the connections dialed through it.
+func InstrumentDialer(clientKey string, dialer remotedialer.Dialer) remotedialer.Dialer {
+	cluster := clusterLabel(clientKey)
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		if !prometheusMetrics {
+			return dialer(ctx, network, address)
+		}
+
+		start := time.Now()
+		conn, err := dialer(ctx, network, address)
+		tunnelDialDuration.WithLabelValues(cluster).Observe(time.Since(start).Seconds())
+		if err != nil {
+			tunnelDialErrors.WithLabelValues(cluster).Inc()
+			return nil, err
+		}
+		return &countingConn{
+			Conn:     conn,
+			sent:     tunnelBytes.WithLabelValues(cluster, "sent"),
+			received: tunnelBytes.WithLabelValues(cluster, "received"),
+		}, nil
+	}
+}
+
+type countingConn struct {
+	net.Conn
+	sent     prometheus.Counter
+	received prometheus.Counter
+}
+
+func (c *countingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.received.Add(float64(n))
+	return n, err
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.sent.Add(float64(n))
+	return n, err
+}
+
+func sessionConnected(clientKey string) {
+	if prometheusMetrics {
+		cluster := clusterLabel(clientKey)
+		tunnelSessions.WithLabelValues(cluster).Inc()
+		tunnelConnects.WithLabelValues(cluster).Inc()
+		tunnelLastConnect.WithLabelValues(cluster).SetToCurrentTime()
+	}
+}
+
+func peerAdded(peer string) {
+	if prometheusMetrics {
+		tunnelPeers.WithLabelValues(peer).Set(1)
+		tunnelPeerChanges.WithLabelValues(peer, "add").Inc()
+	}
+}
+
+func peerRemoved(peer string) {
+	if prometheusMetrics {
+		tunnelPeers.WithLabelValues(peer).Set(0)
+		tunnelPeerChanges.WithLabelValues(peer, "remove").Inc()
+	}
+}
+
+// clusterLabel returns the cluster of a client key, which is either the name of a cluster or "<cluster>:<node>" for
+// node agents.
+func clusterLabel(clientKey string) string {
+	cluster, _, _ := strings.Cut(clientKey, ":")
+	return cluster
+}
@@ -0,0 +1,119 @@
+package tunnelserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func enableMetrics(t *testing.T) {
+	prometheusMetrics = true
+	t.Cleanup(func() {
+		prometheusMetrics = false
+		for _, collector := range ClusterCollectors() {
+			collector.(interface{ Reset() }).Reset()
+		}
+		for _, collector := range PeerCollectors() {
+			collector.(interface{ Reset() }).Reset()
+		}
+	})
+}
+
+func TestInstrumentHandler(t *testing.T) {
+	enableMetrics(t)
+
+	auth := &Authorizers{}
+	auth.Add(func(req *http.Request) (string, bool, error) {
+		if req.Header.Get("X-Token") == "" {
+			return "", false, nil
+		}
+		return "c-abc:m-node", true, nil
+	})
+	connected := make(chan float64, 1)
+	server := InstrumentHandler(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if _, ok, _ := auth.Authorize(req); !ok {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		conn, _, err := rw.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		defer conn.Close()
+		connected <- testutil.ToFloat64(tunnelSessions.WithLabelValues("c-abc"))
+	}))
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 0, testutil.CollectAndCount(tunnelConnects))
+
+	req, err := http.NewRequest(http.MethodPost, ts.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Token", "token")
+	if resp, err := http.DefaultClient.Do(req); err == nil {
+		resp.Body.Close()
+	}
+
+	assert.Equal(t, float64(1), <-connected)
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(tunnelDisconnects.WithLabelValues("c-abc")) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, float64(1), testutil.ToFloat64(tunnelConnects.WithLabelValues("c-abc")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(tunnelSessions.WithLabelValues("c-abc")))
+}
+
+func TestInstrumentDialer(t *testing.T) {
+	enableMetrics(t)
+
+	client, server := net.Pipe()
+	defer server.Close()
+	go func() {
+		buf := make([]byte, 4)
+		io.ReadFull(server, buf)
+		server.Write([]byte("pong!"))
+	}()
+
+	dialer := InstrumentDialer("c-abc", func(ctx context.Context, network, address string) (net.Conn, error) {
+		if address == "unreachable:443" {
+			return nil, errors.New("unreachable")
+		}
+		return client, nil
+	})
+
+	_, err := dialer(context.Background(), "tcp", "unreachable:443")
+	assert.Error(t, err)
+	assert.Equal(t, float64(1), testutil.ToFloat64(tunnelDialErrors.WithLabelValues("c-abc")))
+
+	conn, err := dialer(context.Background(), "tcp", "kubernetes:443")
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+	_, err = io.ReadFull(conn, make([]byte, 5))
+	require.NoError(t, err)
+
+	assert.Equal(t, float64(4), testutil.ToFloat64(tunnelBytes.WithLabelValues("c-abc", "sent")))
+	assert.Equal(t, float64(5), testutil.ToFloat64(tunnelBytes.WithLabelValues("c-abc", "received")))
+	assert.Equal(t, 1, testutil.CollectAndCount(tunnelDialDuration))
+}
+
+func TestPeerMetrics(t *testing.T) {
+	enableMetrics(t)
+
+	peerAdded("10.0.0.2")
+	assert.Equal(t, float64(1), testutil.ToFloat64(tunnelPeers.WithLabelValues("10.0.0.2")))
+	peerRemoved("10.0.0.2")
+	assert.Equal(t, float64(0), testutil.ToFloat64(tunnelPeers.WithLabelValues("10.0.0.2")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(tunnelPeerChanges.WithLabelValues("10.0.0.2", "add")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(tunnelPeerChanges.WithLabelValues("10.0.0.2", "remove")))
+}
@@ -173,9 +173,11 @@ func (p *peerManager) addRemovePeers(endpoints *v1.Endpoints) {
 			urlSafeIP = fmt.Sprintf("[%s]", ip)
 		}
 		p.server.AddPeer(fmt.Sprintf(p.urlFormat, urlSafeIP), ip, p.token)
+		peerAdded(ip)
 	}
 	for _, ip := range toDelete {
 		p.server.RemovePeer(ip)
+		peerRemoved(ip)
 	}
 
 	p.peers = newSet
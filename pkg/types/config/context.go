@@ -130,7 +130,7 @@ func NewScaledContext(config rest.Config, opts *ScaleContextOptions) (*ScaledCon
 		if err != nil {
 			return nil, err
 		}
-		context.ControllerFactory = controllerFactory
+		context.ControllerFactory = controllers.InstrumentFactory(controllerFactory, controllers.Scaled, controllers.ManagementCluster)
 	} else {
 		context.ControllerFactory = opts.ControllerFactory
 	}
@@ -409,7 +409,7 @@ func NewUserContext(scaledContext *ScaledContext, config rest.Config, clusterNam
 		KindNamespace: context.KindNamespaces,
 	})
 
-	controllerFactory := controller.NewSharedControllerFactory(cacheFactory, controllers.GetOptsFromEnv(controllers.User))
+	controllerFactory := controllers.InstrumentFactory(controller.NewSharedControllerFactory(cacheFactory, controllers.GetOptsFromEnv(controllers.User)), controllers.User, clusterName)
 	context.ControllerFactory = controllerFactory
 
 	context.K8sClient, err = kubernetes.NewForConfig(&config)
@@ -26,6 +26,7 @@
 	"github.com/rancher/rancher/pkg/catalogv2/helmop"
 	"github.com/rancher/rancher/pkg/catalogv2/system"
 	"github.com/rancher/rancher/pkg/controllers"
+	"github.com/rancher/rancher/pkg/events"
 	"github.com/rancher/rancher/pkg/generated/controllers/catalog.cattle.io"
 	catalogcontrollers "github.com/rancher/rancher/pkg/generated/controllers/catalog.cattle.io/v1"
 	capi "github.com/rancher/rancher/pkg/generated/controllers/cluster.x-k8s.io"
@@ -81,6 +82,7 @@
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
 	apiregistrationv12 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
 	capiv1beta1api "sigs.k8s.io/cluster-api/api/v1beta1"
 )
@@ -148,6 +150,7 @@ type Context struct {
 	CatalogContentManager *content.Manager
 	HelmOperations        *helmop.Operations
 	SystemChartsManager   *system.Manager
+	EventRecorder         record.EventRecorder
 
 	mgmt         *management.Factory
 	rbac         *rbac.Factory
@@ -275,6 +278,7 @@ func NewContext(ctx context.Context, clientConfig clientcmd.ClientConfig, restCo
 	if err != nil {
 		return nil, err
 	}
+	controllerFactory = controllers.InstrumentFactory(controllerFactory, controllers.Management, controllers.ManagementCluster)
 
 	opts := &generic.FactoryOptions{
 		SharedControllerFactory: controllerFactory,
@@ -453,6 +457,7 @@ func NewContext(ctx context.Context, clientConfig clientcmd.ClientConfig, restCo
 		CatalogContentManager:   content,
 		HelmOperations:          helmop,
 		SystemChartsManager:     systemCharts,
+		EventRecorder:           events.NewRecorder(ctx, k8s, Scheme),
 		TunnelAuthorizer:        tunnelAuth,
 		TunnelServer:            tunnelServer,
 		Plan:                    plan.Upgrade().V1(),